@@ -0,0 +1,77 @@
+/*
+appendix.go adds the source metadata JSON to the PDF report as a monospaced appendix, for provenance.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+	"github.com/johnfercher/maroto/pkg/props"
+)
+
+// the font size of the appendix, in points
+const source_font_size float64 = 7
+
+// the row height of an appendix line, in mm
+const source_row_height float64 = 3
+
+// the width of a Courier glyph in mm per point of font size, Courier glyphs are 0.6 em wide
+const courier_char_width float64 = 0.6 * 25.4 / 72
+
+// the source pretty printed with a two space indent, in its own key order, and cut into lines of at most
+// width characters; a continuation line keeps the indentation of the line it belongs to
+func source_json_lines(raw []byte, width int) []string {
+	var buf bytes.Buffer
+	if json.Indent(&buf, bytes.TrimSpace(raw), "", "  ") != nil {
+		// not valid JSON, shown as it is
+		buf.Reset()
+		buf.Write(raw)
+	}
+
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(buf.String(), "\r\n", "\n"), "\n") {
+		line = strings.ReplaceAll(line, "\t", "  ")
+		runes := []rune(line)
+		indent := len(runes) - len([]rune(strings.TrimLeft(line, " ")))
+		if indent > width/2 {
+			indent = width / 2
+		}
+		for len(runes) > width {
+			lines = append(lines, string(runes[:width]))
+			runes = append([]rune(strings.Repeat(" ", indent)), runes[width:]...)
+		}
+		lines = append(lines, string(runes))
+	}
+	return lines
+}
+
+// add a page with the source JSON after the report, maroto starts a new page when the lines fill one
+func pdf_write_source_appendix(doc pdf.Maroto, raw []byte) {
+	var colwidth uint = 12
+	var rowheight float64 = 4
+
+	width, _ := doc.GetPageSize()
+	left, _, right, _ := doc.GetPageMargins()
+	chars := int((width - left - right) / (courier_char_width * source_font_size))
+
+	doc.AddPage()
+	pdf_write_row(doc, "Appendix: source metadata", rowheight, colwidth, consts.Bold, pdfBlack())
+	pdf_write_empty_row(doc, rowheight, colwidth)
+	for _, line := range source_json_lines(raw, chars-1) {
+		doc.Row(source_row_height, func() {
+			doc.Col(colwidth, func() {
+				doc.Text(line, props.Text{
+					Family:      consts.Courier,
+					Size:        source_font_size,
+					Extrapolate: true,
+					Color:       pdfBlack(),
+				})
+			})
+		})
+	}
+}
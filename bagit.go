@@ -0,0 +1,156 @@
+/*
+bagit.go writing the BagIt (RFC 8493) tag files next to converted metadata: bagit.txt, bag-info.txt and a
+manifest with the checksum of every output file, for long-term preservation workflows.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the BagIt version written to bagit.txt
+const bagit_version string = "1.0"
+
+// the checksum algorithms of the manifest, by their BagIt name
+var bagit_algorithms = map[string]func() hash.Hash{
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// a tag file of the bag itself, which is not listed in the payload manifest
+func is_bagit_tag_file(name string) bool {
+	return name == "bagit.txt" || name == "bag-info.txt" ||
+		(strings.HasSuffix(name, ".txt") && (strings.HasPrefix(name, "manifest-") || strings.HasPrefix(name, "tagmanifest-")))
+}
+
+// a file path as the manifest writes it: relative, with forward slashes, and CR, LF and % percent-encoded
+func bagit_path(rel string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(filepath.ToSlash(rel))
+}
+
+// the checksum of a file in hex
+func bagit_checksum(fname string, new_hash func() hash.Hash) (string, error) {
+	f, err := os.Open(fname)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := new_hash()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+// the bag-info.txt lines taken from doc: the title, the affiliation of the first creator, the ContactPerson
+// (otherwise the first creator) and a Keywords line per tag
+func bagit_info(doc Yoda18Metadata) [][2]string {
+	var info [][2]string
+	if title := strings.Join(strings.Fields(doc.Title), " "); title != "" {
+		info = append(info, [2]string{"External-Description", title})
+	}
+	if len(doc.Creator) > 0 {
+		if len(doc.Creator[0].Affiliation) > 0 {
+			info = append(info, [2]string{"Source-Organization", doc.Creator[0].Affiliation[0]})
+		}
+	}
+	contact := ""
+	for _, con := range doc.Contributor {
+		if con.ContributorType == "ContactPerson" {
+			contact = formatName(con.Name, "full")
+			break
+		}
+	}
+	if contact == "" && len(doc.Creator) > 0 {
+		contact = formatName(doc.Creator[0].Name, "full")
+	}
+	if contact != "" {
+		info = append(info, [2]string{"Contact-Name", contact})
+	}
+	for _, tag := range doc.Tag {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			info = append(info, [2]string{"Keywords", tag})
+		}
+	}
+	return info
+}
+
+// WriteBagItManifest writes bagit.txt, bag-info.txt and manifest-<algorithm>.txt (sha256 or sha512) to dir,
+// the manifest lists the checksum of every file below dir except the tag files; bag-info.txt describes the
+// first of docs and gives the Payload-Oxum (bytes.files) of the listed files
+func WriteBagItManifest(dir string, docs []Yoda18Metadata, algorithm string) error {
+	new_hash, ok := bagit_algorithms[algorithm]
+	if !ok {
+		return fmt.Errorf("unknown BagIt checksum algorithm %q, use sha256 or sha512", algorithm)
+	}
+
+	var files []string
+	var octets int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == d.Name() && is_bagit_tag_file(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		octets += info.Size()
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	var manifest strings.Builder
+	for _, rel := range files {
+		sum, err := bagit_checksum(filepath.Join(dir, rel), new_hash)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&manifest, "%s  %s\n", sum, bagit_path(rel))
+	}
+
+	bagit := fmt.Sprintf("BagIt-Version: %s\nTag-File-Character-Encoding: UTF-8\n", bagit_version)
+
+	var info strings.Builder
+	fmt.Fprintf(&info, "Bagging-Date: %s\n", time.Now().Format("2006-01-02"))
+	fmt.Fprintf(&info, "Bag-Software-Agent: readYmeta v%s\n", _MYVERSION_)
+	fmt.Fprintf(&info, "Payload-Oxum: %d.%d\n", octets, len(files))
+	if len(docs) > 0 {
+		for _, line := range bagit_info(docs[0]) {
+			// a tag value is a single line
+			fmt.Fprintf(&info, "%s: %s\n", line[0], strings.Join(strings.Fields(line[1]), " "))
+		}
+	}
+
+	for name, content := range map[string]string{
+		"bagit.txt":                      bagit,
+		"bag-info.txt":                   info.String(),
+		"manifest-" + algorithm + ".txt": manifest.String(),
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+/*
+batch.go reading and converting several input files concurrently with a pool of workers.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"sync"
+)
+
+// the outcome of processing one input file
+type batch_result struct {
+	File string
+	Data Yoda18Metadata
+	Err  error
+}
+
+// process every file with work using the given number of goroutines, the results are in the order of fnames,
+// a failing or panicking file only sets the Err of its own result; once ctx is cancelled no further files are
+// started, their results carry the context error which is also returned
+func run_batch(ctx context.Context, fnames []string, workers int, work func(context.Context, string) (Yoda18Metadata, error)) ([]batch_result, error) {
+	results := make([]batch_result, len(fnames))
+	err := run_batch_each(ctx, fnames, workers, work, func(i int, res batch_result) {
+		results[i] = res
+	})
+	return results, err
+}
+
+// process every file with work like run_batch, but hand each result to each as soon as its file is done
+// instead of collecting them, so the results of a long run can be written while it goes on; each is called
+// for one result at a time, with the index of its file in fnames
+func run_batch_each(ctx context.Context, fnames []string, workers int, work func(context.Context, string) (Yoda18Metadata, error), each func(int, batch_result)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	progress := new_batch_progress(len(fnames))
+
+	var mu sync.Mutex
+	done := 0
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					mu.Lock()
+					each(i, batch_result{File: fnames[i], Err: ctx.Err()})
+					mu.Unlock()
+					continue
+				}
+				res := batch_process(ctx, fnames[i], work)
+
+				mu.Lock()
+				done++
+				slog.Info("processed", "file", fnames[i], "done", done, "total", len(fnames), "ok", res.Err == nil)
+				progress.update(res.Err == nil)
+				each(i, res)
+				mu.Unlock()
+			}
+		}()
+	}
+	fed := 0
+feed:
+	for fed < len(fnames) {
+		select {
+		case jobs <- fed:
+			fed++
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	progress.finish()
+
+	// files that were never handed to a worker
+	for i := fed; i < len(fnames); i++ {
+		each(i, batch_result{File: fnames[i], Err: ctx.Err()})
+	}
+	return ctx.Err()
+}
+
+// run work on a single file, turning a panic into an error
+func batch_process(ctx context.Context, fname string, work func(context.Context, string) (Yoda18Metadata, error)) (res batch_result) {
+	res.File = fname
+	defer func() {
+		if r := recover(); r != nil {
+			res.Err = fmt.Errorf("processing failed: %v", r)
+		}
+	}()
+	res.Data, res.Err = work(ctx, fname)
+	return res
+}
+
+// convert every input file to its own output in the given format
+func write_batch_format(ctx context.Context, fnames []string, format string, output_path string, workers int) error {
+	results, err := run_batch(ctx, fnames, workers, func(ctx context.Context, fname string) (Yoda18Metadata, error) {
+		data, err := read_metadata_file(ctx, fname)
+		if err != nil {
+			return data, err
+		}
+		return data, write_output_format(data, format, filepath.Join(output_path, input_file_stem(fname)), "", fname)
+	})
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("conversion failed", "file", res.File, "error", res.Err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(fnames))
+	}
+	return nil
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the test-data metadata files
+func batch_test_files(t testing.TB) []string {
+	t.Helper()
+	fnames, err := filepath.Glob(filepath.Join("test-data", "yoda-metadata*.json"))
+	if err != nil || len(fnames) == 0 {
+		t.Fatalf("no test metadata files: %v", err)
+	}
+	return fnames
+}
+
+func TestRunBatch(t *testing.T) {
+	fnames := append(batch_test_files(t), filepath.Join("test-data", "missing.json"))
+	results, err := run_batch(context.Background(), fnames, 4, read_metadata_file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, res := range results {
+		if res.File != fnames[i] {
+			t.Errorf("result %d is of %s, want %s", i, res.File, fnames[i])
+		}
+		if missing := i == len(fnames)-1; (res.Err != nil) != missing {
+			t.Errorf("%s: error %v", res.File, res.Err)
+		}
+	}
+}
+
+func TestRunBatchPanic(t *testing.T) {
+	results, err := run_batch(context.Background(), []string{"a", "b"}, 2, func(_ context.Context, fname string) (Yoda18Metadata, error) {
+		if fname == "a" {
+			panic("broken")
+		}
+		return Yoda18Metadata{}, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Err == nil || results[1].Err != nil {
+		t.Errorf("errors %v and %v, want only the panicking file to fail", results[0].Err, results[1].Err)
+	}
+}
+
+func TestRunBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fnames := []string{"a", "b", "c", "d"}
+	started := 0
+	results, err := run_batch(ctx, fnames, 1, func(context.Context, string) (Yoda18Metadata, error) {
+		started++
+		// cancelled while the first file is processed, the others must not be started
+		cancel()
+		return Yoda18Metadata{}, nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v, want context.Canceled", err)
+	}
+	if started != 1 {
+		t.Errorf("%d files started after the cancel, want 1", started)
+	}
+	for _, res := range results[1:] {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("%s: error %v, want context.Canceled", res.File, res.Err)
+		}
+	}
+}
+
+func TestRunBatchTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := run_batch(ctx, []string{"a", "b", "c"}, 1, func(ctx context.Context, _ string) (Yoda18Metadata, error) {
+		<-ctx.Done()
+		return Yoda18Metadata{}, nil
+	})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestReadMetadataFileCancel(t *testing.T) {
+	set_test_flag(t, "ror-enrich", "true")
+	set_test_flag(t, "funder-enrich", "true")
+	logged := capture_test_log(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the lookups get the cancelled batch context and give up at once instead of waiting for their timeouts
+	start := time.Now()
+	data, err := read_metadata_file(ctx, filepath.Join("test-data", "yoda-metadata[douwe].json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %v after the cancel", elapsed)
+	}
+	for _, want := range []string{"ROR enrichment failed", "funder enrichment failed"} {
+		if !strings.Contains(logged.String(), want) {
+			t.Errorf("log misses %q:\n%s", want, logged)
+		}
+	}
+	if strings.Count(logged.String(), "context canceled") != 2 {
+		t.Errorf("lookups not cancelled:\n%s", logged)
+	}
+	if data.Creator[0].Affiliation[0] != "Vrije Universiteit Amsterdam" {
+		t.Errorf("affiliation %q, want it kept as given", data.Creator[0].Affiliation[0])
+	}
+}
+
+func TestValidateAllCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	validations, err := ValidateAll(ctx, batch_test_files(t), 2, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("error %v, want context.Canceled", err)
+	}
+	if validations != nil {
+		t.Errorf("validations %v of a cancelled run", validations)
+	}
+}
+
+func TestValidateAll(t *testing.T) {
+	fnames := []string{filepath.Join("test-data", "yoda-metadata.json"), filepath.Join("test-data", "missing.json")}
+	validations, err := ValidateAll(context.Background(), fnames, 2, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(validations) != 2 || validations[0].File != fnames[0] {
+		t.Fatalf("validations %v, want one per file in order", validations)
+	}
+	if len(validations[1].Issues) != 1 || validations[1].Issues[0].Severity != severity_error {
+		t.Errorf("issues of a missing file %v, want a single error", validations[1].Issues)
+	}
+}
+
+func TestRenderCancel(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := RenderHTML(ctx, doc, true); !errors.Is(err, context.Canceled) {
+		t.Errorf("RenderHTML error %v, want context.Canceled", err)
+	}
+	var buf bytes.Buffer
+	if err := RenderPDF(ctx, doc, "test", &buf); !errors.Is(err, context.Canceled) {
+		t.Errorf("RenderPDF error %v, want context.Canceled", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("RenderPDF wrote %d bytes after the cancel", buf.Len())
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := RenderPDF(ctx, doc, "test", &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) {
+		t.Error("RenderPDF did not write a PDF")
+	}
+}
+
+// copies of the test-data metadata files, n in total, in a directory of their own
+func batch_bench_dir(b *testing.B, n int) string {
+	b.Helper()
+	dir := b.TempDir()
+	fnames := batch_test_files(b)
+	for i := 0; i < n; i++ {
+		raw, err := os.ReadFile(fnames[i%len(fnames)])
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("dataset-%03d.json", i)), raw, 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// the worker counts the benchmarks compare: a single worker and one per CPU, at least 4
+var batch_bench_workers = []int{1, max(4, runtime.NumCPU())}
+
+func BenchmarkRunBatch(b *testing.B) {
+	set_test_flag(b, "no-progress", "true")
+	fnames, err := metadata_input_files([]string{batch_bench_dir(b, 64)})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, workers := range batch_bench_workers {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				results, err := run_batch(context.Background(), fnames, workers, func(ctx context.Context, fname string) (Yoda18Metadata, error) {
+					data, err := read_metadata_file(ctx, fname)
+					if err == nil {
+						Validate(data, false)
+					}
+					return data, err
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, res := range results {
+					if res.Err != nil {
+						b.Fatal(res.Err)
+					}
+				}
+			}
+			b.ReportMetric(float64(b.N*len(fnames))/b.Elapsed().Seconds(), "files/s")
+		})
+	}
+}
+
+// a directory argument converted as -dir does, one worker against several
+func BenchmarkDirectory(b *testing.B) {
+	set_test_flag(b, "no-progress", "true")
+	dir := batch_bench_dir(b, 64)
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{{"sequential", 1}, {"parallel", batch_bench_workers[1]}} {
+		b.Run(bench.name, func(b *testing.B) {
+			out := b.TempDir()
+			for i := 0; i < b.N; i++ {
+				fnames, err := metadata_input_files([]string{dir})
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err := write_batch_format(context.Background(), fnames, "jsonld", out, bench.workers); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// the output of a directory is the same whatever the number of workers
+func TestDirectoryDeterministic(t *testing.T) {
+	set_test_flag(t, "no-progress", "true")
+	dir := t.TempDir()
+	for i, fname := range batch_test_files(t) {
+		raw, err := os.ReadFile(fname)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("dataset-%d.json", i)), raw, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fnames, err := metadata_input_files([]string{dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var outputs [2]map[string]string
+	for i, workers := range []int{1, 4} {
+		out := t.TempDir()
+		if err := write_batch_format(context.Background(), fnames, "jsonld", out, workers); err != nil {
+			t.Fatal(err)
+		}
+		outputs[i] = map[string]string{}
+		err := filepath.WalkDir(out, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			raw, err := os.ReadFile(path)
+			outputs[i][strings.TrimPrefix(path, out)] = string(raw)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(outputs[0]) != len(fnames) || !maps.Equal(outputs[0], outputs[1]) {
+		t.Errorf("the output of 1 and 4 workers differs: %d and %d files for %d inputs", len(outputs[0]), len(outputs[1]), len(fnames))
+	}
+}
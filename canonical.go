@@ -0,0 +1,231 @@
+/*
+canonical.go writes Yoda metadata as pretty-printed JSON with the keys in the order of the Yoda metadata form,
+so normalised files give clean diffs.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+)
+
+// the key order of each object, by the key that holds it ("" is the document itself); it follows the Yoda
+// metadata form (and the bundled schema) except that links, which the form does not show, comes last
+var canonical_key_order = map[string][]string{
+	"": {"Title", "Description", "Discipline", "Version", "Language", "Collected", "Covered_Geolocation_Place",
+		"Covered_Period", "Tag", "Related_Datapackage", "Retention_Period", "Retention_Information", "Embargo_End_Date",
+		"Data_Classification", "Collection_Name", "Funding_Reference", "Remarks", "Creator", "Contributor", "Data_Type",
+		"Data_Access_Restriction", "License", "links", "System"},
+	"Collected":             {"Start_Date", "End_Date"},
+	"Covered_Period":        {"Start_Date", "End_Date"},
+	"Related_Datapackage":   {"Persistent_Identifier", "Relation_Type", "Title"},
+	"Persistent_Identifier": {"Identifier_Scheme", "Identifier"},
+	"Funding_Reference":     {"Funder_Name", "Award_Number"},
+	"Creator":               {"Name", "Affiliation", "Person_Identifier"},
+	"Contributor":           {"Name", "Affiliation", "Person_Identifier", "Contributor_Type"},
+	"Name":                  {"Given_Name", "Family_Name"},
+	"Person_Identifier":     {"Name_Identifier_Scheme", "Name_Identifier"},
+	"links":                 {"rel", "href"},
+	"System": {"Last_Modified_Date", "Persistent_Identifier_Datapackage", "Publication_Date", "Open_Access_Link",
+		"License_URI"},
+	"Persistent_Identifier_Datapackage": {"Identifier_Scheme", "Identifier"},
+}
+
+// the key order of -pretty, which lists no keys so those of every object are sorted alphabetically
+var sorted_key_order = map[string][]string{}
+
+// the key order of the -format json and combi output, alphabetical with -pretty
+func output_key_order() map[string][]string {
+	if *pretty_json {
+		return sorted_key_order
+	}
+	return canonical_key_order
+}
+
+// the keys of an object in the order of the key holding it, keys missing from the order list follow
+// alphabetically
+func canonical_keys(order map[string][]string, parent string, obj map[string]interface{}) []string {
+	rank := make(map[string]int)
+	for i, key := range order[parent] {
+		rank[key] = i + 1
+	}
+	var keys []string
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		ri, rj := rank[keys[i]], rank[keys[j]]
+		switch {
+		case ri != 0 && rj != 0:
+			return ri < rj
+		case ri != 0 || rj != 0:
+			return ri != 0
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// true for the values the V2 omitempty tags leave out, and for objects left empty by them
+func canonical_empty(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		for _, item := range t {
+			if !canonical_empty(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// write v as indented JSON, parent is the key holding v and selects its key order
+func canonical_write(buf *bytes.Buffer, order map[string][]string, parent string, v interface{}, indent string) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		var keys []string
+		for _, key := range canonical_keys(order, parent, t) {
+			if !canonical_empty(t[key]) {
+				keys = append(keys, key)
+			}
+		}
+		if len(keys) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i, key := range keys {
+			buf.WriteString(indent + "  ")
+			err := canonical_scalar(buf, key)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(": ")
+			err = canonical_write(buf, order, key, t[key], indent+"  ")
+			if err != nil {
+				return err
+			}
+			if i < len(keys)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "}")
+	case []interface{}:
+		if len(t) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range t {
+			buf.WriteString(indent + "  ")
+			// the items of an array are ordered like the objects of the key holding the array
+			err := canonical_write(buf, order, parent, item, indent+"  ")
+			if err != nil {
+				return err
+			}
+			if i < len(t)-1 {
+				buf.WriteString(",")
+			}
+			buf.WriteString("\n")
+		}
+		buf.WriteString(indent + "]")
+	default:
+		return canonical_scalar(buf, v)
+	}
+	return nil
+}
+
+// write a string, number or boolean without escaping <, > and & so the text stays readable
+func canonical_scalar(buf *bytes.Buffer, v interface{}) error {
+	var sb strings.Builder
+	enc := json.NewEncoder(&sb)
+	enc.SetEscapeHTML(false)
+	err := enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	buf.WriteString(strings.TrimSuffix(sb.String(), "\n"))
+	return nil
+}
+
+// the metadata as a JSON object tree, with the empty optional fields dropped by the omitempty tags of
+// Yoda18MetadataV2 and the unmapped fields of the input added
+func canonical_tree(doc Yoda18Metadata) (map[string]interface{}, error) {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	var v2 Yoda18MetadataV2
+	err = json.Unmarshal(raw, &v2)
+	if err != nil {
+		return nil, err
+	}
+	raw, err = json.Marshal(v2)
+	if err != nil {
+		return nil, err
+	}
+
+	// decode into maps to reorder the keys, numbers are kept as written
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var tree map[string]interface{}
+	err = dec.Decode(&tree)
+	if err != nil {
+		return nil, err
+	}
+	// the keys the model does not have are written back as they were read
+	for key, value := range doc.Unmapped {
+		dec := json.NewDecoder(bytes.NewReader(value))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, err
+		}
+		tree[key] = v
+	}
+	return tree, nil
+}
+
+// CanonicalJSON returns the metadata as JSON with a two space indent and the keys in the Yoda form order,
+// empty optional fields are dropped
+func CanonicalJSON(doc Yoda18Metadata) ([]byte, error) {
+	return ordered_json(doc, canonical_key_order)
+}
+
+// the metadata as indented JSON with the keys in the given order, empty optional fields are dropped
+func ordered_json(doc Yoda18Metadata, order map[string][]string) ([]byte, error) {
+	tree, err := canonical_tree(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	err = canonical_write(&buf, order, "", tree, "")
+	if err != nil {
+		return nil, err
+	}
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// exportJSON writes the canonical JSON of the metadata to w, with -pretty its keys sorted alphabetically
+func exportJSON(doc Yoda18Metadata, w io.Writer) error {
+	out, err := ordered_json(doc, output_key_order())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
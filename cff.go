@@ -0,0 +1,207 @@
+/*
+cff.go renders Yoda metadata as a CITATION.cff (Citation File Format 1.2) file recognised by GitHub and Zenodo.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CFF person author
+type CFFAuthor struct {
+	FamilyNames string `yaml:"family-names,omitempty"`
+	GivenNames  string `yaml:"given-names,omitempty"`
+	Affiliation string `yaml:"affiliation,omitempty"`
+	Orcid       string `yaml:"orcid,omitempty"`
+}
+
+// CFF identifier, type is one of doi, url, swh or other
+type CFFIdentifier struct {
+	Type        string `yaml:"type"`
+	Value       string `yaml:"value"`
+	Description string `yaml:"description,omitempty"`
+}
+
+// CFF 1.2 document
+type CFFDocument struct {
+	CFFVersion   string          `yaml:"cff-version"`
+	Message      string          `yaml:"message"`
+	Type         string          `yaml:"type"`
+	Title        string          `yaml:"title"`
+	Abstract     string          `yaml:"abstract,omitempty"`
+	Version      string          `yaml:"version,omitempty"`
+	Authors      []CFFAuthor     `yaml:"authors"`
+	Identifiers  []CFFIdentifier `yaml:"identifiers,omitempty"`
+	Keywords     []string        `yaml:"keywords,omitempty"`
+	License      string          `yaml:"license,omitempty"`
+	DateReleased string          `yaml:"date-released,omitempty"`
+}
+
+const cff_message string = "If you use this dataset, please cite it using the metadata from this file."
+
+// RenderCitationCFF creates a CFF 1.2 YAML document from the metadata, metadata without the title or authors CFF
+// requires gives an *invalid_output_error
+func RenderCitationCFF(doc Yoda18Metadata) ([]byte, error) {
+	cff := CFFDocument{
+		CFFVersion: "1.2.0",
+		Message:    cff_message,
+		Type:       "dataset",
+		Title:      doc.Title,
+		Abstract:   strings.TrimSpace(doc.Description),
+		Version:    doc.Version,
+	}
+
+	// CFF only accepts SPDX identifiers
+	if license, err := NormalizeLicense(doc.License); err == nil {
+		cff.License = license
+	}
+
+	for i := range doc.Creator {
+		author := CFFAuthor{
+			FamilyNames: doc.Creator[i].Name.FamilyName,
+			GivenNames:  doc.Creator[i].Name.GivenName,
+			Affiliation: strings.Join(doc.Creator[i].Affiliation, "; "),
+		}
+		for j := range doc.Creator[i].PersonIdentifier {
+			pid := doc.Creator[i].PersonIdentifier[j]
+			// the CFF schema rejects anything that is not a full ORCID URL
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && cff_orcid_pattern.MatchString(orcid_url(pid.NameIdentifier)) {
+				author.Orcid = orcid_url(pid.NameIdentifier)
+			}
+		}
+		// the CFF schema requires the authors, identifiers and keywords to be unique
+		if (author.FamilyNames != "" || author.GivenNames != "") && !slices.Contains(cff.Authors, author) {
+			cff.Authors = append(cff.Authors, author)
+		}
+	}
+
+	for i := range doc.Links {
+		// "describedby" points at the Yoda schema, not at the dataset
+		if doc.Links[i].Href != "" && doc.Links[i].Rel != "describedby" {
+			cff.Identifiers = cff_add_identifier(cff.Identifiers, cff_identifier("URL", doc.Links[i].Href, doc.Links[i].Rel))
+		}
+	}
+	for i := range doc.RelatedDatapackage {
+		pid := doc.RelatedDatapackage[i].PersistentIdentifier
+		if pid.Identifier != "" {
+			cff.Identifiers = cff_add_identifier(cff.Identifiers, cff_identifier(pid.IdentifierScheme, pid.Identifier, doc.RelatedDatapackage[i].Title))
+		}
+	}
+
+	for i := range doc.Tag {
+		if doc.Tag[i] != "" && !slices.Contains(cff.Keywords, doc.Tag[i]) {
+			cff.Keywords = append(cff.Keywords, doc.Tag[i])
+		}
+	}
+
+	// the end of the collection, or of the embargo, is the closest Yoda has to a release date
+	for _, date := range []string{doc.Collected.EndDate, doc.EmbargoEndDate} {
+		if len(csl_date_parts(date)) == 3 {
+			cff.DateReleased = date
+			break
+		}
+	}
+
+	err := validate_cff(cff)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(&cff)
+}
+
+// patterns from the CFF 1.2.0 schema
+var cff_orcid_pattern = regexp.MustCompile(`^https://orcid\.org/[0-9]{4}-[0-9]{4}-[0-9]{4}-[0-9]{3}[0-9X]$`)
+var cff_doi_pattern = regexp.MustCompile(`^10\.\d{4,9}(\.\d+)?/[A-Za-z0-9:/_;\-\.\(\)\[\]\\]+$`)
+var cff_date_pattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+
+// check the document against the CFF 1.2.0 schema rules so GitHub does not reject the file
+func validate_cff(cff CFFDocument) error {
+	var problems []string
+
+	if cff.CFFVersion == "" {
+		problems = append(problems, "cff-version is required")
+	}
+	if cff.Message == "" {
+		problems = append(problems, "message is required")
+	}
+	if strings.TrimSpace(cff.Title) == "" {
+		problems = append(problems, "title is required")
+	}
+	if len(cff.Authors) == 0 {
+		problems = append(problems, "at least one author is required")
+	}
+	for i, author := range cff.Authors {
+		if author.Orcid != "" && !cff_orcid_pattern.MatchString(author.Orcid) {
+			problems = append(problems, fmt.Sprintf("authors[%d].orcid is not a valid ORCID URL: %s", i, author.Orcid))
+		}
+	}
+	for i, id := range cff.Identifiers {
+		switch id.Type {
+		case "doi":
+			if !cff_doi_pattern.MatchString(id.Value) {
+				problems = append(problems, fmt.Sprintf("identifiers[%d] is not a valid DOI: %s", i, id.Value))
+			}
+		case "url", "swh", "other":
+		default:
+			problems = append(problems, fmt.Sprintf("identifiers[%d] has an unknown type: %s", i, id.Type))
+		}
+	}
+	if cff.DateReleased != "" && !cff_date_pattern.MatchString(cff.DateReleased) {
+		problems = append(problems, "date-released is not a YYYY-MM-DD date: "+cff.DateReleased)
+	}
+
+	if len(problems) > 0 {
+		return &invalid_output_error{"CITATION.cff", problems}
+	}
+	return nil
+}
+
+// map a Yoda identifier scheme and value to a CFF identifier
+func cff_identifier(scheme string, value string, description string) CFFIdentifier {
+	if doi := doi_from_string(value); doi != "" && cff_doi_pattern.MatchString(doi) {
+		return CFFIdentifier{Type: "doi", Value: doi, Description: description}
+	}
+	if link, err := NormalizePID(scheme, value); err == nil {
+		return CFFIdentifier{Type: "url", Value: link, Description: description}
+	}
+	if strings.EqualFold(scheme, "URL") || strings.HasPrefix(value, "http://") || strings.HasPrefix(value, "https://") {
+		return CFFIdentifier{Type: "url", Value: value, Description: description}
+	}
+	return CFFIdentifier{Type: "other", Value: value, Description: description}
+}
+
+// add an identifier unless the same type and value are already listed
+func cff_add_identifier(ids []CFFIdentifier, id CFFIdentifier) []CFFIdentifier {
+	for _, listed := range ids {
+		if listed.Type == id.Type && listed.Value == id.Value {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
+
+// an ORCID as the https URL form, bare identifiers are prefixed with the ORCID resolver
+func orcid_url(orcid string) string {
+	orcid = strings.TrimSpace(orcid)
+	orcid = strings.TrimPrefix(orcid, "http://orcid.org/")
+	orcid = strings.TrimPrefix(orcid, "https://orcid.org/")
+	return "https://orcid.org/" + orcid
+}
+
+// exportCFF writes the CITATION.cff YAML to w, metadata missing keys CFF requires gives the *invalid_output_error
+// of RenderCitationCFF and nothing is written
+func exportCFF(doc Yoda18Metadata, w io.Writer) error {
+	out, err := RenderCitationCFF(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
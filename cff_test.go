@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderCitationCFFInvalid(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[blank].json")
+	_, err := RenderCitationCFF(doc)
+	var invalid *invalid_output_error
+	if !errors.As(err, &invalid) {
+		t.Fatalf("error %v, want an *invalid_output_error", err)
+	}
+	if !strings.Contains(err.Error(), "title is required") {
+		t.Errorf("error %q does not name the missing title", err)
+	}
+}
+
+func TestExportCFFInvalid(t *testing.T) {
+	var buf bytes.Buffer
+	err := exportCFF(load_test_metadata(t, "yoda-metadata[blank].json"), &buf)
+	var invalid *invalid_output_error
+	if !errors.As(err, &invalid) {
+		t.Fatalf("error %v, want an *invalid_output_error", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("%d bytes written for invalid metadata", buf.Len())
+	}
+}
+
+// the CITATION.cff as read by a CFF tool: YAML, checked against the CFF schema
+func read_test_cff(t *testing.T, raw []byte) map[string]any {
+	t.Helper()
+	var cff map[string]any
+	if err := yaml.Unmarshal(raw, &cff); err != nil {
+		t.Fatalf("the CITATION.cff is not YAML: %v", err)
+	}
+	js, err := json.Marshal(cff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check_test_schema(t, "citation-cff.json", js)
+	return cff
+}
+
+func TestRenderCitationCFF(t *testing.T) {
+	for _, name := range []string{"yoda-metadata.json", "yoda-metadata[douwe].json", "yoda-metadata[test].json"} {
+		doc := load_test_metadata(t, name)
+		raw, err := RenderCitationCFF(doc)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		cff := read_test_cff(t, raw)
+		if cff["title"] != doc.Title {
+			t.Errorf("%s: title %v, want %q", name, cff["title"], doc.Title)
+		}
+		if license, ok := cff["license"].(string); ok && canonical_license(license) != license {
+			t.Errorf("%s: license %q is not an SPDX identifier", name, license)
+		}
+		orcids := 0
+		for _, a := range cff["authors"].([]any) {
+			if orcid, ok := a.(map[string]any)["orcid"].(string); ok {
+				orcids++
+				if !strings.HasPrefix(orcid, "https://orcid.org/") {
+					t.Errorf("%s: orcid %q is not a full URL", name, orcid)
+				}
+			}
+		}
+		want := 0
+		for _, cre := range doc.Creator {
+			for _, pid := range cre.PersonIdentifier {
+				if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && cff_orcid_pattern.MatchString(orcid_url(pid.NameIdentifier)) {
+					want++
+				}
+			}
+		}
+		if orcids != want {
+			t.Errorf("%s: %d ORCIDs, want %d", name, orcids, want)
+		}
+	}
+}
+
+func TestOrcidURL(t *testing.T) {
+	for _, orcid := range []string{"0000-0002-1825-0097", " https://orcid.org/0000-0002-1825-0097", "http://orcid.org/0000-0002-1825-0097"} {
+		if got := orcid_url(orcid); got != "https://orcid.org/0000-0002-1825-0097" {
+			t.Errorf("orcid_url(%q) = %q", orcid, got)
+		}
+	}
+}
+
+func TestCFFIdentifier(t *testing.T) {
+	tests := []struct {
+		scheme, value string
+		want          string
+	}{
+		{"DOI", "10.1234/abc.def", "doi"},
+		{"DOI", "https://doi.org/10.1234/abc", "doi"},
+		{"URL", "https://example.org/data", "url"},
+		{"Handle", "20.500.12345/678", "url"},
+		{"ISBN", "not an isbn", "other"},
+	}
+	for _, tt := range tests {
+		if got := cff_identifier(tt.scheme, tt.value, ""); got.Type != tt.want {
+			t.Errorf("cff_identifier(%q, %q) type %q, want %q", tt.scheme, tt.value, got.Type, tt.want)
+		}
+	}
+}
+
+// the schema check itself rejects what GitHub rejects
+func TestCFFSchemaRejects(t *testing.T) {
+	for _, raw := range []string{
+		`{"cff-version": "1.2.0", "message": "m", "title": "t", "authors": [{"family-names": "F", "orcid": "0000-0002-1825-0097"}]}`,
+		`{"cff-version": "1.2.0", "message": "m", "title": "t", "authors": []}`,
+		`{"cff-version": "1.2.0", "message": "m", "title": "t", "authors": [{"family-names": "F"}], "keywords": ["a", "a"]}`,
+		`{"cff-version": "1.2.0", "message": "m", "title": "t", "authors": [{"family-names": "F"}], "identifiers": [{"type": "doi", "value": "https://doi.org/10.1234/x"}]}`,
+	} {
+		violations, err := validate_json_schema([]byte(raw), filepath.Join("test-data", "schemas", "citation-cff.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(violations) == 0 {
+			t.Errorf("%s passes the schema", raw)
+		}
+	}
+}
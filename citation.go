@@ -0,0 +1,257 @@
+/*
+citation.go formatting a ready to paste dataset citation, in APA (7th edition) or in one of the CSL styles.
+*/
+
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// the bundled CSL styles
+//
+//go:embed assets/csl/*.csl
+var csl_style_files embed.FS
+
+// the CSL file of each bundled -citation-style, apa is formatted without CSL
+var citation_styles = map[string]string{
+	"vancouver": "assets/csl/vancouver.csl",
+	"chicago":   "assets/csl/chicago-author-date.csl",
+	"ieee":      "assets/csl/ieee.csl",
+}
+
+// the publisher named in the citation
+const citation_publisher string = "Yoda, Vrije Universiteit Amsterdam"
+
+// APA lists up to this many authors, more are shortened to the first ones, an ellipsis and the last author
+const apa_max_authors int = 20
+
+// a date that starts with a year
+var citation_year_pattern = regexp.MustCompile(`^([0-9]{4})`)
+
+// the initials of given names, "Jan-Willem Anne" gives "J.-W. A."
+func apa_initials(given string) string {
+	var words []string
+	for _, word := range strings.Fields(given) {
+		var parts []string
+		for _, part := range strings.Split(word, "-") {
+			if r := []rune(strings.Trim(part, ".")); len(r) > 0 {
+				parts = append(parts, string(r[0])+".")
+			}
+		}
+		if len(parts) > 0 {
+			words = append(words, strings.Join(parts, "-"))
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// an author as "Family, G."
+func apa_author(name NameStruct) string {
+	family := strings.TrimSpace(name.FamilyName)
+	initials := apa_initials(name.GivenName)
+	if family == "" || initials == "" {
+		return family + initials
+	}
+	return family + ", " + initials
+}
+
+// the author list: one author as is, two to twenty joined with ", " and ", & " before the last, more than
+// twenty as the first nineteen, an ellipsis and the last author
+func apa_author_list(authors []string) string {
+	switch {
+	case len(authors) == 0:
+		return ""
+	case len(authors) == 1:
+		return authors[0]
+	case len(authors) > apa_max_authors:
+		return strings.Join(authors[:apa_max_authors-1], ", ") + ", . . . " + authors[len(authors)-1]
+	}
+	return strings.Join(authors[:len(authors)-1], ", ") + ", & " + authors[len(authors)-1]
+}
+
+// the year of the citation: from the Publication_Date of the data package, otherwise the Collected end date,
+// otherwise the Embargo_End_Date, otherwise now
+func citation_year(doc Yoda18Metadata, sys System, now time.Time) string {
+	for _, date := range []string{sys.PublicationDate, doc.Collected.EndDate, doc.EmbargoEndDate} {
+		if m := citation_year_pattern.FindStringSubmatch(strings.TrimSpace(date)); m != nil {
+			return m[1]
+		}
+	}
+	return fmt.Sprint(now.Year())
+}
+
+// the DOI of the dataset: the one of the data package (from a vault export, -system or -set DOI=...),
+// otherwise a related datapackage that IsIdenticalTo it, otherwise a DOI in the links
+func citation_doi(doc Yoda18Metadata, sys System) string {
+	if pid := sys.PersistentIdentifierDatapackage; strings.EqualFold(pid.IdentifierScheme, "DOI") && pid.Identifier != "" {
+		return doi_from_string(pid.Identifier)
+	}
+	for _, rel := range doc.RelatedDatapackage {
+		pid := rel.PersistentIdentifier
+		if datacite_relation_type(rel.RelationType) == "IsIdenticalTo" && strings.EqualFold(pid.IdentifierScheme, "DOI") {
+			if doi := doi_from_string(pid.Identifier); doi != "" {
+				return doi
+			}
+		}
+	}
+	for _, link := range doc.Links {
+		if doi := doi_from_string(link.Href); doi != "" {
+			return doi
+		}
+	}
+	return ""
+}
+
+// the APA citation of the dataset on a single line, without authors the title takes their place
+func apa_citation(doc Yoda18Metadata, sys System, now time.Time) string {
+	var authors []string
+	for _, cre := range doc.Creator {
+		if a := apa_author(cre.Name); a != "" {
+			authors = append(authors, a)
+		}
+	}
+	title := strings.Join(strings.Fields(doc.Title), " ")
+	if version := strings.TrimSpace(doc.Version); version != "" {
+		title += " (Version " + version + ")"
+	}
+	title += " [Data set]."
+	year := "(" + citation_year(doc, sys, now) + ")."
+
+	var parts []string
+	if list := apa_author_list(authors); list != "" {
+		// an author list ending in an initial already has its period
+		if !strings.HasSuffix(list, ".") {
+			list += "."
+		}
+		parts = append(parts, list, year, title)
+	} else {
+		parts = append(parts, title, year)
+	}
+	parts = append(parts, citation_publisher+".")
+	if doi := citation_doi(doc, sys); doi != "" {
+		parts = append(parts, pid_link("DOI", doi))
+	}
+	return strings.Join(parts, " ")
+}
+
+// check that a -citation-style value is supported
+func check_citation_style(style string) error {
+	names := []string{"apa"}
+	for name := range citation_styles {
+		names = append(names, name)
+	}
+	if slices.Contains(names, style) {
+		return nil
+	}
+	sort.Strings(names[1:])
+	return fmt.Errorf("unknown citation style %q, use one of: %s", style, strings.Join(names, ", "))
+}
+
+// the CSL style in style_file, otherwise the bundled one of the named style
+func load_citation_style(style string, style_file string) (*csl_style, error) {
+	var raw []byte
+	var err error
+	if style_file == "" {
+		raw, err = csl_style_files.ReadFile(citation_styles[style])
+		if err != nil {
+			return nil, err
+		}
+		return parse_csl_style(raw)
+	}
+	raw, err = os.ReadFile(style_file)
+	if err != nil {
+		return nil, err
+	}
+	csl, err := parse_csl_style(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", style_file, err)
+	}
+	return csl, nil
+}
+
+// the CSL-JSON item of the citation, with the same DOI and year as the APA citation
+func citation_item(doc Yoda18Metadata, sys System, now time.Time) CSLItem {
+	item := csl_item_from_metadata(doc)
+	if doi := citation_doi(doc, sys); doi != "" {
+		item.DOI = doi
+	}
+	if item.Issued == nil || citation_year_pattern.MatchString(strings.TrimSpace(sys.PublicationDate)) {
+		var year int
+		fmt.Sscan(citation_year(doc, sys, now), &year)
+		item.Issued = &CSLDate{DateParts: [][]int{{year}}}
+	}
+	return item
+}
+
+// the citation of the dataset in style, or in the CSL style read from style_file when given, as plain text or
+// as HTML; sys gives the DOI of the data package
+func format_citation(doc Yoda18Metadata, sys System, style string, style_file string, as_html bool, now time.Time) (string, error) {
+	if style_file == "" && style == "apa" {
+		citation := apa_citation(doc, sys, now)
+		if as_html {
+			citation = html.EscapeString(citation)
+		}
+		return citation, nil
+	}
+	csl, err := load_citation_style(style, style_file)
+	if err != nil {
+		return "", err
+	}
+	return render_csl(csl, citation_item(doc, sys, now), as_html)
+}
+
+// exportCitation writes the citation of the metadata read from source to w, in the -citation-style
+func exportCitation(doc Yoda18Metadata, source string, w io.Writer) error {
+	citation, err := format_citation(doc, oai_system(doc, source), *citation_style, *citation_style_file, *citation_html, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, citation)
+	return err
+}
+
+// formatCitation gives the citation of the dataset in one of the bundled styles as a single line of plain text,
+// for a landing page; the DOI and year are those of the System block given with -system or -set, if any
+func formatCitation(doc Yoda18Metadata, style string) (string, error) {
+	if err := check_citation_style(style); err != nil {
+		return "", err
+	}
+	citation, err := format_citation(doc, oai_system(doc, ""), style, "", false, time.Now())
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(citation), " "), nil
+}
+
+// print the citation of every file on a line of its own, in the -citation-style, in the order of fnames
+func write_citations(ctx context.Context, w io.Writer, fnames []string, workers int) error {
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, res := range results {
+		if res.Err != nil {
+			return fmt.Errorf("%s: %w", res.File, res.Err)
+		}
+		citation, err := format_citation(res.Data, oai_system(res.Data, res.File), *citation_style, *citation_style_file, *citation_html, now)
+		if err != nil {
+			return fmt.Errorf("%s: %w", res.File, err)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(strings.Fields(citation), " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
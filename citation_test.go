@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// metadata with n creators "Family1, G." to "Familyn, G."
+func citation_test_metadata(t *testing.T, n int) Yoda18Metadata {
+	var creators []string
+	for i := 1; i <= n; i++ {
+		creators = append(creators, fmt.Sprintf(`{"Name": {"Given_Name": "Given", "Family_Name": "Family%d"}}`, i))
+	}
+	return parse_test_metadata(t, `{"Title": "A  dataset", "Version": "2", "Collected": {"End_Date": "2019-06-30"},
+		"Creator": [`+strings.Join(creators, ", ")+`]}`)
+}
+
+func TestAPACitationAuthors(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	rest := " (2019). A dataset (Version 2) [Data set]. " + citation_publisher + "."
+	tests := []struct {
+		creators int
+		want     string
+	}{
+		{1, "Family1, G." + rest},
+		{2, "Family1, G., & Family2, G." + rest},
+		{3, "Family1, G., Family2, G., & Family3, G." + rest},
+		{0, "A dataset (Version 2) [Data set]. (2019). " + citation_publisher + "."},
+	}
+	for _, tt := range tests {
+		if got := apa_citation(citation_test_metadata(t, tt.creators), System{}, now); got != tt.want {
+			t.Errorf("%d creators:\n got %s\nwant %s", tt.creators, got, tt.want)
+		}
+	}
+
+	twenty := apa_citation(citation_test_metadata(t, 20), System{}, now)
+	if strings.Contains(twenty, ". . .") || !strings.Contains(twenty, "Family19, G., & Family20, G. (2019)") {
+		t.Errorf("20 creators are all listed: %s", twenty)
+	}
+	many := apa_citation(citation_test_metadata(t, 21), System{}, now)
+	if !strings.HasPrefix(many, "Family1, G., ") || !strings.Contains(many, "Family19, G., . . . Family21, G. (2019)") {
+		t.Errorf("21 creators: %s", many)
+	}
+	if strings.Contains(many, "Family20,") {
+		t.Errorf("the 20th of 21 creators is listed: %s", many)
+	}
+}
+
+func TestAPAAuthor(t *testing.T) {
+	tests := map[NameStruct]string{
+		{GivenName: "Jan-Willem Anne", FamilyName: "de Vries"}: "de Vries, J.-W. A.",
+		{GivenName: "B. G.", FamilyName: "Olivier"}:            "Olivier, B. G.",
+		{GivenName: "Élodie", FamilyName: "Ñúñez"}:             "Ñúñez, É.",
+		{FamilyName: "Plato"}:                                  "Plato",
+		{GivenName: "Douwe"}:                                   "D.",
+	}
+	for name, want := range tests {
+		if got := apa_author(name); got != want {
+			t.Errorf("apa_author(%+v) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCitationYear(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	doc := parse_test_metadata(t, `{"Collected": {"End_Date": "2019-06-30"}, "Embargo_End_Date": "2030-01-01"}`)
+	if got := citation_year(doc, System{PublicationDate: "2021-03-04T10:00:00"}, now); got != "2021" {
+		t.Errorf("year %s, want the Publication_Date year", got)
+	}
+	if got := citation_year(doc, System{PublicationDate: "unknown"}, now); got != "2019" {
+		t.Errorf("year %s, want the Collected end year", got)
+	}
+	doc.Collected.EndDate = ""
+	if got := citation_year(doc, System{}, now); got != "2030" {
+		t.Errorf("year %s, want the Embargo_End_Date year", got)
+	}
+	doc.EmbargoEndDate = ""
+	if got := citation_year(doc, System{}, now); got != "2024" {
+		t.Errorf("year %s without dates, want the current year", got)
+	}
+}
+
+func TestAPACitationDOI(t *testing.T) {
+	doc := citation_test_metadata(t, 1)
+	var sys System
+	sys.PersistentIdentifierDatapackage.IdentifierScheme = "DOI"
+	sys.PersistentIdentifierDatapackage.Identifier = "doi:10.1234/ABC"
+	if got := apa_citation(doc, sys, time.Now()); !strings.HasSuffix(got, citation_publisher+". https://doi.org/10.1234/ABC") {
+		t.Errorf("citation %s does not end in the DOI link", got)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+codemeta.go exports Yoda metadata as a CodeMeta 2.0 codemeta.json for datasets that describe software.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// repeatable -set key=value flag, used to fill fields that Yoda has no counterpart for
+type set_flags []string
+
+func (s *set_flags) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *set_flags) Set(value string) error {
+	if !strings.Contains(value, "=") {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	*s = append(*s, value)
+	return nil
+}
+
+// the overrides as a map, later values for the same key win
+func (s set_flags) values() map[string]string {
+	out := make(map[string]string)
+	for _, kv := range s {
+		parts := strings.SplitN(kv, "=", 2)
+		out[strings.TrimSpace(parts[0])] = parts[1]
+	}
+	return out
+}
+
+// a CodeMeta person with optional ORCID @id and affiliation
+func codemeta_person(n NameStruct, affiliations []string, scheme_ids [][2]string) map[string]interface{} {
+	person := map[string]interface{}{
+		"@type":      "Person",
+		"givenName":  n.GivenName,
+		"familyName": n.FamilyName,
+	}
+	for _, id := range scheme_ids {
+		if strings.EqualFold(id[0], "ORCID") && id[1] != "" {
+			person["@id"] = orcid_url(id[1])
+		}
+	}
+	var orgs []map[string]interface{}
+	for _, aff := range affiliations {
+		if aff != "" {
+			orgs = append(orgs, map[string]interface{}{"@type": "Organization", "name": aff})
+		}
+	}
+	if len(orgs) > 0 {
+		person["affiliation"] = orgs
+	}
+	return person
+}
+
+// map the metadata to CodeMeta, overrides set or replace top level keys (an empty value removes the key)
+func codemeta_document(doc Yoda18Metadata, overrides map[string]string) map[string]interface{} {
+	cm := map[string]interface{}{
+		"@context": "https://doi.org/10.5063/schema/codemeta-2.0",
+		"@type":    "SoftwareSourceCode",
+		"name":     doc.Title,
+	}
+	if doc.Description != "" {
+		cm["description"] = doc.Description
+	}
+	if doc.Version != "" {
+		cm["version"] = doc.Version
+	}
+	if url := license_url(doc.License); url != "" {
+		cm["license"] = url
+	} else if doc.License != "" {
+		cm["license"] = doc.License
+	}
+
+	var authors []map[string]interface{}
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		authors = append(authors, codemeta_person(cre.Name, cre.Affiliation, ids))
+	}
+	if len(authors) > 0 {
+		cm["author"] = authors
+	}
+
+	var contributors []map[string]interface{}
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		contributors = append(contributors, codemeta_person(con.Name, con.Affiliation, ids))
+	}
+	if len(contributors) > 0 {
+		cm["contributor"] = contributors
+	}
+
+	var keywords []string
+	for _, tag := range doc.Tag {
+		if tag != "" {
+			keywords = append(keywords, tag)
+		}
+	}
+	if len(keywords) > 0 {
+		cm["keywords"] = keywords
+	}
+
+	// CodeMeta funding is free text, the funders are Organizations
+	var funding []string
+	var funders []map[string]interface{}
+	for _, fund := range doc.FundingReference {
+		name := strings.TrimSpace(fund.FunderName)
+		if name == "" {
+			continue
+		}
+		if fund.AwardNumber != "" {
+			funding = append(funding, fmt.Sprintf("%s (%s)", name, fund.AwardNumber))
+		} else {
+			funding = append(funding, name)
+		}
+		funders = append(funders, map[string]interface{}{"@type": "Organization", "name": name})
+	}
+	if len(funding) > 0 {
+		cm["funding"] = strings.Join(funding, "; ")
+		cm["funder"] = funders
+	}
+
+	if doc.Collected.StartDate != "" {
+		cm["dateCreated"] = doc.Collected.StartDate
+	}
+	if doc.Collected.EndDate != "" {
+		cm["dateModified"] = doc.Collected.EndDate
+	}
+
+	for key, value := range overrides {
+		if value == "" {
+			delete(cm, key)
+		} else {
+			cm[key] = value
+		}
+	}
+
+	return cm
+}
+
+// exportCodeMeta writes the metadata as codemeta.json to w
+func exportCodeMeta(doc Yoda18Metadata, overrides map[string]string, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(codemeta_document(doc, overrides))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestExportCodeMetaGolden(t *testing.T) {
+	for _, tt := range []struct {
+		input  string
+		golden string
+	}{
+		{"yoda-metadata.json", "codemeta.json"},
+		{"yoda-metadata[douwe].json", "codemeta-douwe.json"},
+	} {
+		doc := load_test_metadata(t, tt.input)
+		var buf bytes.Buffer
+		overrides := map[string]string{
+			"programmingLanguage": "Go",
+			"codeRepository":      "https://github.com/vu-rdm-tech/yoda-metadata-toolkit",
+		}
+		if err := exportCodeMeta(doc, overrides, &buf); err != nil {
+			t.Fatal(err)
+		}
+		check_golden(t, tt.golden, buf.Bytes())
+	}
+}
+
+func TestCodeMetaOverrides(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	cm := codemeta_document(doc, map[string]string{"name": "Another name", "funding": ""})
+	if cm["name"] != "Another name" {
+		t.Errorf("name %v, want the override", cm["name"])
+	}
+	if _, ok := cm["funding"]; ok {
+		t.Error("an empty override does not remove the key")
+	}
+	if _, err := json.Marshal(cm); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetFlags(t *testing.T) {
+	var s set_flags
+	for _, v := range []string{"a=1", "b = x=y", "a=2"} {
+		if err := s.Set(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Set("no value"); err == nil {
+		t.Error("a -set without = is accepted")
+	}
+	if got, want := s.values(), map[string]string{"a": "2", "b": " x=y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("values %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,126 @@
+/*
+combi.go the Yoda vault "combi" JSON: the metadata of a published data package with its System block.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// System is the block the Yoda vault adds to the metadata of a published data package
+type System struct {
+	LastModifiedDate                string `json:"Last_Modified_Date,omitempty"`
+	PersistentIdentifierDatapackage struct {
+		IdentifierScheme string `json:"Identifier_Scheme,omitempty"`
+		Identifier       string `json:"Identifier,omitempty"`
+	} `json:"Persistent_Identifier_Datapackage,omitempty"`
+	PublicationDate string `json:"Publication_Date,omitempty"`
+	OpenAccessLink  string `json:"Open_Access_Link,omitempty"`
+	LicenseURI      string `json:"License_URI,omitempty"`
+}
+
+// the -set keys that fill the System block, the DOI sets the Persistent_Identifier_Datapackage
+var combi_set_keys = []string{"Last_Modified_Date", "DOI", "Publication_Date", "Open_Access_Link", "License_URI"}
+
+// the System block from the -system sidecar file, if given, with the -set values on top; the licence URI
+// and, for open data, the DOI landing page are filled in when not supplied
+func combi_system(doc Yoda18Metadata, system_file string, overrides map[string]string) (System, error) {
+	var sys System
+	if system_file != "" {
+		raw, err := os.ReadFile(system_file)
+		if err != nil {
+			return sys, err
+		}
+		err = json.Unmarshal(raw, &sys)
+		if err != nil {
+			return sys, fmt.Errorf("%s: %w", system_file, err)
+		}
+	}
+
+	for key, value := range overrides {
+		switch key {
+		case "Last_Modified_Date":
+			sys.LastModifiedDate = value
+		case "DOI":
+			sys.PersistentIdentifierDatapackage.IdentifierScheme = "DOI"
+			sys.PersistentIdentifierDatapackage.Identifier = value
+		case "Publication_Date":
+			sys.PublicationDate = value
+		case "Open_Access_Link":
+			sys.OpenAccessLink = value
+		case "License_URI":
+			sys.LicenseURI = value
+		default:
+			slog.Warn("-set key is not part of the System block, ignored", "key", key, "allowed", strings.Join(combi_set_keys, ", "))
+		}
+	}
+
+	if sys.LicenseURI == "" {
+		sys.LicenseURI = license_url(doc.License)
+	}
+	pid := sys.PersistentIdentifierDatapackage
+	if sys.OpenAccessLink == "" && pid.Identifier != "" && strings.EqualFold(pid.IdentifierScheme, "DOI") &&
+		doc.DataAccessRestriction == "Open - freely retrievable" {
+		sys.OpenAccessLink = "https://doi.org/" + pid.Identifier
+	}
+	return sys, nil
+}
+
+// exportCombi writes the metadata with the System block as the vault publishes it, in the canonical key order
+// (alphabetical with -pretty)
+func exportCombi(doc Yoda18Metadata, system_file string, overrides map[string]string, w io.Writer) error {
+	sys, err := combi_system(doc, system_file, overrides)
+	if err != nil {
+		return err
+	}
+	tree, err := canonical_tree(doc)
+	if err != nil {
+		return err
+	}
+	raw, err := json.Marshal(sys)
+	if err != nil {
+		return err
+	}
+	var system interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	err = dec.Decode(&system)
+	if err != nil {
+		return err
+	}
+	tree["System"] = system
+
+	var buf bytes.Buffer
+	err = canonical_write(&buf, output_key_order(), "", tree, "")
+	if err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+// the metadata of a combi JSON document without its System block, other documents are returned unchanged
+func unwrap_combi_json(raw []byte) ([]byte, error) {
+	if !bytes.Contains(raw, []byte(`"System"`)) {
+		return raw, nil
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		// left for the JSON parser to report
+		return raw, nil
+	}
+	if _, ok := fields["System"]; !ok {
+		return raw, nil
+	}
+	slog.Debug("combi JSON input, System block ignored")
+	delete(fields, "System")
+	return json.Marshal(fields)
+}
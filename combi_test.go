@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// a System block as the vault writes it
+const combi_test_system string = `{
+	"Last_Modified_Date": "2024-03-01T10:00:00",
+	"Persistent_Identifier_Datapackage": {"Identifier_Scheme": "DOI", "Identifier": "10.48338/VU01-ABCDEF"},
+	"Publication_Date": "2024-03-02T09:00:00",
+	"Open_Access_Link": "https://doi.org/10.48338/VU01-ABCDEF",
+	"License_URI": "https://creativecommons.org/licenses/by/4.0/"
+}`
+
+func TestSystemJSON(t *testing.T) {
+	var sys System
+	if err := json.Unmarshal([]byte(combi_test_system), &sys); err != nil {
+		t.Fatal(err)
+	}
+	if sys.PersistentIdentifierDatapackage.Identifier != "10.48338/VU01-ABCDEF" || sys.PublicationDate != "2024-03-02T09:00:00" {
+		t.Errorf("System read as %+v", sys)
+	}
+	raw, err := json.Marshal(sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want map[string]any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(combi_test_system), &want); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("System written as %s", raw)
+	}
+}
+
+func TestCombiSystem(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "system.json")
+	if err := os.WriteFile(fname, []byte(`{"Publication_Date": "2024-01-01", "License_URI": "https://example.org/licence"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := parse_test_metadata(t, `{"License": "CC-BY-4.0", "Data_Access_Restriction": "Open - freely retrievable"}`)
+
+	sys, err := combi_system(doc, fname, map[string]string{"Publication_Date": "2024-02-02", "DOI": "10.1234/abc"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sys.PublicationDate != "2024-02-02" {
+		t.Errorf("Publication_Date %q, -set must win over the sidecar", sys.PublicationDate)
+	}
+	if sys.LicenseURI != "https://example.org/licence" {
+		t.Errorf("License_URI %q, want the one of the sidecar", sys.LicenseURI)
+	}
+	if pid := sys.PersistentIdentifierDatapackage; pid.IdentifierScheme != "DOI" || pid.Identifier != "10.1234/abc" {
+		t.Errorf("Persistent_Identifier_Datapackage %+v", pid)
+	}
+	if sys.OpenAccessLink != "https://doi.org/10.1234/abc" {
+		t.Errorf("Open_Access_Link %q, want the DOI landing page of open data", sys.OpenAccessLink)
+	}
+
+	sys, err = combi_system(doc, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sys.LicenseURI != "https://creativecommons.org/licenses/by/4.0/" || sys.OpenAccessLink != "" {
+		t.Errorf("System without sidecar %+v", sys)
+	}
+
+	if _, err := combi_system(doc, filepath.Join(t.TempDir(), "missing.json"), nil); err == nil {
+		t.Error("a missing sidecar is not an error")
+	}
+}
+
+func TestExportCombiRoundTrip(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	var buf bytes.Buffer
+	if err := exportCombi(doc, "", map[string]string{"DOI": "10.1234/abc"}, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	var combi struct {
+		System System
+	}
+	if err := json.Unmarshal(buf.Bytes(), &combi); err != nil {
+		t.Fatal(err)
+	}
+	if combi.System.PersistentIdentifierDatapackage.Identifier != "10.1234/abc" {
+		t.Errorf("System block %+v", combi.System)
+	}
+
+	// a combi file is read as its metadata
+	raw, err := unwrap_combi_json(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	back, err := decode_metadata(raw, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := CanonicalJSON(back)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := CanonicalJSON(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("the metadata of the combi file differs from the input")
+	}
+}
+
+func TestUnwrapCombiJSON(t *testing.T) {
+	plain := []byte(`{"Title": "System of a dataset"}`)
+	if got, err := unwrap_combi_json(plain); err != nil || !bytes.Equal(got, plain) {
+		t.Errorf("a document mentioning System in a value is changed: %s %v", got, err)
+	}
+	got, err := unwrap_combi_json([]byte(`{"Title": "x", "System": {"DOI": "y"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != `{"Title":"x"}` {
+		t.Errorf("unwrapped %s", got)
+	}
+}
@@ -0,0 +1,117 @@
+/*
+combined.go merging the metadata of several input files into a single PDF report, one section per dataset.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+)
+
+// the combined report filename used when -output is not given
+const combined_default_name string = "combined-metadata.pdf"
+
+// read and parse a single metadata input file, applying -sort; ctx bounds the -ror-enrich and -funder-enrich lookups
+func read_metadata_file(ctx context.Context, fname string) (Yoda18Metadata, error) {
+	raw, err := read_metadata_input(fname)
+	if err != nil {
+		return Yoda18Metadata{}, err
+	}
+	data, err := decode_metadata(raw, *strict_schema)
+	if err != nil {
+		return data, err
+	}
+	if *decode_html {
+		data = DecodeHTMLEntities(data)
+	}
+	data = NormalizeUnicode(data)
+	data, err = apply_patch_file(data, *patch_file)
+	data = filter_contributors(data, *contributor_types)
+	if err == nil && *ror_enrich {
+		// each lookup is bounded by its own timeout, and all of them by ctx
+		enriched, err := EnrichAffiliations(ctx, data)
+		if err != nil {
+			slog.Warn("ROR enrichment failed, affiliations kept as given", "file", fname, "error", err)
+		} else {
+			data = enriched
+		}
+	}
+	if err == nil && *funder_enrich {
+		enriched, err := EnrichFunding(ctx, data)
+		if err != nil {
+			slog.Warn("funder enrichment failed, funder names kept as given", "file", fname, "error", err)
+		} else {
+			data = enriched
+		}
+	}
+	if err == nil && *normalize {
+		data = normalize_metadata(data)
+	}
+	if err == nil && *sort_values {
+		sort_metadata(&data, *sort_order)
+	}
+	return data, err
+}
+
+// write one PDF with a titled section per input file, files that cannot be read get an error block instead,
+// the files are read by a pool of workers
+func write_combined_pdf_report(ctx context.Context, fnames []string, outname string, workers int) error {
+	var ctime = time.Now().String()
+	var colwidth uint = 12
+	var rowheight float64 = 4
+	var empty_line_height float64 = 2
+
+	if outname == "" {
+		outname = filepath.Join("output", combined_default_name)
+		slog.Info("output filename not provided, using default", "file", outname)
+	}
+
+	doc := new_pdf_document()
+	pdf_write_header(doc, fmt.Sprintf("Combined metadata of %d datasets", len(fnames)), rowheight, colwidth, nil)
+	pdf_write_footer(doc, fmt.Sprintf("Combined metadata generated on %s\nby readYmeta v%s", ctime, _MYVERSION_), rowheight, colwidth)
+
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+	for i, res := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		fname := res.File
+		if i > 0 {
+			doc.AddPage()
+		}
+		pdf_write_row(doc, fmt.Sprintf("Dataset %d: %s", i+1, fname), rowheight*2, colwidth, consts.Bold, pdfBlack())
+		doc.Line(1)
+		pdf_write_empty_row(doc, empty_line_height, colwidth)
+
+		if res.Err != nil {
+			slog.Error("cannot read metadata", "file", fname, "error", res.Err)
+			pdf_write_labelled_row(doc, "Error", res.Err.Error(), rowheight, colwidth, empty_line_height, consts.Normal, pdfErrorColour())
+			continue
+		}
+
+		// the diagnostics at the end of each section only count that dataset
+		ERROR_COUNT = 0
+		doc = pdf_write_report_body(res.Data, doc)
+	}
+
+	err = os.MkdirAll(filepath.Dir(outname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	err = doc.OutputFileAndClose(outname)
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", outname)
+	return nil
+}
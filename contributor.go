@@ -0,0 +1,86 @@
+/*
+contributor.go grouping contributors by their Contributor_Type for the reports, and the -contributor-type filter.
+*/
+
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// the group of contributors without a Contributor_Type
+const unspecified_contributor_type string = "Unspecified"
+
+// the order of the contributor groups: the DataCite contributorType list with Other last, types outside
+// the list follow alphabetically and Unspecified comes at the end
+var contributor_type_order = []string{
+	"ContactPerson", "DataCollector", "DataCurator", "DataManager", "Distributor", "Editor", "HostingInstitution",
+	"Producer", "ProjectLeader", "ProjectManager", "ProjectMember", "RegistrationAgency", "RegistrationAuthority",
+	"RelatedPerson", "Researcher", "ResearchGroup", "RightsHolder", "Sponsor", "Supervisor", "WorkPackageLeader",
+	"Other",
+}
+
+// the group heading of a Contributor_Type
+func contributor_group(contributor_type string) string {
+	contributor_type = strings.TrimSpace(contributor_type)
+	if contributor_type == "" {
+		return unspecified_contributor_type
+	}
+	return contributor_type
+}
+
+// true if group a comes before group b
+func contributor_group_less(a string, b string) bool {
+	rank := func(group string) int {
+		if group == unspecified_contributor_type {
+			return len(contributor_type_order) + 1
+		}
+		for i, t := range contributor_type_order {
+			if t == group {
+				return i
+			}
+		}
+		return len(contributor_type_order)
+	}
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		return ra < rb
+	}
+	return a < b
+}
+
+// the indexes of the contributors ordered by group, within a group the contributors keep their order
+func contributor_order(doc Yoda18Metadata) []int {
+	order := make([]int, len(doc.Contributor))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return contributor_group_less(contributor_group(doc.Contributor[order[i]].ContributorType),
+			contributor_group(doc.Contributor[order[j]].ContributorType))
+	})
+	return order
+}
+
+// keep only the contributors of the given comma separated types, matched without regard to case, "Unspecified"
+// selects the contributors without a type; an empty filter keeps everyone
+func filter_contributors(doc Yoda18Metadata, types string) Yoda18Metadata {
+	wanted := make(map[string]bool)
+	for _, t := range strings.Split(types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			wanted[strings.ToLower(t)] = true
+		}
+	}
+	if len(wanted) == 0 {
+		return doc
+	}
+	kept := doc.Contributor[:0:0]
+	for _, con := range doc.Contributor {
+		if wanted[strings.ToLower(contributor_group(con.ContributorType))] {
+			kept = append(kept, con)
+		}
+	}
+	doc.Contributor = kept
+	return doc
+}
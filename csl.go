@@ -0,0 +1,116 @@
+/*
+csl.go exports Yoda metadata as CSL-JSON, the citation format used by citeproc based tools.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// CSL name variable
+type CSLName struct {
+	Family string `json:"family,omitempty"`
+	Given  string `json:"given,omitempty"`
+}
+
+// CSL date variable, date-parts holds [[year, month, day]] with month and day optional
+type CSLDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// CSL item of type "dataset"
+type CSLItem struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title,omitempty"`
+	Abstract  string    `json:"abstract,omitempty"`
+	Author    []CSLName `json:"author,omitempty"`
+	Issued    *CSLDate  `json:"issued,omitempty"`
+	Version   string    `json:"version,omitempty"`
+	Publisher string    `json:"publisher,omitempty"`
+	DOI       string    `json:"DOI,omitempty"`
+	URL       string    `json:"URL,omitempty"`
+	Keyword   string    `json:"keyword,omitempty"`
+}
+
+// map the metadata to a CSL dataset item
+func csl_item_from_metadata(doc Yoda18Metadata) CSLItem {
+	item := CSLItem{
+		ID:        "yoda-dataset",
+		Type:      "dataset",
+		Title:     doc.Title,
+		Abstract:  doc.Description,
+		Version:   doc.Version,
+		Publisher: citation_publisher,
+	}
+
+	for i := range doc.Creator {
+		name := CSLName{
+			Family: doc.Creator[i].Name.FamilyName,
+			Given:  doc.Creator[i].Name.GivenName,
+		}
+		if name.Family != "" || name.Given != "" {
+			item.Author = append(item.Author, name)
+		}
+	}
+
+	// citeproc fails on invalid date-parts so a date is only used if it parses
+	for _, date := range []string{doc.Collected.EndDate, doc.EmbargoEndDate} {
+		if parts := csl_date_parts(date); parts != nil {
+			item.Issued = &CSLDate{DateParts: [][]int{parts}}
+			break
+		}
+	}
+
+	for i := range doc.Links {
+		if doi := doi_from_string(doc.Links[i].Href); doi != "" {
+			item.DOI = doi
+			break
+		}
+	}
+	// "describedby" points at the Yoda schema, not at the dataset
+	for i := range doc.Links {
+		if doc.Links[i].Href != "" && doc.Links[i].Rel != "describedby" && doi_from_string(doc.Links[i].Href) == "" {
+			item.URL = doc.Links[i].Href
+			break
+		}
+	}
+
+	var tags []string
+	for i := range doc.Tag {
+		if doc.Tag[i] != "" {
+			tags = append(tags, doc.Tag[i])
+		}
+	}
+	item.Keyword = strings.Join(tags, ", ")
+
+	return item
+}
+
+// parse an ISO 8601 date (YYYY, YYYY-MM or YYYY-MM-DD) into CSL date-parts, nil if it is empty or invalid
+func csl_date_parts(date string) []int {
+	date = strings.TrimSpace(date)
+	for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+		if len(date) != len(layout) {
+			continue
+		}
+		t, err := time.Parse(layout, date)
+		if err != nil {
+			return nil
+		}
+		parts := []int{t.Year(), int(t.Month()), t.Day()}
+		return parts[:strings.Count(layout, "-")+1]
+	}
+	return nil
+}
+
+// exportCSL writes the metadata as a CSL-JSON array holding a single dataset item
+func exportCSL(doc Yoda18Metadata, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode([]CSLItem{csl_item_from_metadata(doc)})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// validate the output against a schema in test-data/schemas
+func check_test_schema(t *testing.T, schema string, raw []byte) {
+	t.Helper()
+	violations, err := validate_json_schema(raw, filepath.Join("test-data", "schemas", schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range violations {
+		t.Errorf("%s: %s: %s", schema, v.Path, v.Message)
+	}
+}
+
+func TestExportCSL(t *testing.T) {
+	for _, name := range []string{"yoda-metadata.json", "yoda-metadata[blank].json", "yoda-metadata[douwe].json"} {
+		doc := load_test_metadata(t, name)
+		var buf bytes.Buffer
+		if err := exportCSL(doc, &buf); err != nil {
+			t.Fatal(err)
+		}
+		check_test_schema(t, "csl-data.json", buf.Bytes())
+
+		var items []CSLItem
+		if err := json.Unmarshal(buf.Bytes(), &items); err != nil {
+			t.Fatal(err)
+		}
+		if len(items) != 1 || !reflect.DeepEqual(items[0], csl_item_from_metadata(doc)) {
+			t.Errorf("%s: the CSL-JSON does not read back as the item", name)
+		}
+	}
+}
+
+func TestCSLDateParts(t *testing.T) {
+	tests := []struct {
+		date string
+		want []int
+	}{
+		{"2023-05-17", []int{2023, 5, 17}},
+		{"2023-05", []int{2023, 5}},
+		{"2023", []int{2023}},
+		{" 2023-05-17 ", []int{2023, 5, 17}},
+		{"", nil},
+		{"17-05-2023", nil},
+		{"2023-13-01", nil},
+		{"2023-02-30", nil},
+		{"May 2023", nil},
+	}
+	for _, tt := range tests {
+		if got := csl_date_parts(tt.date); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("csl_date_parts(%q) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestCSLIssuedFallback(t *testing.T) {
+	doc := parse_test_metadata(t, `{"Collected": {"End_Date": "not a date"}, "Embargo_End_Date": "2030-01-01"}`)
+	if issued := csl_item_from_metadata(doc).Issued; issued == nil || !reflect.DeepEqual(issued.DateParts, [][]int{{2030, 1, 1}}) {
+		t.Errorf("issued %v, want the embargo end date", issued)
+	}
+	doc = parse_test_metadata(t, `{"Collected": {"End_Date": ""}}`)
+	if issued := csl_item_from_metadata(doc).Issued; issued != nil {
+		t.Errorf("issued %v without any date", issued)
+	}
+}
+
+// the schema check itself rejects what citeproc chokes on
+func TestCSLSchemaRejects(t *testing.T) {
+	for _, raw := range []string{
+		`[{"id": "x", "type": "dataset", "issued": {"date-parts": [["2023"]]}}]`,
+		`[{"id": "x", "type": "dataset", "keywords": "a, b"}]`,
+		`[{"type": "dataset"}]`,
+	} {
+		violations, err := validate_json_schema([]byte(raw), filepath.Join("test-data", "schemas", "csl-data.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(violations) == 0 {
+			t.Errorf("%s passes the schema", raw)
+		}
+	}
+}
@@ -0,0 +1,618 @@
+/*
+cslproc.go a small CSL 1.0 processor that renders the bibliography entry of a single CSL-JSON dataset item, it
+supports the elements and attributes the citation styles of datasets use: macros, text, number, names, date,
+group and choose, affixes, quotes, text-case and font-style/font-weight for HTML output.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// the English terms used when a style does not define them in its locale
+var csl_default_terms = map[string]string{
+	"and":          "and",
+	"et-al":        "et al.",
+	"version":      "version",
+	"no date":      "n.d.",
+	"available at": "available at",
+	"accessed":     "accessed",
+	"retrieved":    "retrieved",
+	"from":         "from",
+	"in":           "in",
+	"online":       "online",
+}
+
+var csl_month_names = []string{"January", "February", "March", "April", "May", "June", "July", "August", "September",
+	"October", "November", "December"}
+
+// an element of a CSL style
+type csl_node struct {
+	Name     string
+	Attr     map[string]string
+	Children []*csl_node
+	Text     string
+}
+
+// a parsed CSL style, inherit holds the name options given on the style and bibliography elements
+type csl_style struct {
+	macros      map[string]*csl_node
+	terms       map[string]string
+	layout      *csl_node
+	inherit     map[string]string
+	quote_punct bool
+}
+
+// the output of an element, called tells whether it used a variable and filled whether one had a value; a
+// group whose variables are all empty is left out
+type csl_output struct {
+	text   string
+	called bool
+	filled bool
+}
+
+// read the element started by start and everything inside it
+func parse_csl_node(dec *xml.Decoder, start xml.StartElement) (*csl_node, error) {
+	n := &csl_node{Name: start.Name.Local, Attr: make(map[string]string)}
+	for _, a := range start.Attr {
+		n.Attr[a.Name.Local] = a.Value
+	}
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			child, err := parse_csl_node(dec, t)
+			if err != nil {
+				return nil, err
+			}
+			n.Children = append(n.Children, child)
+		case xml.CharData:
+			n.Text += string(t)
+		case xml.EndElement:
+			n.Text = strings.TrimSpace(n.Text)
+			return n, nil
+		}
+	}
+}
+
+// the first child element with the given name, nil if there is none
+func (n *csl_node) child(name string) *csl_node {
+	for _, c := range n.Children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// parse a CSL style, it needs a bibliography layout
+func parse_csl_style(raw []byte) (*csl_style, error) {
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	var root *csl_node
+	for root == nil {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("not a CSL style: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			if root, err = parse_csl_node(dec, start); err != nil {
+				return nil, fmt.Errorf("not a CSL style: %w", err)
+			}
+		}
+	}
+	if root.Name != "style" {
+		return nil, fmt.Errorf("not a CSL style: the root element is %s", root.Name)
+	}
+
+	s := &csl_style{macros: make(map[string]*csl_node), terms: make(map[string]string), inherit: make(map[string]string)}
+	for k, v := range csl_default_terms {
+		s.terms[k] = v
+	}
+	// the quotes take the punctuation that follows them in American English
+	locale := root.Attr["default-locale"]
+	s.quote_punct = locale == "" || locale == "en-US"
+	for k, v := range root.Attr {
+		s.inherit[k] = v
+	}
+	for _, c := range root.Children {
+		switch c.Name {
+		case "macro":
+			s.macros[c.Attr["name"]] = c
+		case "locale":
+			if terms := c.child("terms"); terms != nil {
+				for _, term := range terms.Children {
+					text := term.Text
+					if single := term.child("single"); single != nil {
+						text = single.Text
+					}
+					key := term.Attr["name"]
+					if form := term.Attr["form"]; form != "" && form != "long" {
+						key += "/" + form
+					}
+					s.terms[key] = text
+				}
+			}
+		case "bibliography":
+			for k, v := range c.Attr {
+				s.inherit[k] = v
+			}
+			s.layout = c.child("layout")
+		}
+	}
+	if s.layout == nil {
+		return nil, fmt.Errorf("the CSL style has no bibliography layout")
+	}
+	return s, nil
+}
+
+// a CSL renderer for one item
+type csl_renderer struct {
+	style   *csl_style
+	item    CSLItem
+	as_html bool
+	depth   int
+}
+
+// render the bibliography entry of item in the style
+func render_csl(style *csl_style, item CSLItem, as_html bool) (string, error) {
+	r := &csl_renderer{style: style, item: item, as_html: as_html}
+	out, err := r.render_element(style.layout)
+	if err != nil {
+		return "", err
+	}
+	text := strings.ReplaceAll(out.text, "..", ".")
+	if style.quote_punct {
+		text = strings.NewReplacer("”.", ".”", "”,", ",”").Replace(text)
+	}
+	return strings.TrimSpace(text), nil
+}
+
+// the value of a plain variable
+func (r *csl_renderer) variable(name string) string {
+	switch name {
+	case "title":
+		return r.item.Title
+	case "abstract":
+		return r.item.Abstract
+	case "version":
+		return r.item.Version
+	case "DOI":
+		return r.item.DOI
+	case "URL":
+		return r.item.URL
+	case "publisher":
+		return r.item.Publisher
+	case "keyword":
+		return r.item.Keyword
+	case "type":
+		return r.item.Type
+	case "citation-number":
+		return "1"
+	}
+	return ""
+}
+
+// whether a variable of any kind has a value
+func (r *csl_renderer) has_variable(name string) bool {
+	switch name {
+	case "author":
+		return len(r.item.Author) > 0
+	case "issued":
+		return r.item.Issued != nil && len(r.item.Issued.DateParts) > 0 && len(r.item.Issued.DateParts[0]) > 0
+	}
+	return r.variable(name) != ""
+}
+
+// render the children of n joined by delimiter
+func (r *csl_renderer) render_children(n *csl_node, delimiter string) (csl_output, error) {
+	var out csl_output
+	var parts []string
+	for _, c := range n.Children {
+		o, err := r.render_element(c)
+		if err != nil {
+			return out, err
+		}
+		out.called = out.called || o.called
+		out.filled = out.filled || o.filled
+		if o.text != "" {
+			parts = append(parts, o.text)
+		}
+	}
+	out.text = strings.Join(parts, delimiter)
+	return out, nil
+}
+
+// render a rendering element, the affixes and formatting of n are applied to its output
+func (r *csl_renderer) render_element(n *csl_node) (csl_output, error) {
+	var out csl_output
+	var err error
+	escaped := false
+	switch n.Name {
+	case "layout":
+		out, err = r.render_children(n, n.Attr["delimiter"])
+		escaped = true
+	case "group":
+		out, err = r.render_children(n, n.Attr["delimiter"])
+		if out.called && !out.filled {
+			return csl_output{}, err
+		}
+		escaped = true
+	case "choose":
+		return r.render_choose(n)
+	case "text":
+		out, escaped, err = r.render_text(n)
+	case "number":
+		value := r.variable(n.Attr["variable"])
+		out = csl_output{text: value, called: true, filled: value != ""}
+	case "names":
+		out, escaped, err = r.render_names(n)
+	case "date":
+		out = r.render_date(n)
+		escaped = true
+	}
+	if err != nil || out.text == "" {
+		return out, err
+	}
+	out.text = r.format(n, out.text, escaped)
+	return out, nil
+}
+
+// render a text element: a variable, macro, term or value
+func (r *csl_renderer) render_text(n *csl_node) (csl_output, bool, error) {
+	switch {
+	case n.Attr["variable"] != "":
+		value := r.variable(n.Attr["variable"])
+		return csl_output{text: value, called: true, filled: value != ""}, false, nil
+	case n.Attr["macro"] != "":
+		macro, ok := r.style.macros[n.Attr["macro"]]
+		if !ok {
+			return csl_output{}, false, fmt.Errorf("the CSL style has no macro %q", n.Attr["macro"])
+		}
+		// a macro calling itself would never end
+		if r.depth > 20 {
+			return csl_output{}, false, fmt.Errorf("the CSL macro %q nests too deep", n.Attr["macro"])
+		}
+		r.depth++
+		out, err := r.render_children(macro, "")
+		r.depth--
+		return out, true, err
+	case n.Attr["term"] != "":
+		return csl_output{text: r.term(n.Attr["term"], n.Attr["form"])}, false, nil
+	}
+	return csl_output{text: n.Attr["value"]}, false, nil
+}
+
+// a term in the given form, falling back to the long form
+func (r *csl_renderer) term(name string, form string) string {
+	if form != "" && form != "long" {
+		if t, ok := r.style.terms[name+"/"+form]; ok {
+			return t
+		}
+	}
+	return r.style.terms[name]
+}
+
+// render the first branch of a choose element whose condition holds
+func (r *csl_renderer) render_choose(n *csl_node) (csl_output, error) {
+	for _, branch := range n.Children {
+		if branch.Name == "else" || r.condition(branch) {
+			return r.render_children(branch, "")
+		}
+	}
+	return csl_output{}, nil
+}
+
+// test the variable and type conditions of an if or else-if element, combined by its match attribute
+func (r *csl_renderer) condition(n *csl_node) bool {
+	var results []bool
+	for _, v := range strings.Fields(n.Attr["variable"]) {
+		results = append(results, r.has_variable(v))
+	}
+	for _, t := range strings.Fields(n.Attr["type"]) {
+		results = append(results, t == r.item.Type)
+	}
+	switch n.Attr["match"] {
+	case "any":
+		for _, ok := range results {
+			if ok {
+				return true
+			}
+		}
+		return false
+	case "none":
+		for _, ok := range results {
+			if ok {
+				return false
+			}
+		}
+		return true
+	}
+	for _, ok := range results {
+		if !ok {
+			return false
+		}
+	}
+	return len(results) > 0
+}
+
+// a name option from the name element, otherwise inherited from the bibliography or style
+func (r *csl_renderer) name_option(name *csl_node, key string, fallback string) string {
+	if name != nil {
+		if v, ok := name.Attr[key]; ok {
+			return v
+		}
+	}
+	if v, ok := r.style.inherit[key]; ok {
+		return v
+	}
+	return fallback
+}
+
+// the initials of given names, each followed by with, hyphenated names keep their hyphen unless hyphen is false
+func csl_initials(given string, with string, hyphen bool) string {
+	var sb strings.Builder
+	for _, word := range strings.Fields(given) {
+		var parts []string
+		for _, part := range strings.Split(word, "-") {
+			if c, _ := utf8.DecodeRuneInString(strings.Trim(part, ".")); c != utf8.RuneError {
+				parts = append(parts, string(c))
+			}
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if hyphen {
+			sb.WriteString(strings.Join(parts, strings.TrimRight(with, " ")+"-") + with)
+		} else {
+			sb.WriteString(strings.Join(parts, with) + with)
+		}
+	}
+	return strings.TrimRight(sb.String(), " ")
+}
+
+// render the authors of a names element, the substitute is rendered when there are none
+func (r *csl_renderer) render_names(n *csl_node) (csl_output, bool, error) {
+	if !strings.Contains(" "+n.Attr["variable"]+" ", " author ") || len(r.item.Author) == 0 {
+		if sub := n.child("substitute"); sub != nil {
+			for _, c := range sub.Children {
+				o, err := r.render_element(c)
+				if err != nil || o.text != "" {
+					return o, true, err
+				}
+			}
+		}
+		return csl_output{called: true}, false, nil
+	}
+
+	name := n.child("name")
+	delimiter := r.name_option(name, "delimiter", ", ")
+	sort_order := r.name_option(name, "name-as-sort-order", "")
+	sort_separator := r.name_option(name, "sort-separator", ", ")
+	initialize_with, initialize := "", false
+	if name != nil {
+		initialize_with, initialize = name.Attr["initialize-with"]
+	}
+	if r.name_option(name, "initialize", "true") == "false" {
+		initialize = false
+	}
+	short := name != nil && name.Attr["form"] == "short"
+	hyphen := r.name_option(name, "initialize-with-hyphen", "true") != "false"
+
+	var names []string
+	for i, a := range r.item.Author {
+		family, given := strings.TrimSpace(a.Family), strings.TrimSpace(a.Given)
+		if initialize {
+			given = csl_initials(given, initialize_with, hyphen)
+		}
+		switch {
+		case short || given == "":
+			names = append(names, family)
+		case family == "":
+			names = append(names, given)
+		case sort_order == "all" || (sort_order == "first" && i == 0):
+			names = append(names, family+sort_separator+given)
+		default:
+			names = append(names, given+" "+family)
+		}
+	}
+
+	// et al. when there are at least et-al-min names
+	et_al := ""
+	var min, use_first int
+	fmt.Sscan(r.name_option(name, "et-al-min", "0"), &min)
+	fmt.Sscan(r.name_option(name, "et-al-use-first", "0"), &use_first)
+	if min > 0 && use_first > 0 && len(names) >= min && use_first < len(names) {
+		names = names[:use_first]
+		node := n.child("et-al")
+		if node == nil {
+			node = &csl_node{}
+		}
+		term := node.Attr["term"]
+		if term == "" {
+			term = "et-al"
+		}
+		et_al = r.format(node, r.term(term, ""), false)
+	}
+
+	and := ""
+	switch r.name_option(name, "and", "") {
+	case "text":
+		and = r.term("and", "")
+	case "symbol":
+		and = "&"
+	}
+	// the names are escaped here so the et-al formatting is not escaped again
+	if r.as_html {
+		for i := range names {
+			names[i] = html.EscapeString(names[i])
+		}
+		delimiter, and = html.EscapeString(delimiter), html.EscapeString(and)
+	}
+	precedes_last := r.name_option(name, "delimiter-precedes-last", "contextual")
+	var text string
+	switch {
+	case len(names) == 1 || et_al != "":
+		text = strings.Join(names, delimiter)
+	case and == "":
+		text = strings.Join(names, delimiter)
+	default:
+		last := names[len(names)-1]
+		text = strings.Join(names[:len(names)-1], delimiter)
+		if precedes_last == "always" || (precedes_last == "contextual" && len(names) > 2) {
+			text += delimiter + and + " " + last
+		} else {
+			text += " " + and + " " + last
+		}
+	}
+	if et_al != "" {
+		precedes := r.name_option(name, "delimiter-precedes-et-al", "contextual")
+		if precedes == "always" || (precedes == "contextual" && len(names) > 1) {
+			text += delimiter + et_al
+		} else {
+			text += " " + et_al
+		}
+	}
+	return csl_output{text: text, called: true, filled: true}, true, nil
+}
+
+// render the issued date, by its date-part children or as a localized date
+func (r *csl_renderer) render_date(n *csl_node) csl_output {
+	if n.Attr["variable"] != "issued" || !r.has_variable("issued") {
+		return csl_output{called: true}
+	}
+	parts := r.item.Issued.DateParts[0]
+	year, month, day := parts[0], 0, 0
+	if len(parts) > 1 {
+		month = parts[1]
+	}
+	if len(parts) > 2 {
+		day = parts[2]
+	}
+
+	var date_parts []*csl_node
+	for _, c := range n.Children {
+		if c.Name == "date-part" {
+			date_parts = append(date_parts, c)
+		}
+	}
+	if len(date_parts) == 0 {
+		// a localized date limited to the date-parts attribute
+		switch n.Attr["date-parts"] {
+		case "year":
+			month, day = 0, 0
+		case "year-month":
+			day = 0
+		}
+		text := fmt.Sprint(year)
+		switch {
+		case n.Attr["form"] == "numeric" && day > 0:
+			text = fmt.Sprintf("%d/%d/%d", month, day, year)
+		case n.Attr["form"] == "numeric" && month > 0:
+			text = fmt.Sprintf("%d/%d", month, year)
+		case day > 0:
+			text = fmt.Sprintf("%s %d, %d", csl_month_names[month-1], day, year)
+		case month > 0:
+			text = fmt.Sprintf("%s %d", csl_month_names[month-1], year)
+		}
+		return csl_output{text: text, called: true, filled: true}
+	}
+
+	var texts []string
+	for _, p := range date_parts {
+		var text string
+		form := p.Attr["form"]
+		switch p.Attr["name"] {
+		case "year":
+			text = fmt.Sprint(year)
+			if form == "short" {
+				text = fmt.Sprintf("%02d", year%100)
+			}
+		case "month":
+			if month == 0 {
+				continue
+			}
+			switch form {
+			case "numeric":
+				text = fmt.Sprint(month)
+			case "numeric-leading-zeros":
+				text = fmt.Sprintf("%02d", month)
+			case "short":
+				text = csl_month_names[month-1][:3]
+			default:
+				text = csl_month_names[month-1]
+			}
+		case "day":
+			if day == 0 {
+				continue
+			}
+			text = fmt.Sprint(day)
+			if form == "numeric-leading-zeros" {
+				text = fmt.Sprintf("%02d", day)
+			}
+		}
+		texts = append(texts, r.format(p, text, false))
+	}
+	return csl_output{text: strings.Join(texts, n.Attr["delimiter"]), called: true, filled: true}
+}
+
+// apply text-case, strip-periods, quotes, font-style, font-weight and the affixes of n to text; escaped tells
+// whether text is already HTML
+func (r *csl_renderer) format(n *csl_node, text string, escaped bool) string {
+	if !escaped {
+		text = csl_text_case(text, n.Attr["text-case"])
+		if n.Attr["strip-periods"] == "true" {
+			text = strings.ReplaceAll(text, ".", "")
+		}
+		if r.as_html {
+			text = html.EscapeString(text)
+		}
+	}
+	if n.Attr["quotes"] == "true" {
+		text = "“" + text + "”"
+	}
+	if r.as_html {
+		if n.Attr["font-style"] == "italic" || n.Attr["font-style"] == "oblique" {
+			text = "<i>" + text + "</i>"
+		}
+		if n.Attr["font-weight"] == "bold" {
+			text = "<b>" + text + "</b>"
+		}
+	}
+	prefix, suffix := n.Attr["prefix"], n.Attr["suffix"]
+	if r.as_html {
+		prefix, suffix = html.EscapeString(prefix), html.EscapeString(suffix)
+	}
+	return prefix + text + suffix
+}
+
+// change the case of text as the text-case attribute asks, title case capitalizes every word
+func csl_text_case(text string, text_case string) string {
+	switch text_case {
+	case "lowercase":
+		return strings.ToLower(text)
+	case "uppercase":
+		return strings.ToUpper(text)
+	case "capitalize-first", "sentence":
+		r, size := utf8.DecodeRuneInString(text)
+		return string(unicode.ToUpper(r)) + text[size:]
+	case "capitalize-all", "title":
+		words := strings.Split(text, " ")
+		for i, w := range words {
+			if r, size := utf8.DecodeRuneInString(w); size > 0 {
+				words[i] = string(unicode.ToUpper(r)) + w[size:]
+			}
+		}
+		return strings.Join(words, " ")
+	}
+	return text
+}
@@ -0,0 +1,146 @@
+/*
+csv.go exports Yoda metadata as CSV, either key/value pairs of a single dataset or one row per dataset.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// the wide CSV filename used when -output is not given
+const combined_csv_default_name string = "combined-metadata.csv"
+
+// flatten_metadata lists every populated field as a key/value pair in document order, the keys use the JSON
+// names joined by dots and slice elements are numbered from 1, e.g. Creator.1.Name.Family_Name or Tag.3
+func flatten_metadata(doc Yoda18Metadata) [][2]string {
+	var out [][2]string
+	flatten_value("", reflect.ValueOf(doc), &out)
+	return out
+}
+
+func flatten_value(key string, v reflect.Value, out *[][2]string) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = t.Field(i).Name
+			}
+			flatten_value(flatten_key(key, name), v.Field(i), out)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			flatten_value(flatten_key(key, strconv.Itoa(i+1)), v.Index(i), out)
+		}
+	default:
+		if !v.IsZero() {
+			*out = append(*out, [2]string{key, fmt.Sprint(v.Interface())})
+		}
+	}
+}
+
+func flatten_key(prefix string, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+// exportCSV writes the populated fields of the metadata as a two column key,value CSV to w
+func exportCSV(doc Yoda18Metadata, w io.Writer) error {
+	cw := csv.NewWriter(w)
+	err := cw.Write([]string{"key", "value"})
+	if err != nil {
+		return err
+	}
+	for _, kv := range flatten_metadata(doc) {
+		err = cw.Write(kv[:])
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// the get_basic_data lines split into label and value
+func basic_data_fields(doc Yoda18Metadata) [][2]string {
+	var out [][2]string
+	for _, line := range get_basic_data(doc) {
+		label, value, _ := strings.Cut(line, ": ")
+		out = append(out, [2]string{label, value})
+	}
+	return out
+}
+
+// write one CSV row per input file with the scalar fields as columns, files that cannot be read are skipped,
+// the files are read by a pool of workers
+func write_combined_csv(ctx context.Context, fnames []string, outname string, workers int) error {
+	if outname == "" {
+		outname = filepath.Join("output", combined_csv_default_name)
+		slog.Info("output filename not provided, using default", "file", outname)
+	}
+
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(outname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(outname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cw := csv.NewWriter(f)
+	header := []string{"File"}
+	for _, field := range basic_data_fields(Yoda18Metadata{}) {
+		header = append(header, field[0])
+	}
+	err = cw.Write(header)
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("cannot read metadata, skipped", "file", res.File, "error", res.Err)
+			continue
+		}
+		row := []string{res.File}
+		for _, field := range basic_data_fields(res.Data) {
+			row = append(row, field[1])
+		}
+		err = cw.Write(row)
+		if err != nil {
+			return err
+		}
+		rows++
+	}
+	cw.Flush()
+	err = cw.Error()
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", outname, "datasets", rows)
+	return nil
+}
@@ -0,0 +1,88 @@
+/*
+datatype.go the Yoda Data_Type controlled vocabulary and its mapping onto DataCite resource types.
+*/
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// allowed Data_Type values, one "value<TAB>resourceTypeGeneral<TAB>resourceType" per line
+//
+//go:embed assets/yoda-data-types.tsv
+var yoda_data_type_list string
+
+// a Data_Type value and the DataCite resource type it maps onto
+type yoda_data_type struct {
+	Value               string
+	ResourceTypeGeneral string
+	ResourceType        string
+}
+
+// the parsed allow-list, built once on first use
+var yoda_data_types []yoda_data_type
+var yoda_data_types_once sync.Once
+
+func load_data_types() []yoda_data_type {
+	yoda_data_types_once.Do(build_data_types)
+	return yoda_data_types
+}
+
+func build_data_types() {
+	for _, line := range strings.Split(yoda_data_type_list, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			continue
+		}
+		yoda_data_types = append(yoda_data_types, yoda_data_type{fields[0], fields[1], fields[2]})
+	}
+}
+
+// the allowed Data_Type values in list order
+func data_type_values() []string {
+	var values []string
+	for _, dt := range load_data_types() {
+		values = append(values, dt.Value)
+	}
+	return values
+}
+
+// check that a Data_Type is in the Yoda vocabulary, an empty value is not checked
+func check_data_type(value string) error {
+	if value == "" {
+		return nil
+	}
+	for _, dt := range load_data_types() {
+		if dt.Value == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown Data_Type %q, expected one of: %s", value, strings.Join(data_type_values(), ", "))
+}
+
+// the DataCite resourceTypeGeneral and resourceType for a Data_Type, unknown values map to "Other"
+func datacite_resource_type(value string) (string, string) {
+	for _, dt := range load_data_types() {
+		if dt.Value == value {
+			return dt.ResourceTypeGeneral, dt.ResourceType
+		}
+	}
+	return "Other", value
+}
+
+// warn if the Data_Type is not one of the allowed values
+func report_data_type(doc Yoda18Metadata, fname string) {
+	if err := check_data_type(doc.DataType); err != nil {
+		slog.Warn("unknown Data_Type", "file", fname, "field_path", "Data_Type", "value", doc.DataType,
+			"allowed", strings.Join(data_type_values(), ", "))
+	}
+}
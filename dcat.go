@@ -0,0 +1,195 @@
+/*
+dcat.go exports Yoda metadata as a DCAT dcat:Dataset description in Turtle, for harvesting by data catalogues.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// the prefixes used in the Turtle output
+var dcat_prefixes = [][2]string{
+	{"dcat", "http://www.w3.org/ns/dcat#"},
+	{"dct", "http://purl.org/dc/terms/"},
+	{"foaf", "http://xmlns.com/foaf/0.1/"},
+	{"rdfs", "http://www.w3.org/2000/01/rdf-schema#"},
+	{"xsd", "http://www.w3.org/2001/XMLSchema#"},
+}
+
+// a date that can be typed as xsd:date
+var xsd_date_pattern = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+
+// a Turtle string literal
+func turtle_literal(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// a Turtle IRI, the characters an IRI cannot hold are percent-encoded
+func turtle_iri(iri string) string {
+	var sb strings.Builder
+	for _, r := range strings.TrimSpace(iri) {
+		if r <= ' ' || strings.ContainsRune("<>\"{}|^`\\", r) {
+			fmt.Fprintf(&sb, "%%%02X", r)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return "<" + sb.String() + ">"
+}
+
+// a date literal, typed as xsd:date when it is a full date
+func turtle_date(date string) string {
+	if xsd_date_pattern.MatchString(date) {
+		return turtle_literal(date) + "^^xsd:date"
+	}
+	return turtle_literal(date)
+}
+
+// the subject IRI of the dataset: the given uri, otherwise the DOI of the data package or one in the links,
+// otherwise a blank node
+func dcat_subject(doc Yoda18Metadata, sys System, uri string) string {
+	if uri = strings.TrimSpace(uri); uri != "" {
+		return turtle_iri(uri)
+	}
+	if pid := sys.PersistentIdentifierDatapackage; strings.EqualFold(pid.IdentifierScheme, "DOI") && pid.Identifier != "" {
+		return turtle_iri(pid_link("DOI", pid.Identifier))
+	}
+	for _, link := range doc.Links {
+		if doi := doi_from_string(link.Href); doi != "" {
+			return turtle_iri(pid_link("DOI", doi))
+		}
+	}
+	return "_:dataset"
+}
+
+// a foaf:Person object, the ORCID IRI when there is one with its description added once to extra, otherwise
+// a blank node
+func dcat_person(name NameStruct, scheme_ids [][2]string, described map[string]bool, extra *strings.Builder) string {
+	var props []string
+	props = append(props, "a foaf:Person", "foaf:name "+turtle_literal(formatName(name, "full")))
+	if name.GivenName != "" {
+		props = append(props, "foaf:givenName "+turtle_literal(name.GivenName))
+	}
+	if name.FamilyName != "" {
+		props = append(props, "foaf:familyName "+turtle_literal(name.FamilyName))
+	}
+	if orcid := xlsx_orcid(scheme_ids); orcid != "" {
+		iri := turtle_iri(orcid_url(orcid))
+		if described[iri] {
+			return iri
+		}
+		described[iri] = true
+		fmt.Fprintf(extra, "\n%s\n    %s .\n", iri, strings.Join(props, " ;\n    "))
+		return iri
+	}
+	return "[ " + strings.Join(props, " ; ") + " ]"
+}
+
+// the Turtle description of the dataset, sys gives the DOI when there is no uri
+func dcat_turtle(doc Yoda18Metadata, sys System, uri string) string {
+	var props [][2]string
+	add := func(predicate string, object string) {
+		props = append(props, [2]string{predicate, object})
+	}
+	// the persons with an ORCID are described after the dataset
+	var extra strings.Builder
+	described := make(map[string]bool)
+
+	add("a", "dcat:Dataset")
+	if doc.Title != "" {
+		add("dct:title", turtle_literal(doc.Title))
+	}
+	if doc.Description != "" {
+		add("dct:description", turtle_literal(doc.Description))
+	}
+	if pid := sys.PersistentIdentifierDatapackage; pid.Identifier != "" {
+		add("dct:identifier", turtle_literal(pid.Identifier))
+	}
+	if doc.Version != "" {
+		add("dcat:version", turtle_literal(doc.Version))
+	}
+	if code := language_code(doc.Language); code != "" {
+		add("dct:language", turtle_literal(code))
+	}
+	for _, tag := range doc.Tag {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			add("dcat:keyword", turtle_literal(tag))
+		}
+	}
+
+	if start, end := doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate; start != "" || end != "" {
+		period := []string{"a dct:PeriodOfTime"}
+		if start != "" {
+			period = append(period, "dcat:startDate "+turtle_date(start))
+		}
+		if end != "" {
+			period = append(period, "dcat:endDate "+turtle_date(end))
+		}
+		add("dct:temporal", "[ "+strings.Join(period, " ; ")+" ]")
+	}
+	for _, place := range doc.CoveredGeolocationPlace {
+		if place = strings.TrimSpace(place); place != "" {
+			add("dct:spatial", "[ a dct:Location ; rdfs:label "+turtle_literal(place)+" ]")
+		}
+	}
+
+	if url := license_url(doc.License); url != "" {
+		add("dct:license", turtle_iri(url))
+	} else if doc.License != "" {
+		add("dct:license", "[ a dct:LicenseDocument ; rdfs:label "+turtle_literal(doc.License)+" ]")
+	}
+	if doc.DataAccessRestriction != "" {
+		add("dct:accessRights", "[ a dct:RightsStatement ; rdfs:label "+turtle_literal(doc.DataAccessRestriction)+" ]")
+	}
+
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		add("dct:creator", dcat_person(cre.Name, ids, described, &extra))
+	}
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		add("dct:contributor", dcat_person(con.Name, ids, described, &extra))
+	}
+
+	// "describedby" points at the Yoda schema, not at the dataset
+	for _, link := range doc.Links {
+		if link.Href != "" && link.Rel != "describedby" {
+			add("dcat:landingPage", turtle_iri(link.Href))
+		}
+	}
+	if sys.OpenAccessLink != "" {
+		add("dcat:landingPage", turtle_iri(sys.OpenAccessLink))
+	}
+
+	var sb strings.Builder
+	for _, prefix := range dcat_prefixes {
+		fmt.Fprintf(&sb, "@prefix %s: <%s> .\n", prefix[0], prefix[1])
+	}
+	sb.WriteString("\n" + dcat_subject(doc, sys, uri) + "\n")
+	for i, p := range props {
+		end := " ;"
+		if i == len(props)-1 {
+			end = " ."
+		}
+		sb.WriteString("    " + p[0] + " " + p[1] + end + "\n")
+	}
+	sb.WriteString(extra.String())
+	return sb.String()
+}
+
+// exportDCAT writes the metadata read from source as a DCAT dataset in Turtle to w
+func exportDCAT(doc Yoda18Metadata, source string, w io.Writer) error {
+	_, err := io.WriteString(w, dcat_turtle(doc, oai_system(doc, source), *dcat_uri))
+	return err
+}
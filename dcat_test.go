@@ -0,0 +1,251 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+const rdf_type string = "http://www.w3.org/1999/02/22-rdf-syntax-ns#type"
+
+// a Turtle triple, an IRI as <iri>, a literal as its quoted Go string with ^^<datatype> and a blank node as _:name
+type turtle_test_triple struct {
+	Subject, Predicate, Object string
+}
+
+// a parser of the Turtle subset dcat_turtle writes: @prefix, IRIs, prefixed names, "a", string literals with
+// a datatype, blank node labels and [ ] property lists
+type turtle_test_parser struct {
+	t        *testing.T
+	tokens   []string
+	prefixes map[string]string
+	triples  []turtle_test_triple
+	blanks   int
+}
+
+// split Turtle into its tokens, a string literal kept with its quotes and escapes
+func turtle_test_tokens(t *testing.T, src string) []string {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\n' || c == '\t' || c == '\r':
+			i++
+		case c == '<':
+			end := strings.IndexByte(src[i:], '>')
+			if end < 0 || strings.ContainsAny(src[i+1:i+end], " \n\"{}|^`\\<") {
+				t.Fatalf("bad IRI at %q", src[i:min(len(src), i+40)])
+			}
+			tokens = append(tokens, src[i:i+end+1])
+			i += end + 1
+		case c == '"':
+			end := i + 1
+			for ; end < len(src) && src[end] != '"'; end++ {
+				if src[end] == '\\' {
+					end++
+				} else if src[end] == '\n' {
+					t.Fatalf("line break in a literal at %q", src[i:end])
+				}
+			}
+			if end >= len(src) {
+				t.Fatalf("unterminated literal at %q", src[i:])
+			}
+			tokens = append(tokens, src[i:end+1])
+			i = end + 1
+		case strings.HasPrefix(src[i:], "^^"):
+			tokens = append(tokens, "^^")
+			i += 2
+		case strings.IndexByte("[];,.", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		default:
+			end := i
+			for end < len(src) && (src[end] == ':' || src[end] == '_' || src[end] == '-' || src[end] == '@' ||
+				unicode.IsLetter(rune(src[end])) || unicode.IsDigit(rune(src[end]))) {
+				end++
+			}
+			if end == i {
+				t.Fatalf("unexpected %q at %q", c, src[i:min(len(src), i+40)])
+			}
+			tokens = append(tokens, src[i:end])
+			i = end
+		}
+	}
+	return tokens
+}
+
+// parse the Turtle document src into its triples
+func turtle_test_parse(t *testing.T, src string) []turtle_test_triple {
+	t.Helper()
+	p := &turtle_test_parser{t: t, tokens: turtle_test_tokens(t, src), prefixes: map[string]string{}}
+	for len(p.tokens) > 0 {
+		if p.peek() == "@prefix" {
+			p.next()
+			name, iri := p.next(), p.next()
+			if !strings.HasSuffix(name, ":") || !strings.HasPrefix(iri, "<") {
+				t.Fatalf("bad prefix %s %s", name, iri)
+			}
+			p.prefixes[strings.TrimSuffix(name, ":")] = strings.Trim(iri, "<>")
+		} else {
+			subject := p.term()
+			p.predicate_objects(subject)
+		}
+		p.expect(".")
+	}
+	return p.triples
+}
+
+func (p *turtle_test_parser) peek() string {
+	if len(p.tokens) == 0 {
+		p.t.Fatal("unexpected end of the document")
+	}
+	return p.tokens[0]
+}
+
+func (p *turtle_test_parser) next() string {
+	token := p.peek()
+	p.tokens = p.tokens[1:]
+	return token
+}
+
+func (p *turtle_test_parser) expect(token string) {
+	if got := p.next(); got != token {
+		p.t.Fatalf("got %q, want %q before %v", got, token, p.tokens[:min(len(p.tokens), 8)])
+	}
+}
+
+// a subject or object: an IRI, prefixed name, blank node, literal or [ ] property list
+func (p *turtle_test_parser) term() string {
+	token := p.next()
+	switch {
+	case token == "[":
+		p.blanks++
+		node := "_:b" + strconv.Itoa(p.blanks)
+		p.predicate_objects(node)
+		p.expect("]")
+		return node
+	case strings.HasPrefix(token, "<"), strings.HasPrefix(token, "_:"):
+		return token
+	case strings.HasPrefix(token, `"`):
+		value, err := strconv.Unquote(token)
+		if err != nil {
+			p.t.Fatalf("literal %s: %v", token, err)
+		}
+		literal := strconv.Quote(value)
+		if len(p.tokens) > 0 && p.peek() == "^^" {
+			p.next()
+			literal += "^^" + p.iri(p.next())
+		}
+		return literal
+	}
+	return p.iri(token)
+}
+
+// the IRI of a prefixed name, "a" or an IRI
+func (p *turtle_test_parser) iri(token string) string {
+	if token == "a" {
+		return "<" + rdf_type + ">"
+	}
+	if strings.HasPrefix(token, "<") {
+		return token
+	}
+	prefix, local, ok := strings.Cut(token, ":")
+	ns, declared := p.prefixes[prefix]
+	if !ok || !declared {
+		p.t.Fatalf("%q is not a declared prefixed name", token)
+	}
+	return "<" + ns + local + ">"
+}
+
+// the predicate object list of subject, up to the closing . or ]
+func (p *turtle_test_parser) predicate_objects(subject string) {
+	for {
+		predicate := p.iri(p.next())
+		for {
+			p.triples = append(p.triples, turtle_test_triple{subject, predicate, p.term()})
+			if p.peek() != "," {
+				break
+			}
+			p.next()
+		}
+		if p.peek() != ";" {
+			return
+		}
+		p.next()
+	}
+}
+
+// the objects of subject and predicate in triples
+func turtle_test_objects(triples []turtle_test_triple, subject, predicate string) []string {
+	var objects []string
+	for _, tr := range triples {
+		if tr.Subject == subject && tr.Predicate == predicate {
+			objects = append(objects, tr.Object)
+		}
+	}
+	return objects
+}
+
+func TestDCATTurtle(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	doc.Title = "A \"quoted\" title\\with a backslash\nand a line break"
+	var sys System
+	sys.PersistentIdentifierDatapackage.IdentifierScheme = "DOI"
+	sys.PersistentIdentifierDatapackage.Identifier = "10.1234/ABC"
+
+	triples := turtle_test_parse(t, dcat_turtle(doc, sys, ""))
+	// the dataset: type, title, description, identifier, version, language, 5 keywords, license, accessRights,
+	// a creator and 3 contributors (17); the access rights node (2); the contributor without ORCID (4); the creator
+	// and the project leader with ORCID (4 each)
+	if want := 17 + 2 + 4 + 2*4; len(triples) != want {
+		t.Errorf("%d triples, want %d", len(triples), want)
+	}
+	subject := "<https://doi.org/10.1234/ABC>"
+	dcat, dct, foaf := "<http://www.w3.org/ns/dcat#", "<http://purl.org/dc/terms/", "<http://xmlns.com/foaf/0.1/"
+
+	if types := turtle_test_objects(triples, subject, "<"+rdf_type+">"); len(types) != 1 || types[0] != dcat+"Dataset>" {
+		t.Fatalf("type of %s %v, want dcat:Dataset", subject, types)
+	}
+	if titles := turtle_test_objects(triples, subject, dct+"title>"); len(titles) != 1 || titles[0] != strconv.Quote(doc.Title) {
+		t.Errorf("dct:title %v, want %q", titles, doc.Title)
+	}
+	if keywords := turtle_test_objects(triples, subject, dcat+"keyword>"); len(keywords) != len(doc.Tag) {
+		t.Errorf("%d keywords, want %d", len(keywords), len(doc.Tag))
+	}
+	if licenses := turtle_test_objects(triples, subject, dct+"license>"); len(licenses) != 1 || licenses[0] != "<https://creativecommons.org/licenses/by/4.0/>" {
+		t.Errorf("dct:license %v", licenses)
+	}
+	creators := turtle_test_objects(triples, subject, dct+"creator>")
+	if len(creators) != len(doc.Creator) {
+		t.Fatalf("creators %v, want %d", creators, len(doc.Creator))
+	}
+	for _, person := range append(creators, turtle_test_objects(triples, subject, dct+"contributor>")...) {
+		if names := turtle_test_objects(triples, person, foaf+"name>"); len(names) != 1 {
+			t.Errorf("person %s has names %v, want one", person, names)
+		}
+	}
+	for _, period := range turtle_test_objects(triples, subject, dct+"temporal>") {
+		for _, date := range turtle_test_objects(triples, period, dcat+"startDate>") {
+			if !strings.HasSuffix(date, "^^<http://www.w3.org/2001/XMLSchema#date>") {
+				t.Errorf("start date %s is not an xsd:date", date)
+			}
+		}
+	}
+}
+
+func TestDCATTurtleBlankSubject(t *testing.T) {
+	doc := parse_test_metadata(t, `{"Title": "No identifier", "Creator": [
+		{"Name": {"Given_Name": "Ada", "Family_Name": "Lovelace"}, "Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}]}],
+		"Contributor": [
+		{"Name": {"Given_Name": "Ada", "Family_Name": "Lovelace"}, "Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}]}]}`)
+	triples := turtle_test_parse(t, dcat_turtle(doc, System{}, ""))
+	orcid := "<https://orcid.org/0000-0002-1825-0097>"
+	if creators := turtle_test_objects(triples, "_:dataset", "<http://purl.org/dc/terms/creator>"); len(creators) != 1 || creators[0] != orcid {
+		t.Errorf("creators of the blank dataset node %v", creators)
+	}
+	// the person is described once though they are creator and contributor
+	if names := turtle_test_objects(triples, orcid, "<http://xmlns.com/foaf/0.1/name>"); len(names) != 1 {
+		t.Errorf("names of %s %v, want one", orcid, names)
+	}
+}
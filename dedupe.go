@@ -0,0 +1,152 @@
+/*
+dedupe.go merging the creators of a collection of metadata documents into a list of unique persons, matched by
+ORCID or by a Jaro-Winkler comparison of their names.
+*/
+
+package main
+
+import (
+	"strings"
+)
+
+// the Jaro-Winkler similarity from which two names without conflicting ORCIDs are the same person
+const creator_name_threshold float64 = 0.93
+
+// CreatorRecord is a unique creator with the name variants, affiliations and dataset titles it was found with
+type CreatorRecord struct {
+	Name         NameStruct
+	ORCID        string
+	Variants     []string
+	Affiliations []string
+	Datasets     []string
+}
+
+// append value if it is not blank and not in the list yet, compared case insensitively
+func append_unique(list []string, value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return list
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+// the Jaro similarity of a and b, 1 for equal strings and 0 for strings without common characters
+func jaro(a, b []rune) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	window := max(len(a), len(b))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+	a_matched := make([]bool, len(a))
+	b_matched := make([]bool, len(b))
+	matches := 0
+	for i := range a {
+		for j := max(0, i-window); j < min(len(b), i+window+1); j++ {
+			if !b_matched[j] && a[i] == b[j] {
+				a_matched[i], b_matched[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+	// half the number of matched characters that are out of order
+	transpositions, j := 0, 0
+	for i := range a {
+		if !a_matched[i] {
+			continue
+		}
+		for !b_matched[j] {
+			j++
+		}
+		if a[i] != b[j] {
+			transpositions++
+		}
+		j++
+	}
+	m := float64(matches)
+	return (m/float64(len(a)) + m/float64(len(b)) + (m-float64(transpositions)/2)/m) / 3
+}
+
+// the Jaro-Winkler similarity of a and b, the Jaro similarity raised for a common prefix of up to 4 characters
+func jaro_winkler(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	sim := jaro(ra, rb)
+	prefix := 0
+	for prefix < min(4, len(ra), len(rb)) && ra[prefix] == rb[prefix] {
+		prefix++
+	}
+	return sim + float64(prefix)*0.1*(1-sim)
+}
+
+// the index of the record the creator belongs to, -1 for a new person; an ORCID match wins, a name only
+// matches when the ORCIDs do not conflict
+func find_creator_record(records []CreatorRecord, name string, orcid string) int {
+	if orcid != "" {
+		for i, r := range records {
+			if r.ORCID == orcid {
+				return i
+			}
+		}
+	}
+	best, best_sim := -1, creator_name_threshold
+	for i, r := range records {
+		if orcid != "" && r.ORCID != "" {
+			continue
+		}
+		for _, variant := range r.Variants {
+			if sim := jaro_winkler(match_key(variant), name); sim >= best_sim {
+				best, best_sim = i, sim
+			}
+		}
+	}
+	return best
+}
+
+// DeduplicateCreators returns the creators of all documents once, creators with the same ORCID or a close
+// enough name are merged and keep every name variant, affiliation and dataset title they were listed with
+func DeduplicateCreators(docs []Yoda18Metadata) []CreatorRecord {
+	var records []CreatorRecord
+	for _, doc := range docs {
+		for _, cre := range doc.Creator {
+			full := formatName(cre.Name, "full")
+			if full == "" {
+				continue
+			}
+			orcid := ""
+			for _, pid := range cre.PersonIdentifier {
+				if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+					orcid = orcid_url(pid.NameIdentifier)
+				}
+			}
+
+			i := find_creator_record(records, match_key(full), orcid)
+			if i < 0 {
+				records = append(records, CreatorRecord{Name: cre.Name})
+				i = len(records) - 1
+			}
+			r := &records[i]
+			if r.ORCID == "" {
+				r.ORCID = orcid
+			}
+			r.Variants = append_unique(r.Variants, full)
+			for _, aff := range cre.Affiliation {
+				r.Affiliations = append_unique(r.Affiliations, aff)
+			}
+			r.Datasets = append_unique(r.Datasets, doc.Title)
+		}
+	}
+	return records
+}
@@ -0,0 +1,167 @@
+/*
+diff.go field by field comparison of two metadata files, creators and contributors are matched by identifier or name.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// a difference between two files, Old is empty for an added and New for a removed value
+type diff_entry struct {
+	Key string
+	Old string
+	New string
+}
+
+// a person flattened for comparison, Match identifies the same person in both files
+type diff_person struct {
+	Match  string
+	Label  string
+	Fields [][2]string
+}
+
+// compare two metadata documents, the differences are listed in document order
+func diff_metadata(a Yoda18Metadata, b Yoda18Metadata) []diff_entry {
+	diffs := diff_fields(diff_scalar_fields(a), diff_scalar_fields(b))
+	diffs = append(diffs, diff_persons("Creator", diff_creators(a), diff_creators(b))...)
+	diffs = append(diffs, diff_persons("Contributor", diff_contributors(a), diff_contributors(b))...)
+	return diffs
+}
+
+// the flattened fields without the persons, those are compared separately
+func diff_scalar_fields(doc Yoda18Metadata) [][2]string {
+	var out [][2]string
+	for _, kv := range flatten_metadata(doc) {
+		if !strings.HasPrefix(kv[0], "Creator.") && !strings.HasPrefix(kv[0], "Contributor.") {
+			out = append(out, kv)
+		}
+	}
+	return out
+}
+
+// compare two key/value lists, keys only in b are listed after those of a
+func diff_fields(a [][2]string, b [][2]string) []diff_entry {
+	bvals := make(map[string]string)
+	for _, kv := range b {
+		bvals[kv[0]] = kv[1]
+	}
+	seen := make(map[string]bool)
+	var diffs []diff_entry
+	for _, kv := range a {
+		seen[kv[0]] = true
+		if kv[1] != bvals[kv[0]] {
+			diffs = append(diffs, diff_entry{kv[0], kv[1], bvals[kv[0]]})
+		}
+	}
+	for _, kv := range b {
+		if !seen[kv[0]] {
+			diffs = append(diffs, diff_entry{kv[0], "", kv[1]})
+		}
+	}
+	return diffs
+}
+
+// the match key of a person: the first identifier, otherwise the name
+func diff_person_match(n NameStruct, scheme_ids [][2]string) string {
+	for _, id := range scheme_ids {
+		if id[1] != "" {
+			return strings.ToLower(id[0] + ":" + strings.TrimSpace(id[1]))
+		}
+	}
+	return strings.ToLower(formatName(n, "citation"))
+}
+
+func diff_new_person(n NameStruct, scheme_ids [][2]string, person interface{}) diff_person {
+	p := diff_person{Match: diff_person_match(n, scheme_ids), Label: formatName(n, "citation")}
+	if p.Label == "" {
+		p.Label = "unnamed"
+	}
+	flatten_value("", reflect.ValueOf(person), &p.Fields)
+	return p
+}
+
+func diff_creators(doc Yoda18Metadata) []diff_person {
+	var out []diff_person
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		out = append(out, diff_new_person(cre.Name, ids, cre))
+	}
+	return out
+}
+
+func diff_contributors(doc Yoda18Metadata) []diff_person {
+	var out []diff_person
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		out = append(out, diff_new_person(con.Name, ids, con))
+	}
+	return out
+}
+
+// compare the persons of one role, a person only present on one side is reported as a whole
+func diff_persons(role string, a []diff_person, b []diff_person) []diff_entry {
+	used := make([]bool, len(b))
+	var diffs []diff_entry
+	for _, pa := range a {
+		key := fmt.Sprintf("%s[%s]", role, pa.Label)
+		found := false
+		for j, pb := range b {
+			if !used[j] && pb.Match == pa.Match {
+				used[j] = true
+				found = true
+				for _, d := range diff_fields(pa.Fields, pb.Fields) {
+					diffs = append(diffs, diff_entry{key + "." + d.Key, d.Old, d.New})
+				}
+				break
+			}
+		}
+		if !found {
+			diffs = append(diffs, diff_entry{key, pa.Label, ""})
+		}
+	}
+	for j, pb := range b {
+		if !used[j] {
+			diffs = append(diffs, diff_entry{fmt.Sprintf("%s[%s]", role, pb.Label), "", pb.Label})
+		}
+	}
+	return diffs
+}
+
+// write the differences as a unified diff like listing, "-" lines are from name_a and "+" lines from name_b
+func write_diff(w io.Writer, name_a string, name_b string, diffs []diff_entry) error {
+	_, err := fmt.Fprintf(w, "--- %s\n+++ %s\n", name_a, name_b)
+	if err != nil {
+		return err
+	}
+	for _, d := range diffs {
+		if d.Old != "" {
+			_, err = fmt.Fprintf(w, "- %s: %s\n", d.Key, diff_flatten(d.Old))
+			if err != nil {
+				return err
+			}
+		}
+		if d.New != "" {
+			_, err = fmt.Fprintf(w, "+ %s: %s\n", d.Key, diff_flatten(d.New))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// keep multi-line values such as Description on a single line
+func diff_flatten(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r", ""), "\n", `\n`)
+}
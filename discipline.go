@@ -0,0 +1,178 @@
+/*
+discipline.go checking the Discipline values against the OECD Fields of Science list, or a -discipline-list file.
+*/
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// the Yoda Discipline values, one "Field - Subfield (code)" per line, # starts a comment
+//
+//go:embed assets/oecd-fos-disciplines.txt
+var oecd_discipline_list string
+
+// the discipline list in use, read once from -discipline-list or the embedded list
+var discipline_values []string
+var discipline_err error
+var discipline_once sync.Once
+
+// the list of valid Discipline values, from the -discipline-list file if given
+func load_disciplines() ([]string, error) {
+	discipline_once.Do(func() {
+		list := oecd_discipline_list
+		if *discipline_list_file != "" {
+			raw, err := os.ReadFile(*discipline_list_file)
+			if err != nil {
+				discipline_err = err
+				return
+			}
+			list = string(raw)
+		}
+		discipline_values = parse_discipline_list(list)
+	})
+	return discipline_values, discipline_err
+}
+
+// the non-empty, non-comment lines of a discipline list
+func parse_discipline_list(list string) []string {
+	var values []string
+	for _, line := range strings.Split(list, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			values = append(values, line)
+		}
+	}
+	return values
+}
+
+// lower case with single spaces, so case and spacing differences still match
+func match_key(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// the code at the end of a discipline, "1.3" for "Natural Sciences - Physical sciences (1.3)"
+var discipline_code_pattern = regexp.MustCompile(`\(\s*([0-9]+(?:\.[0-9]+)*)\s*\)\s*$`)
+
+// the code of a discipline, the value itself if it is a bare code
+func discipline_code_of(value string) string {
+	if m := discipline_code_pattern.FindStringSubmatch(value); m != nil {
+		return m[1]
+	}
+	value = strings.TrimSpace(value)
+	if strings.Trim(value, "0123456789.") == "" {
+		return value
+	}
+	return ""
+}
+
+// check a Discipline against the list: a value is valid if it is one of the listed values or their code,
+// otherwise the closest listed value is returned as suggestion
+func check_discipline(value string, list []string) (bool, string) {
+	key := match_key(value)
+	code := discipline_code_of(value)
+	for _, d := range list {
+		if match_key(d) == key || (code != "" && code == strings.TrimSpace(value) && discipline_code_of(d) == code) {
+			return true, ""
+		}
+	}
+	// a listed code is the best hint for a mistyped label
+	if code != "" {
+		for _, d := range list {
+			if discipline_code_of(d) == code {
+				return false, d
+			}
+		}
+	}
+	best, best_dist := "", -1
+	for _, d := range list {
+		// compare against the whole value and against the subfield name alone, as in "Physical sciences"
+		candidates := []string{match_key(d)}
+		if _, sub, ok := strings.Cut(d, " - "); ok {
+			candidates = append(candidates, match_key(discipline_code_pattern.ReplaceAllString(sub, "")))
+		}
+		for _, c := range candidates {
+			dist := edit_distance(key, c)
+			// a shortened name such as "Physics" is compared with the start of the listed name too
+			if rc := []rune(c); len(rc) > len([]rune(key)) {
+				dist = min(dist, edit_distance(key, string(rc[:len([]rune(key))]))+1)
+			}
+			if best_dist < 0 || dist < best_dist {
+				best, best_dist = d, dist
+			}
+		}
+	}
+	// a value that is nothing like any listed discipline gets no suggestion
+	if best_dist > len([]rune(key))/2 {
+		return false, ""
+	}
+	return false, best
+}
+
+// the Levenshtein distance between a and b, in runes
+func edit_distance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+// the warnings for the Discipline values that are not in the list, with the closest listed value
+func discipline_issues(doc Yoda18Metadata) ([]ValidationIssue, error) {
+	list, err := load_disciplines()
+	if err != nil {
+		return nil, err
+	}
+	var issues []ValidationIssue
+	for i, d := range doc.Discipline {
+		if strings.TrimSpace(d) == "" {
+			continue
+		}
+		if ok, suggestion := check_discipline(d, list); !ok {
+			msg := fmt.Sprintf("unknown Discipline %q", d)
+			if suggestion != "" {
+				msg += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			issues = append(issues, ValidationIssue{fmt.Sprintf("Discipline[%d]", i), msg, severity_warning})
+		}
+	}
+	return issues, nil
+}
+
+// warn about every Discipline that is not in the discipline list, suggesting the closest match
+func report_disciplines(doc Yoda18Metadata, fname string) {
+	list, err := load_disciplines()
+	if err != nil {
+		slog.Error("cannot read the discipline list", "file", *discipline_list_file, "error", err)
+		return
+	}
+	for i, d := range doc.Discipline {
+		if strings.TrimSpace(d) == "" {
+			continue
+		}
+		if ok, suggestion := check_discipline(d, list); !ok {
+			slog.Warn("unknown Discipline", "file", fname, "field_path", fmt.Sprintf("Discipline[%d]", i), "value", d, "suggestion", suggestion)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+/*
+docx.go exports the metadata report as a Word document for data management documentation, written as plain
+Office Open XML so neither Word nor a docx library is needed.
+*/
+
+package main
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// hex RGB colours matching the PDF report
+var docx_level_colours = map[report_level]string{
+	report_good:    "00C800",
+	report_info:    "FFA500",
+	report_warning: "0000C8",
+	report_error:   "C80000",
+}
+
+const docx_content_types string = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>
+</Types>`
+
+const docx_package_rels string = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>
+</Relationships>`
+
+const docx_document_rels string = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+// the built-in style ids Word recognises as Heading 1 and Heading 2, so they show up in the navigation pane
+const docx_styles string = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>
+<w:pPrDefault><w:pPr><w:spacing w:after="120"/></w:pPr></w:pPrDefault></w:docDefaults>
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:qFormat/></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="120"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:qFormat/>
+<w:pPr><w:keepNext/><w:spacing w:before="200" w:after="80"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="26"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListParagraph"><w:name w:val="List Paragraph"/><w:basedOn w:val="Normal"/><w:qFormat/>
+<w:pPr><w:spacing w:after="0"/><w:ind w:left="360" w:hanging="240"/></w:pPr></w:style>
+<w:style w:type="table" w:styleId="TableGrid"><w:name w:val="Table Grid"/>
+<w:tblPr><w:tblBorders><w:top w:val="single" w:sz="4" w:space="0" w:color="808080"/><w:left w:val="single" w:sz="4" w:space="0" w:color="808080"/>
+<w:bottom w:val="single" w:sz="4" w:space="0" w:color="808080"/><w:right w:val="single" w:sz="4" w:space="0" w:color="808080"/>
+<w:insideH w:val="single" w:sz="4" w:space="0" w:color="808080"/><w:insideV w:val="single" w:sz="4" w:space="0" w:color="808080"/></w:tblBorders>
+<w:tblCellMar><w:left w:w="80" w:type="dxa"/><w:right w:w="80" w:type="dxa"/></w:tblCellMar></w:tblPr></w:style>
+</w:styles>`
+
+// escape s for use in XML text and attributes
+func docx_escape(s string) string {
+	var sb strings.Builder
+	xml.EscapeText(&sb, []byte(s))
+	return sb.String()
+}
+
+// a run of text coloured by its report level, line breaks in s become <w:br/>
+func docx_run(v report_value, bold bool) string {
+	var props string
+	if bold {
+		props += "<w:b/>"
+	}
+	if colour, ok := docx_level_colours[v.Level]; ok {
+		props += `<w:color w:val="` + colour + `"/>`
+	}
+	if props != "" {
+		props = "<w:rPr>" + props + "</w:rPr>"
+	}
+	var sb strings.Builder
+	sb.WriteString("<w:r>" + props)
+	for i, line := range strings.Split(strings.ReplaceAll(v.Text, "\r\n", "\n"), "\n") {
+		if i > 0 {
+			sb.WriteString("<w:br/>")
+		}
+		sb.WriteString(`<w:t xml:space="preserve">` + docx_escape(line) + "</w:t>")
+	}
+	sb.WriteString("</w:r>")
+	return sb.String()
+}
+
+// a paragraph in the given style, an empty style is Normal
+func docx_paragraph(style string, runs ...string) string {
+	var props string
+	if style != "" {
+		props = `<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`
+	}
+	return "<w:p>" + props + strings.Join(runs, "") + "</w:p>\n"
+}
+
+// a bulleted list, one paragraph per item
+func docx_list(items []report_value) string {
+	var sb strings.Builder
+	for _, item := range items {
+		sb.WriteString(docx_paragraph("ListParagraph", docx_run(report_value{"• ", report_normal}, false), docx_run(item, false)))
+	}
+	return sb.String()
+}
+
+// a table with a bold header row that is repeated on every page, a cell may hold several lines
+func docx_table(header []string, rows [][][]report_value) string {
+	var sb strings.Builder
+	sb.WriteString(`<w:tbl><w:tblPr><w:tblStyle w:val="TableGrid"/><w:tblW w:w="5000" w:type="pct"/></w:tblPr>`)
+	sb.WriteString(`<w:tr><w:trPr><w:tblHeader/></w:trPr>`)
+	for _, h := range header {
+		sb.WriteString("<w:tc>" + docx_paragraph("", docx_run(report_value{h, report_normal}, true)) + "</w:tc>")
+	}
+	sb.WriteString("</w:tr>\n")
+	for _, row := range rows {
+		sb.WriteString("<w:tr>")
+		for _, cell := range row {
+			sb.WriteString("<w:tc>")
+			if len(cell) == 0 {
+				// every cell needs at least one paragraph
+				sb.WriteString(docx_paragraph(""))
+			}
+			for _, v := range cell {
+				sb.WriteString(docx_paragraph("", docx_run(v, false)))
+			}
+			sb.WriteString("</w:tc>")
+		}
+		sb.WriteString("</w:tr>\n")
+	}
+	sb.WriteString("</w:tbl>\n")
+	// Word merges a table with a following one unless a paragraph separates them
+	sb.WriteString(docx_paragraph(""))
+	return sb.String()
+}
+
+// the person table rows, contributors get a Type column
+func docx_person_rows(persons []report_person, with_role bool) [][][]report_value {
+	var rows [][][]report_value
+	for _, p := range persons {
+		row := [][]report_value{{p.Name}}
+		if with_role {
+			row = append(row, []report_value{p.Role})
+		}
+		rows = append(rows, append(row, p.Affiliations, p.Identifiers))
+	}
+	return rows
+}
+
+// the description split on blank lines, single line breaks are kept within a paragraph
+func docx_description(v report_value) string {
+	var sb strings.Builder
+	for _, paragraph := range paragraph_break.Split(strings.ReplaceAll(v.Text, "\r\n", "\n"), -1) {
+		if strings.TrimSpace(paragraph) != "" {
+			sb.WriteString(docx_paragraph("", docx_run(report_value{strings.Trim(paragraph, "\n"), v.Level}, false)))
+		}
+	}
+	return sb.String()
+}
+
+// the body of word/document.xml
+func docx_document_body(report report_model) string {
+	var sb strings.Builder
+	sb.WriteString(docx_paragraph("Heading1", docx_run(report.Title, false)))
+
+	sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Description", report_normal}, false)))
+	sb.WriteString(docx_description(report.Description))
+
+	sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Tags", report_normal}, false)))
+	sb.WriteString(docx_list(report.Tags))
+
+	sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Creators", report_normal}, false)))
+	sb.WriteString(docx_table([]string{"Name", "Affiliation", "Identifier"}, docx_person_rows(report.Creators, false)))
+
+	sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Contributors", report_normal}, false)))
+	if report.Note.Text != "" {
+		sb.WriteString(docx_paragraph("", docx_run(report.Note, false)))
+	}
+	sb.WriteString(docx_table([]string{"Name", "Type", "Affiliation", "Identifier"}, docx_person_rows(report.Contributors, true)))
+
+	sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Disciplines", report_normal}, false)))
+	sb.WriteString(docx_list(report.Disciplines))
+
+	for _, period := range []struct {
+		Title string
+		Pairs []report_pair
+	}{{"Collected", report.Collected}, {"Covered Period", report.CoveredPeriod}} {
+		sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{period.Title, report_normal}, false)))
+		for _, pair := range period.Pairs {
+			sb.WriteString(docx_paragraph("", docx_run(report_value{pair.Label + ": ", report_normal}, true), docx_run(pair.Value, false)))
+		}
+	}
+
+	sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Funding references", report_normal}, false)))
+	var rows [][][]report_value
+	for _, fund := range report.Funding {
+		rows = append(rows, [][]report_value{{{fund.Label, report_normal}}, {fund.Value}})
+	}
+	sb.WriteString(docx_table([]string{"Funder", "Award number"}, rows))
+
+	sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Related datapackages", report_normal}, false)))
+	rows = nil
+	for _, rel := range report.Related {
+		rows = append(rows, [][]report_value{{rel.Relation}, {rel.Identifier}, {rel.Title}})
+	}
+	sb.WriteString(docx_table([]string{"Relation type", "Identifier", "Title"}, rows))
+
+	for _, field := range report.Fields {
+		sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{field.Label, report_normal}, false)))
+		sb.WriteString(docx_paragraph("", docx_run(field.Value, false)))
+	}
+
+	if len(report.Unmapped) > 0 {
+		sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"Unmapped fields", report_normal}, false)))
+		rows = nil
+		for _, field := range report.Unmapped {
+			rows = append(rows, [][]report_value{{{field.Label, report_normal}}, {field.Value}})
+		}
+		sb.WriteString(docx_table([]string{"Field", "Value"}, rows))
+	}
+
+	if issues := report_issue_count(report); issues > 0 {
+		sb.WriteString(docx_paragraph("Heading2", docx_run(report_value{"readYmeta diagnostics", report_normal}, false)))
+		sb.WriteString(docx_paragraph("", docx_run(report_value{fmt.Sprintf("%d warnings were generated, please check for missing (optional) information.", issues), report_normal}, false)))
+	}
+	return sb.String()
+}
+
+// exportDOCX writes the metadata report as a Word document to w
+func exportDOCX(doc Yoda18Metadata, w io.Writer) error {
+	report := build_report(doc)
+
+	document := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+` + docx_document_body(report) + `<w:sectPr><w:pgSz w:w="11906" w:h="16838"/><w:pgMar w:top="1134" w:right="1134" w:bottom="1134" w:left="1134" w:header="567" w:footer="567" w:gutter="0"/></w:sectPr>
+</w:body></w:document>`
+
+	core := `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">
+<dc:title>` + docx_escape(doc.Title) + `</dc:title><dc:creator>readYmeta v` + _MYVERSION_ + `</dc:creator>
+</cp:coreProperties>`
+
+	zw := zip.NewWriter(w)
+	for _, part := range [][2]string{
+		{"[Content_Types].xml", docx_content_types},
+		{"_rels/.rels", docx_package_rels},
+		{"word/_rels/document.xml.rels", docx_document_rels},
+		{"word/document.xml", document},
+		{"word/styles.xml", docx_styles},
+		{"docProps/core.xml", core},
+	} {
+		f, err := zw.Create(part[0])
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, part[1])
+		if err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
@@ -0,0 +1,246 @@
+/*
+dot.go draws the related datapackages of one or more datasets as a Graphviz DOT digraph.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the diagram filename stem used for several input files when -output is not given
+const diagram_default_stem string = "related-datapackages"
+
+// the node colour of each access class
+var dot_access_colours = map[string]string{
+	"open":       "palegreen",
+	"restricted": "gold",
+	"closed":     "lightcoral",
+	"unknown":    "lightgrey",
+}
+
+// a DOT double quoted string
+func dot_quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	s = strings.ReplaceAll(s, "\r\n", `\n`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+// the access class of a Data_Access_Restriction, the word it starts with: open, restricted or closed,
+// otherwise unknown
+func access_class(restriction string) string {
+	word, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(restriction)), " ")
+	switch word {
+	case "open", "restricted", "closed":
+		return word
+	}
+	return "unknown"
+}
+
+// a dataset or related datapackage in the relation diagrams, Related marks a datapackage that is not one of
+// the datasets
+type relation_node struct {
+	ID          string
+	Label       string
+	Restriction string
+	Related     bool
+}
+
+// a Related_Datapackage entry of a dataset, Relation is the DataCite relation type
+type relation_edge struct {
+	From     string
+	To       string
+	Relation string
+}
+
+// the key of a dataset or related datapackage in the diagrams: its identifier as resolver link, so a DOI
+// written as doi:10.1234/foo or https://doi.org/10.1234/FOO is the same node (DOIs are case insensitive), or
+// its title when it has none
+func relation_node_key(scheme, identifier, title string) string {
+	if strings.TrimSpace(identifier) != "" {
+		link := pid_link(scheme, strings.TrimSpace(identifier))
+		if strings.HasPrefix(link, "https://doi.org/") {
+			link = strings.ToLower(link)
+		}
+		return "pid:" + link
+	}
+	return "title:" + match_key(strings.Join(strings.Fields(title), " "))
+}
+
+// the DOI of the dataset read from source, from its System block (or -system and -set DOI=...) or a related
+// datapackage it IsIdenticalTo, empty if it has none
+func dataset_doi(doc Yoda18Metadata, source string) string {
+	return citation_doi(doc, oai_system(doc, source))
+}
+
+// the nodes and edges of the DOT and Mermaid diagrams, dois holds the DOI of each dataset (or is shorter, or
+// nil, when they are not known); a dataset is keyed by its DOI and a related datapackage with that DOI points
+// to it, a related datapackage without a matching DOI points to the dataset without DOI that has its title,
+// the other related datapackages are shared by identifier, or by title when they have none; a relation that
+// is listed twice gives one edge and a dataset is not drawn related to itself, cycles are drawn as they are
+func relation_graph(docs []Yoda18Metadata, dois []string) ([]relation_node, []relation_edge) {
+	var nodes []relation_node
+	var edges []relation_edge
+
+	datasets := make(map[string]string)
+	for i, doc := range docs {
+		id := fmt.Sprintf("dataset%d", i+1)
+		title := strings.Join(strings.Fields(doc.Title), " ")
+		doi := ""
+		if i < len(dois) {
+			doi = dois[i]
+		}
+		if doi != "" {
+			if _, ok := datasets[relation_node_key("DOI", doi, "")]; !ok {
+				datasets[relation_node_key("DOI", doi, "")] = id
+			}
+		} else if title != "" {
+			if _, ok := datasets[relation_node_key("", "", title)]; !ok {
+				datasets[relation_node_key("", "", title)] = id
+			}
+		}
+		if title == "" {
+			title = "Untitled"
+		}
+		nodes = append(nodes, relation_node{ID: id, Label: title, Restriction: doc.DataAccessRestriction})
+	}
+
+	related := make(map[string]string)
+	seen := make(map[relation_edge]bool)
+	for i, doc := range docs {
+		from := fmt.Sprintf("dataset%d", i+1)
+		for _, rel := range doc.RelatedDatapackage {
+			pid := rel.PersistentIdentifier
+			title := strings.Join(strings.Fields(rel.Title), " ")
+			if title == "" && pid.Identifier == "" {
+				continue
+			}
+			key := relation_node_key(pid.IdentifierScheme, pid.Identifier, title)
+			target, ok := datasets[key]
+			if !ok {
+				target, ok = datasets[relation_node_key("", "", title)]
+			}
+			if !ok {
+				if target, ok = related[key]; !ok {
+					target = fmt.Sprintf("related%d", len(related)+1)
+					related[key] = target
+					label := title
+					if pid.Identifier != "" {
+						label = strings.TrimSpace(label + "\n" + pid_link(pid.IdentifierScheme, pid.Identifier))
+					}
+					nodes = append(nodes, relation_node{ID: target, Label: label, Related: true})
+				}
+			}
+			edge := relation_edge{from, target, datacite_relation_type(rel.RelationType)}
+			if target == from || seen[edge] {
+				continue
+			}
+			seen[edge] = true
+			edges = append(edges, edge)
+		}
+	}
+	return nodes, edges
+}
+
+// the DOIs the metadata of the datasets holds themselves, in a related datapackage they IsIdenticalTo or a link
+func metadata_dois(docs []Yoda18Metadata) []string {
+	dois := make([]string, len(docs))
+	for i, doc := range docs {
+		dois[i] = citation_doi(doc, System{})
+	}
+	return dois
+}
+
+// RenderDOT returns a digraph with a node per dataset, coloured by its Data_Access_Restriction, and an edge,
+// labelled with the Relation_Type, to each of its related datapackages; related datapackages that are not one
+// of the datasets are dashed; a dataset is keyed by the DOI in its metadata
+func RenderDOT(docs []Yoda18Metadata) ([]byte, error) {
+	return RenderDOTWithDOIs(docs, metadata_dois(docs))
+}
+
+// RenderDOTWithDOIs is RenderDOT with the DOI of each dataset given, such as the one of the System block of a
+// vault export; dois may be shorter than docs
+func RenderDOTWithDOIs(docs []Yoda18Metadata, dois []string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("digraph related_datapackages {\n")
+	buf.WriteString("  rankdir=LR;\n")
+	buf.WriteString("  node [shape=box, style=filled];\n")
+
+	nodes, edges := relation_graph(docs, dois)
+	for _, n := range nodes {
+		if n.Related {
+			fmt.Fprintf(&buf, "  %s [label=%s, style=\"dashed\"];\n", n.ID, dot_quote(n.Label))
+		} else {
+			fmt.Fprintf(&buf, "  %s [label=%s, fillcolor=%s];\n", n.ID, dot_quote(n.Label), dot_quote(dot_access_colours[access_class(n.Restriction)]))
+		}
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&buf, "  %s -> %s [label=%s];\n", e.From, e.To, dot_quote(e.Relation))
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// write the graph of a single dataset, read from source
+func exportDOT(doc Yoda18Metadata, source string, w io.Writer) error {
+	out, err := RenderDOTWithDOIs([]Yoda18Metadata{doc}, []string{dataset_doi(doc, source)})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// write one diagram of all input files so the relations between them show, files that cannot be read are left out
+func write_combined_diagram(ctx context.Context, fnames []string, format string, outname string, workers int) error {
+	ext, _, err := output_renderer(format, "", "")
+	if err != nil {
+		return err
+	}
+	render := RenderDOTWithDOIs
+	if format == "mermaid" {
+		render = RenderMermaidWithDOIs
+	}
+	if outname == "" {
+		outname = filepath.Join("output", diagram_default_stem+ext)
+		slog.Info("output filename not provided, using default", "file", outname)
+	}
+
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+	var docs []Yoda18Metadata
+	var dois []string
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("cannot read metadata, skipped", "file", res.File, "error", res.Err)
+			continue
+		}
+		docs = append(docs, res.Data)
+		dois = append(dois, dataset_doi(res.Data, res.File))
+	}
+	out, err := render(docs, dois)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(outname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(outname, out, 0644)
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", outname, "format", format, "datasets", len(docs))
+	return nil
+}
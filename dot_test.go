@@ -0,0 +1,216 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestRelationNodeKey(t *testing.T) {
+	same := []string{
+		relation_node_key("DOI", "10.1234/ABC", ""),
+		relation_node_key("DOI", "doi:10.1234/abc", "A title"),
+		relation_node_key("DOI", "https://doi.org/10.1234/Abc", ""),
+	}
+	for _, key := range same[1:] {
+		if key != same[0] {
+			t.Errorf("DOI key %q, want %q", key, same[0])
+		}
+	}
+	if got := relation_node_key("", "", "  A   Title "); got != relation_node_key("", "", "a title") {
+		t.Errorf("title key %q does not match the same title in other case and spacing", got)
+	}
+	if relation_node_key("DOI", "10.1234/abc", "A title") == relation_node_key("", "", "A title") {
+		t.Error("an identifier and a title give the same key")
+	}
+}
+
+// two datasets in the input relate to each other, one by DOI and one by title only, with a duplicate relation,
+// a relation to itself and one to an external DOI
+func relation_test_docs(t *testing.T) []Yoda18Metadata {
+	first := parse_test_metadata(t, `{"Title": "First dataset", "Data_Access_Restriction": "Open - freely retrievable",
+		"Related_Datapackage": [
+			{"Title": "Second dataset", "Relation_Type": "References: Current datapackage is referencing", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "doi:10.1234/SECOND"}},
+			{"Title": "Second dataset", "Relation_Type": "References: Current datapackage is referencing", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "https://doi.org/10.1234/second"}},
+			{"Title": "First dataset", "Relation_Type": "IsVersionOf: Current datapackage is a version of"},
+			{"Title": "External", "Relation_Type": "Cites: Current datapackage cites", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.9999/external"}}
+		]}`)
+	second := parse_test_metadata(t, `{"Title": "Second dataset", "Data_Access_Restriction": "Closed",
+		"Related_Datapackage": [
+			{"Title": "First dataset", "Relation_Type": "IsReferencedBy: Current datapackage is referenced by"}
+		]}`)
+	return []Yoda18Metadata{first, second}
+}
+
+func TestRelationGraph(t *testing.T) {
+	nodes, edges := relation_graph(relation_test_docs(t), []string{"", "10.1234/second"})
+	if len(nodes) != 3 {
+		t.Fatalf("%d nodes, want the two datasets and the external one: %+v", len(nodes), nodes)
+	}
+	if !nodes[2].Related || !strings.Contains(nodes[2].Label, "https://doi.org/10.9999/external") {
+		t.Errorf("external node %+v", nodes[2])
+	}
+	want := []relation_edge{
+		{"dataset1", "dataset2", "References"},
+		{"dataset1", "related1", "Cites"},
+		{"dataset2", "dataset1", "IsReferencedBy"},
+	}
+	if len(edges) != len(want) {
+		t.Fatalf("edges %+v, want %+v", edges, want)
+	}
+	for i := range want {
+		if edges[i] != want[i] {
+			t.Errorf("edge %d = %+v, want %+v", i, edges[i], want[i])
+		}
+	}
+}
+
+func TestRenderDOTKeysByMetadataDOI(t *testing.T) {
+	docs := relation_test_docs(t)
+	// without a DOI the second dataset is not found by the DOI of the first relation, only by title
+	out, err := RenderDOT(docs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(out), "dataset1 -> dataset2") {
+		t.Errorf("no edge between the datasets:\n%s", out)
+	}
+	with, err := RenderDOTWithDOIs(docs, []string{"", "10.1234/second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(with), "dataset1 -> dataset2") {
+		t.Errorf("no edge between the datasets with the DOI given:\n%s", with)
+	}
+}
+
+// split DOT source into its tokens: IDs, quoted strings with their escapes undone, "->" and the punctuation
+func dot_test_tokens(t *testing.T, src string) []string {
+	var tokens []string
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"':
+			var sb strings.Builder
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					if runes[i] == 'n' {
+						sb.WriteRune('\n')
+						continue
+					}
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				t.Fatalf("unterminated string in %s", src)
+			}
+			tokens = append(tokens, "\""+sb.String())
+			i++
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '>':
+			tokens = append(tokens, "->")
+			i += 2
+		case strings.ContainsRune("{}[];,=", r):
+			tokens = append(tokens, string(r))
+			i++
+		case r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			t.Fatalf("unexpected %q in %s", r, src)
+		}
+	}
+	return tokens
+}
+
+// a parsed DOT statement: a node or an edge with its attributes, quoted values without their leading quote
+type dot_test_statement struct {
+	From, To string
+	Attrs    map[string]string
+}
+
+// parse the statements of a digraph as RenderDOT writes them, graph and node defaults left out
+func dot_test_parse(t *testing.T, src string) []dot_test_statement {
+	tokens := dot_test_tokens(t, src)
+	if len(tokens) < 3 || tokens[0] != "digraph" || tokens[2] != "{" || tokens[len(tokens)-1] != "}" {
+		t.Fatalf("not a digraph: %v", tokens)
+	}
+	var stmts []dot_test_statement
+	tokens = tokens[3 : len(tokens)-1]
+	for len(tokens) > 0 {
+		end := 0
+		for end < len(tokens) && tokens[end] != ";" {
+			end++
+		}
+		if end == len(tokens) {
+			t.Fatalf("statement without ; %v", tokens)
+		}
+		stmt, rest := tokens[:end], tokens[end+1:]
+		tokens = rest
+		if len(stmt) == 3 && stmt[1] == "=" || stmt[0] == "node" {
+			continue
+		}
+		var s dot_test_statement
+		s.From, stmt = stmt[0], stmt[1:]
+		if len(stmt) > 1 && stmt[0] == "->" {
+			s.To, stmt = stmt[1], stmt[2:]
+		}
+		if len(stmt) < 2 || stmt[0] != "[" || stmt[len(stmt)-1] != "]" {
+			t.Fatalf("statement %s without attribute list: %v", s.From, stmt)
+		}
+		s.Attrs = map[string]string{}
+		for _, attr := range strings.Split(strings.Join(stmt[1:len(stmt)-1], "\x00"), "\x00,\x00") {
+			key, value, ok := strings.Cut(attr, "\x00=\x00")
+			if !ok {
+				t.Fatalf("attribute %q of %s", attr, s.From)
+			}
+			s.Attrs[key] = strings.TrimPrefix(value, "\"")
+		}
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+func TestRenderDOTParses(t *testing.T) {
+	docs := relation_test_docs(t)
+	docs[1].Title = `Second "quoted" dataset\with a backslash`
+	out, err := RenderDOTWithDOIs(docs, []string{"", "10.1234/second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	nodes := map[string]map[string]string{}
+	var edges []dot_test_statement
+	for _, s := range dot_test_parse(t, string(out)) {
+		if s.To == "" {
+			nodes[s.From] = s.Attrs
+		} else {
+			edges = append(edges, s)
+		}
+	}
+	if len(nodes) != 3 || len(edges) != 3 {
+		t.Fatalf("%d nodes and %d edges, want 3 and 3:\n%s", len(nodes), len(edges), out)
+	}
+	if got := nodes["dataset2"]["label"]; got != docs[1].Title {
+		t.Errorf("label %q, want the title %q", got, docs[1].Title)
+	}
+	if nodes["dataset1"]["fillcolor"] != "palegreen" || nodes["dataset2"]["fillcolor"] != "lightcoral" {
+		t.Errorf("fill colours %v and %v", nodes["dataset1"], nodes["dataset2"])
+	}
+	if nodes["related1"]["style"] != "dashed" {
+		t.Errorf("related node %v is not dashed", nodes["related1"])
+	}
+	for _, e := range edges {
+		if nodes[e.From] == nil || nodes[e.To] == nil {
+			t.Errorf("edge %s -> %s to an undeclared node", e.From, e.To)
+		}
+		if e.Attrs["label"] == "" {
+			t.Errorf("edge %s -> %s without label", e.From, e.To)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+/*
+email.go the -format email output: a plain text message body for the confirmation e-mail to the researchers after
+a vault ingest, with the key metadata and the DOI link, wrapped at 72 columns so it can be piped into sendmail.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// the line width of the message body
+const email_width int = 72
+
+// the text between the salutation and the metadata
+const email_intro string = "Your data package has been stored in the vault. Below you find the key metadata as it was " +
+	"archived, please check it and let us know if anything needs to be corrected."
+
+// the -email-template, nil for the built-in message
+var email_template *template.Template
+
+// EmailMessage is what an -email-template is executed with: the whole metadata (.Title, .Creator, ...) and the
+// parts of the built-in message
+type EmailMessage struct {
+	Yoda18Metadata
+	Salutation string
+	Link       string
+	Fields     []EmailField
+}
+
+// EmailField is one "Label: value" line of the message
+type EmailField struct {
+	Label string
+	Value string
+}
+
+// word wrap s to lines of at most width columns, existing line breaks are kept; unlike text_wrap a word longer
+// than the line, such as a URL, is not cut
+func email_wrap(s string, width int) string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case runewidth.StringWidth(line)+1+runewidth.StringWidth(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// the salutation, the -salutation greeting and the given name of the first creator, e.g. "Dear Jan,"; the full
+// name when there is no given name
+func email_salutation(doc Yoda18Metadata, greeting string) string {
+	name := ""
+	if len(doc.Creator) > 0 {
+		name = strings.TrimSpace(doc.Creator[0].Name.GivenName)
+		if name == "" {
+			name = formatName(doc.Creator[0].Name, "full")
+		}
+	}
+	if name == "" {
+		name = "researcher"
+	}
+	return strings.TrimSpace(greeting+" "+name) + ","
+}
+
+// the message of the metadata read from source: the salutation, the get_basic_data fields and the DOI link,
+// or the landing page when there is no DOI
+func email_message(doc Yoda18Metadata, source string) EmailMessage {
+	msg := EmailMessage{Yoda18Metadata: doc, Salutation: email_salutation(doc, *email_greeting)}
+	for _, line := range get_basic_data(doc) {
+		label, value, _ := strings.Cut(line, ": ")
+		msg.Fields = append(msg.Fields, EmailField{label, value})
+	}
+	sys := oai_system(doc, source)
+	doi := doi_from_string(*qr_doi)
+	if doi == "" {
+		doi = citation_doi(doc, sys)
+	}
+	switch {
+	case doi != "":
+		msg.Link = pid_link("DOI", doi)
+	case sys.OpenAccessLink != "":
+		msg.Link = sys.OpenAccessLink
+	}
+	return msg
+}
+
+// the built-in message body, every paragraph and field wrapped to email_width
+func email_body(msg EmailMessage) string {
+	var sb strings.Builder
+	sb.WriteString(msg.Salutation + "\n\n")
+	sb.WriteString(email_wrap(email_intro, email_width) + "\n\n")
+	for _, field := range msg.Fields {
+		// continuation lines of a long value are indented
+		value := email_wrap(field.Label+": "+field.Value, email_width-2)
+		sb.WriteString(strings.ReplaceAll(value, "\n", "\n  ") + "\n")
+	}
+	if msg.Link != "" {
+		sb.WriteString("\n" + email_wrap("The data package can be found at "+msg.Link, email_width) + "\n")
+	}
+	return sb.String()
+}
+
+// wrap a text to width columns in a template, e.g. {{wrap 72 .Description}}
+func template_wrap(width int, s string) string {
+	return email_wrap(s, width)
+}
+
+// parse the -email-template file with the -template functions
+func load_email_template(fname string) (*template.Template, error) {
+	t, err := template.New(filepath.Base(fname)).Funcs(template_funcs).Option("missingkey=error").ParseFiles(fname)
+	if err != nil {
+		return nil, fmt.Errorf("cannot use e-mail template: %w", err)
+	}
+	return t, nil
+}
+
+// exportEmail writes the message body for the metadata read from source to w, with the -email-template when
+// given; nothing is written when the template fails
+func exportEmail(doc Yoda18Metadata, source string, w io.Writer) error {
+	msg := email_message(doc, source)
+	if email_template == nil {
+		_, err := io.WriteString(w, email_body(msg))
+		return err
+	}
+	var buf bytes.Buffer
+	err := email_template.Execute(&buf, msg)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,335 @@
+/*
+eml.go exports Yoda metadata as an Ecological Metadata Language (EML 2.2) document, the fields EML has no
+place for are kept in additionalInfo.
+*/
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	eml_namespace       string = "https://eml.ecoinformatics.org/eml-2.2.0"
+	eml_schema_location string = "https://eml.ecoinformatics.org/eml-2.2.0 https://eml.ecoinformatics.org/eml-2.2.0/eml.xsd"
+)
+
+// EML text with one para per paragraph
+type EMLText struct {
+	Para []string `xml:"para"`
+}
+
+type EMLIndividualName struct {
+	GivenName string `xml:"givenName,omitempty"`
+	SurName   string `xml:"surName"`
+}
+
+type EMLUserID struct {
+	Directory string `xml:"directory,attr"`
+	Value     string `xml:",chardata"`
+}
+
+// an EML ResponsibleParty, Role is only used by associatedParty and personnel
+type EMLParty struct {
+	IndividualName   *EMLIndividualName `xml:"individualName"`
+	OrganizationName string             `xml:"organizationName,omitempty"`
+	UserID           *EMLUserID         `xml:"userId"`
+	Role             string             `xml:"role,omitempty"`
+}
+
+type EMLLicensed struct {
+	LicenseName string `xml:"licenseName"`
+	URL         string `xml:"url,omitempty"`
+	Identifier  string `xml:"identifier,omitempty"`
+}
+
+type EMLDate struct {
+	CalendarDate string `xml:"calendarDate"`
+}
+
+type EMLTemporalCoverage struct {
+	SingleDateTime *EMLDate `xml:"singleDateTime"`
+	RangeOfDates   *struct {
+		BeginDate EMLDate `xml:"beginDate"`
+		EndDate   EMLDate `xml:"endDate"`
+	} `xml:"rangeOfDates"`
+}
+
+// EML requires bounding coordinates, Yoda only has place names so these span the whole world
+type EMLBoundingCoordinates struct {
+	West  string `xml:"westBoundingCoordinate"`
+	East  string `xml:"eastBoundingCoordinate"`
+	North string `xml:"northBoundingCoordinate"`
+	South string `xml:"southBoundingCoordinate"`
+}
+
+type EMLGeographicCoverage struct {
+	GeographicDescription string                 `xml:"geographicDescription"`
+	BoundingCoordinates   EMLBoundingCoordinates `xml:"boundingCoordinates"`
+}
+
+type EMLCoverage struct {
+	GeographicCoverage []EMLGeographicCoverage `xml:"geographicCoverage"`
+	TemporalCoverage   *EMLTemporalCoverage    `xml:"temporalCoverage"`
+}
+
+type EMLProject struct {
+	Title     string     `xml:"title"`
+	Personnel []EMLParty `xml:"personnel"`
+	Funding   *EMLText   `xml:"funding"`
+}
+
+// the EML dataset, the element order is fixed by the schema
+type EMLDataset struct {
+	Title           string     `xml:"title"`
+	Creator         []EMLParty `xml:"creator"`
+	AssociatedParty []EMLParty `xml:"associatedParty"`
+	Language        string     `xml:"language,omitempty"`
+	Abstract        *EMLText   `xml:"abstract"`
+	KeywordSet      *struct {
+		Keyword []string `xml:"keyword"`
+	} `xml:"keywordSet"`
+	AdditionalInfo     *EMLText     `xml:"additionalInfo"`
+	IntellectualRights *EMLText     `xml:"intellectualRights"`
+	Licensed           *EMLLicensed `xml:"licensed"`
+	Coverage           *EMLCoverage `xml:"coverage"`
+	Contact            []EMLParty   `xml:"contact"`
+	Project            *EMLProject  `xml:"project"`
+}
+
+// the EML document
+type EML struct {
+	XMLName        xml.Name   `xml:"eml:eml"`
+	XmlnsEML       string     `xml:"xmlns:eml,attr"`
+	XmlnsXsi       string     `xml:"xmlns:xsi,attr"`
+	SchemaLocation string     `xml:"xsi:schemaLocation,attr"`
+	PackageID      string     `xml:"packageId,attr"`
+	System         string     `xml:"system,attr"`
+	Dataset        EMLDataset `xml:"dataset"`
+}
+
+// the paragraphs of a text, nil when it is empty
+func eml_text(s string) *EMLText {
+	var paras []string
+	for _, para := range paragraph_break.Split(strings.TrimSpace(s), -1) {
+		if para = strings.TrimSpace(para); para != "" {
+			paras = append(paras, para)
+		}
+	}
+	if len(paras) == 0 {
+		return nil
+	}
+	return &EMLText{Para: paras}
+}
+
+// a person, the surname is required so a name without family name is used as a whole, a person without name is
+// only the organization
+func eml_party(name NameStruct, affiliations []string, scheme_ids [][2]string, role string) EMLParty {
+	p := EMLParty{IndividualName: &EMLIndividualName{GivenName: strings.TrimSpace(name.GivenName), SurName: strings.TrimSpace(name.FamilyName)}, Role: role}
+	if p.IndividualName.SurName == "" {
+		p.IndividualName = &EMLIndividualName{SurName: strings.TrimSpace(formatName(name, "full"))}
+	}
+	if p.IndividualName.SurName == "" {
+		p.IndividualName = nil
+	}
+	for _, aff := range affiliations {
+		if aff != "" {
+			p.OrganizationName = aff
+			break
+		}
+	}
+	if orcid := xlsx_orcid(scheme_ids); orcid != "" {
+		p.UserID = &EMLUserID{Directory: "https://orcid.org", Value: orcid_url(orcid)}
+	}
+	return p
+}
+
+// the fields EML has no element for, as "label: value" paragraphs
+func eml_additional_info(doc Yoda18Metadata) *EMLText {
+	var paras []string
+	add := func(label string, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			paras = append(paras, label+": "+value)
+		}
+	}
+	add("Version", doc.Version)
+	add("Discipline", strings.Join(doc.Discipline, "; "))
+	add("Data type", doc.DataType)
+	add("Data classification", doc.DataClassification)
+	add("Data access restriction", doc.DataAccessRestriction)
+	if doc.Collected.StartDate != "" || doc.Collected.EndDate != "" {
+		add("Collected", doc.Collected.StartDate+" to "+doc.Collected.EndDate)
+	}
+	if doc.RetentionPeriod != 0 {
+		add("Retention period", fmt.Sprintf("%d years", doc.RetentionPeriod))
+	}
+	add("Retention information", doc.RetentionInformation)
+	add("Embargo end date", doc.EmbargoEndDate)
+	add("Collection name", doc.CollectionName)
+	for _, rel := range doc.RelatedDatapackage {
+		pid := rel.PersistentIdentifier
+		add("Related datapackage", strings.TrimSpace(datacite_relation_type(rel.RelationType)+" "+rel.Title+" "+pid_link(pid.IdentifierScheme, pid.Identifier)))
+	}
+	add("Remarks", doc.Remarks)
+	if len(paras) == 0 {
+		return nil
+	}
+	return &EMLText{Para: paras}
+}
+
+// map the metadata to an EML document, sys gives the DOI used as packageId
+func eml_document(doc Yoda18Metadata, sys System) (EML, error) {
+	e := EML{
+		XmlnsEML:       eml_namespace,
+		XmlnsXsi:       xsi_namespace,
+		SchemaLocation: eml_schema_location,
+		System:         "https://doi.org",
+	}
+	if pid := sys.PersistentIdentifierDatapackage; strings.EqualFold(pid.IdentifierScheme, "DOI") && pid.Identifier != "" {
+		e.PackageID = pid.Identifier
+	} else {
+		// the same identifier as the OAI record when there is no DOI
+		id, err := oai_identifier(doc, sys, "")
+		if err != nil {
+			return e, err
+		}
+		e.PackageID, e.System = id, "readYmeta"
+	}
+
+	d := &e.Dataset
+	d.Title = doc.Title
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		d.Creator = append(d.Creator, eml_party(cre.Name, cre.Affiliation, ids, ""))
+	}
+	var contacts []EMLParty
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		d.AssociatedParty = append(d.AssociatedParty, eml_party(con.Name, con.Affiliation, ids, contributor_group(con.ContributorType)))
+		if con.ContributorType == "ContactPerson" {
+			contacts = append(contacts, eml_party(con.Name, con.Affiliation, ids, ""))
+		}
+	}
+	// a contact is required, the creators are contacted when no contact person is listed
+	d.Contact = contacts
+	if len(d.Contact) == 0 {
+		d.Contact = d.Creator
+	}
+
+	d.Language = language_code(doc.Language)
+	d.Abstract = eml_text(doc.Description)
+	var keywords []string
+	for _, tag := range doc.Tag {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			keywords = append(keywords, tag)
+		}
+	}
+	if len(keywords) > 0 {
+		d.KeywordSet = &struct {
+			Keyword []string `xml:"keyword"`
+		}{keywords}
+	}
+	d.AdditionalInfo = eml_additional_info(doc)
+
+	if doc.License != "" {
+		rights := canonical_license(doc.License)
+		if url := license_url(doc.License); url != "" {
+			rights += " (" + url + ")"
+		}
+		d.IntellectualRights = &EMLText{Para: []string{rights}}
+		if spdx, err := NormalizeLicense(doc.License); err == nil {
+			d.Licensed = &EMLLicensed{LicenseName: canonical_license(doc.License), URL: license_url(doc.License), Identifier: spdx}
+		}
+	}
+
+	var coverage EMLCoverage
+	for _, place := range doc.CoveredGeolocationPlace {
+		if place = strings.TrimSpace(place); place != "" {
+			coverage.GeographicCoverage = append(coverage.GeographicCoverage, EMLGeographicCoverage{
+				GeographicDescription: place,
+				BoundingCoordinates:   EMLBoundingCoordinates{West: "-180", East: "180", North: "90", South: "-90"},
+			})
+		}
+	}
+	start, end := doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate
+	switch {
+	case start != "" && end != "":
+		coverage.TemporalCoverage = &EMLTemporalCoverage{RangeOfDates: &struct {
+			BeginDate EMLDate `xml:"beginDate"`
+			EndDate   EMLDate `xml:"endDate"`
+		}{EMLDate{start}, EMLDate{end}}}
+	case start != "" || end != "":
+		coverage.TemporalCoverage = &EMLTemporalCoverage{SingleDateTime: &EMLDate{start + end}}
+	}
+	if len(coverage.GeographicCoverage) > 0 || coverage.TemporalCoverage != nil {
+		d.Coverage = &coverage
+	}
+
+	var funding []string
+	for _, fund := range doc.FundingReference {
+		switch {
+		case fund.FunderName != "" && fund.AwardNumber != "":
+			funding = append(funding, fmt.Sprintf("%s (%s)", fund.FunderName, fund.AwardNumber))
+		case fund.FunderName != "":
+			funding = append(funding, fund.FunderName)
+		}
+	}
+	if len(funding) > 0 && len(d.Creator) > 0 {
+		// a project needs personnel, the creators are the project personnel
+		project := EMLProject{Title: doc.Title, Funding: &EMLText{Para: funding}}
+		for _, p := range d.Creator {
+			p.Role = "creator"
+			project.Personnel = append(project.Personnel, p)
+		}
+		d.Project = &project
+	}
+	return e, nil
+}
+
+// check the title, creator and contact EML requires, a party needs a name or organization
+func validate_eml(e EML) error {
+	var problems []string
+	d := e.Dataset
+	if strings.TrimSpace(d.Title) == "" {
+		problems = append(problems, "title is required")
+	}
+	if len(d.Creator) == 0 {
+		problems = append(problems, "a creator is required")
+	}
+	check := func(kind string, parties []EMLParty) {
+		for i, p := range parties {
+			if p.IndividualName == nil && p.OrganizationName == "" {
+				problems = append(problems, fmt.Sprintf("%s[%d] has no name and no affiliation", kind, i))
+			}
+		}
+	}
+	check("creator", d.Creator)
+	check("associatedParty", d.AssociatedParty)
+
+	if len(problems) > 0 {
+		return &invalid_output_error{"EML", problems}
+	}
+	return nil
+}
+
+// exportEML writes the metadata read from source as an EML 2.2 document to w, metadata missing what EML requires
+// gives an *invalid_output_error and nothing is written
+func exportEML(doc Yoda18Metadata, source string, w io.Writer) error {
+	e, err := eml_document(doc, oai_system(doc, source))
+	if err != nil {
+		return err
+	}
+	if err := validate_eml(e); err != nil {
+		return err
+	}
+	return write_xml_document(w, e)
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"testing"
+)
+
+// the EML document as a repository reads it, only the root is in the EML namespace
+type eml_test_document struct {
+	XMLName   xml.Name `xml:"https://eml.ecoinformatics.org/eml-2.2.0 eml"`
+	PackageID string   `xml:"packageId,attr"`
+	System    string   `xml:"system,attr"`
+	Dataset   struct {
+		Title    string `xml:"title"`
+		Creators []struct {
+			SurName string `xml:"individualName>surName"`
+		} `xml:"creator"`
+	} `xml:"dataset"`
+}
+
+func TestExportEML(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	var buf bytes.Buffer
+	if err := exportEML(doc, "", &buf); err != nil {
+		t.Fatal(err)
+	}
+	check_xml_namespaces(t, buf.Bytes(), eml_namespace, "")
+	check_test_xsd(t, "eml-2.2.0.xsd", buf.Bytes())
+
+	var e eml_test_document
+	if err := xml.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.PackageID == "" || e.System == "" {
+		t.Errorf("packageId %q and system %q are required", e.PackageID, e.System)
+	}
+	if e.Dataset.Title != doc.Title || len(e.Dataset.Creators) != 1 || e.Dataset.Creators[0].SurName != "Molenaar" {
+		t.Errorf("dataset %+v", e.Dataset)
+	}
+}
+
+func TestExportEMLSchema(t *testing.T) {
+	for _, name := range []string{"yoda-metadata.json", "yoda-metadata[uu011].json", "yoda-metadata[uu012].json", "yoda-metadata[test].json"} {
+		var buf bytes.Buffer
+		if err := exportEML(load_test_metadata(t, name), "", &buf); err != nil {
+			t.Fatal(err)
+		}
+		for _, problem := range test_xsd_problems(t, "eml-2.2.0.xsd", buf.Bytes()) {
+			t.Errorf("%s: %s", name, problem)
+		}
+	}
+
+	// a creator known by the affiliation only is an organization
+	var buf bytes.Buffer
+	doc := parse_test_metadata(t, `{"Title": "Organization", "Creator": [{"Affiliation": ["Vrije Universiteit"]}]}`)
+	if err := exportEML(doc, "", &buf); err != nil {
+		t.Fatal(err)
+	}
+	check_test_xsd(t, "eml-2.2.0.xsd", buf.Bytes())
+
+	// the blank metadata has no title, writing it would give an EML document the schema rejects
+	var invalid *invalid_output_error
+	buf.Reset()
+	if err := exportEML(load_test_metadata(t, "yoda-metadata[blank].json"), "", &buf); !errors.As(err, &invalid) || buf.Len() > 0 {
+		t.Errorf("blank metadata gives %v, want an invalid output error and nothing written", err)
+	}
+
+	// the schema rejects what a repository would
+	buf.Reset()
+	if err := exportEML(load_test_metadata(t, "yoda-metadata[test].json"), "", &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, edit := range [][2]string{
+		{`</title>`, `</title><shortName>x</shortName>`},
+		{`<givenName>`, `<surName>x</surName><givenName>`},
+		{`<geographicDescription>type_string<`, `<geographicDescription> <`},
+		{`<westBoundingCoordinate>-180<`, `<westBoundingCoordinate>-181<`},
+		{`<calendarDate>2022-08-02<`, `<calendarDate>2022-13-02<`},
+		{`</associatedParty>`, `<role>twice</role></associatedParty>`},
+		{`</creator>`, `<role>creator</role></creator>`},
+		{` system="readYmeta"`, ``},
+	} {
+		raw := bytes.Replace(buf.Bytes(), []byte(edit[0]), []byte(edit[1]), 1)
+		if bytes.Equal(raw, buf.Bytes()) {
+			t.Fatalf("%s is not in the EML", edit[0])
+		}
+		if len(test_xsd_problems(t, "eml-2.2.0.xsd", raw)) == 0 {
+			t.Errorf("the document with %s is valid, want a schema error", edit[1])
+		}
+	}
+}
+
+func TestEMLDocument(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	var sys System
+	sys.PersistentIdentifierDatapackage.IdentifierScheme = "DOI"
+	sys.PersistentIdentifierDatapackage.Identifier = "10.1234/abc"
+	e, err := eml_document(doc, sys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.PackageID != "10.1234/abc" || e.System != "https://doi.org" {
+		t.Errorf("packageId %q in system %q, want the DOI", e.PackageID, e.System)
+	}
+	d := e.Dataset
+	if len(d.Creator) != len(doc.Creator) || len(d.AssociatedParty) != len(doc.Contributor) {
+		t.Errorf("%d creators and %d associated parties", len(d.Creator), len(d.AssociatedParty))
+	}
+	for _, p := range append(d.Creator, d.AssociatedParty...) {
+		if p.IndividualName.SurName == "" {
+			t.Errorf("party %+v without the required surName", p)
+		}
+	}
+	// no ContactPerson among the contributors, so the creators are the contacts
+	if len(d.Contact) != len(d.Creator) {
+		t.Errorf("contacts %+v, want the creators", d.Contact)
+	}
+	if d.Licensed == nil || d.Licensed.Identifier != "CC-BY-4.0" {
+		t.Errorf("licensed %+v", d.Licensed)
+	}
+
+	noid, err := eml_document(parse_test_metadata(t, `{"Title": "No DOI", "Creator": [{"Name": {"Given_Name": "Plato"}}]}`), System{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if noid.System != "readYmeta" || noid.PackageID == "" {
+		t.Errorf("packageId %q in system %q without DOI", noid.PackageID, noid.System)
+	}
+	if name := noid.Dataset.Creator[0].IndividualName; name.SurName != "Plato" || name.GivenName != "" {
+		t.Errorf("a name without family name gives %+v, want it as surName", name)
+	}
+}
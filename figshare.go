@@ -0,0 +1,170 @@
+/*
+figshare.go exports Yoda metadata as the JSON body of the Figshare article creation API, with the categories
+and licence IDs of the Figshare instance taken from a mapping file.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Figshare author, either a name or an ORCID is enough for Figshare
+type FigshareAuthor struct {
+	Name    string `json:"name"`
+	ORCIDID string `json:"orcid_id,omitempty"`
+}
+
+// Figshare funding entry, only the title is used as Yoda does not hold Figshare funder IDs
+type FigshareFunding struct {
+	Title string `json:"title"`
+}
+
+// the article creation request body
+type FigshareArticle struct {
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	DefinedType string            `json:"defined_type"`
+	Authors     []FigshareAuthor  `json:"authors"`
+	Categories  []int             `json:"categories,omitempty"`
+	Tags        []string          `json:"tags,omitempty"`
+	License     int               `json:"license,omitempty"`
+	FundingList []FigshareFunding `json:"funding_list,omitempty"`
+}
+
+// the -category-map file: the Figshare category ID of each Discipline, by the full Discipline value or by its
+// OECD code as in "1.3", and optionally the Figshare licence IDs by SPDX identifier; JSON or YAML
+type FigshareMapping struct {
+	Categories map[string]int `json:"categories" yaml:"categories"`
+	Licenses   map[string]int `json:"licenses" yaml:"licenses"`
+}
+
+// the licence IDs of figshare.com, used when the mapping file has no licenses section
+var figshare_default_licenses = map[string]int{
+	"CC-BY-4.0": 1,
+	"CC0-1.0":   2,
+	"MIT":       3,
+}
+
+// the OECD field code at the end of a Yoda Discipline, as in "Natural Sciences - Physical sciences (1.3)"
+var discipline_code = regexp.MustCompile(`\(([0-9.]+)\)\s*$`)
+
+// read a -category-map file, YAML is a superset of JSON so both are read by the YAML parser
+func load_figshare_mapping(fname string) (FigshareMapping, error) {
+	var mapping FigshareMapping
+	if fname == "" {
+		return mapping, nil
+	}
+	raw, err := os.ReadFile(fname)
+	if err != nil {
+		return mapping, err
+	}
+	err = yaml.Unmarshal(raw, &mapping)
+	if err != nil {
+		return mapping, fmt.Errorf("%s: %w", fname, err)
+	}
+	return mapping, nil
+}
+
+// the Figshare category of a Discipline, the full value is tried before its OECD code
+func figshare_category(mapping FigshareMapping, discipline string) (int, bool) {
+	if id, ok := mapping.Categories[strings.TrimSpace(discipline)]; ok {
+		return id, true
+	}
+	if m := discipline_code.FindStringSubmatch(discipline); m != nil {
+		id, ok := mapping.Categories[m[1]]
+		return id, ok
+	}
+	return 0, false
+}
+
+// the Figshare licence ID, 0 if the licence is not recognised or has no ID in the mapping
+func figshare_license(mapping FigshareMapping, license string) int {
+	id, err := NormalizeLicense(license)
+	if err != nil {
+		slog.Warn("licence left out of the Figshare article, it is not recognised", "license", license)
+		return 0
+	}
+	licenses := mapping.Licenses
+	if licenses == nil {
+		licenses = figshare_default_licenses
+	}
+	if n, ok := licenses[id]; ok {
+		return n
+	}
+	slog.Warn("licence left out of the Figshare article, it has no Figshare licence ID", "license", id)
+	return 0
+}
+
+// map the metadata to a Figshare article, disciplines without a category in the mapping are reported
+func figshare_article(doc Yoda18Metadata, mapping FigshareMapping) FigshareArticle {
+	article := FigshareArticle{
+		Title:       doc.Title,
+		Description: doc.Description,
+		DefinedType: "dataset",
+		Authors:     []FigshareAuthor{},
+		License:     figshare_license(mapping, doc.License),
+	}
+
+	for _, creator := range doc.Creator {
+		author := FigshareAuthor{Name: strings.TrimSpace(creator.Name.GivenName + " " + creator.Name.FamilyName)}
+		for _, pid := range creator.PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+				author.ORCIDID = strings.TrimPrefix(orcid_url(pid.NameIdentifier), "https://orcid.org/")
+				break
+			}
+		}
+		if author.Name != "" || author.ORCIDID != "" {
+			article.Authors = append(article.Authors, author)
+		}
+	}
+
+	for _, discipline := range doc.Discipline {
+		if discipline == "" {
+			continue
+		}
+		id, ok := figshare_category(mapping, discipline)
+		if !ok {
+			slog.Warn("discipline has no Figshare category in the -category-map", "discipline", discipline)
+			continue
+		}
+		article.Categories = append(article.Categories, id)
+	}
+
+	for _, tag := range doc.Tag {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			article.Tags = append(article.Tags, tag)
+		}
+	}
+
+	for _, fund := range doc.FundingReference {
+		title := strings.TrimSpace(fund.FunderName)
+		if fund.AwardNumber != "" {
+			title = strings.TrimSpace(title + " (" + fund.AwardNumber + ")")
+		}
+		if title != "" {
+			article.FundingList = append(article.FundingList, FigshareFunding{Title: title})
+		}
+	}
+
+	return article
+}
+
+// exportFigshare writes the Figshare article creation JSON of the metadata to w, using the -category-map file
+func exportFigshare(doc Yoda18Metadata, category_map string, w io.Writer) error {
+	mapping, err := load_figshare_mapping(category_map)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(figshare_article(doc, mapping))
+}
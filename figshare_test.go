@@ -0,0 +1,104 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFigshareMapping(t *testing.T) {
+	yaml_mapping, err := load_figshare_mapping(filepath.Join("test-data", "figshare-category-map.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if yaml_mapping.Categories["1.3"] != 1 || yaml_mapping.Licenses["CC0-1.0"] != 2 {
+		t.Errorf("YAML mapping read as %+v", yaml_mapping)
+	}
+
+	fname := filepath.Join(t.TempDir(), "map.json")
+	if err := os.WriteFile(fname, []byte(`{"categories": {"1.3": 7}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	json_mapping, err := load_figshare_mapping(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if json_mapping.Categories["1.3"] != 7 || json_mapping.Licenses != nil {
+		t.Errorf("JSON mapping read as %+v", json_mapping)
+	}
+
+	if mapping, err := load_figshare_mapping(""); err != nil || mapping.Categories != nil {
+		t.Errorf("no mapping file gives %+v, %v", mapping, err)
+	}
+	if _, err := load_figshare_mapping(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("a missing mapping file is not an error")
+	}
+	if err := os.WriteFile(fname, []byte(`categories: [1, 2`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := load_figshare_mapping(fname); err == nil || !strings.Contains(err.Error(), fname) {
+		t.Errorf("an invalid mapping file gives %v, want an error naming it", err)
+	}
+}
+
+func TestFigshareCategory(t *testing.T) {
+	mapping, err := load_figshare_mapping(filepath.Join("test-data", "figshare-category-map.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		discipline string
+		id         int
+		ok         bool
+	}{
+		{"Natural Sciences - Physical sciences (1.3)", 1, true},
+		{"Natural Sciences - Biological sciences (1.6) ", 2, true},
+		{"Engineering and Technology - Other engineering and technologies (2.11)", 3, true},
+		{"Humanities - Other humanities (6.5)", 0, false},
+		{"Physics", 0, false},
+	}
+	for _, tt := range tests {
+		if id, ok := figshare_category(mapping, tt.discipline); id != tt.id || ok != tt.ok {
+			t.Errorf("figshare_category(%q) = %d, %t, want %d, %t", tt.discipline, id, ok, tt.id, tt.ok)
+		}
+	}
+}
+
+func TestFigshareArticleFallback(t *testing.T) {
+	log := capture_test_log(t)
+	doc := parse_test_metadata(t, `{
+		"Title": "t",
+		"License": "Creative Commons Attribution 4.0 International Public License",
+		"Discipline": ["Natural Sciences - Physical sciences (1.3)", "Humanities - Other humanities (6.5)"],
+		"Creator": [{"Name": {"Given_Name": "A", "Family_Name": "B"}, "Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "https://orcid.org/0000-0002-1825-0097"}]}]
+	}`)
+
+	// without a mapping file no discipline has a category, the licence uses the figshare.com IDs
+	article := figshare_article(doc, FigshareMapping{})
+	if article.Categories != nil || article.License != 1 {
+		t.Errorf("without mapping: categories %v, licence %d", article.Categories, article.License)
+	}
+	if want := []FigshareAuthor{{"A B", "0000-0002-1825-0097"}}; !reflect.DeepEqual(article.Authors, want) {
+		t.Errorf("authors %v, want %v", article.Authors, want)
+	}
+
+	mapping, err := load_figshare_mapping(filepath.Join("test-data", "figshare-category-map.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Reset()
+	article = figshare_article(doc, mapping)
+	if !reflect.DeepEqual(article.Categories, []int{1}) {
+		t.Errorf("categories %v, want [1]", article.Categories)
+	}
+	if !strings.Contains(log.String(), "Humanities - Other humanities (6.5)") {
+		t.Errorf("the unmapped discipline is not reported: %s", log)
+	}
+
+	mapping.Licenses = map[string]int{"MIT": 3}
+	if article = figshare_article(doc, mapping); article.License != 0 {
+		t.Errorf("licence %d without an ID in the mapping, want it left out", article.License)
+	}
+}
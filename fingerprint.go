@@ -0,0 +1,63 @@
+/*
+fingerprint.go a SHA-256 fingerprint of the metadata content, the same for files that only differ in key order,
+indentation or other formatting.
+*/
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FingerprintMetadata returns the hex SHA-256 of the canonical JSON of doc (keys in the Yoda form order, fixed
+// indentation), the same for documents that only differ in key order or formatting
+func FingerprintMetadata(doc Yoda18Metadata) (string, error) {
+	canonical, err := CanonicalJSON(doc)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// print the fingerprint of each file, for several files followed by the filename as sha256sum does; with a
+// dir each fingerprint is also written to <stem>.sha256 there, in the sha256sum format
+func write_fingerprints(ctx context.Context, w io.Writer, fnames []string, workers int, dir string) error {
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+	if dir != "" {
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			return fmt.Errorf("%s: %w", res.File, res.Err)
+		}
+		fingerprint, err := FingerprintMetadata(res.Data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", res.File, err)
+		}
+		if dir != "" {
+			fname := filepath.Join(dir, output_dir_stem(res.Data, res.File)+".sha256")
+			line := fmt.Sprintf("%s  %s\n", fingerprint, filepath.Base(res.File))
+			if err := os.WriteFile(fname, []byte(line), 0644); err != nil {
+				return err
+			}
+		}
+		if len(fnames) == 1 {
+			fmt.Fprintln(w, fingerprint)
+		} else {
+			fmt.Fprintf(w, "%s  %s\n", fingerprint, res.File)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// raw JSON written again with the keys of every object in reverse order and without whitespace
+func fingerprint_test_reorder(t *testing.T, raw []byte) []byte {
+	t.Helper()
+	var tree interface{}
+	if err := json.Unmarshal(raw, &tree); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	var write func(v interface{})
+	write = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(v))
+			for key := range v {
+				keys = append(keys, key)
+			}
+			sort.Sort(sort.Reverse(sort.StringSlice(keys)))
+			buf.WriteString("{")
+			for i, key := range keys {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				name, _ := json.Marshal(key)
+				buf.Write(name)
+				buf.WriteString(":")
+				write(v[key])
+			}
+			buf.WriteString("}")
+		case []interface{}:
+			buf.WriteString("[")
+			for i, item := range v {
+				if i > 0 {
+					buf.WriteString(",")
+				}
+				write(item)
+			}
+			buf.WriteString("]")
+		default:
+			value, _ := json.Marshal(v)
+			buf.Write(value)
+		}
+	}
+	write(tree)
+	return buf.Bytes()
+}
+
+// the fingerprint of raw JSON metadata
+func fingerprint_test_raw(t *testing.T, raw []byte) string {
+	t.Helper()
+	var doc Yoda18Metadata
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatal(err)
+	}
+	fingerprint, err := FingerprintMetadata(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fingerprint
+}
+
+func TestFingerprintKeyOrder(t *testing.T) {
+	for _, name := range []string{"yoda-metadata[douwe].json", "yoda-metadata[test].json", "yoda-metadata[uu011].json"} {
+		raw, err := os.ReadFile(filepath.Join("test-data", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reordered := fingerprint_test_reorder(t, raw)
+		if bytes.Equal(raw, reordered) {
+			t.Fatalf("%s not reordered", name)
+		}
+		if a, b := fingerprint_test_raw(t, raw), fingerprint_test_raw(t, reordered); a != b {
+			t.Errorf("%s: fingerprint %s after reordering, was %s", name, b, a)
+		}
+	}
+}
+
+func TestFingerprintUnmappedKeyOrder(t *testing.T) {
+	a := fingerprint_test_raw(t, []byte(`{"Title": "T", "Extra_B": {"y": 1, "x": 2}, "Extra_A": [1, 2]}`))
+	b := fingerprint_test_raw(t, []byte(`{"Extra_A": [1,2], "Title": "T", "Extra_B": {"x": 2, "y": 1}}`))
+	if a != b {
+		t.Errorf("unmapped keys in another order give %s and %s", a, b)
+	}
+}
+
+func TestFingerprintContent(t *testing.T) {
+	base := fingerprint_test_raw(t, []byte(`{"Title": "T", "Tag": ["a", "b"]}`))
+	if len(base) != 64 || strings.Trim(base, "0123456789abcdef") != "" {
+		t.Errorf("fingerprint %q is not a hex SHA-256", base)
+	}
+	for _, raw := range []string{`{"Title": "T "}`, `{"Title": "T", "Tag": ["b", "a"]}`, `{"Title": "T", "Tag": ["a"]}`} {
+		if fingerprint_test_raw(t, []byte(raw)) == base {
+			t.Errorf("%s has the fingerprint of other content", raw)
+		}
+	}
+}
+
+func TestWriteFingerprints(t *testing.T) {
+	dir := t.TempDir()
+	fnames := []string{filepath.Join("test-data", "yoda-metadata[douwe].json"), filepath.Join("test-data", "yoda-metadata[test].json")}
+	var buf bytes.Buffer
+	if err := write_fingerprints(context.Background(), &buf, fnames, 2, dir); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "  "+fnames[0]) {
+		t.Errorf("output %q, want a sha256sum line per file", lines)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.sha256"))
+	if len(files) != 2 {
+		t.Errorf("files %v, want a .sha256 file per input", files)
+	}
+	written, _ := os.ReadFile(files[0])
+	fingerprint, _, _ := strings.Cut(string(written), "  ")
+	if !slices.ContainsFunc(lines, func(line string) bool { return strings.HasPrefix(line, fingerprint+"  ") }) {
+		t.Errorf("%s holds %q, not one of the printed fingerprints", files[0], written)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+format.go choosing the output format from -format or the -output extension, and the -h usage text.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// metadata that an output format cannot be written from, such as a CITATION.cff without title; the problems
+// are for the user to fix in the metadata
+type invalid_output_error struct {
+	Output   string
+	Problems []string
+}
+
+func (e *invalid_output_error) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Output, strings.Join(e.Problems, "; "))
+}
+
+// the format used when neither -format nor a recognised -output extension is given
+const default_output_format string = "text"
+
+// the -output extensions that select a format when -format is not given
+var output_extension_formats = map[string]string{
+	".pdf":     "pdf",
+	".csv":     "csv",
+	".xlsx":    "xlsx",
+	".docx":    "docx",
+	".html":    "html",
+	".htm":     "html",
+	".txt":     "text",
+	".tex":     "latex",
+	".ris":     "ris",
+	".cff":     "cff",
+	".jsonld":  "jsonld",
+	".json":    "json",
+	".yaml":    "yaml",
+	".yml":     "yaml",
+	".dot":     "dot",
+	".mmd":     "mermaid",
+	".ttl":     "dcat",
+	".db":      "sqlite",
+	".sqlite":  "sqlite",
+	".ndjson":  "ndjson",
+	".jsonl":   "ndjson",
+	".headers": "signposting",
+	".xmp":     "xmp",
+}
+
+// other names accepted for a format, as its file extension
+var output_format_aliases = map[string]string{
+	"txt":   "text",
+	"excel": "xlsx",
+}
+
+// the output format to use: the -format value if given, otherwise the one matching the -output extension,
+// otherwise the plain text report on the console
+func resolve_output_format(format string, output string) (string, error) {
+	if format == "" {
+		if f, ok := output_extension_formats[strings.ToLower(filepath.Ext(output))]; ok {
+			return f, nil
+		}
+		return default_output_format, nil
+	}
+	if f, ok := output_format_aliases[format]; ok {
+		format = f
+	}
+	// the graph of the related datapackages is written in the -graph-syntax
+	if format == "graph" {
+		if *graph_syntax != "dot" && *graph_syntax != "mermaid" {
+			return "", fmt.Errorf("unknown -graph-syntax %q, use dot or mermaid", *graph_syntax)
+		}
+		format = *graph_syntax
+	}
+	// the PDF report, the SQLite database and the NDJSON stream are not written through a renderer
+	if format == "pdf" || format == "sqlite" || format == "ndjson" {
+		return format, nil
+	}
+	_, _, err := output_renderer(format, "", "")
+	return format, err
+}
+
+// the -h text, the flag list is preceded by how the output format is chosen
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [options] [metadata.json | data package directory | - ...]\n\n", filepath.Base(flag.CommandLine.Name()))
+	fmt.Fprintln(out, "Without -format the report is printed to the console and no files are written.")
+	fmt.Fprintln(out, "A PDF (with a Markdown summary next to it) is only written with -format pdf or an -output ending in .pdf,")
+	fmt.Fprintln(out, "other -output extensions (.csv, .xlsx, .docx, .txt, ...) select their format in the same way.")
+	fmt.Fprintln(out, "Files are written to -output, or to the output directory named after the input file.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Options:")
+	flag.PrintDefaults()
+}
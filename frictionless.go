@@ -0,0 +1,258 @@
+/*
+frictionless.go exports Yoda metadata as a Frictionless Data datapackage.json, the files of a data package
+directory become its resources.
+*/
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Frictionless requires this exact filename so each data package gets its own directory
+const frictionless_metadata_name string = "datapackage.json"
+
+// the Frictionless contributor role of the Contributor_Types that are not plain contributors
+var frictionless_roles = map[string]string{
+	"ContactPerson":      "maintainer",
+	"DataCurator":        "wrangler",
+	"DataManager":        "wrangler",
+	"Distributor":        "publisher",
+	"HostingInstitution": "publisher",
+}
+
+type FrictionlessLicense struct {
+	Name  string `json:"name,omitempty"`
+	Path  string `json:"path,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+type FrictionlessContributor struct {
+	Title        string `json:"title"`
+	Path         string `json:"path,omitempty"`
+	Role         string `json:"role"`
+	Organization string `json:"organization,omitempty"`
+}
+
+type FrictionlessSource struct {
+	Title string `json:"title"`
+	Path  string `json:"path,omitempty"`
+}
+
+type FrictionlessResource struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+	Hash  string `json:"hash,omitempty"`
+}
+
+// the datapackage.json descriptor
+type FrictionlessPackage struct {
+	Name         string                    `json:"name"`
+	Title        string                    `json:"title,omitempty"`
+	Description  string                    `json:"description,omitempty"`
+	Version      string                    `json:"version,omitempty"`
+	Licenses     []FrictionlessLicense     `json:"licenses,omitempty"`
+	Contributors []FrictionlessContributor `json:"contributors,omitempty"`
+	Keywords     []string                  `json:"keywords,omitempty"`
+	Sources      []FrictionlessSource      `json:"sources,omitempty"`
+	Resources    []FrictionlessResource    `json:"resources"`
+}
+
+// a Frictionless name: lowercase letters, digits, "-", "_" and "."
+func frictionless_name(s string) string {
+	var sb strings.Builder
+	for _, r := range title_file_stem(s) {
+		switch {
+		case r == '_':
+			sb.WriteRune('-')
+		case r == '-' || (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9'):
+			sb.WriteRune(r)
+		}
+	}
+	name := strings.Trim(sb.String(), "-")
+	if name == "" {
+		return untitled_file_stem
+	}
+	return name
+}
+
+// a contributor entry, the ORCID is its path and the first affiliation its organization
+func frictionless_contributor(name NameStruct, affiliations []string, scheme_ids [][2]string, role string) FrictionlessContributor {
+	c := FrictionlessContributor{Title: formatName(name, "full"), Role: role}
+	if orcid := xlsx_orcid(scheme_ids); orcid != "" {
+		c.Path = orcid_url(orcid)
+	}
+	for _, aff := range affiliations {
+		if aff != "" {
+			c.Organization = aff
+			break
+		}
+	}
+	return c
+}
+
+// the sha256 of a file as Frictionless writes it, "sha256:<hex>"
+func frictionless_file_hash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// the files below dir as resources in path order, hashed by the given number of goroutines unless hash is false
+func frictionless_resources(dir string, hash bool, workers int) ([]FrictionlessResource, error) {
+	var resources []FrictionlessResource
+	var paths []string
+	// resource names must be unique within the package
+	used := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() == frictionless_metadata_name {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		// resource paths are POSIX paths relative to the descriptor
+		resources = append(resources, FrictionlessResource{
+			Name:  unique_file_stem(used, frictionless_name(strings.TrimSuffix(d.Name(), filepath.Ext(d.Name())))),
+			Path:  filepath.ToSlash(rel),
+			Bytes: info.Size(),
+		})
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil || !hash {
+		return resources, err
+	}
+
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	errs := make([]error, len(paths))
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// each worker only writes its own index so resources needs no locking
+			for i := range jobs {
+				resources[i].Hash, errs[i] = frictionless_file_hash(paths[i])
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resources, nil
+}
+
+// map the metadata to a datapackage.json descriptor, if data_dir is not empty its files become the resources
+func frictionless_package(doc Yoda18Metadata, data_dir string, hash bool, workers int) (FrictionlessPackage, error) {
+	p := FrictionlessPackage{
+		Name:        frictionless_name(doc.Title),
+		Title:       doc.Title,
+		Description: doc.Description,
+		Version:     doc.Version,
+		Resources:   []FrictionlessResource{},
+	}
+
+	if doc.License != "" {
+		license := FrictionlessLicense{Path: license_url(doc.License), Title: doc.License}
+		if spdx, err := NormalizeLicense(doc.License); err == nil {
+			license.Name = spdx
+		}
+		if license.Path == "" && license.Name == "" {
+			license.Path = doc.License
+		}
+		p.Licenses = append(p.Licenses, license)
+	}
+
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		p.Contributors = append(p.Contributors, frictionless_contributor(cre.Name, cre.Affiliation, ids, "author"))
+	}
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		role, ok := frictionless_roles[con.ContributorType]
+		if !ok {
+			role = "contributor"
+		}
+		p.Contributors = append(p.Contributors, frictionless_contributor(con.Name, con.Affiliation, ids, role))
+	}
+
+	for _, tag := range doc.Tag {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			p.Keywords = append(p.Keywords, tag)
+		}
+	}
+
+	for _, rel := range doc.RelatedDatapackage {
+		pid := rel.PersistentIdentifier
+		if rel.Title == "" && pid.Identifier == "" {
+			continue
+		}
+		source := FrictionlessSource{Title: rel.Title, Path: pid_link(pid.IdentifierScheme, pid.Identifier)}
+		if source.Title == "" {
+			source.Title = source.Path
+		}
+		p.Sources = append(p.Sources, source)
+	}
+
+	if data_dir != "" {
+		resources, err := frictionless_resources(data_dir, hash, workers)
+		if err != nil {
+			return p, err
+		}
+		p.Resources = append(p.Resources, resources...)
+	}
+	return p, nil
+}
+
+// exportFrictionless writes the datapackage.json of the dataset, files in data_dir (if any) become its resources
+func exportFrictionless(doc Yoda18Metadata, data_dir string, w io.Writer) error {
+	p, err := frictionless_package(doc, data_dir, !*no_hash, *workers)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(p)
+}
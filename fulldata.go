@@ -0,0 +1,100 @@
+/*
+fulldata.go flattening Yoda metadata into "Label: value" lines for the Markdown summary, the PDF report and the text report.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the scalar metadata fields as "Label: value" lines
+func get_basic_data(doc Yoda18Metadata) []string {
+	var output []string
+	output = append(output, fmt.Sprintf("Title: %s", doc.Title))
+	output = append(output, fmt.Sprintf("Description: %s", doc.Description))
+	output = append(output, fmt.Sprintf("Version: %s", doc.Version))
+	output = append(output, fmt.Sprintf("Language: %s", doc.Language))
+	output = append(output, fmt.Sprintf("Data_Type: %s", doc.DataType))
+	output = append(output, fmt.Sprintf("License: %s", canonical_license(doc.License)))
+	output = append(output, fmt.Sprintf("Data_Classification: %s", doc.DataClassification))
+	output = append(output, fmt.Sprintf("Data_Access_Restriction: %s", doc.DataAccessRestriction))
+	output = append(output, fmt.Sprintf("Collected: %s - %s", doc.Collected.StartDate, doc.Collected.EndDate))
+	output = append(output, fmt.Sprintf("Covered_Period: %s - %s", doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate))
+	output = append(output, fmt.Sprintf("Retention_Period: %d", doc.RetentionPeriod))
+	output = append(output, fmt.Sprintf("Retention_Information: %s", doc.RetentionInformation))
+	output = append(output, fmt.Sprintf("Embargo_End_Date: %s", doc.EmbargoEndDate))
+	output = append(output, fmt.Sprintf("Collection_Name: %s", doc.CollectionName))
+	output = append(output, fmt.Sprintf("Remarks: %s", doc.Remarks))
+	return output
+}
+
+// format a person as "Family, Given (SCHEME: id) ..."
+func full_data_person(n NameStruct, ids [][2]string) string {
+	name := formatName(n, "citation")
+	for _, id := range ids {
+		if id[0] != "" || id[1] != "" {
+			name += fmt.Sprintf(" (%s: %s)", id[0], id[1])
+		}
+	}
+	return name
+}
+
+// GetFullData returns all metadata fields as "Label: value" lines, slice fields produce one indexed line per element
+func GetFullData(doc Yoda18Metadata) []string {
+	output := get_basic_data(doc)
+
+	for i, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		output = append(output, fmt.Sprintf("Creator[%d]: %s", i, full_data_person(cre.Name, ids)))
+		for j, aff := range cre.Affiliation {
+			output = append(output, fmt.Sprintf("Creator[%d].Affiliation[%d]: %s", i, j, aff))
+		}
+	}
+
+	for i, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		output = append(output, fmt.Sprintf("Contributor[%d]: %s [%s]", i, full_data_person(con.Name, ids), con.ContributorType))
+		for j, aff := range con.Affiliation {
+			output = append(output, fmt.Sprintf("Contributor[%d].Affiliation[%d]: %s", i, j, aff))
+		}
+	}
+
+	for i, tag := range doc.Tag {
+		output = append(output, fmt.Sprintf("Tag[%d]: %s", i, tag))
+	}
+	for i, disc := range doc.Discipline {
+		output = append(output, fmt.Sprintf("Discipline[%d]: %s", i, disc))
+	}
+	for i, place := range doc.CoveredGeolocationPlace {
+		output = append(output, fmt.Sprintf("Covered_Geolocation_Place[%d]: %s", i, place))
+	}
+	for i, fund := range doc.FundingReference {
+		output = append(output, fmt.Sprintf("Funding_Reference[%d]: %s (Award: %s)", i, fund.FunderName, fund.AwardNumber))
+	}
+	for i, rel := range doc.RelatedDatapackage {
+		output = append(output, fmt.Sprintf("Related_Datapackage[%d]: %s [%s] (%s: %s)", i, rel.Title, rel.RelationType,
+			rel.PersistentIdentifier.IdentifierScheme, pid_link(rel.PersistentIdentifier.IdentifierScheme, rel.PersistentIdentifier.Identifier)))
+	}
+	for i, link := range doc.Links {
+		output = append(output, fmt.Sprintf("Links[%d]: %s %s", i, link.Rel, link.Href))
+	}
+
+	return output
+}
+
+// GetFullData as a markdown list, multi-line values are kept on a single list item
+func full_data_markdown(doc Yoda18Metadata) string {
+	var out string
+	for _, line := range GetFullData(doc) {
+		out += "- " + strings.Join(strings.Fields(line), " ") + "\n"
+	}
+	return out
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTextReportFullData(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	got := render_text_report(doc, 200)
+	_, all, ok := strings.Cut(got, "All metadata:\n")
+	if !ok {
+		t.Fatalf("no All metadata section in\n%s", got)
+	}
+	// the section lists every line of GetFullData, also the fields the report leaves out
+	for _, line := range GetFullData(doc) {
+		label, _, _ := strings.Cut(line, ":")
+		if !strings.Contains(all, "  - "+label+":") {
+			t.Errorf("All metadata misses %q", label)
+		}
+	}
+	for _, want := range []string{"Creator[0].Affiliation[0]: Vrije Universiteit Amsterdam", "Retention_Period: 10", "Links[0]: "} {
+		if !strings.Contains(all, want) {
+			t.Errorf("All metadata misses %q", want)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+/*
+funder.go looking up Funder_Name in the Crossref Funder Registry, to replace free text funder names by the
+registry name and DOI.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the Crossref Funder Registry search endpoint
+const crossref_funders_url string = "https://api.crossref.org/funders"
+
+// the maximum time a single funder lookup may take
+const funder_lookup_timeout = 10 * time.Second
+
+// the registry entry of a funder, empty when the registry has no match
+type FunderRecord struct {
+	Name string
+	DOI  string
+}
+
+// response of the Crossref funders search, only the fields used here
+type crossref_funders_response struct {
+	Message struct {
+		Items []struct {
+			ID       string   `json:"id"`
+			Name     string   `json:"name"`
+			AltNames []string `json:"alt-names"`
+		} `json:"items"`
+	} `json:"message"`
+}
+
+// the registry record of every funder name looked up in this run
+var funder_cache = make(map[string]FunderRecord)
+var funder_mu sync.Mutex
+
+// LookupFunder searches the Crossref Funder Registry for a funder name and returns the registry name and the
+// funder DOI (10.13039/...); only a funder whose name or alternative name equals the given name, without regard
+// to case, is returned, otherwise the record is empty; each name is looked up once per run
+func LookupFunder(ctx context.Context, name string) (FunderRecord, error) {
+	key := match_key(name)
+	if key == "" {
+		return FunderRecord{}, nil
+	}
+	funder_mu.Lock()
+	rec, ok := funder_cache[key]
+	funder_mu.Unlock()
+	if ok {
+		return rec, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, funder_lookup_timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, crossref_funders_url+"?rows=10&query="+url.QueryEscape(name), nil)
+	if err != nil {
+		return rec, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", url_user_agent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return rec, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return rec, fmt.Errorf("Crossref funder lookup of %q failed: %s", name, resp.Status)
+	}
+	var found crossref_funders_response
+	err = json.NewDecoder(resp.Body).Decode(&found)
+	if err != nil {
+		return rec, err
+	}
+
+	for _, item := range found.Message.Items {
+		names := append([]string{item.Name}, item.AltNames...)
+		for _, n := range names {
+			if match_key(n) == key {
+				rec = FunderRecord{Name: item.Name, DOI: "10.13039/" + item.ID}
+				break
+			}
+		}
+		if rec.DOI != "" {
+			break
+		}
+	}
+	funder_mu.Lock()
+	funder_cache[key] = rec
+	funder_mu.Unlock()
+	return rec, nil
+}
+
+// EnrichFunding replaces each Funder_Name found in the Crossref Funder Registry by the registry name followed by
+// the funder DOI, as in "Bill & Melinda Gates Foundation (https://doi.org/10.13039/100000865)"; names that
+// already hold a funder DOI or have no registry match are kept
+func EnrichFunding(ctx context.Context, doc Yoda18Metadata) (Yoda18Metadata, error) {
+	// copied so the caller's document is left alone
+	doc.FundingReference = append(doc.FundingReference[:0:0], doc.FundingReference...)
+	for i, fund := range doc.FundingReference {
+		if fund.FunderName == "" || strings.Contains(fund.FunderName, "10.13039/") {
+			continue
+		}
+		rec, err := LookupFunder(ctx, fund.FunderName)
+		if err != nil {
+			return doc, err
+		}
+		if rec.DOI != "" {
+			doc.FundingReference[i].FunderName = rec.Name + " (https://doi.org/" + rec.DOI + ")"
+		}
+	}
+	return doc, nil
+}
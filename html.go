@@ -0,0 +1,140 @@
+/*
+html.go exports the metadata report as a single HTML page, with the same sections, values and warning colours as the
+PDF and DOCX reports; -html-jsonld embeds the schema.org JSON-LD of -format jsonld in its head for search engines.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"io"
+)
+
+// CSS classes matching the PDF report colours
+var html_level_classes = map[report_level]string{
+	report_good:    "good",
+	report_info:    "info",
+	report_warning: "warning",
+	report_error:   "error",
+}
+
+const html_report_template string = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Report.Title.Text}}</title>
+<meta name="generator" content="readYmeta v{{.Version}}">
+<style>
+body { font-family: sans-serif; max-width: 60em; margin: 2em auto; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #999; padding: 0.2em 0.5em; text-align: left; vertical-align: top; }
+.good { color: #00C800; }
+.info { color: #FFA500; }
+.warning { color: #0000C8; }
+.error { color: #C80000; }
+</style>
+{{.JSONLD}}</head>
+<body>
+<h1>{{template "value" .Report.Title}}</h1>
+<h2>Description</h2>
+{{range paragraphs .Report.Description}}<p>{{template "value" .}}</p>
+{{end}}<h2>Tags</h2>
+<ul>
+{{range .Report.Tags}}<li>{{template "value" .}}</li>
+{{end}}</ul>
+<h2>Creators</h2>
+<table>
+<tr><th>Name</th><th>Affiliation</th><th>Identifier</th></tr>
+{{range .Report.Creators}}<tr><td>{{template "value" .Name}}</td><td>{{template "values" .Affiliations}}</td><td>{{template "values" .Identifiers}}</td></tr>
+{{end}}</table>
+<h2>Contributors</h2>
+{{if .Report.Note.Text}}<p>{{template "value" .Report.Note}}</p>
+{{end}}<table>
+<tr><th>Name</th><th>Type</th><th>Affiliation</th><th>Identifier</th></tr>
+{{range .Report.Contributors}}<tr><td>{{template "value" .Name}}</td><td>{{template "value" .Role}}</td><td>{{template "values" .Affiliations}}</td><td>{{template "values" .Identifiers}}</td></tr>
+{{end}}</table>
+<h2>Disciplines</h2>
+<ul>
+{{range .Report.Disciplines}}<li>{{template "value" .}}</li>
+{{end}}</ul>
+<h2>Collected</h2>
+{{range .Report.Collected}}<p><b>{{.Label}}:</b> {{template "value" .Value}}</p>
+{{end}}<h2>Covered Period</h2>
+{{range .Report.CoveredPeriod}}<p><b>{{.Label}}:</b> {{template "value" .Value}}</p>
+{{end}}<h2>Funding references</h2>
+<table>
+<tr><th>Funder</th><th>Award number</th></tr>
+{{range .Report.Funding}}<tr><td>{{.Label}}</td><td>{{template "value" .Value}}</td></tr>
+{{end}}</table>
+<h2>Related datapackages</h2>
+<table>
+<tr><th>Relation type</th><th>Identifier</th><th>Title</th></tr>
+{{range .Report.Related}}<tr><td>{{template "value" .Relation}}</td><td>{{template "value" .Identifier}}</td><td>{{template "value" .Title}}</td></tr>
+{{end}}</table>
+{{range .Report.Fields}}<h2>{{.Label}}</h2>
+<p>{{template "value" .Value}}</p>
+{{end}}{{if .Report.Unmapped}}<h2>Unmapped fields</h2>
+<table>
+<tr><th>Field</th><th>Value</th></tr>
+{{range .Report.Unmapped}}<tr><td>{{.Label}}</td><td>{{template "value" .Value}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+{{define "value"}}{{with level_class .Level}}<span class="{{.}}">{{end}}{{.Text}}{{with level_class .Level}}</span>{{end}}{{end}}
+{{- define "values"}}{{range $i, $v := .}}{{if $i}}<br>{{end}}{{template "value" $v}}{{end}}{{end}}`
+
+var html_report = template.Must(template.New("report").Funcs(template.FuncMap{
+	"level_class": func(level report_level) string { return html_level_classes[level] },
+	"paragraphs":  html_paragraphs,
+}).Parse(html_report_template))
+
+// the description split on blank lines like the DOCX report
+func html_paragraphs(v report_value) []report_value {
+	var out []report_value
+	for _, paragraph := range paragraph_break.Split(v.Text, -1) {
+		if paragraph != "" {
+			out = append(out, report_value{paragraph, v.Level})
+		}
+	}
+	return out
+}
+
+// RenderHTML returns the HTML report of the metadata, with its JSON-LD in a script element when with_jsonld is set,
+// or the ctx error once ctx is cancelled
+func RenderHTML(ctx context.Context, doc Yoda18Metadata, with_jsonld bool) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var page struct {
+		Report  report_model
+		Version string
+		JSONLD  template.HTML
+	}
+	page.Report = build_report(doc)
+	page.Version = _MYVERSION_
+	if with_jsonld {
+		script, err := jsonld_script_tag(doc)
+		if err != nil {
+			return nil, err
+		}
+		// the JSON-LD encoder escapes <, > and & so it is safe to embed as is
+		page.JSONLD = template.HTML(script)
+	}
+	var buf bytes.Buffer
+	if err := html_report.Execute(&buf, page); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), ctx.Err()
+}
+
+// exportHTML writes the HTML report to w
+func exportHTML(doc Yoda18Metadata, w io.Writer) error {
+	page, err := RenderHTML(context.Background(), doc, *html_jsonld)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(page)
+	return err
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// the JSON in the <script type="application/ld+json"> elements of a page
+var html_jsonld_script = regexp.MustCompile(`(?s)<script type="application/ld\+json">(.*?)</script>`)
+
+func TestRenderHTML(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	page, err := RenderHTML(context.Background(), doc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if html_jsonld_script.Match(page) {
+		t.Error("JSON-LD embedded without asking for it")
+	}
+	for _, want := range []string{"<h2>Creators</h2>", "<h2>Related datapackages</h2>", "<h2>Licence</h2>", doc.Creator[0].Name.FamilyName} {
+		if !strings.Contains(string(page), want) {
+			t.Errorf("the page has no %q", want)
+		}
+	}
+}
+
+func TestRenderHTMLJSONLD(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	page, err := RenderHTML(context.Background(), doc, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, _, _ := strings.Cut(string(page), "</head>")
+	scripts := html_jsonld_script.FindAllStringSubmatch(head, -1)
+	if len(scripts) != 1 {
+		t.Fatalf("%d JSON-LD scripts in the head, want 1", len(scripts))
+	}
+	var ds JSONLDDataset
+	if err := json.Unmarshal([]byte(scripts[0][1]), &ds); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Type != "Dataset" || ds.Name != doc.Title {
+		t.Errorf("embedded %s %q, want Dataset %q", ds.Type, ds.Name, doc.Title)
+	}
+}
+
+func TestRenderHTMLEscapes(t *testing.T) {
+	doc := parse_test_metadata(t, `{"Title": "<b>bold</b> & co", "Description": "first\n\nsecond"}`)
+	page, err := RenderHTML(context.Background(), doc, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(page), "<b>bold</b>") {
+		t.Error("the title is not escaped")
+	}
+	if !strings.Contains(string(page), "<p>first</p>") || !strings.Contains(string(page), "<p>second</p>") {
+		t.Error("the description paragraphs are not kept apart")
+	}
+}
@@ -0,0 +1,17 @@
+/*
+htmlentity.go decoding the HTML entities some Yoda deployments store in the metadata text.
+*/
+
+package main
+
+import (
+	"html"
+)
+
+// DecodeHTMLEntities returns a copy of doc with the HTML entities in every string decoded, "Caf&eacute;" and
+// "Caf&#233;" become "Café"; it is only applied with -decode-html, other sources may mean a literal "&amp;"
+func DecodeHTMLEntities(doc Yoda18Metadata) Yoda18Metadata {
+	return map_metadata_strings(doc, func(s string, _ bool) string {
+		return html.UnescapeString(s)
+	})
+}
@@ -0,0 +1,110 @@
+/*
+input.go reading the raw Yoda metadata from a file or stdin, gzip compressed input is decompressed transparently.
+*/
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the input filename that selects stdin
+const stdin_name string = "-"
+
+// the first two bytes of any gzip stream
+var gzip_magic = []byte{0x1f, 0x8b}
+
+// read the metadata from a file, or stdin if fname is "-", decompressing gzip content and converting YAML files
+func read_metadata_input(fname string) ([]byte, error) {
+	var raw []byte
+	var err error
+
+	if fname == stdin_name {
+		raw, err = io.ReadAll(os.Stdin)
+	} else {
+		raw, err = os.ReadFile(fname)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(strings.ToLower(fname), ".gz") || bytes.HasPrefix(raw, gzip_magic) {
+		raw, err = gunzip_bytes(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// YAML input is converted to the JSON the rest of the tool expects
+	if is_yaml_name(fname) {
+		return yaml_to_json(raw)
+	}
+	return unwrap_combi_json(raw)
+}
+
+// decompress a gzip compressed byte slice
+func gunzip_bytes(raw []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	return io.ReadAll(zr)
+}
+
+// the input filename without the .gz and .json (or .yaml) extensions, used to name output files
+func input_file_stem(fname string) string {
+	if fname == stdin_name {
+		return "stdin"
+	}
+	if strings.HasSuffix(strings.ToLower(fname), ".gz") {
+		fname = fname[:len(fname)-3]
+	}
+	return strings.TrimSuffix(fname, filepath.Ext(fname))
+}
+
+// the metadata files of the input arguments: a file as given, a data package directory as its
+// yoda-metadata.json, any other directory as the yoda-metadata.json files below it, or when it has none of
+// those as the .json and .json.gz files below it
+func metadata_input_files(args []string) ([]string, error) {
+	var fnames []string
+	for _, arg := range args {
+		fi, err := os.Stat(arg)
+		if err != nil || !fi.IsDir() {
+			fnames = append(fnames, arg)
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(arg, "yoda-metadata.json")); err == nil {
+			fnames = append(fnames, filepath.Join(arg, "yoda-metadata.json"))
+			continue
+		}
+		var metadata, json_files []string
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			name := strings.ToLower(d.Name())
+			if name == "yoda-metadata.json" {
+				metadata = append(metadata, path)
+			} else if strings.HasSuffix(name, ".json") || strings.HasSuffix(name, ".json.gz") {
+				json_files = append(json_files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(metadata) > 0 {
+			fnames = append(fnames, metadata...)
+		} else {
+			fnames = append(fnames, json_files...)
+		}
+	}
+	return fnames, nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// the gzip compression of raw
+func gzip_test_bytes(t *testing.T, raw []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// read fname as stdin
+func read_test_stdin(t *testing.T, fname string) []byte {
+	t.Helper()
+	f, err := os.Open(fname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	old := os.Stdin
+	os.Stdin = f
+	defer func() { os.Stdin = old }()
+	raw, err := read_metadata_input(stdin_name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func TestReadGzipFixture(t *testing.T) {
+	plain := load_test_metadata(t, "yoda-metadata.json")
+	compressed := load_test_metadata(t, "yoda-metadata.json.gz")
+	if !reflect.DeepEqual(plain, compressed) {
+		t.Error("the gzip fixture reads differently from the plain file")
+	}
+}
+
+func TestReadGzipRoundTrip(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("test-data", "yoda-metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := read_metadata_input(filepath.Join("test-data", "yoda-metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	gz := filepath.Join(dir, "metadata.json.gz")
+	if err := os.WriteFile(gz, gzip_test_bytes(t, raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// gzip content without .gz extension is recognised by its magic bytes
+	magic := filepath.Join(dir, "metadata.json")
+	if err := os.WriteFile(magic, gzip_test_bytes(t, raw), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fname := range []string{gz, magic} {
+		got, err := read_metadata_input(fname)
+		if err != nil {
+			t.Fatalf("%s: %v", fname, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s does not read as the uncompressed file", fname)
+		}
+	}
+	if got := read_test_stdin(t, gz); !bytes.Equal(got, want) {
+		t.Error("gzip compressed stdin does not read as the uncompressed file")
+	}
+	if got := read_test_stdin(t, filepath.Join("test-data", "yoda-metadata.json")); !bytes.Equal(got, want) {
+		t.Error("plain stdin does not read as the file")
+	}
+}
+
+func TestReadGzipCorrupt(t *testing.T) {
+	fname := filepath.Join(t.TempDir(), "broken.json.gz")
+	if err := os.WriteFile(fname, []byte("{\"Title\": \"not compressed\"}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read_metadata_input(fname); err == nil {
+		t.Error("a .gz file that is not gzip compressed is read without error")
+	}
+}
+
+func TestInputFileStem(t *testing.T) {
+	tests := map[string]string{
+		"data/yoda-metadata.json":    "data/yoda-metadata",
+		"data/yoda-metadata.json.gz": "data/yoda-metadata",
+		"data/yoda-metadata.JSON.GZ": "data/yoda-metadata",
+		stdin_name:                   "stdin",
+	}
+	for fname, want := range tests {
+		if got := input_file_stem(fname); got != want {
+			t.Errorf("input_file_stem(%q) = %q, want %q", fname, got, want)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+/*
+iso19139.go exports Yoda metadata as a minimal ISO 19115 metadata record in the ISO 19139 XML encoding, for
+GIS portals harvesting geospatial datasets.
+*/
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	iso_gmd_namespace        string = "http://www.isotc211.org/2005/gmd"
+	iso_gco_namespace        string = "http://www.isotc211.org/2005/gco"
+	iso_gml_namespace        string = "http://www.opengis.net/gml/3.2"
+	iso_schema_location      string = "http://www.isotc211.org/2005/gmd http://schemas.opengis.net/iso/19139/20070417/gmd/gmd.xsd"
+	iso_codelist_location    string = "http://standards.iso.org/iso/19139/resources/gmxCodelists.xml"
+	iso_metadata_standard    string = "ISO 19115:2003/19139"
+	iso_metadata_std_version string = "1.0"
+)
+
+// a gco:CharacterString property
+type ISOString struct {
+	Value string `xml:"gco:CharacterString"`
+}
+
+// a code list value, the element name gives the code list
+type ISOCode struct {
+	CodeList      string `xml:"codeList,attr"`
+	CodeListValue string `xml:"codeListValue,attr"`
+	Value         string `xml:",chardata"`
+}
+
+type ISOResponsibleParty struct {
+	IndividualName   *ISOString `xml:"gmd:individualName"`
+	OrganisationName *ISOString `xml:"gmd:organisationName"`
+	Role             struct {
+		Code ISOCode `xml:"gmd:CI_RoleCode"`
+	} `xml:"gmd:role"`
+}
+
+type ISOParty struct {
+	Party ISOResponsibleParty `xml:"gmd:CI_ResponsibleParty"`
+}
+
+type ISODate struct {
+	Date struct {
+		Date     string `xml:"gmd:date>gco:Date"`
+		DateType struct {
+			Code ISOCode `xml:"gmd:CI_DateTypeCode"`
+		} `xml:"gmd:dateType"`
+	} `xml:"gmd:CI_Date"`
+}
+
+type ISOCitation struct {
+	Title                 ISOString  `xml:"gmd:title"`
+	Date                  []ISODate  `xml:"gmd:date"`
+	Edition               *ISOString `xml:"gmd:edition"`
+	Identifier            *ISOString `xml:"gmd:identifier>gmd:MD_Identifier>gmd:code"`
+	CitedResponsibleParty []ISOParty `xml:"gmd:citedResponsibleParty"`
+}
+
+type ISOKeywords struct {
+	Keywords struct {
+		Keyword []ISOString `xml:"gmd:keyword"`
+		Type    struct {
+			Code ISOCode `xml:"gmd:MD_KeywordTypeCode"`
+		} `xml:"gmd:type"`
+	} `xml:"gmd:MD_Keywords"`
+}
+
+type ISORestriction struct {
+	Code ISOCode `xml:"gmd:MD_RestrictionCode"`
+}
+
+// gmd:MD_LegalConstraints, the element order is fixed by the schema
+type ISOLegalConstraints struct {
+	UseLimitation     []ISOString      `xml:"gmd:useLimitation"`
+	AccessConstraints []ISORestriction `xml:"gmd:accessConstraints"`
+	UseConstraints    []ISORestriction `xml:"gmd:useConstraints"`
+	OtherConstraints  []ISOString      `xml:"gmd:otherConstraints"`
+}
+
+type ISOConstraints struct {
+	Legal ISOLegalConstraints `xml:"gmd:MD_LegalConstraints"`
+}
+
+// a coordinate of the bounding box
+type ISODecimal struct {
+	Value string `xml:"gco:Decimal"`
+}
+
+type ISOBoundingBox struct {
+	Box struct {
+		West  ISODecimal `xml:"gmd:westBoundLongitude"`
+		East  ISODecimal `xml:"gmd:eastBoundLongitude"`
+		South ISODecimal `xml:"gmd:southBoundLatitude"`
+		North ISODecimal `xml:"gmd:northBoundLatitude"`
+	} `xml:"gmd:EX_GeographicBoundingBox"`
+}
+
+// a gml:beginPosition or gml:endPosition, unknown when there is no date
+type ISOTimePosition struct {
+	Indeterminate string `xml:"indeterminatePosition,attr,omitempty"`
+	Value         string `xml:",chardata"`
+}
+
+type ISOTemporalExtent struct {
+	Extent struct {
+		Period struct {
+			ID    string          `xml:"gml:id,attr"`
+			Begin ISOTimePosition `xml:"gml:beginPosition"`
+			End   ISOTimePosition `xml:"gml:endPosition"`
+		} `xml:"gmd:extent>gml:TimePeriod"`
+	} `xml:"gmd:EX_TemporalExtent"`
+}
+
+type ISOExtent struct {
+	Extent struct {
+		Description       *ISOString         `xml:"gmd:description"`
+		GeographicElement *ISOBoundingBox    `xml:"gmd:geographicElement"`
+		TemporalElement   *ISOTemporalExtent `xml:"gmd:temporalElement"`
+	} `xml:"gmd:EX_Extent"`
+}
+
+// gmd:MD_DataIdentification, the element order is fixed by the schema
+type ISODataIdentification struct {
+	Citation            ISOCitation      `xml:"gmd:citation>gmd:CI_Citation"`
+	Abstract            ISOString        `xml:"gmd:abstract"`
+	PointOfContact      *ISOParty        `xml:"gmd:pointOfContact"`
+	DescriptiveKeywords []ISOKeywords    `xml:"gmd:descriptiveKeywords"`
+	ResourceConstraints []ISOConstraints `xml:"gmd:resourceConstraints"`
+	Language            ISOString        `xml:"gmd:language"`
+	Extent              *ISOExtent       `xml:"gmd:extent"`
+}
+
+// the gmd:MD_Metadata record, the element order is fixed by the schema
+type ISOMetadata struct {
+	XMLName        xml.Name  `xml:"gmd:MD_Metadata"`
+	XmlnsGMD       string    `xml:"xmlns:gmd,attr"`
+	XmlnsGCO       string    `xml:"xmlns:gco,attr"`
+	XmlnsGML       string    `xml:"xmlns:gml,attr"`
+	XmlnsXsi       string    `xml:"xmlns:xsi,attr"`
+	SchemaLocation string    `xml:"xsi:schemaLocation,attr"`
+	FileIdentifier ISOString `xml:"gmd:fileIdentifier"`
+	Language       ISOString `xml:"gmd:language"`
+	CharacterSet   struct {
+		Code ISOCode `xml:"gmd:MD_CharacterSetCode"`
+	} `xml:"gmd:characterSet"`
+	HierarchyLevel struct {
+		Code ISOCode `xml:"gmd:MD_ScopeCode"`
+	} `xml:"gmd:hierarchyLevel"`
+	Contact                 []ISOParty            `xml:"gmd:contact"`
+	DateStamp               string                `xml:"gmd:dateStamp>gco:DateTime"`
+	MetadataStandardName    ISOString             `xml:"gmd:metadataStandardName"`
+	MetadataStandardVersion ISOString             `xml:"gmd:metadataStandardVersion"`
+	IdentificationInfo      ISODataIdentification `xml:"gmd:identificationInfo>gmd:MD_DataIdentification"`
+}
+
+// a value of the ISO 19139 code list with the given name
+func iso_code(list string, value string) ISOCode {
+	return ISOCode{CodeList: iso_codelist_location + "#" + list, CodeListValue: value, Value: value}
+}
+
+// a gco:CharacterString property, nil when s is blank
+func iso_string(s string) *ISOString {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil
+	}
+	return &ISOString{Value: s}
+}
+
+// a responsible party with the given role, organisation is its first affiliation
+func iso_party(name NameStruct, affiliations []string, role string) ISOParty {
+	var p ISOParty
+	p.Party.IndividualName = iso_string(formatName(name, "full"))
+	for _, aff := range affiliations {
+		if p.Party.OrganisationName = iso_string(aff); p.Party.OrganisationName != nil {
+			break
+		}
+	}
+	p.Party.Role.Code = iso_code("CI_RoleCode", role)
+	return p
+}
+
+// the bounding box of a -bbox value "west,east,south,north" in decimal degrees, nil when it is empty
+func parse_iso_bbox(value string) (*ISOBoundingBox, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("-bbox needs west,east,south,north, got %q", value)
+	}
+	var coords [4]string
+	limits := [4]float64{180, 180, 90, 90}
+	for i, part := range parts {
+		part = strings.TrimSpace(part)
+		f, err := strconv.ParseFloat(part, 64)
+		if err != nil || f < -limits[i] || f > limits[i] {
+			return nil, fmt.Errorf("-bbox coordinate %q is not a number between %g and %g", part, -limits[i], limits[i])
+		}
+		coords[i] = part
+	}
+	south, _ := strconv.ParseFloat(coords[2], 64)
+	north, _ := strconv.ParseFloat(coords[3], 64)
+	if south > north {
+		return nil, fmt.Errorf("-bbox south %s lies north of north %s", coords[2], coords[3])
+	}
+	var box ISOBoundingBox
+	box.Box.West, box.Box.East = ISODecimal{coords[0]}, ISODecimal{coords[1]}
+	box.Box.South, box.Box.North = ISODecimal{coords[2]}, ISODecimal{coords[3]}
+	return &box, nil
+}
+
+// a gml time position, indeterminate when the date is not known
+func iso_time_position(date string) ISOTimePosition {
+	if date = strings.TrimSpace(date); date == "" {
+		return ISOTimePosition{Indeterminate: "unknown"}
+	}
+	return ISOTimePosition{Value: date}
+}
+
+// map the metadata to an ISO 19139 record, sys gives the DOI and dates, bbox the placeholder geographic extent
+func iso_document(doc Yoda18Metadata, sys System, datestamp string, bbox *ISOBoundingBox) (ISOMetadata, error) {
+	m := ISOMetadata{
+		XmlnsGMD:       iso_gmd_namespace,
+		XmlnsGCO:       iso_gco_namespace,
+		XmlnsGML:       iso_gml_namespace,
+		XmlnsXsi:       xsi_namespace,
+		SchemaLocation: iso_schema_location,
+	}
+	doi := ""
+	if pid := sys.PersistentIdentifierDatapackage; strings.EqualFold(pid.IdentifierScheme, "DOI") && pid.Identifier != "" {
+		doi = pid.Identifier
+		m.FileIdentifier.Value = doi
+	} else {
+		// the same identifier as the OAI record when there is no DOI
+		id, err := oai_identifier(doc, sys, "")
+		if err != nil {
+			return m, err
+		}
+		m.FileIdentifier.Value = id
+	}
+
+	language := language_code_639_2(doc.Language)
+	if language == "" {
+		language = "und"
+	}
+	m.Language.Value = language
+	m.CharacterSet.Code = iso_code("MD_CharacterSetCode", "utf8")
+	m.HierarchyLevel.Code = iso_code("MD_ScopeCode", "dataset")
+
+	// the first contact person is the point of contact, the metadata contact falls back to the first creator
+	var contact *ISOParty
+	for _, con := range doc.Contributor {
+		if con.ContributorType == "ContactPerson" {
+			p := iso_party(con.Name, con.Affiliation, "pointOfContact")
+			contact = &p
+			break
+		}
+	}
+	switch {
+	case contact != nil:
+		m.Contact = []ISOParty{*contact}
+	case len(doc.Creator) > 0:
+		m.Contact = []ISOParty{iso_party(doc.Creator[0].Name, doc.Creator[0].Affiliation, "pointOfContact")}
+	default:
+		// a contact is required, its role is the only required part
+		var p ISOParty
+		p.Party.Role.Code = iso_code("CI_RoleCode", "pointOfContact")
+		m.Contact = []ISOParty{p}
+	}
+	m.DateStamp = datestamp
+	m.MetadataStandardName.Value = iso_metadata_standard
+	m.MetadataStandardVersion.Value = iso_metadata_std_version
+
+	id := &m.IdentificationInfo
+	id.Citation.Title.Value = doc.Title
+	var date ISODate
+	if sys.PublicationDate != "" && xsd_date_pattern.MatchString(sys.PublicationDate) {
+		date.Date.Date = sys.PublicationDate
+		date.Date.DateType.Code = iso_code("CI_DateTypeCode", "publication")
+	} else {
+		date.Date.Date = datestamp[:len("2006-01-02")]
+		date.Date.DateType.Code = iso_code("CI_DateTypeCode", "revision")
+	}
+	id.Citation.Date = []ISODate{date}
+	id.Citation.Edition = iso_string(doc.Version)
+	if doi != "" {
+		id.Citation.Identifier = iso_string(pid_link("DOI", doi))
+	}
+	for _, cre := range doc.Creator {
+		id.Citation.CitedResponsibleParty = append(id.Citation.CitedResponsibleParty, iso_party(cre.Name, cre.Affiliation, "author"))
+	}
+	id.Abstract.Value = strings.TrimSpace(doc.Description)
+	id.PointOfContact = contact
+
+	var keywords ISOKeywords
+	for _, tag := range doc.Tag {
+		if s := iso_string(tag); s != nil {
+			keywords.Keywords.Keyword = append(keywords.Keywords.Keyword, *s)
+		}
+	}
+	if len(keywords.Keywords.Keyword) > 0 {
+		keywords.Keywords.Type.Code = iso_code("MD_KeywordTypeCode", "theme")
+		id.DescriptiveKeywords = []ISOKeywords{keywords}
+	}
+
+	if doc.License != "" {
+		var c ISOConstraints
+		use := canonical_license(doc.License)
+		if url := license_url(doc.License); url != "" {
+			use += " (" + url + ")"
+		}
+		c.Legal.UseLimitation = []ISOString{{use}}
+		c.Legal.UseConstraints = []ISORestriction{{iso_code("MD_RestrictionCode", "license")}}
+		id.ResourceConstraints = append(id.ResourceConstraints, c)
+	}
+	if s := iso_string(doc.DataAccessRestriction); s != nil {
+		var c ISOConstraints
+		c.Legal.AccessConstraints = []ISORestriction{{iso_code("MD_RestrictionCode", "otherRestrictions")}}
+		c.Legal.OtherConstraints = []ISOString{*s}
+		id.ResourceConstraints = append(id.ResourceConstraints, c)
+	}
+	id.Language.Value = language
+
+	var extent ISOExtent
+	var places []string
+	for _, place := range doc.CoveredGeolocationPlace {
+		if place = strings.TrimSpace(place); place != "" {
+			places = append(places, place)
+		}
+	}
+	extent.Extent.Description = iso_string(strings.Join(places, "; "))
+	extent.Extent.GeographicElement = bbox
+	if start, end := doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate; start != "" || end != "" {
+		var temporal ISOTemporalExtent
+		temporal.Extent.Period.ID = "covered-period"
+		temporal.Extent.Period.Begin = iso_time_position(start)
+		temporal.Extent.Period.End = iso_time_position(end)
+		extent.Extent.TemporalElement = &temporal
+	}
+	if extent.Extent.Description != nil || extent.Extent.GeographicElement != nil || extent.Extent.TemporalElement != nil {
+		id.Extent = &extent
+	}
+	return m, nil
+}
+
+// exportISO19139 writes the metadata read from source as an ISO 19139 record to w, with the -bbox placeholder
+// as geographic extent
+func exportISO19139(doc Yoda18Metadata, source string, w io.Writer) error {
+	bbox, err := parse_iso_bbox(*iso_bbox)
+	if err != nil {
+		return err
+	}
+	sys := oai_system(doc, source)
+	m, err := iso_document(doc, sys, oai_datestamp(sys, source, time.Now()), bbox)
+	if err != nil {
+		return err
+	}
+	return write_xml_document(w, m)
+}
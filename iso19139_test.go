@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+// the values of the record a catalogue shows
+type iso_test_record struct {
+	XMLName        xml.Name `xml:"http://www.isotc211.org/2005/gmd MD_Metadata"`
+	FileIdentifier string   `xml:"fileIdentifier>CharacterString"`
+	DateStamp      string   `xml:"dateStamp>DateTime"`
+	Identification struct {
+		Title    string   `xml:"citation>CI_Citation>title>CharacterString"`
+		Keywords []string `xml:"descriptiveKeywords>MD_Keywords>keyword>CharacterString"`
+		Extent   struct {
+			West  string `xml:"geographicElement>EX_GeographicBoundingBox>westBoundLongitude>Decimal"`
+			Begin string `xml:"temporalElement>EX_TemporalExtent>extent>TimePeriod>beginPosition"`
+		} `xml:"extent>EX_Extent"`
+	} `xml:"http://www.isotc211.org/2005/gmd identificationInfo>MD_DataIdentification"`
+}
+
+func TestISO19139(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate = "2016-01-01", ""
+	bbox, err := parse_iso_bbox("-17.5, -11.4, 12.3, 16.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sys System
+	sys.PersistentIdentifierDatapackage.IdentifierScheme = "DOI"
+	sys.PersistentIdentifierDatapackage.Identifier = "10.1234/abc"
+	m, err := iso_document(doc, sys, "2024-01-02T03:04:05Z", bbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := write_xml_document(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	check_xml_namespaces(t, buf.Bytes(), iso_gmd_namespace, iso_gco_namespace, iso_gml_namespace)
+	check_test_xsd(t, "iso19139/gmd.xsd", buf.Bytes())
+
+	var rec iso_test_record
+	if err := xml.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if rec.FileIdentifier == "" || rec.DateStamp != "2024-01-02T03:04:05Z" {
+		t.Errorf("fileIdentifier %q, dateStamp %q", rec.FileIdentifier, rec.DateStamp)
+	}
+	id := rec.Identification
+	if id.Title != doc.Title {
+		t.Errorf("title %q, want %q", id.Title, doc.Title)
+	}
+	if len(id.Keywords) == 0 {
+		t.Error("no keywords")
+	}
+	if id.Extent.West != "-17.5" || id.Extent.Begin != "2016-01-01" {
+		t.Errorf("extent %+v", id.Extent)
+	}
+	if !strings.Contains(buf.String(), `indeterminatePosition="unknown"`) {
+		t.Error("the missing end date is not an indeterminate position")
+	}
+}
+
+func TestISO19139Schema(t *testing.T) {
+	bbox, err := parse_iso_bbox("3.2,7.3,50.7,53.6")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sys System
+	for _, name := range []string{"yoda-metadata.json", "yoda-metadata[blank].json", "yoda-metadata[uu011].json", "yoda-metadata[uu012].json", "yoda-metadata[test].json"} {
+		m, err := iso_document(load_test_metadata(t, name), sys, "2024-01-02T03:04:05Z", bbox)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := write_xml_document(&buf, m); err != nil {
+			t.Fatal(err)
+		}
+		for _, problem := range test_xsd_problems(t, "iso19139/gmd.xsd", buf.Bytes()) {
+			t.Errorf("%s: %s", name, problem)
+		}
+	}
+
+	// the schema rejects what a GIS portal would
+	m, err := iso_document(load_test_metadata(t, "yoda-metadata[test].json"), sys, "2024-01-02T03:04:05Z", bbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := write_xml_document(&buf, m); err != nil {
+		t.Fatal(err)
+	}
+	for _, edit := range [][2]string{
+		{`<gmd:dateStamp>`, `<gmd:metadataStandardName><gco:CharacterString>x</gco:CharacterString></gmd:metadataStandardName><gmd:dateStamp>`},
+		{`<gco:Decimal>3.2</gco:Decimal>`, `<gco:Decimal>east</gco:Decimal>`},
+		{`<gco:Date>2024-01-02<`, `<gco:Date>2024-02-30<`},
+		{` codeListValue="author"`, ``},
+		{`<gml:TimePeriod gml:id="covered-period">`, `<gml:TimePeriod>`},
+		{`<gmd:role>`, `<gmd:role codeList="CI_RoleCode">`},
+		{`<gmd:abstract>`, `<gmd:purpose><gco:CharacterString>x</gco:CharacterString></gmd:purpose><gmd:abstract>`},
+	} {
+		raw := bytes.Replace(buf.Bytes(), []byte(edit[0]), []byte(edit[1]), 1)
+		if bytes.Equal(raw, buf.Bytes()) {
+			t.Fatalf("%s is not in the record", edit[0])
+		}
+		if len(test_xsd_problems(t, "iso19139/gmd.xsd", raw)) == 0 {
+			t.Errorf("the record with %s is valid, want a schema error", edit[1])
+		}
+	}
+}
+
+func TestParseISOBBox(t *testing.T) {
+	if box, err := parse_iso_bbox(" "); box != nil || err != nil {
+		t.Errorf("empty -bbox gives %v, %v", box, err)
+	}
+	for _, value := range []string{"1,2,3", "a,2,3,4", "-181,0,0,0", "0,0,91,0", "0,0,10,5"} {
+		if _, err := parse_iso_bbox(value); err == nil {
+			t.Errorf("-bbox %q accepted", value)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+/*
+jsonld.go exports Yoda metadata as schema.org/Dataset JSON-LD for landing pages and Google Dataset Search.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// schema.org Organization
+type JSONLDOrganization struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// schema.org Person
+type JSONLDPerson struct {
+	Type        string               `json:"@type"`
+	Name        string               `json:"name,omitempty"`
+	GivenName   string               `json:"givenName,omitempty"`
+	FamilyName  string               `json:"familyName,omitempty"`
+	Affiliation []JSONLDOrganization `json:"affiliation,omitempty"`
+	SameAs      []string             `json:"sameAs,omitempty"`
+}
+
+// schema.org Place
+type JSONLDPlace struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// schema.org Grant (MonetaryGrant) with its funder
+type JSONLDGrant struct {
+	Type       string              `json:"@type"`
+	Identifier string              `json:"identifier,omitempty"`
+	Funder     *JSONLDOrganization `json:"funder,omitempty"`
+}
+
+// schema.org CreativeWork used for the related datapackages
+type JSONLDCreativeWork struct {
+	Type        string `json:"@type"`
+	Name        string `json:"name,omitempty"`
+	Identifier  string `json:"identifier,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// schema.org Dataset
+type JSONLDDataset struct {
+	Context          string               `json:"@context"`
+	Type             string               `json:"@type"`
+	Name             string               `json:"name"`
+	Description      string               `json:"description,omitempty"`
+	Version          string               `json:"version,omitempty"`
+	License          string               `json:"license,omitempty"`
+	InLanguage       string               `json:"inLanguage,omitempty"`
+	Creator          []JSONLDPerson       `json:"creator,omitempty"`
+	Contributor      []JSONLDPerson       `json:"contributor,omitempty"`
+	Keywords         []string             `json:"keywords,omitempty"`
+	TemporalCoverage string               `json:"temporalCoverage,omitempty"`
+	SpatialCoverage  []JSONLDPlace        `json:"spatialCoverage,omitempty"`
+	Funder           []JSONLDOrganization `json:"funder,omitempty"`
+	Funding          []JSONLDGrant        `json:"funding,omitempty"`
+	IsRelatedTo      []JSONLDCreativeWork `json:"isRelatedTo,omitempty"`
+}
+
+// build a schema.org Person from the Yoda name, affiliations and person identifiers
+func jsonld_person(name NameStruct, affiliations []string, scheme_ids [][2]string) JSONLDPerson {
+	person := JSONLDPerson{
+		Type:       "Person",
+		Name:       formatName(name, "full"),
+		GivenName:  name.GivenName,
+		FamilyName: name.FamilyName,
+	}
+	for _, aff := range affiliations {
+		if aff != "" {
+			person.Affiliation = append(person.Affiliation, JSONLDOrganization{Type: "Organization", Name: aff})
+		}
+	}
+	for _, id := range scheme_ids {
+		if strings.EqualFold(id[0], "ORCID") && id[1] != "" {
+			person.SameAs = append(person.SameAs, orcid_url(id[1]))
+		}
+	}
+	return person
+}
+
+// map the metadata to a schema.org Dataset
+func jsonld_dataset(doc Yoda18Metadata) JSONLDDataset {
+	ds := JSONLDDataset{
+		Context:     "https://schema.org/",
+		Type:        "Dataset",
+		Name:        doc.Title,
+		Description: doc.Description,
+		Version:     doc.Version,
+		InLanguage:  language_code(doc.Language),
+	}
+
+	// prefer a resolvable licence URL, fall back on the licence text
+	ds.License = license_url(doc.License)
+	if ds.License == "" {
+		ds.License = doc.License
+	}
+
+	for i := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range doc.Creator[i].PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		ds.Creator = append(ds.Creator, jsonld_person(doc.Creator[i].Name, doc.Creator[i].Affiliation, ids))
+	}
+	for i := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range doc.Contributor[i].PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		ds.Contributor = append(ds.Contributor, jsonld_person(doc.Contributor[i].Name, doc.Contributor[i].Affiliation, ids))
+	}
+
+	for _, tag := range doc.Tag {
+		if tag != "" {
+			ds.Keywords = append(ds.Keywords, tag)
+		}
+	}
+
+	// ISO 8601 interval, ".." marks an open start or end
+	if doc.CoveredPeriod.StartDate != "" || doc.CoveredPeriod.EndDate != "" {
+		start, end := doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate
+		if start == "" {
+			start = ".."
+		}
+		if end == "" {
+			end = ".."
+		}
+		ds.TemporalCoverage = start + "/" + end
+	}
+
+	for _, place := range doc.CoveredGeolocationPlace {
+		if place != "" {
+			ds.SpatialCoverage = append(ds.SpatialCoverage, JSONLDPlace{Type: "Place", Name: place})
+		}
+	}
+
+	for _, fund := range doc.FundingReference {
+		if fund.FunderName == "" && fund.AwardNumber == "" {
+			continue
+		}
+		grant := JSONLDGrant{Type: "MonetaryGrant", Identifier: fund.AwardNumber}
+		if fund.FunderName != "" {
+			funder := JSONLDOrganization{Type: "Organization", Name: fund.FunderName}
+			grant.Funder = &funder
+			ds.Funder = append(ds.Funder, funder)
+		}
+		ds.Funding = append(ds.Funding, grant)
+	}
+
+	for _, rel := range doc.RelatedDatapackage {
+		work := JSONLDCreativeWork{
+			Type:        "CreativeWork",
+			Name:        rel.Title,
+			Identifier:  rel.PersistentIdentifier.Identifier,
+			Description: rel.RelationType,
+		}
+		if link, err := NormalizePID(rel.PersistentIdentifier.IdentifierScheme, work.Identifier); err == nil {
+			work.Identifier = link
+		} else if doi := doi_from_string(work.Identifier); doi != "" {
+			work.Identifier = "https://doi.org/" + doi
+		}
+		if work.Name != "" || work.Identifier != "" {
+			ds.IsRelatedTo = append(ds.IsRelatedTo, work)
+		}
+	}
+
+	return ds
+}
+
+// exportJSONLD writes the metadata as schema.org/Dataset JSON-LD to w
+func exportJSONLD(doc Yoda18Metadata, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(jsonld_dataset(doc))
+}
+
+// the JSON-LD wrapped in a <script type="application/ld+json"> block for embedding in an HTML page
+func jsonld_script_tag(doc Yoda18Metadata) (string, error) {
+	var sb strings.Builder
+	sb.WriteString("<script type=\"application/ld+json\">\n")
+	// the encoder escapes <, > and & so the JSON cannot close the script element
+	err := exportJSONLD(doc, &sb)
+	if err != nil {
+		return "", err
+	}
+	sb.WriteString("</script>\n")
+	return sb.String(), nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/piprate/json-gold/ld"
+)
+
+// expand JSON-LD whose @context is https://schema.org/ with the context in test-data/schemas, in safe mode so a
+// term that does not expand to an IRI is an error instead of being dropped
+func expand_schemaorg(t *testing.T, raw []byte) ([]any, error) {
+	t.Helper()
+	context_raw, err := os.ReadFile(filepath.Join("test-data", "schemas", "schemaorg-context.jsonld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var context_doc any
+	if err := json.Unmarshal(context_raw, &context_doc); err != nil {
+		t.Fatal(err)
+	}
+	loader := ld.NewCachingDocumentLoader(nil)
+	loader.AddDocument("https://schema.org/", context_doc)
+
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("the output is not JSON: %v", err)
+	}
+	opts := ld.NewJsonLdOptions("")
+	opts.DocumentLoader = loader
+	opts.SafeMode = true
+	return ld.NewJsonLdProcessor().Expand(doc, opts)
+}
+
+// expand_schemaorg failing the test on an expansion error, returns the expanded nodes
+func expand_test_jsonld(t *testing.T, raw []byte) []any {
+	t.Helper()
+	expanded, err := expand_schemaorg(t, raw)
+	if err != nil {
+		t.Fatalf("JSON-LD expansion: %v", err)
+	}
+	return expanded
+}
+
+// check that every @type of the expanded nodes is a schema.org IRI
+func check_test_expanded_types(t *testing.T, v any) {
+	t.Helper()
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			check_test_expanded_types(t, item)
+		}
+	case map[string]any:
+		for key, value := range v {
+			if key != "@type" {
+				check_test_expanded_types(t, value)
+				continue
+			}
+			for _, typ := range value.([]any) {
+				if !strings.HasPrefix(typ.(string), "http://schema.org/") {
+					t.Errorf("@type %v is not a schema.org type", typ)
+				}
+			}
+		}
+	}
+}
+
+func TestExportJSONLD(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	var buf bytes.Buffer
+	if err := exportJSONLD(doc, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var ds map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &ds); err != nil {
+		t.Fatalf("the output is not JSON: %v", err)
+	}
+	if ds["@context"] != "https://schema.org/" {
+		t.Errorf("@context %v, want https://schema.org/", ds["@context"])
+	}
+	if ds["@type"] != "Dataset" {
+		t.Errorf("@type %v, want Dataset", ds["@type"])
+	}
+	if ds["name"] != doc.Title {
+		t.Errorf("name %v, want %q", ds["name"], doc.Title)
+	}
+	expanded := expand_test_jsonld(t, buf.Bytes())
+	if len(expanded) != 1 {
+		t.Fatalf("%d expanded nodes, want the dataset", len(expanded))
+	}
+	check_test_expanded_types(t, expanded)
+	creators, _ := ds["creator"].([]any)
+	if len(creators) != len(doc.Creator) {
+		t.Fatalf("%d creators, want %d", len(creators), len(doc.Creator))
+	}
+	for _, c := range creators {
+		person := c.(map[string]any)
+		if person["@type"] != "Person" {
+			t.Errorf("creator @type %v, want Person", person["@type"])
+		}
+		sameas, _ := person["sameAs"].([]any)
+		for _, id := range sameas {
+			if !strings.HasPrefix(id.(string), "https://orcid.org/") {
+				t.Errorf("sameAs %v is not an ORCID URL", id)
+			}
+		}
+	}
+}
+
+func TestExportJSONLDExpands(t *testing.T) {
+	for _, name := range []string{"yoda-metadata[douwe].json", "yoda-metadata[blank].json", "yoda-metadata[uu011].json"} {
+		var buf bytes.Buffer
+		if err := exportJSONLD(load_test_metadata(t, name), &buf); err != nil {
+			t.Fatal(err)
+		}
+		node := expand_test_jsonld(t, buf.Bytes())[0].(map[string]any)
+		for _, key := range []string{"http://schema.org/name", "http://schema.org/creator"} {
+			if _, ok := node[key]; !ok && name != "yoda-metadata[blank].json" {
+				t.Errorf("%s: %s missing from the expanded dataset", name, key)
+			}
+		}
+		if license, ok := node["http://schema.org/license"].([]any); ok {
+			if id, _ := license[0].(map[string]any)["@id"].(string); !strings.HasPrefix(id, "https://") {
+				t.Errorf("%s: license %v is not an IRI", name, license)
+			}
+		}
+	}
+	// the safe mode expansion rejects a term schema.org does not have
+	for _, raw := range []string{
+		`{"@context": "https://schema.org/", "@type": "Dataset", "nmae": "typo"}`,
+		`{"@context": "https://schema.org/", "@type": "Dataset", "creator": [{"@type": "Person", "orcid": "0000"}]}`,
+	} {
+		if _, err := expand_schemaorg(t, []byte(raw)); err == nil {
+			t.Errorf("%s expanded, want an error for the unknown term", raw)
+		}
+	}
+}
+
+func TestJSONLDScriptTag(t *testing.T) {
+	doc := parse_test_metadata(t, `{"Title": "Closing </script><script>alert(1)</script> & more"}`)
+	script, err := jsonld_script_tag(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, ok := strings.CutPrefix(script, "<script type=\"application/ld+json\">\n")
+	if !ok {
+		t.Fatalf("no script start tag in %q", script)
+	}
+	body, ok = strings.CutSuffix(body, "</script>\n")
+	if !ok {
+		t.Fatalf("no script end tag in %q", script)
+	}
+	if strings.Contains(body, "<") || strings.Contains(body, ">") {
+		t.Errorf("the embedded JSON can close the script element: %s", body)
+	}
+	var ds JSONLDDataset
+	if err := json.Unmarshal([]byte(body), &ds); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Name != doc.Title {
+		t.Errorf("name %q, want %q", ds.Name, doc.Title)
+	}
+}
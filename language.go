@@ -0,0 +1,116 @@
+/*
+language.go recognising the Language field as an ISO 639 code or English language name, and normalising it
+to the ISO 639-1 code.
+*/
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// the ISO 639 languages, "639-1<TAB>639-3<TAB>639-2/B or -<TAB>names separated by ;" per line
+//
+//go:embed assets/iso-639-languages.tsv
+var iso_language_list string
+
+// the ISO 639-1 code of every code and name in the list, by its lower case form
+var iso_language_keys map[string]string
+var iso_language_once sync.Once
+
+func load_iso_language_keys() map[string]string {
+	iso_language_once.Do(func() {
+		iso_language_keys = make(map[string]string)
+		for _, line := range strings.Split(iso_language_list, "\n") {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			fields := strings.Split(strings.TrimSpace(line), "\t")
+			if len(fields) != 4 {
+				continue
+			}
+			keys := append([]string{fields[0], fields[1], fields[2]}, strings.Split(fields[3], ";")...)
+			for _, key := range keys {
+				if key != "-" {
+					iso_language_keys[strings.ToLower(key)] = fields[0]
+				}
+			}
+		}
+	})
+	return iso_language_keys
+}
+
+// NormalizeLanguage returns the ISO 639-1 code of a language given as ISO 639-1, 639-2 or 639-3 code, as English
+// name, as BCP 47 tag such as "en-GB" or in the Yoda form "en - English"
+func NormalizeLanguage(language string) (string, error) {
+	keys := load_iso_language_keys()
+	language = strings.TrimSpace(language)
+	if language == "" {
+		return "", fmt.Errorf("no language specified")
+	}
+
+	candidates := []string{language}
+	if code, name, ok := strings.Cut(language, " - "); ok {
+		candidates = append(candidates, code, name)
+	}
+	// the primary language subtag of a tag such as en-GB or en_GB
+	if primary, _, ok := strings.Cut(strings.ReplaceAll(language, "_", "-"), "-"); ok {
+		candidates = append(candidates, primary)
+	}
+	for _, candidate := range candidates {
+		if code, ok := keys[strings.ToLower(strings.TrimSpace(candidate))]; ok {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognised language: %q", language)
+}
+
+// the ISO 639-1 code of a language if it can be recognised, otherwise the language as given
+func language_code(language string) string {
+	code, err := NormalizeLanguage(language)
+	if err != nil {
+		return language
+	}
+	return code
+}
+
+// the ISO 639-2/B code of a language if it can be recognised, otherwise the language as given
+func language_code_639_2(language string) string {
+	code, err := NormalizeLanguage(language)
+	if err != nil {
+		return language
+	}
+	for _, line := range strings.Split(iso_language_list, "\n") {
+		fields := strings.Split(strings.TrimSpace(line), "\t")
+		if len(fields) != 4 || fields[0] != code {
+			continue
+		}
+		if fields[2] != "-" {
+			return fields[2]
+		}
+		return fields[1]
+	}
+	return code
+}
+
+// warn if the Language is set but not recognised
+func report_language(doc Yoda18Metadata, fname string) {
+	if doc.Language == "" {
+		return
+	}
+	if _, err := NormalizeLanguage(doc.Language); err != nil {
+		slog.Warn("unknown Language, expected an ISO 639 code or English language name", "file", fname, "field_path", "Language", "value", doc.Language)
+	}
+}
+
+// the -normalize step: the whitespace of every text is cleaned up and the values with a canonical form are
+// replaced by it, for now the Language by its ISO 639-1 code
+func normalize_metadata(doc Yoda18Metadata) Yoda18Metadata {
+	doc = NormalizeWhitespace(doc)
+	doc.Language = language_code(doc.Language)
+	return doc
+}
@@ -0,0 +1,186 @@
+/*
+latex.go renders Yoda metadata as a standalone LaTeX article, or a body fragment to \input into a data paper.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// escapes the LaTeX special characters, done in a single pass so replacements are not escaped again
+var latex_escaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	`&`, `\&`,
+	`%`, `\%`,
+	`$`, `\$`,
+	`#`, `\#`,
+	`_`, `\_`,
+	`{`, `\{`,
+	`}`, `\}`,
+	`~`, `\textasciitilde{}`,
+	`^`, `\textasciicircum{}`,
+)
+
+// escape a string for use in LaTeX text
+func latex_escape(s string) string {
+	return latex_escaper.Replace(s)
+}
+
+// a URL in a metadata value, trailing punctuation of the sentence is not part of it
+var latex_url_pattern = regexp.MustCompile(`https?://[^\s<>"]*[^\s<>".,;:!?)\]'}]`)
+
+// characters that cannot appear inside \url{} in a table cell are percent encoded, which leaves the URL unchanged,
+// the remaining specials are escaped the way hyperref expects
+var latex_url_escaper = strings.NewReplacer(
+	`\`, `\%5C`,
+	`{`, `\%7B`,
+	`}`, `\%7D`,
+	`~`, `\%7E`,
+	`^`, `\%5E`,
+	`%`, `\%`,
+	`#`, `\#`,
+	`&`, `\&`,
+)
+
+// escape a value for LaTeX with the URLs in it wrapped in \url{}
+func latex_escape_text(s string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range latex_url_pattern.FindAllStringIndex(s, -1) {
+		sb.WriteString(latex_escape(s[last:loc[0]]))
+		sb.WriteString(`\url{` + latex_url_escaper.Replace(s[loc[0]:loc[1]]) + `}`)
+		last = loc[1]
+	}
+	sb.WriteString(latex_escape(s[last:]))
+	return sb.String()
+}
+
+// the description as LaTeX paragraphs, a blank line in the source starts a new paragraph
+func latex_paragraphs(s string) string {
+	var paragraphs []string
+	for _, paragraph := range paragraph_break.Split(strings.ReplaceAll(s, "\r\n", "\n"), -1) {
+		if lines := strings.Fields(paragraph); len(lines) > 0 {
+			paragraphs = append(paragraphs, latex_escape_text(strings.Join(lines, " ")))
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// the \keywords command and metadata environment of the article, \providecommand and \ifdefined leave the ones of
+// an including document alone; heading is the sectioning command of the metadata table
+func latex_definitions(heading string) string {
+	return "\\providecommand{\\keywords}[1]{\\par\\noindent\\textbf{Keywords:} #1\\par}\n" +
+		"\\ifdefined\\metadata\\else\\newenvironment{metadata}{\\" + heading + "*{Metadata}}{}\\fi\n"
+}
+
+// RenderLaTeX creates a standalone LaTeX article with the title, authors, description, keywords and a table of
+// the scalar fields, with -latex-fragment only the body is written for \input into an existing document
+func RenderLaTeX(doc Yoda18Metadata) ([]byte, error) {
+	var buf bytes.Buffer
+
+	var authors []string
+	for i := range doc.Creator {
+		authors = append(authors, latex_escape(formatName(doc.Creator[i].Name, *name_style)))
+	}
+
+	if *latex_fragment {
+		// the including document needs \usepackage{tabularx} and \usepackage{hyperref}
+		buf.WriteString(latex_definitions("subsection") + "\n")
+		fmt.Fprintf(&buf, "\\section*{%s}\n", latex_escape(doc.Title))
+		if len(authors) > 0 {
+			fmt.Fprintf(&buf, "\\noindent %s\\par\\medskip\n", strings.Join(authors, ", "))
+		}
+		buf.WriteString("\n")
+	} else {
+		buf.WriteString("\\documentclass{article}\n\n")
+		buf.WriteString("\\usepackage[T1]{fontenc}\n")
+		buf.WriteString("\\usepackage[utf8]{inputenc}\n")
+		buf.WriteString("\\usepackage{tabularx}\n")
+		buf.WriteString("\\usepackage{hyperref}\n\n")
+		buf.WriteString(latex_definitions("section") + "\n")
+
+		fmt.Fprintf(&buf, "\\title{%s}\n", latex_escape(doc.Title))
+		fmt.Fprintf(&buf, "\\author{%s}\n", strings.Join(authors, " \\and "))
+		if doc.Collected.EndDate != "" {
+			fmt.Fprintf(&buf, "\\date{%s}\n", latex_escape(doc.Collected.EndDate))
+		} else {
+			buf.WriteString("\\date{\\today}\n")
+		}
+		buf.WriteString("\n\\begin{document}\n\\maketitle\n\n")
+	}
+
+	if description := latex_paragraphs(doc.Description); description != "" {
+		fmt.Fprintf(&buf, "%s\n\n", description)
+	}
+
+	var tags []string
+	for i := range doc.Tag {
+		tags = append(tags, latex_escape(doc.Tag[i]))
+	}
+	if len(tags) > 0 {
+		fmt.Fprintf(&buf, "\\keywords{%s}\n\n", strings.Join(tags, ", "))
+	}
+
+	// without a retention period the row is left out rather than showing 0 years
+	retention := ""
+	if doc.RetentionPeriod > 0 {
+		retention = fmt.Sprintf("%d years", doc.RetentionPeriod)
+	}
+
+	rows := [][2]string{
+		{"Version", doc.Version},
+		{"Language", doc.Language},
+		{"Data Type", doc.DataType},
+		{"Licence", canonical_license(doc.License)},
+		{"Data Classification", doc.DataClassification},
+		{"Data Access Restriction", doc.DataAccessRestriction},
+		{"Collected", date_range(doc.Collected.StartDate, doc.Collected.EndDate)},
+		{"Covered Period", date_range(doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate)},
+		{"Retention Period", retention},
+		{"Retention Information", doc.RetentionInformation},
+		{"Embargo End Date", doc.EmbargoEndDate},
+		{"Collection Name", doc.CollectionName},
+		{"Remarks", doc.Remarks},
+	}
+
+	buf.WriteString("\\begin{metadata}\n")
+	buf.WriteString("\\noindent\n\\begin{tabularx}{\\textwidth}{@{}lX@{}}\n")
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+		// a line break inside a cell would end the row
+		fmt.Fprintf(&buf, "\\textbf{%s} & %s \\\\\n", row[0], latex_escape_text(strings.Join(strings.Fields(row[1]), " ")))
+	}
+	buf.WriteString("\\end{tabularx}\n")
+	buf.WriteString("\\end{metadata}\n")
+
+	if !*latex_fragment {
+		buf.WriteString("\n\\end{document}\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+// format a start and end date as a single range, empty if both are missing
+func date_range(start string, end string) string {
+	if start == "" && end == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s to %s", start, end)
+}
+
+// write the LaTeX article or fragment to w
+func write_latex(doc Yoda18Metadata, w io.Writer) error {
+	out, err := RenderLaTeX(doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLatexEscape(t *testing.T) {
+	tests := map[string]string{
+		`R&D`:        `R\&D`,
+		`50%`:        `50\%`,
+		`$5`:         `\$5`,
+		`c#`:         `c\#`,
+		`snake_case`: `snake\_case`,
+		`{x}`:        `\{x\}`,
+		`~home`:      `\textasciitilde{}home`,
+		`x^2`:        `x\textasciicircum{}2`,
+		`back\slash`: `back\textbackslash{}slash`,
+	}
+	for in, want := range tests {
+		if got := latex_escape(in); got != want {
+			t.Errorf("latex_escape(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLatexEscapeText(t *testing.T) {
+	got := latex_escape_text("see https://example.org/a_b?x=1&y=50%25#top, or 100%")
+	want := `see \url{https://example.org/a_b?x=1\&y=50\%25\#top}, or 100\%`
+	if got != want {
+		t.Errorf("latex_escape_text = %q, want %q", got, want)
+	}
+}
+
+func TestRenderLaTeX(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[latex].json")
+	out, err := RenderLaTeX(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check_golden(t, "latex.tex", out)
+
+	text := string(out)
+	for _, want := range []string{`\documentclass{article}`, `\usepackage{tabularx}`, `\keywords{R\&D, 50\%, c\#, snake\_case, \textasciitilde{}home, x\textasciicircum{}2, back\textbackslash{}slash}`, `\begin{metadata}`, `\end{document}`} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Count(text, `\begin{`) != strings.Count(text, `\end{`) {
+		t.Error("unbalanced \\begin and \\end")
+	}
+}
+
+func TestRenderLaTeXFragment(t *testing.T) {
+	set_test_flag(t, "latex-fragment", "true")
+	doc := load_test_metadata(t, "yoda-metadata[latex].json")
+	out, err := RenderLaTeX(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check_golden(t, "latex-fragment.tex", out)
+	text := string(out)
+	for _, preamble := range []string{`\documentclass`, `\begin{document}`, `\end{document}`} {
+		if strings.Contains(text, preamble) {
+			t.Errorf("the fragment contains %s", preamble)
+		}
+	}
+	if !strings.Contains(text, `\keywords{`) || !strings.Contains(text, `\begin{metadata}`) {
+		t.Error("the fragment has no keywords or metadata table")
+	}
+}
+
+func TestRenderLaTeXNoRetentionPeriod(t *testing.T) {
+	var doc Yoda18Metadata
+	doc.Title = "No retention"
+	doc.Version = "1"
+	out, err := RenderLaTeX(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(out), "Retention Period") {
+		t.Errorf("a missing retention period is written:\n%s", out)
+	}
+}
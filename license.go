@@ -0,0 +1,200 @@
+/*
+license.go normalising free text licence strings to SPDX license identifiers.
+*/
+
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// SPDX license list, one "identifier<TAB>full name" per line
+//
+//go:embed assets/spdx-licenses.tsv
+var spdx_license_list string
+
+// aliases that the name matching cannot derive from the SPDX list
+var license_aliases = map[string]string{
+	"cc0":           "CC0-1.0",
+	"cc-zero":       "CC0-1.0",
+	"public-domain": "CC0-1.0",
+	"apache":        "Apache-2.0",
+	"apache-2":      "Apache-2.0",
+	"gpl-3.0":       "GPL-3.0-only",
+	"gpl-2.0":       "GPL-2.0-only",
+	"gnu-gpl-v3":    "GPL-3.0-only",
+	"odbl":          "ODbL-1.0",
+	"odc-by":        "ODC-By-1.0",
+	"pddl":          "PDDL-1.0",
+	"eupl":          "EUPL-1.2",
+	"cc-by-4":       "CC-BY-4.0",
+	"cc-by-sa-4":    "CC-BY-SA-4.0",
+	"cc-by-nc-4":    "CC-BY-NC-4.0",
+	"cc-by-nd-4":    "CC-BY-ND-4.0",
+	"cc-by-nc-sa-4": "CC-BY-NC-SA-4.0",
+	"cc-by-nc-nd-4": "CC-BY-NC-ND-4.0",
+}
+
+// words rewritten to the abbreviations used in SPDX identifiers before matching
+var license_word_replacer = strings.NewReplacer(
+	"creative commons", "cc",
+	"attribution", "by",
+	"share-alike", "sa",
+	"sharealike", "sa",
+	"non-commercial", "nc",
+	"noncommercial", "nc",
+	"no-derivatives", "nd",
+	"noderivatives", "nd",
+	"no derivatives", "nd",
+	"noderivs", "nd",
+)
+
+// words that carry no meaning when matching a licence name
+var license_stop_words = map[string]bool{
+	"international": true,
+	"public":        true,
+	"license":       true,
+	"licence":       true,
+	"generic":       true,
+	"unported":      true,
+	"version":       true,
+	"the":           true,
+}
+
+var license_token_split = regexp.MustCompile(`[^a-z0-9.+]+`)
+var license_parenthesised = regexp.MustCompile(`\(([^()]*)\)`)
+
+// lookup table from a normalised key to the SPDX identifier, built once on first use
+var spdx_license_keys map[string]string
+var spdx_license_keys_once sync.Once
+
+// reduce a licence identifier or name to a comparable key, e.g. "Creative Commons Attribution 4.0" -> "cc-by-4.0"
+func license_key(s string) string {
+	s = license_word_replacer.Replace(strings.ToLower(s))
+	var tokens []string
+	for _, tok := range license_token_split.Split(s, -1) {
+		tok = strings.Trim(tok, ".")
+		if tok == "" || license_stop_words[tok] {
+			continue
+		}
+		// "v4.0" -> "4.0"
+		if len(tok) > 1 && tok[0] == 'v' && tok[1] >= '0' && tok[1] <= '9' {
+			tok = tok[1:]
+		}
+		tokens = append(tokens, tok)
+	}
+	return strings.Join(tokens, "-")
+}
+
+// build the key table from the embedded SPDX list, identifiers take precedence over names
+func load_spdx_license_keys() map[string]string {
+	spdx_license_keys_once.Do(build_spdx_license_keys)
+	return spdx_license_keys
+}
+
+func build_spdx_license_keys() {
+	spdx_license_keys = make(map[string]string)
+
+	var names [][2]string
+	for _, line := range strings.Split(spdx_license_list, "\n") {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.SplitN(line, "\t", 2)
+		spdx_license_keys[strings.ToLower(fields[0])] = fields[0]
+		if key := license_key(fields[0]); spdx_license_keys[key] == "" {
+			spdx_license_keys[key] = fields[0]
+		}
+		if len(fields) == 2 {
+			names = append(names, [2]string{license_key(fields[1]), fields[0]})
+		}
+	}
+	for _, name := range names {
+		if spdx_license_keys[name[0]] == "" {
+			spdx_license_keys[name[0]] = name[1]
+		}
+	}
+	for alias, id := range license_aliases {
+		// also under the key of the alias, "public-domain" is matched as "domain" as "public" is a stop word
+		for _, key := range []string{alias, license_key(alias)} {
+			if spdx_license_keys[key] == "" {
+				spdx_license_keys[key] = id
+			}
+		}
+	}
+}
+
+// NormalizeLicense maps a licence identifier, name or common alias to its canonical SPDX identifier
+func NormalizeLicense(license string) (string, error) {
+	keys := load_spdx_license_keys()
+	license = strings.TrimSpace(license)
+	if license == "" {
+		return "", fmt.Errorf("no licence specified")
+	}
+
+	// try the full string, the string without any "(...)" parts and then the parts themselves
+	candidates := []string{license, license_parenthesised.ReplaceAllString(license, " ")}
+	for _, match := range license_parenthesised.FindAllStringSubmatch(license, -1) {
+		candidates = append(candidates, match[1])
+	}
+
+	for _, candidate := range candidates {
+		if id, ok := keys[strings.ToLower(strings.TrimSpace(candidate))]; ok {
+			return id, nil
+		}
+		if id, ok := keys[license_key(candidate)]; ok {
+			return id, nil
+		}
+	}
+	return "", fmt.Errorf("unrecognised licence: %q", license)
+}
+
+// check that a licence is given and recognised as an SPDX licence, for Validate
+func check_license(license string) error {
+	_, err := NormalizeLicense(license)
+	return err
+}
+
+// the SPDX identifier for a licence if it can be recognised, otherwise the licence as given
+func canonical_license(license string) string {
+	id, err := NormalizeLicense(license)
+	if err != nil {
+		return license
+	}
+	return id
+}
+
+// a resolvable URL for a licence, Creative Commons licences link to creativecommons.org and other
+// SPDX licences to spdx.org, empty if the licence is not recognised
+func license_url(license string) string {
+	id, err := NormalizeLicense(license)
+	if err != nil {
+		return ""
+	}
+	if id == "CC0-1.0" {
+		return "https://creativecommons.org/publicdomain/zero/1.0/"
+	}
+	if strings.HasPrefix(id, "CC-BY") {
+		// CC-BY-NC-SA-4.0 -> https://creativecommons.org/licenses/by-nc-sa/4.0/
+		parts := strings.Split(strings.ToLower(id), "-")
+		last := len(parts) - 1
+		// ported licences such as CC-BY-3.0-NL carry a jurisdiction after the version
+		if !strings.Contains(parts[last], ".") {
+			return "https://creativecommons.org/licenses/" + strings.Join(parts[1:last-1], "-") + "/" + parts[last-1] + "/" + parts[last] + "/"
+		}
+		return "https://creativecommons.org/licenses/" + strings.Join(parts[1:last], "-") + "/" + parts[last] + "/"
+	}
+	return "https://spdx.org/licenses/" + id + ".html"
+}
+
+// the licence URL of a dataset: the License_URI of its System block, otherwise the URL of its licence
+func dataset_license_url(doc Yoda18Metadata, sys System) string {
+	if uri := strings.TrimSpace(sys.LicenseURI); uri != "" {
+		return uri
+	}
+	return license_url(doc.License)
+}
@@ -0,0 +1,79 @@
+package main
+
+import "testing"
+
+func TestNormalizeLicense(t *testing.T) {
+	tests := []struct {
+		license string
+		want    string
+	}{
+		{"CC-BY-4.0", "CC-BY-4.0"},
+		{"cc-by-4.0", "CC-BY-4.0"},
+		{" CC BY 4.0 ", "CC-BY-4.0"},
+		{"Creative Commons Attribution 4.0 International", "CC-BY-4.0"},
+		{"Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International", "CC-BY-NC-SA-4.0"},
+		{"MIT License", "MIT"},
+		// aliases
+		{"CC0", "CC0-1.0"},
+		{"Public Domain", "CC0-1.0"},
+		{"Apache", "Apache-2.0"},
+		{"GPL-3.0", "GPL-3.0-only"},
+		{"ODbL", "ODbL-1.0"},
+		{"cc-by-sa-4", "CC-BY-SA-4.0"},
+		// parenthesised names and identifiers
+		{"Attribution 4.0 (CC-BY-4.0)", "CC-BY-4.0"},
+		{"Open licence (CC0-1.0)", "CC0-1.0"},
+		{"CC-BY-NC-4.0 (Creative Commons Attribution Non Commercial 4.0)", "CC-BY-NC-4.0"},
+		// ported licences
+		{"CC-BY-3.0-NL", "CC-BY-3.0-NL"},
+		{"cc-by-3.0-nl", "CC-BY-3.0-NL"},
+		{"Creative Commons Attribution 3.0 Netherlands", "CC-BY-3.0-NL"},
+		{"CC-BY-NC-SA-3.0-DE", "CC-BY-NC-SA-3.0-DE"},
+		{"", ""},
+		{"Custom", ""},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeLicense(tt.license)
+		if got != tt.want {
+			t.Errorf("NormalizeLicense(%q) = %q, want %q", tt.license, got, tt.want)
+		}
+		if (err != nil) != (tt.want == "") {
+			t.Errorf("NormalizeLicense(%q) error %v", tt.license, err)
+		}
+		if (check_license(tt.license) != nil) != (tt.want == "") {
+			t.Errorf("check_license(%q) does not agree with NormalizeLicense", tt.license)
+		}
+	}
+}
+
+func TestLicenseURL(t *testing.T) {
+	tests := []struct {
+		license string
+		want    string
+	}{
+		{"CC-BY-4.0", "https://creativecommons.org/licenses/by/4.0/"},
+		{"Creative Commons Attribution-NonCommercial-ShareAlike 4.0 International", "https://creativecommons.org/licenses/by-nc-sa/4.0/"},
+		{"CC0", "https://creativecommons.org/publicdomain/zero/1.0/"},
+		{"CC-BY-3.0-NL", "https://creativecommons.org/licenses/by/3.0/nl/"},
+		{"CC-BY-NC-ND-3.0-DE", "https://creativecommons.org/licenses/by-nc-nd/3.0/de/"},
+		{"MIT", "https://spdx.org/licenses/MIT.html"},
+		{"Apache", "https://spdx.org/licenses/Apache-2.0.html"},
+		{"Custom", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := license_url(tt.license); got != tt.want {
+			t.Errorf("license_url(%q) = %q, want %q", tt.license, got, tt.want)
+		}
+	}
+}
+
+func TestDatasetLicenseURL(t *testing.T) {
+	doc := Yoda18Metadata{License: "CC-BY-4.0"}
+	if got := dataset_license_url(doc, System{LicenseURI: "https://example.org/licence"}); got != "https://example.org/licence" {
+		t.Errorf("the License_URI of the System block is not used: %q", got)
+	}
+	if got := dataset_license_url(doc, System{}); got != "https://creativecommons.org/licenses/by/4.0/" {
+		t.Errorf("dataset_license_url without License_URI = %q", got)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+logging.go structured diagnostic logging with log/slog, written to stderr as text or JSON.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// the log level for -log-level, -v and -quiet: an explicit -log-level wins, -v logs every step, -quiet only the
+// errors, otherwise the warnings about the metadata and the errors are logged
+func log_level_from_flags(level string, verbose bool, quiet bool) (string, error) {
+	switch {
+	case level != "":
+		return level, nil
+	case verbose:
+		return "debug", nil
+	case quiet:
+		return "error", nil
+	}
+	return "warn", nil
+}
+
+// install the default slog logger for the -log-level and -log-format flags
+func setup_logging(level string, format string) error {
+	var lvl slog.Level
+	switch strings.ToLower(level) {
+	case "debug":
+		lvl = slog.LevelDebug
+	case "info":
+		lvl = slog.LevelInfo
+	case "warn", "warning":
+		lvl = slog.LevelWarn
+	case "error":
+		lvl = slog.LevelError
+	default:
+		return fmt.Errorf("unknown log level %q, use one of: debug, info, warn, error", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q, use one of: text, json", format)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestLogLevelFromFlags(t *testing.T) {
+	tests := []struct {
+		level   string
+		verbose bool
+		quiet   bool
+		want    string
+	}{
+		{"", false, false, "warn"},
+		{"", true, false, "debug"},
+		{"", false, true, "error"},
+		{"warn", false, true, "warn"},
+		{"info", true, false, "info"},
+	}
+	for _, tt := range tests {
+		got, err := log_level_from_flags(tt.level, tt.verbose, tt.quiet)
+		if err != nil {
+			t.Fatalf("log_level_from_flags(%q, %t, %t): %v", tt.level, tt.verbose, tt.quiet, err)
+		}
+		if got != tt.want {
+			t.Errorf("log_level_from_flags(%q, %t, %t) = %q, want %q", tt.level, tt.verbose, tt.quiet, got, tt.want)
+		}
+	}
+}
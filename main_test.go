@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// go test -update rewrites the golden files in test-data/golden with the current output
+var update_golden = flag.Bool("update", false, "rewrite the golden files")
+
+// the tests log like the command line does without -v, errors only
+func TestMain(m *testing.M) {
+	if err := setup_logging("error", "text"); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// the metadata of a file in test-data, read as the command line reads an input file
+func load_test_metadata(t testing.TB, name string) Yoda18Metadata {
+	t.Helper()
+	doc, err := read_metadata_file(context.Background(), filepath.Join("test-data", name))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return doc
+}
+
+// the metadata of a JSON document written in the test
+func parse_test_metadata(t testing.TB, raw string) Yoda18Metadata {
+	t.Helper()
+	var doc Yoda18Metadata
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("%v in %s", err, raw)
+	}
+	return doc
+}
+
+// compare got with the golden file test-data/golden/name
+func check_golden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	fname := filepath.Join("test-data", "golden", name)
+	if *update_golden {
+		if err := os.MkdirAll(filepath.Dir(fname), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fname, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(fname)
+	if err != nil {
+		t.Fatalf("%v (run go test -update to create it)", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("output differs from %s (go test -update rewrites it):\n%s", fname, got)
+	}
+}
+
+// set a flag for the duration of the test
+func set_test_flag(t testing.TB, name string, value string) {
+	t.Helper()
+	old := flag.Lookup(name).Value.String()
+	if err := flag.Set(name, value); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { flag.Set(name, old) })
+}
+
+// collect the log records of the test, at warning level and above, as text
+func capture_test_log(t testing.TB) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+// check that raw is well-formed XML whose elements are all in one of the namespaces and whose prefixed attributes
+// have a declared namespace, returns the local names of the elements in document order
+func check_xml_namespaces(t *testing.T, raw []byte, namespaces ...string) []string {
+	t.Helper()
+	allowed := map[string]bool{}
+	for _, ns := range namespaces {
+		allowed[ns] = true
+	}
+	var elements []string
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("not well-formed XML: %v", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if !allowed[start.Name.Space] {
+			t.Errorf("element %s in namespace %q", start.Name.Local, start.Name.Space)
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Space != "" && attr.Name.Space != "xmlns" && !strings.Contains(attr.Name.Space, "/") {
+				t.Errorf("attribute %s:%s of %s has no declared namespace", attr.Name.Space, attr.Name.Local, start.Name.Local)
+			}
+		}
+		elements = append(elements, start.Name.Local)
+	}
+	return elements
+}
@@ -0,0 +1,32 @@
+/*
+merge.go overlaying a partial metadata document on a complete one, the Go API counterpart of -patch.
+*/
+
+package main
+
+import (
+	"reflect"
+)
+
+// Merge returns base with every field that is set in patch replaced by the patch value; empty fields of the
+// patch leave base unchanged, nested objects such as Collected are merged field by field and a list that is
+// present in the patch (Creator, Tag, ...) replaces the whole list
+func Merge(base, patch Yoda18MetadataV2) Yoda18MetadataV2 {
+	merge_struct(reflect.ValueOf(&base).Elem(), reflect.ValueOf(patch))
+	return base
+}
+
+// overlay the set fields of patch on the struct dst
+func merge_struct(dst reflect.Value, patch reflect.Value) {
+	for i := 0; i < patch.NumField(); i++ {
+		field := patch.Field(i)
+		if field.IsZero() {
+			continue
+		}
+		if field.Kind() == reflect.Struct {
+			merge_struct(dst.Field(i), field)
+			continue
+		}
+		dst.Field(i).Set(field)
+	}
+}
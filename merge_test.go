@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// a Yoda18MetadataV2 from JSON
+func merge_test_metadata(t *testing.T, raw string) Yoda18MetadataV2 {
+	t.Helper()
+	var doc Yoda18MetadataV2
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatalf("%v in %s", err, raw)
+	}
+	return doc
+}
+
+func TestMerge(t *testing.T) {
+	base := merge_test_metadata(t, `{"Title": "Base title", "Language": "en - English", "Version": "1",
+		"Tag": ["one", "two", "three"],
+		"Collected": {"Start_Date": "2020-01-01", "End_Date": "2020-12-31"},
+		"Creator": [{"Name": {"Given_Name": "Ada", "Family_Name": "Lovelace"}}, {"Name": {"Given_Name": "Alan", "Family_Name": "Turing"}}],
+		"Retention_Period": 10}`)
+	patch := merge_test_metadata(t, `{"Title": "Patched title", "Tag": ["four"],
+		"Collected": {"End_Date": "2021-06-30"},
+		"Creator": [{"Name": {"Given_Name": "Grace", "Family_Name": "Hopper"}}]}`)
+
+	got := Merge(base, patch)
+	if got.Title != "Patched title" {
+		t.Errorf("Title %q, want the patch value", got.Title)
+	}
+	if got.Language != "en - English" || got.Version != "1" || got.RetentionPeriod != 10 {
+		t.Errorf("fields missing from the patch changed: %q %q %d", got.Language, got.Version, got.RetentionPeriod)
+	}
+	if !slices.Equal(got.Tag, StringOrSlice{"four"}) {
+		t.Errorf("Tag %v, want the patch list replacing the base list", got.Tag)
+	}
+	if len(got.Creator) != 1 || got.Creator[0].Name.FamilyName != "Hopper" {
+		t.Errorf("Creator %+v, want the patch list replacing the base list", got.Creator)
+	}
+	if got.Collected.StartDate != "2020-01-01" || got.Collected.EndDate != "2021-06-30" {
+		t.Errorf("Collected %+v, want the start date kept and the end date patched", got.Collected)
+	}
+	if len(base.Tag) != 3 || base.Title != "Base title" {
+		t.Errorf("base changed by Merge: %q %v", base.Title, base.Tag)
+	}
+}
+
+func TestMergeEmptyPatch(t *testing.T) {
+	base := merge_test_metadata(t, `{"Title": "Base title", "Tag": ["one"], "Collected": {"Start_Date": "2020-01-01"}}`)
+	got := Merge(base, Yoda18MetadataV2{})
+	want, _ := json.Marshal(base)
+	have, _ := json.Marshal(got)
+	if string(have) != string(want) {
+		t.Errorf("an empty patch gives %s, want %s", have, want)
+	}
+	// an empty list present in the patch replaces the base list too, so a patch can clear a list
+	if got := Merge(base, merge_test_metadata(t, `{"Tag": []}`)); len(got.Tag) != 0 {
+		t.Errorf("Tag %v after an empty patch list, want it cleared", got.Tag)
+	}
+}
@@ -0,0 +1,73 @@
+/*
+mermaid.go draws the related datapackages of one or more datasets as a Mermaid flowchart, which GitHub and
+GitLab render in a ```mermaid fenced code block.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// a node id with only letters and digits, Mermaid reads other characters as syntax
+func mermaid_id(id string) string {
+	var sb strings.Builder
+	for _, r := range id {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// a Mermaid quoted label, quotes become entity codes and line breaks <br/>
+func mermaid_quote(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	s = strings.ReplaceAll(s, "\r\n", "<br/>")
+	return `"` + strings.ReplaceAll(s, "\n", "<br/>") + `"`
+}
+
+// RenderMermaid returns a graph LR flowchart with a node per dataset, coloured by its Data_Access_Restriction,
+// and an edge, labelled with the Relation_Type, to each of its related datapackages; related datapackages
+// that are not one of the datasets are dashed; a dataset is keyed by the DOI in its metadata
+func RenderMermaid(docs []Yoda18Metadata) ([]byte, error) {
+	return RenderMermaidWithDOIs(docs, metadata_dois(docs))
+}
+
+// RenderMermaidWithDOIs is RenderMermaid with the DOI of each dataset given, as RenderDOTWithDOIs
+func RenderMermaidWithDOIs(docs []Yoda18Metadata, dois []string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("graph LR\n")
+
+	nodes, edges := relation_graph(docs, dois)
+	for _, n := range nodes {
+		class := "related"
+		if !n.Related {
+			class = access_class(n.Restriction)
+		}
+		fmt.Fprintf(&buf, "  %s[%s]:::%s\n", mermaid_id(n.ID), mermaid_quote(n.Label), class)
+	}
+	for _, e := range edges {
+		fmt.Fprintf(&buf, "  %s -->|%s| %s\n", mermaid_id(e.From), mermaid_quote(e.Relation), mermaid_id(e.To))
+	}
+	// the colours of the DOT graph
+	buf.WriteString("  classDef open fill:#98fb98,stroke:#333\n")
+	buf.WriteString("  classDef restricted fill:#ffd700,stroke:#333\n")
+	buf.WriteString("  classDef closed fill:#f08080,stroke:#333\n")
+	buf.WriteString("  classDef unknown fill:#d3d3d3,stroke:#333\n")
+	buf.WriteString("  classDef related fill:#fff,stroke:#333,stroke-dasharray:5 5\n")
+	return buf.Bytes(), nil
+}
+
+// write the diagram of a single dataset, read from source
+func exportMermaid(doc Yoda18Metadata, source string, w io.Writer) error {
+	out, err := RenderMermaidWithDOIs([]Yoda18Metadata{doc}, []string{dataset_doi(doc, source)})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
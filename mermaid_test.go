@@ -0,0 +1,75 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMermaidID(t *testing.T) {
+	tests := map[string]string{
+		"dataset1":        "dataset1",
+		"related-1":       "related1",
+		"a b;c[d]{e}|f>g": "abcdefg",
+		"node\"\n--> end": "nodeend",
+		"Ünïcode_ñode_2":  "ncodeode2",
+	}
+	for id, want := range tests {
+		if got := mermaid_id(id); got != want {
+			t.Errorf("mermaid_id(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestMermaidQuote(t *testing.T) {
+	if got := mermaid_quote("A \"quoted\"\r\ntitle\nhere"); got != `"A #quot;quoted#quot;<br/>title<br/>here"` {
+		t.Errorf("quoted label %s", got)
+	}
+}
+
+var (
+	mermaid_test_node = regexp.MustCompile(`^  ([A-Za-z0-9]+)\["([^"]*)"\]:::(open|restricted|closed|unknown|related)$`)
+	mermaid_test_edge = regexp.MustCompile(`^  ([A-Za-z0-9]+) -->\|"([^"]*)"\| ([A-Za-z0-9]+)$`)
+	mermaid_test_def  = regexp.MustCompile(`^  classDef [a-z]+ `)
+)
+
+func TestRenderMermaid(t *testing.T) {
+	docs := relation_test_docs(t)
+	docs[0].RelatedDatapackage[3].Title = `External "quoted" dataset` + "\n[draft]"
+	out, err := RenderMermaidWithDOIs(docs, []string{"", "10.1234/second"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+	if lines[0] != "graph LR" {
+		t.Fatalf("first line %q, want graph LR", lines[0])
+	}
+	nodes := map[string]string{}
+	var edges [][]string
+	for _, line := range lines[1:] {
+		if m := mermaid_test_node.FindStringSubmatch(line); m != nil {
+			nodes[m[1]] = m[3]
+		} else if m := mermaid_test_edge.FindStringSubmatch(line); m != nil {
+			edges = append(edges, m[1:])
+		} else if !mermaid_test_def.MatchString(line) {
+			t.Errorf("line %q is not a node, edge or class definition", line)
+		}
+	}
+	want := map[string]string{"dataset1": "open", "dataset2": "closed", "related1": "related"}
+	if len(nodes) != len(want) {
+		t.Errorf("nodes %v, want %v", nodes, want)
+	}
+	for id, class := range want {
+		if nodes[id] != class {
+			t.Errorf("node %s has class %q, want %q", id, nodes[id], class)
+		}
+	}
+	if len(edges) != 3 {
+		t.Errorf("edges %v, want 3", edges)
+	}
+	for _, e := range edges {
+		if nodes[e[0]] == "" || nodes[e[2]] == "" {
+			t.Errorf("edge %v to an undeclared node", e)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+/*
+metrics.go Prometheus metrics of the HTTP server mode.
+*/
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// status label values of yodameta_conversions_total
+const (
+	conversion_ok        string = "ok"
+	conversion_error     string = "error"
+	conversion_cancelled string = "cancelled"
+)
+
+var metric_conversions = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "yodameta_conversions_total",
+	Help: "Number of /convert requests by output format and status.",
+}, []string{"format", "status"})
+
+var metric_conversion_duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "yodameta_conversion_duration_seconds",
+	Help:    "Time taken to convert a metadata document by output format.",
+	Buckets: prometheus.DefBuckets,
+}, []string{"format"})
+
+var metric_validation_errors = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "yodameta_validation_errors_total",
+	Help: "Number of problems found by /validate by severity.",
+}, []string{"severity"})
+
+func init() {
+	prometheus.MustRegister(metric_conversions, metric_conversion_duration, metric_validation_errors)
+}
+
+// count a conversion, the duration is only observed for conversions that were attempted
+func record_conversion(format string, status string, duration time.Duration) {
+	metric_conversions.WithLabelValues(format, status).Inc()
+	if duration > 0 {
+		metric_conversion_duration.WithLabelValues(format).Observe(duration.Seconds())
+	}
+}
+
+// count the problems of a /validate result
+func record_validation(result ValidationResult) {
+	for _, e := range result.Errors {
+		metric_validation_errors.WithLabelValues(e.Severity).Inc()
+	}
+}
+
+// the /metrics endpoint for Prometheus scraping
+func metrics_handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.Handler())
+	return mux
+}
@@ -0,0 +1,269 @@
+/*
+mods.go exports Yoda metadata as a MODS 3.7 XML record, for ingest into a library catalogue.
+*/
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+// a MARC relator term, https://id.loc.gov/vocabulary/relators
+type MARCRelator struct {
+	Code string
+	Term string
+}
+
+// MARCRelators maps the Yoda (DataCite) Contributor_Types to MARC relators, types without a close relator are
+// left out and get a text role term only; Creator is the role of the creators
+var MARCRelators = map[string]MARCRelator{
+	"Creator":            {"cre", "creator"},
+	"DataCollector":      {"col", "collector"},
+	"DataCurator":        {"cur", "curator"},
+	"DataManager":        {"dtm", "data manager"},
+	"Distributor":        {"dst", "distributor"},
+	"Editor":             {"edt", "editor"},
+	"HostingInstitution": {"his", "host institution"},
+	"Producer":           {"pro", "producer"},
+	"ProjectLeader":      {"pdr", "project director"},
+	"ProjectMember":      {"ctb", "contributor"},
+	"RelatedPerson":      {"asn", "associated name"},
+	"Researcher":         {"res", "researcher"},
+	"RightsHolder":       {"cph", "copyright holder"},
+	"Sponsor":            {"spn", "sponsor"},
+	"Supervisor":         {"dgs", "degree supervisor"},
+	"Other":              {"ctb", "contributor"},
+}
+
+// the MODS relatedItem type of the DataCite relation types that have one
+var mods_related_types = map[string]string{
+	"Continues":           "preceding",
+	"IsContinuedBy":       "succeeding",
+	"IsPartOf":            "host",
+	"HasPart":             "constituent",
+	"IsReferencedBy":      "isReferencedBy",
+	"IsCitedBy":           "isReferencedBy",
+	"References":          "references",
+	"Cites":               "references",
+	"HasVersion":          "otherVersion",
+	"IsVersionOf":         "otherVersion",
+	"IsNewVersionOf":      "otherVersion",
+	"IsPreviousVersionOf": "otherVersion",
+	"IsVariantFormOf":     "otherFormat",
+	"IsOriginalFormOf":    "otherFormat",
+	"IsDerivedFrom":       "original",
+}
+
+const (
+	mods_namespace       string = "http://www.loc.gov/mods/v3"
+	mods_schema_location string = "http://www.loc.gov/mods/v3 http://www.loc.gov/standards/mods/v3/mods-3-7.xsd"
+	xlink_namespace      string = "http://www.w3.org/1999/xlink"
+	xsi_namespace        string = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// a MODS text element with its optional attributes
+type MODSText struct {
+	Type      string `xml:"type,attr,omitempty"`
+	Authority string `xml:"authority,attr,omitempty"`
+	Encoding  string `xml:"encoding,attr,omitempty"`
+	Point     string `xml:"point,attr,omitempty"`
+	Value     string `xml:",chardata"`
+}
+
+// MODS titleInfo
+type MODSTitleInfo struct {
+	Title string `xml:"title"`
+}
+
+// MODS role, a relator code and term
+type MODSRole struct {
+	RoleTerms []MODSText `xml:"roleTerm"`
+}
+
+// MODS name of a person
+type MODSName struct {
+	Type           string     `xml:"type,attr"`
+	NameParts      []MODSText `xml:"namePart"`
+	Affiliations   []string   `xml:"affiliation"`
+	Role           MODSRole   `xml:"role"`
+	NameIdentifier []MODSText `xml:"nameIdentifier"`
+}
+
+// MODS originInfo, the collection period and version
+type MODSOriginInfo struct {
+	DateCreated []MODSText `xml:"dateCreated"`
+	Edition     string     `xml:"edition,omitempty"`
+}
+
+// MODS language
+type MODSLanguage struct {
+	LanguageTerm MODSText `xml:"languageTerm"`
+}
+
+// MODS subject holding a keyword
+type MODSSubject struct {
+	Topic string `xml:"topic"`
+}
+
+// MODS accessCondition, the licence or the access restriction
+type MODSAccessCondition struct {
+	Type  string `xml:"type,attr"`
+	Href  string `xml:"xlink:href,attr,omitempty"`
+	Value string `xml:",chardata"`
+}
+
+// MODS relatedItem, the type is left out when the relation has no MODS counterpart
+type MODSRelatedItem struct {
+	Type         string         `xml:"type,attr,omitempty"`
+	DisplayLabel string         `xml:"displayLabel,attr,omitempty"`
+	TitleInfo    *MODSTitleInfo `xml:"titleInfo"`
+	Identifier   *MODSText      `xml:"identifier"`
+}
+
+// the MODS record, the top level elements of MODS may come in any order
+type MODS struct {
+	XMLName          xml.Name              `xml:"mods"`
+	Xmlns            string                `xml:"xmlns,attr"`
+	XmlnsXlink       string                `xml:"xmlns:xlink,attr"`
+	XmlnsXsi         string                `xml:"xmlns:xsi,attr"`
+	SchemaLocation   string                `xml:"xsi:schemaLocation,attr"`
+	Version          string                `xml:"version,attr"`
+	TitleInfo        MODSTitleInfo         `xml:"titleInfo"`
+	Names            []MODSName            `xml:"name"`
+	TypeOfResource   string                `xml:"typeOfResource"`
+	Genre            string                `xml:"genre"`
+	OriginInfo       *MODSOriginInfo       `xml:"originInfo"`
+	Language         *MODSLanguage         `xml:"language"`
+	Abstract         string                `xml:"abstract,omitempty"`
+	Subjects         []MODSSubject         `xml:"subject"`
+	RelatedItems     []MODSRelatedItem     `xml:"relatedItem"`
+	AccessConditions []MODSAccessCondition `xml:"accessCondition"`
+}
+
+// the role terms of a Contributor_Type, the relator code and term if there is one, otherwise the type as text
+func mods_role(contributor_type string) MODSRole {
+	if relator, ok := MARCRelators[contributor_type]; ok {
+		return MODSRole{RoleTerms: []MODSText{
+			{Type: "code", Authority: "marcrelator", Value: relator.Code},
+			{Type: "text", Authority: "marcrelator", Value: relator.Term},
+		}}
+	}
+	return MODSRole{RoleTerms: []MODSText{{Type: "text", Value: contributor_group(contributor_type)}}}
+}
+
+// a personal name with its role and ORCID
+func mods_name(name NameStruct, affiliations []string, scheme_ids [][2]string, role string) MODSName {
+	n := MODSName{Type: "personal", Role: mods_role(role)}
+	if given := strings.TrimSpace(name.GivenName); given != "" {
+		n.NameParts = append(n.NameParts, MODSText{Type: "given", Value: given})
+	}
+	if family := strings.TrimSpace(name.FamilyName); family != "" {
+		n.NameParts = append(n.NameParts, MODSText{Type: "family", Value: family})
+	}
+	for _, aff := range affiliations {
+		if aff != "" {
+			n.Affiliations = append(n.Affiliations, aff)
+		}
+	}
+	if orcid := xlsx_orcid(scheme_ids); orcid != "" {
+		n.NameIdentifier = append(n.NameIdentifier, MODSText{Type: "orcid", Value: orcid_url(orcid)})
+	}
+	return n
+}
+
+// map the metadata to a MODS record
+func mods_record(doc Yoda18Metadata) MODS {
+	m := MODS{
+		Xmlns:          mods_namespace,
+		XmlnsXlink:     xlink_namespace,
+		XmlnsXsi:       xsi_namespace,
+		SchemaLocation: mods_schema_location,
+		Version:        "3.7",
+		TitleInfo:      MODSTitleInfo{Title: doc.Title},
+		TypeOfResource: "software, multimedia",
+		Genre:          "dataset",
+		Abstract:       doc.Description,
+	}
+
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		m.Names = append(m.Names, mods_name(cre.Name, cre.Affiliation, ids, "Creator"))
+	}
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		m.Names = append(m.Names, mods_name(con.Name, con.Affiliation, ids, con.ContributorType))
+	}
+
+	origin := MODSOriginInfo{Edition: doc.Version}
+	if doc.Collected.StartDate != "" {
+		origin.DateCreated = append(origin.DateCreated, MODSText{Encoding: "w3cdtf", Point: "start", Value: doc.Collected.StartDate})
+	}
+	if doc.Collected.EndDate != "" {
+		origin.DateCreated = append(origin.DateCreated, MODSText{Encoding: "w3cdtf", Point: "end", Value: doc.Collected.EndDate})
+	}
+	if len(origin.DateCreated) > 0 || origin.Edition != "" {
+		m.OriginInfo = &origin
+	}
+
+	if code := language_code(doc.Language); code != "" {
+		m.Language = &MODSLanguage{LanguageTerm: MODSText{Type: "code", Authority: "rfc5646", Value: code}}
+	}
+
+	for _, tag := range doc.Tag {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			m.Subjects = append(m.Subjects, MODSSubject{Topic: tag})
+		}
+	}
+
+	for _, rel := range doc.RelatedDatapackage {
+		pid := rel.PersistentIdentifier
+		if rel.Title == "" && pid.Identifier == "" {
+			continue
+		}
+		relation := datacite_relation_type(rel.RelationType)
+		item := MODSRelatedItem{Type: mods_related_types[relation], DisplayLabel: relation}
+		if rel.Title != "" {
+			item.TitleInfo = &MODSTitleInfo{Title: rel.Title}
+		}
+		if pid.Identifier != "" {
+			item.Identifier = &MODSText{Type: strings.ToLower(pid.IdentifierScheme), Value: pid_link(pid.IdentifierScheme, pid.Identifier)}
+		}
+		m.RelatedItems = append(m.RelatedItems, item)
+	}
+
+	if doc.License != "" {
+		m.AccessConditions = append(m.AccessConditions, MODSAccessCondition{
+			Type:  "use and reproduction",
+			Href:  license_url(doc.License),
+			Value: canonical_license(doc.License),
+		})
+	}
+	if doc.DataAccessRestriction != "" {
+		m.AccessConditions = append(m.AccessConditions, MODSAccessCondition{Type: "restriction on access", Value: doc.DataAccessRestriction})
+	}
+	return m
+}
+
+// exportMODS writes the metadata as a MODS 3.7 XML record to w
+func exportMODS(doc Yoda18Metadata, w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(mods_record(doc))
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+// the MODS record as a catalogue reads it, every element in the MODS namespace
+type mods_test_record struct {
+	XMLName   xml.Name `xml:"http://www.loc.gov/mods/v3 mods"`
+	Version   string   `xml:"version,attr"`
+	TitleInfo []struct {
+		Title string `xml:"http://www.loc.gov/mods/v3 title"`
+	} `xml:"http://www.loc.gov/mods/v3 titleInfo"`
+	Names []struct {
+		Type      string `xml:"type,attr"`
+		NameParts []struct {
+			Type  string `xml:"type,attr"`
+			Value string `xml:",chardata"`
+		} `xml:"http://www.loc.gov/mods/v3 namePart"`
+		RoleTerms []struct {
+			Type      string `xml:"type,attr"`
+			Authority string `xml:"authority,attr"`
+			Value     string `xml:",chardata"`
+		} `xml:"http://www.loc.gov/mods/v3 role>roleTerm"`
+		NameIdentifier []string `xml:"http://www.loc.gov/mods/v3 nameIdentifier"`
+	} `xml:"http://www.loc.gov/mods/v3 name"`
+	TypeOfResource string `xml:"http://www.loc.gov/mods/v3 typeOfResource"`
+	Genre          string `xml:"http://www.loc.gov/mods/v3 genre"`
+	DateCreated    []struct {
+		Point string `xml:"point,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"http://www.loc.gov/mods/v3 originInfo>dateCreated"`
+	Language         string   `xml:"http://www.loc.gov/mods/v3 language>languageTerm"`
+	Topics           []string `xml:"http://www.loc.gov/mods/v3 subject>topic"`
+	AccessConditions []struct {
+		Type  string `xml:"type,attr"`
+		Href  string `xml:"http://www.w3.org/1999/xlink href,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"http://www.loc.gov/mods/v3 accessCondition"`
+}
+
+func TestExportMODS(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	var buf bytes.Buffer
+	if err := exportMODS(doc, &buf); err != nil {
+		t.Fatal(err)
+	}
+	check_xml_namespaces(t, buf.Bytes(), mods_namespace)
+	check_test_xsd(t, "mods-3-7.xsd", buf.Bytes())
+
+	var m mods_test_record
+	if err := xml.Unmarshal(buf.Bytes(), &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != "3.7" {
+		t.Errorf("version %q, want 3.7", m.Version)
+	}
+	if len(m.TitleInfo) != 1 || m.TitleInfo[0].Title != doc.Title {
+		t.Errorf("titleInfo %v, want %q", m.TitleInfo, doc.Title)
+	}
+	if len(m.Names) != len(doc.Creator)+len(doc.Contributor) {
+		t.Fatalf("%d names, want a name per creator and contributor", len(m.Names))
+	}
+	if role := m.Names[0].RoleTerms; len(role) != 2 || role[0].Type != "code" || role[0].Value != "cre" || role[0].Authority != "marcrelator" {
+		t.Errorf("role of the creator %v, want the marcrelator code cre", role)
+	}
+	leader := m.Names[len(doc.Creator)]
+	if leader.RoleTerms[0].Value != MARCRelators["ProjectLeader"].Code {
+		t.Errorf("role of the project leader %v", leader.RoleTerms)
+	}
+	for _, name := range m.Names {
+		if name.Type != "personal" {
+			t.Errorf("name type %q", name.Type)
+		}
+	}
+	if m.Genre != "dataset" || m.TypeOfResource == "" {
+		t.Errorf("genre %q, typeOfResource %q", m.Genre, m.TypeOfResource)
+	}
+	if m.Language != "en" {
+		t.Errorf("language %q, want en", m.Language)
+	}
+	if len(m.Topics) != len(doc.Tag) {
+		t.Errorf("%d topics, want %d", len(m.Topics), len(doc.Tag))
+	}
+	if len(m.AccessConditions) != 2 {
+		t.Fatalf("accessConditions %v, want the licence and the access restriction", m.AccessConditions)
+	}
+	if lic := m.AccessConditions[0]; lic.Type != "use and reproduction" || lic.Value != "CC-BY-4.0" || lic.Href != "https://creativecommons.org/licenses/by/4.0/" {
+		t.Errorf("licence %+v", lic)
+	}
+}
+
+func TestExportMODSSchema(t *testing.T) {
+	for _, name := range []string{"yoda-metadata.json", "yoda-metadata[blank].json", "yoda-metadata[uu011].json", "yoda-metadata[uu012].json", "yoda-metadata[test].json"} {
+		var buf bytes.Buffer
+		if err := exportMODS(load_test_metadata(t, name), &buf); err != nil {
+			t.Fatal(err)
+		}
+		for _, problem := range test_xsd_problems(t, "mods-3-7.xsd", buf.Bytes()) {
+			t.Errorf("%s: %s", name, problem)
+		}
+	}
+
+	// the schema rejects what a catalogue would
+	var buf bytes.Buffer
+	if err := exportMODS(load_test_metadata(t, "yoda-metadata[douwe].json"), &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, edit := range [][2]string{
+		{`<genre>dataset</genre>`, `<kind>dataset</kind>`},
+		{`<namePart type="given">`, `<namePart type="first">`},
+		{`<typeOfResource>software, multimedia</typeOfResource>`, `<typeOfResource>dataset</typeOfResource>`},
+		{`point="start"`, `point="begin"`},
+		{`<relatedItem displayLabel=`, `<relatedItem type="supplement" displayLabel=`},
+		{`<role>`, `<role><affiliation>VU</affiliation>`},
+	} {
+		raw := bytes.Replace(buf.Bytes(), []byte(edit[0]), []byte(edit[1]), 1)
+		if len(test_xsd_problems(t, "mods-3-7.xsd", raw)) == 0 {
+			t.Errorf("the record with %s is valid, want a schema error", edit[1])
+		}
+	}
+}
+
+func TestMODSRole(t *testing.T) {
+	if role := mods_role("DataManager"); role.RoleTerms[0].Value != "dtm" || role.RoleTerms[1].Value != "data manager" {
+		t.Errorf("DataManager role %v", role)
+	}
+	if role := mods_role("WorkPackageLeader"); len(role.RoleTerms) != 1 || role.RoleTerms[0].Type != "text" || role.RoleTerms[0].Authority != "" {
+		t.Errorf("a type without relator gets %v, want a text role term only", role)
+	}
+	for yoda, relator := range MARCRelators {
+		if len(relator.Code) != 3 || relator.Term == "" {
+			t.Errorf("relator of %s: %+v", yoda, relator)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+/*
+names.go formatting person names consistently across the output formats.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the supported name styles
+var name_styles = []string{"full", "citation"}
+
+// check that a -name-style value is supported
+func check_name_style(style string) error {
+	for _, s := range name_styles {
+		if style == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown name style %q, use one of: %s", style, strings.Join(name_styles, ", "))
+}
+
+// format a person name, "full" gives "Given Family" and "citation" gives "Family, Given",
+// a missing part is left out without leaving a stray separator
+func formatName(n NameStruct, style string) string {
+	given := strings.TrimSpace(n.GivenName)
+	family := strings.TrimSpace(n.FamilyName)
+
+	if style == "citation" {
+		if given == "" || family == "" {
+			return family + given
+		}
+		return family + ", " + given
+	}
+	return strings.TrimSpace(given + " " + family)
+}
@@ -0,0 +1,123 @@
+/*
+ndjson.go streaming the metadata of a batch of input files as newline delimited JSON, one record per line, for
+jq, DuckDB and other line oriented tools.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// the record of a file that could not be read
+type ndjson_failure struct {
+	SourcePath string `json:"_source_path"`
+	ParseOK    bool   `json:"_parse_ok"`
+	Error      string `json:"_error"`
+}
+
+// the line of one input file: its canonical JSON on a single line, starting with _source_path and _parse_ok,
+// or for a file that could not be read the error; json escapes the newlines in the values so every record
+// stays on its own line
+func ndjson_record(res batch_result) ([]byte, error) {
+	if res.Err != nil {
+		line, err := json.Marshal(ndjson_failure{SourcePath: res.File, Error: res.Err.Error()})
+		return append(line, '\n'), err
+	}
+	doc, err := CanonicalJSON(res.Data)
+	if err != nil {
+		return nil, err
+	}
+	var compact bytes.Buffer
+	err = json.Compact(&compact, doc)
+	if err != nil {
+		return nil, err
+	}
+	source, err := json.Marshal(res.File)
+	if err != nil {
+		return nil, err
+	}
+
+	var line bytes.Buffer
+	line.WriteString(`{"_source_path":`)
+	line.Write(source)
+	line.WriteString(`,"_parse_ok":true`)
+	// the metadata fields follow in their canonical order
+	if fields := bytes.TrimPrefix(compact.Bytes(), []byte("{")); !bytes.Equal(fields, []byte("}")) {
+		line.WriteString(",")
+		line.Write(fields)
+	} else {
+		line.WriteString("}")
+	}
+	line.WriteString("\n")
+	return line.Bytes(), nil
+}
+
+// write a line per input file to w as soon as the file is read, in the order the workers finish them (input
+// order with -workers 1), and the counts to stderr at the end; a file that cannot be read gets a line with
+// _parse_ok false and the error instead of stopping the run
+func write_ndjson_stream(ctx context.Context, w io.Writer, args []string, workers int) error {
+	fnames, err := metadata_input_files(args)
+	if err != nil {
+		return err
+	}
+
+	parsed, failed := 0, 0
+	var write_err error
+	err = run_batch_each(ctx, fnames, workers, read_metadata_file, func(_ int, res batch_result) {
+		// files a cancelled run did not get to have nothing to report
+		if write_err != nil || errors.Is(res.Err, context.Canceled) {
+			return
+		}
+		line, err := ndjson_record(res)
+		if err == nil {
+			_, err = w.Write(line)
+		}
+		if err != nil {
+			write_err = fmt.Errorf("%s: %w", res.File, err)
+			return
+		}
+		if res.Err != nil {
+			slog.Error("cannot read metadata", "file", res.File, "error", res.Err)
+			failed++
+		} else {
+			parsed++
+		}
+	})
+	fmt.Fprintf(os.Stderr, "ndjson: %d files, %d parsed, %d failed\n", len(fnames), parsed, failed)
+	if write_err != nil {
+		return write_err
+	}
+	return err
+}
+
+// write the NDJSON of the input files to outname, or to stdout when it is empty
+func write_ndjson(ctx context.Context, args []string, outname string, workers int) error {
+	if outname == "" {
+		return write_ndjson_stream(ctx, os.Stdout, args, workers)
+	}
+	err := os.MkdirAll(filepath.Dir(outname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(outname)
+	if err != nil {
+		return err
+	}
+	err = write_ndjson_stream(ctx, f, args, workers)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		slog.Info("output written", "file", outname)
+	}
+	return err
+}
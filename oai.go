@@ -0,0 +1,345 @@
+/*
+oai.go exports Yoda metadata as an OAI-PMH record holding Dublin Core (oai_dc), and several records as a
+ListRecords response, for serving the metadata from a static OAI-PMH endpoint.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	oai_namespace          string = "http://www.openarchives.org/OAI/2.0/"
+	oai_schema_location    string = "http://www.openarchives.org/OAI/2.0/ http://www.openarchives.org/OAI/2.0/OAI-PMH.xsd"
+	oai_dc_namespace       string = "http://www.openarchives.org/OAI/2.0/oai_dc/"
+	oai_dc_schema_location string = "http://www.openarchives.org/OAI/2.0/oai_dc/ http://www.openarchives.org/OAI/2.0/oai_dc.xsd"
+	dc_namespace           string = "http://purl.org/dc/elements/1.1/"
+)
+
+// the ListRecords response filename used when -output is not given
+const oai_list_default_name string = "oai-records.xml"
+
+// the OAI-PMH datestamp granularity, seconds in UTC
+const oai_datestamp_layout string = "2006-01-02T15:04:05Z"
+
+// the layouts a System Last_Modified_Date is read in, a number is taken as Unix time
+var oai_date_layouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02 15:04:05", "2006-01-02"}
+
+var unix_time_pattern = regexp.MustCompile(`^[0-9]{9,}$`)
+
+// the unqualified Dublin Core record
+type OAIDC struct {
+	XMLName        xml.Name `xml:"oai_dc:dc"`
+	XmlnsOAIDC     string   `xml:"xmlns:oai_dc,attr"`
+	XmlnsDC        string   `xml:"xmlns:dc,attr"`
+	XmlnsXsi       string   `xml:"xmlns:xsi,attr"`
+	SchemaLocation string   `xml:"xsi:schemaLocation,attr"`
+	Title          []string `xml:"dc:title"`
+	Creator        []string `xml:"dc:creator"`
+	Subject        []string `xml:"dc:subject"`
+	Description    []string `xml:"dc:description"`
+	Contributor    []string `xml:"dc:contributor"`
+	Date           []string `xml:"dc:date"`
+	Type           []string `xml:"dc:type"`
+	Identifier     []string `xml:"dc:identifier"`
+	Language       []string `xml:"dc:language"`
+	Relation       []string `xml:"dc:relation"`
+	Coverage       []string `xml:"dc:coverage"`
+	Rights         []string `xml:"dc:rights"`
+}
+
+// the OAI-PMH record header
+type OAIHeader struct {
+	Identifier string `xml:"identifier"`
+	Datestamp  string `xml:"datestamp"`
+}
+
+// an OAI-PMH record, the namespace is only set on a record written on its own
+type OAIRecord struct {
+	XMLName  xml.Name  `xml:"record"`
+	Xmlns    string    `xml:"xmlns,attr,omitempty"`
+	Header   OAIHeader `xml:"header"`
+	Metadata struct {
+		DC OAIDC
+	} `xml:"metadata"`
+}
+
+// the request echoed in an OAI-PMH response
+type OAIRequest struct {
+	Verb           string `xml:"verb,attr"`
+	MetadataPrefix string `xml:"metadataPrefix,attr"`
+}
+
+// an OAI-PMH ListRecords response
+type OAIListRecords struct {
+	XMLName        xml.Name    `xml:"OAI-PMH"`
+	Xmlns          string      `xml:"xmlns,attr"`
+	XmlnsXsi       string      `xml:"xmlns:xsi,attr"`
+	SchemaLocation string      `xml:"xsi:schemaLocation,attr"`
+	ResponseDate   string      `xml:"responseDate"`
+	Request        OAIRequest  `xml:"request"`
+	Records        []OAIRecord `xml:"ListRecords>record"`
+}
+
+// the System block of a vault (combi) export, read from the input file itself since the metadata parser drops
+// it; ok is false when the file cannot be read or has no System block
+func oai_source_system(fname string) (System, bool) {
+	var wrapper struct {
+		System *System `json:"System"`
+	}
+	if fname == "" || fname == stdin_name {
+		return System{}, false
+	}
+	raw, err := os.ReadFile(fname)
+	if err != nil {
+		return System{}, false
+	}
+	if bytes.HasPrefix(raw, gzip_magic) {
+		if raw, err = gunzip_bytes(raw); err != nil {
+			return System{}, false
+		}
+	}
+	if json.Unmarshal(raw, &wrapper) != nil || wrapper.System == nil {
+		return System{}, false
+	}
+	return *wrapper.System, true
+}
+
+// the System of the source file if it is a vault export, otherwise the one given with -system and -set
+func oai_system(doc Yoda18Metadata, source string) System {
+	if sys, ok := oai_source_system(source); ok {
+		return sys
+	}
+	sys, err := combi_system(doc, *system_file, set_overrides.values())
+	if err != nil {
+		slog.Warn("cannot read the System block, OAI identifier and datestamp derived without it", "error", err)
+	}
+	return sys
+}
+
+// the record identifier: the given identifier, otherwise derived from the DOI of the data package, otherwise
+// from a hash of the canonical metadata so the same metadata always gets the same identifier
+func oai_identifier(doc Yoda18Metadata, sys System, identifier string) (string, error) {
+	if identifier = strings.TrimSpace(identifier); identifier != "" {
+		return identifier, nil
+	}
+	pid := sys.PersistentIdentifierDatapackage
+	if strings.EqualFold(pid.IdentifierScheme, "DOI") && pid.Identifier != "" {
+		doi := strings.TrimPrefix(pid_link("DOI", pid.Identifier), "https://doi.org/")
+		return "oai:doi.org:" + strings.ToLower(doi), nil
+	}
+	canonical, err := CanonicalJSON(doc)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return "urn:sha256:" + hex.EncodeToString(sum[:16]), nil
+}
+
+// a System Last_Modified_Date as time, ok is false when it is empty or not understood
+func parse_last_modified(value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	if unix_time_pattern.MatchString(value) {
+		seconds, err := strconv.ParseInt(value, 10, 64)
+		return time.Unix(seconds, 0), err == nil
+	}
+	for _, layout := range oai_date_layouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// the record datestamp: the System Last_Modified_Date, otherwise the modification time of the source file,
+// otherwise now
+func oai_datestamp(sys System, source string, now time.Time) string {
+	if t, ok := parse_last_modified(sys.LastModifiedDate); ok {
+		return t.UTC().Format(oai_datestamp_layout)
+	}
+	if sys.LastModifiedDate != "" {
+		slog.Warn("Last_Modified_Date not understood, not used as OAI datestamp", "value", sys.LastModifiedDate)
+	}
+	if source != "" && source != stdin_name {
+		if fi, err := os.Stat(source); err == nil {
+			return fi.ModTime().UTC().Format(oai_datestamp_layout)
+		}
+	}
+	return now.UTC().Format(oai_datestamp_layout)
+}
+
+// append the values that are not blank
+func oai_append(list []string, values ...string) []string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// map the metadata to unqualified Dublin Core
+func oai_dc_record(doc Yoda18Metadata, sys System) OAIDC {
+	dc := OAIDC{
+		XmlnsOAIDC:     oai_dc_namespace,
+		XmlnsDC:        dc_namespace,
+		XmlnsXsi:       xsi_namespace,
+		SchemaLocation: oai_dc_schema_location,
+	}
+	dc.Title = oai_append(dc.Title, doc.Title)
+	for _, cre := range doc.Creator {
+		dc.Creator = oai_append(dc.Creator, formatName(cre.Name, "citation"))
+	}
+	for _, con := range doc.Contributor {
+		dc.Contributor = oai_append(dc.Contributor, formatName(con.Name, "citation"))
+	}
+	dc.Subject = oai_append(dc.Subject, doc.Discipline...)
+	dc.Subject = oai_append(dc.Subject, doc.Tag...)
+	dc.Description = oai_append(dc.Description, doc.Description)
+
+	dc.Date = oai_append(dc.Date, sys.PublicationDate)
+	if doc.Collected.StartDate != "" || doc.Collected.EndDate != "" {
+		dc.Date = append(dc.Date, doc.Collected.StartDate+"/"+doc.Collected.EndDate)
+	}
+	dc.Type = []string{"Dataset"}
+	if _, resource_type := datacite_resource_type(doc.DataType); resource_type != "" && resource_type != "Dataset" {
+		dc.Type = append(dc.Type, resource_type)
+	}
+
+	if pid := sys.PersistentIdentifierDatapackage; pid.Identifier != "" {
+		dc.Identifier = oai_append(dc.Identifier, pid_link(pid.IdentifierScheme, pid.Identifier))
+	}
+	dc.Language = oai_append(dc.Language, language_code(doc.Language))
+	for _, rel := range doc.RelatedDatapackage {
+		if pid := rel.PersistentIdentifier; pid.Identifier != "" {
+			dc.Relation = oai_append(dc.Relation, pid_link(pid.IdentifierScheme, pid.Identifier))
+		}
+	}
+
+	dc.Coverage = oai_append(dc.Coverage, doc.CoveredGeolocationPlace...)
+	if doc.CoveredPeriod.StartDate != "" || doc.CoveredPeriod.EndDate != "" {
+		dc.Coverage = append(dc.Coverage, doc.CoveredPeriod.StartDate+"/"+doc.CoveredPeriod.EndDate)
+	}
+
+	if doc.License != "" {
+		dc.Rights = oai_append(dc.Rights, canonical_license(doc.License))
+		uri := dataset_license_url(doc, sys)
+		if uri != "" && uri != canonical_license(doc.License) {
+			dc.Rights = append(dc.Rights, uri)
+		}
+	}
+	dc.Rights = oai_append(dc.Rights, doc.DataAccessRestriction)
+	return dc
+}
+
+// the OAI-PMH record of the metadata read from source, the input file ("" when there is none)
+func oai_record(doc Yoda18Metadata, source string, now time.Time) (OAIRecord, error) {
+	var rec OAIRecord
+	sys := oai_system(doc, source)
+	identifier, err := oai_identifier(doc, sys, *oai_identifier_flag)
+	if err != nil {
+		return rec, err
+	}
+	rec.Header = OAIHeader{Identifier: identifier, Datestamp: oai_datestamp(sys, source, now)}
+	rec.Metadata.DC = oai_dc_record(doc, sys)
+	return rec, nil
+}
+
+// write an XML document with its declaration
+func write_xml_document(w io.Writer, v interface{}) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(v)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// exportOAI writes the metadata read from source as a single OAI-PMH record to w
+func exportOAI(doc Yoda18Metadata, source string, w io.Writer) error {
+	rec, err := oai_record(doc, source, time.Now())
+	if err != nil {
+		return err
+	}
+	rec.Xmlns = oai_namespace
+	return write_xml_document(w, rec)
+}
+
+// write the records of all input files as one ListRecords response, files that cannot be read are left out
+func write_oai_list_records(ctx context.Context, fnames []string, outname string, workers int) error {
+	if outname == "" {
+		outname = filepath.Join("output", oai_list_default_name)
+		slog.Info("output filename not provided, using default", "file", outname)
+	}
+
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	list := OAIListRecords{
+		Xmlns:          oai_namespace,
+		XmlnsXsi:       xsi_namespace,
+		SchemaLocation: oai_schema_location,
+		ResponseDate:   now.UTC().Format(oai_datestamp_layout),
+		Request:        OAIRequest{Verb: "ListRecords", MetadataPrefix: "oai_dc"},
+	}
+	seen := make(map[string]string)
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("cannot read metadata, skipped", "file", res.File, "error", res.Err)
+			continue
+		}
+		rec, err := oai_record(res.Data, res.File, now)
+		if err != nil {
+			slog.Error("cannot create OAI record, skipped", "file", res.File, "error", err)
+			continue
+		}
+		if other, ok := seen[rec.Header.Identifier]; ok {
+			slog.Warn("duplicate OAI identifier", "file", res.File, "other_file", other, "identifier", rec.Header.Identifier)
+		}
+		seen[rec.Header.Identifier] = res.File
+		list.Records = append(list.Records, rec)
+	}
+
+	err = os.MkdirAll(filepath.Dir(outname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(outname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = write_xml_document(f, list)
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", outname, "format", "oai", "records", len(list.Records))
+	if len(list.Records) < len(fnames) {
+		return fmt.Errorf("%d of %d files failed", len(fnames)-len(list.Records), len(fnames))
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the record as a harvester reads it, the header in the OAI-PMH and the metadata in the Dublin Core namespaces
+type oai_test_record struct {
+	XMLName    xml.Name `xml:"http://www.openarchives.org/OAI/2.0/ record"`
+	Identifier string   `xml:"http://www.openarchives.org/OAI/2.0/ header>identifier"`
+	Datestamp  string   `xml:"http://www.openarchives.org/OAI/2.0/ header>datestamp"`
+	Metadata   struct {
+		DC struct {
+			Title   []string `xml:"http://purl.org/dc/elements/1.1/ title"`
+			Creator []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			Type    []string `xml:"http://purl.org/dc/elements/1.1/ type"`
+			Rights  []string `xml:"http://purl.org/dc/elements/1.1/ rights"`
+		} `xml:"http://www.openarchives.org/OAI/2.0/oai_dc/ dc"`
+	} `xml:"http://www.openarchives.org/OAI/2.0/ metadata"`
+}
+
+func TestOAIIdentifier(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	var sys System
+	sys.PersistentIdentifierDatapackage.IdentifierScheme = "DOI"
+	sys.PersistentIdentifierDatapackage.Identifier = "https://doi.org/10.1234/ABC"
+
+	if id, _ := oai_identifier(doc, sys, "  oai:example.org:1 "); id != "oai:example.org:1" {
+		t.Errorf("given identifier %q", id)
+	}
+	if id, _ := oai_identifier(doc, sys, ""); id != "oai:doi.org:10.1234/abc" {
+		t.Errorf("identifier from the DOI %q", id)
+	}
+	hashed, err := oai_identifier(doc, System{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hashed, "urn:sha256:") || len(hashed) != len("urn:sha256:")+32 {
+		t.Errorf("identifier from the metadata %q", hashed)
+	}
+	if again, _ := oai_identifier(doc, System{}, ""); again != hashed {
+		t.Errorf("the same metadata gets identifier %q and %q", hashed, again)
+	}
+	doc.Title += " changed"
+	if other, _ := oai_identifier(doc, System{}, ""); other == hashed {
+		t.Error("other metadata gets the same identifier")
+	}
+}
+
+func TestOAIDatestamp(t *testing.T) {
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("CEST", 2*3600))
+	source := filepath.Join(t.TempDir(), "yoda-metadata.json")
+	if err := os.WriteFile(source, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2023, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(source, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		last_modified string
+		source        string
+		want          string
+	}{
+		{"2022-03-04T05:06:07+01:00", source, "2022-03-04T04:06:07Z"},
+		{"2022-03-04 05:06:07", source, "2022-03-04T05:06:07Z"},
+		{"2022-03-04", source, "2022-03-04T00:00:00Z"},
+		{"1700000000", source, "2023-11-14T22:13:20Z"},
+		{"", source, "2023-01-02T03:04:05Z"},
+		{"yesterday", source, "2023-01-02T03:04:05Z"},
+		{"", filepath.Join(t.TempDir(), "missing.json"), "2024-05-06T05:08:09Z"},
+		{"", stdin_name, "2024-05-06T05:08:09Z"},
+		{"", "", "2024-05-06T05:08:09Z"},
+	}
+	for _, tt := range tests {
+		if got := oai_datestamp(System{LastModifiedDate: tt.last_modified}, tt.source, now); got != tt.want {
+			t.Errorf("datestamp of %q from %q = %q, want %q", tt.last_modified, tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestOAIDatestampWarns(t *testing.T) {
+	log := capture_test_log(t)
+	oai_datestamp(System{LastModifiedDate: "yesterday"}, "", time.Now())
+	if !strings.Contains(log.String(), "Last_Modified_Date not understood") {
+		t.Errorf("no warning about the Last_Modified_Date: %s", log)
+	}
+}
+
+func TestExportOAI(t *testing.T) {
+	source := filepath.Join("test-data", "yoda-metadata[douwe].json")
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	var buf bytes.Buffer
+	if err := exportOAI(doc, source, &buf); err != nil {
+		t.Fatal(err)
+	}
+	check_xml_namespaces(t, buf.Bytes(), oai_namespace, oai_dc_namespace, dc_namespace)
+
+	var rec oai_test_record
+	if err := xml.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(rec.Identifier, "urn:sha256:") {
+		t.Errorf("identifier %q", rec.Identifier)
+	}
+	if _, err := time.Parse(oai_datestamp_layout, rec.Datestamp); err != nil {
+		t.Errorf("datestamp %q is not in the seconds granularity: %v", rec.Datestamp, err)
+	}
+	if len(rec.Metadata.DC.Title) != 1 || rec.Metadata.DC.Title[0] != doc.Title {
+		t.Errorf("dc:title %v", rec.Metadata.DC.Title)
+	}
+	if len(rec.Metadata.DC.Creator) != len(doc.Creator) {
+		t.Errorf("dc:creator %v", rec.Metadata.DC.Creator)
+	}
+	if len(rec.Metadata.DC.Type) == 0 || rec.Metadata.DC.Type[0] != "Dataset" {
+		t.Errorf("dc:type %v", rec.Metadata.DC.Type)
+	}
+	if len(rec.Metadata.DC.Rights) < 2 || rec.Metadata.DC.Rights[0] != "CC-BY-4.0" {
+		t.Errorf("dc:rights %v", rec.Metadata.DC.Rights)
+	}
+}
@@ -0,0 +1,102 @@
+/*
+orcid.go checking that the ORCID iDs of creators and contributors exist, using the ORCID public API.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// the ORCID public API, the record of an iD is at <url>/<orcid>/record
+var orcid_api_url string = "https://pub.orcid.org/v3.0"
+
+// the maximum time a single ORCID lookup may take
+const orcid_lookup_timeout = 10 * time.Second
+
+// VerifyORCID asks the ORCID public API whether the record of an ORCID iD (bare or as https://orcid.org/ URL)
+// exists; a missing record is false without an error, other failures are an error
+func VerifyORCID(ctx context.Context, orcid string) (bool, error) {
+	id := strings.TrimPrefix(orcid_url(orcid), "https://orcid.org/")
+	if id == "" || strings.ContainsAny(id, "/?# ") {
+		return false, fmt.Errorf("not an ORCID iD: %q", orcid)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, orcid_lookup_timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, orcid_api_url+"/"+id+"/record", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", url_user_agent)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	// drain the record so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("ORCID lookup of %s failed: %s", id, resp.Status)
+}
+
+// warn about every creator and contributor ORCID iD that has no ORCID record, each iD is looked up once,
+// returns how many do not resolve
+func report_orcids(ctx context.Context, doc Yoda18Metadata, fname string) int {
+	type orcid_field struct {
+		Field string
+		ORCID string
+	}
+	var fields []orcid_field
+	for i, cre := range doc.Creator {
+		for j, pid := range cre.PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+				fields = append(fields, orcid_field{fmt.Sprintf("Creator[%d].Person_Identifier[%d]", i, j), pid.NameIdentifier})
+			}
+		}
+	}
+	for i, con := range doc.Contributor {
+		for j, pid := range con.PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+				fields = append(fields, orcid_field{fmt.Sprintf("Contributor[%d].Person_Identifier[%d]", i, j), pid.NameIdentifier})
+			}
+		}
+	}
+
+	found := make(map[string]bool)
+	missing := 0
+	for _, f := range fields {
+		if ctx.Err() != nil {
+			break
+		}
+		ok, checked := found[f.ORCID]
+		if !checked {
+			var err error
+			ok, err = VerifyORCID(ctx, f.ORCID)
+			if err != nil {
+				slog.Warn("ORCID lookup failed", "file", fname, "field_path", f.Field, "error", err)
+				continue
+			}
+			found[f.ORCID] = ok
+		}
+		if !ok {
+			slog.Warn("ORCID iD does not resolve", "file", fname, "field_path", f.Field, "orcid", f.ORCID)
+			missing++
+		}
+	}
+	return missing
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// an ORCID public API that knows the given iDs, answers 500 for 0000-0000-0000-0500 and counts the requests
+func orcid_test_server(t *testing.T, known ...string) *int {
+	t.Helper()
+	var mu sync.Mutex
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		if r.Header.Get("Accept") != "application/json" {
+			t.Errorf("Accept %q, want application/json", r.Header.Get("Accept"))
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), "/record")
+		switch {
+		case id == "0000-0000-0000-0500":
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+		case strings.Contains(strings.Join(known, " "), id):
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"orcid-identifier": {"path": "` + id + `"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	old := orcid_api_url
+	orcid_api_url = srv.URL
+	t.Cleanup(func() { orcid_api_url = old })
+	return &requests
+}
+
+func TestVerifyORCID(t *testing.T) {
+	orcid_test_server(t, "0000-0002-1825-0097")
+	tests := []struct {
+		orcid   string
+		want    bool
+		wantErr bool
+	}{
+		{"0000-0002-1825-0097", true, false},
+		{"https://orcid.org/0000-0002-1825-0097", true, false},
+		{"0000-0001-0000-0000", false, false},
+		{"0000-0000-0000-0500", false, true},
+		{"", false, true},
+		{"0000-0002-1825-0097/works", false, true},
+	}
+	for _, tt := range tests {
+		got, err := VerifyORCID(context.Background(), tt.orcid)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("VerifyORCID(%q) = %t, %v, want %t (error %t)", tt.orcid, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestReportORCIDs(t *testing.T) {
+	requests := orcid_test_server(t, "0000-0002-1825-0097")
+	log := capture_test_log(t)
+	doc := parse_test_metadata(t, `{
+		"Creator": [
+			{"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}]},
+			{"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0001-0000-0000"}]}
+		],
+		"Contributor": [
+			{"Person_Identifier": [{"Name_Identifier_Scheme": "orcid", "Name_Identifier": "0000-0001-0000-0000"}]},
+			{"Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0000-0000-0500"}]}
+		]
+	}`)
+	if missing := report_orcids(context.Background(), doc, "test.json"); missing != 2 {
+		t.Errorf("%d ORCID iDs do not resolve, want 2", missing)
+	}
+	if *requests != 3 {
+		t.Errorf("%d lookups, want every iD looked up once", *requests)
+	}
+	for _, field := range []string{"Creator[1].Person_Identifier[0]", "Contributor[0].Person_Identifier[0]", "Contributor[1].Person_Identifier[0]"} {
+		if !strings.Contains(log.String(), field) {
+			t.Errorf("no warning for %s: %s", field, log)
+		}
+	}
+	if strings.Contains(log.String(), "Creator[0]") {
+		t.Errorf("a resolving ORCID iD is reported: %s", log)
+	}
+}
+
+func TestVerifyORCIDCancel(t *testing.T) {
+	orcid_test_server(t, "0000-0002-1825-0097")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := VerifyORCID(ctx, "0000-0002-1825-0097"); err == nil {
+		t.Error("a cancelled lookup does not fail")
+	}
+}
@@ -0,0 +1,186 @@
+/*
+osf.go exports Yoda metadata as the JSON:API payloads that create an OSF (Open Science Framework) project and
+add its contributors.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// the file the contributors payload is written to, next to the node payload
+const osf_contributors_name string = "contributors.json"
+
+// the attributes of an OSF node, description is plain text
+type OSFNodeAttributes struct {
+	Title       string   `json:"title"`
+	Category    string   `json:"category"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// an OSF JSON:API node resource
+type OSFNode struct {
+	Type       string            `json:"type"`
+	Attributes OSFNodeAttributes `json:"attributes"`
+}
+
+// the attributes of an OSF contributor, full_name adds an unregistered contributor
+type OSFContributorAttributes struct {
+	FullName      string `json:"full_name"`
+	Bibliographic bool   `json:"bibliographic"`
+	Index         int    `json:"index"`
+}
+
+// the identifiers the OSF user of a contributor can be matched on later, OSF has no field for them
+type OSFContributorMeta struct {
+	ORCID           string `json:"orcid,omitempty"`
+	Affiliation     string `json:"affiliation,omitempty"`
+	ContributorType string `json:"contributor_type,omitempty"`
+}
+
+// an OSF JSON:API contributor resource
+type OSFContributor struct {
+	Type       string                   `json:"type"`
+	Attributes OSFContributorAttributes `json:"attributes"`
+	Meta       OSFContributorMeta       `json:"meta"`
+}
+
+// the node creation request body
+type OSFNodePayload struct {
+	Data OSFNode `json:"data"`
+}
+
+// the contributors, one contributor creation request each
+type OSFContributorsPayload struct {
+	Data []OSFContributor `json:"data"`
+}
+
+// the node and its contributors in a single document, for -osf-combined
+type OSFCombined struct {
+	Node         OSFNodePayload         `json:"node"`
+	Contributors OSFContributorsPayload `json:"contributors"`
+}
+
+// the fields OSF has no place for, as "label: value" lines, so they can go into the description
+func osf_metadata_lines(doc Yoda18Metadata) []string {
+	var lines []string
+	for _, field := range basic_data_fields(doc) {
+		label, value := field[0], strings.TrimSpace(field[1])
+		if label == "Title" || label == "Description" || value == "" || value == "-" || value == "0" {
+			continue
+		}
+		lines = append(lines, label+": "+value)
+	}
+	for _, fund := range doc.FundingReference {
+		if fund.AwardNumber != "" {
+			lines = append(lines, fmt.Sprintf("Funding_Reference: %s (%s)", fund.FunderName, fund.AwardNumber))
+		} else if fund.FunderName != "" {
+			lines = append(lines, "Funding_Reference: "+fund.FunderName)
+		}
+	}
+	for _, rel := range doc.RelatedDatapackage {
+		pid := rel.PersistentIdentifier
+		if rel.Title != "" || pid.Identifier != "" {
+			lines = append(lines, fmt.Sprintf("Related_Datapackage: %s [%s] %s", rel.Title, rel.RelationType, pid_link(pid.IdentifierScheme, pid.Identifier)))
+		}
+	}
+	return lines
+}
+
+// the OSF node: the description is followed by a "Metadata" section holding the fields OSF cannot store,
+// tags combine Tag and Discipline
+func osf_node(doc Yoda18Metadata) OSFNodePayload {
+	description := strings.TrimSpace(doc.Description)
+	if lines := osf_metadata_lines(doc); len(lines) > 0 {
+		description += "\n\nMetadata\n\n" + strings.Join(lines, "\n")
+		description = strings.TrimSpace(description)
+	}
+	node := OSFNode{Type: "nodes", Attributes: OSFNodeAttributes{
+		Title:       doc.Title,
+		Category:    "data",
+		Description: description,
+	}}
+	seen := make(map[string]bool)
+	for _, tag := range append(append([]string{}, doc.Tag...), doc.Discipline...) {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !seen[strings.ToLower(tag)] {
+			seen[strings.ToLower(tag)] = true
+			node.Attributes.Tags = append(node.Attributes.Tags, tag)
+		}
+	}
+	return OSFNodePayload{Data: node}
+}
+
+// the OSF contributors: the creators are bibliographic (listed as authors), the contributors are not
+func osf_contributors(doc Yoda18Metadata) OSFContributorsPayload {
+	payload := OSFContributorsPayload{Data: []OSFContributor{}}
+	add := func(name NameStruct, affiliations []string, scheme_ids [][2]string, contributor_type string, bibliographic bool) {
+		full_name := formatName(name, "full")
+		if strings.TrimSpace(full_name) == "" {
+			return
+		}
+		c := OSFContributor{Type: "contributors", Attributes: OSFContributorAttributes{
+			FullName:      full_name,
+			Bibliographic: bibliographic,
+			Index:         len(payload.Data),
+		}, Meta: OSFContributorMeta{Affiliation: strings.Join(affiliations, "; "), ContributorType: contributor_type}}
+		if orcid := xlsx_orcid(scheme_ids); orcid != "" {
+			c.Meta.ORCID = orcid_url(orcid)
+		}
+		payload.Data = append(payload.Data, c)
+	}
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		add(cre.Name, cre.Affiliation, ids, "", true)
+	}
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		add(con.Name, con.Affiliation, ids, con.ContributorType, false)
+	}
+	return payload
+}
+
+// write v as indented JSON to w
+func osf_encode(v interface{}, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// exportOSF writes the OSF node payload to w, with combined the node and the contributors payloads together
+func exportOSF(doc Yoda18Metadata, combined bool, w io.Writer) error {
+	if combined {
+		return osf_encode(OSFCombined{Node: osf_node(doc), Contributors: osf_contributors(doc)}, w)
+	}
+	return osf_encode(osf_node(doc), w)
+}
+
+// write the contributors payload to contributors.json in the directory of the node payload fname
+func write_osf_contributors(doc Yoda18Metadata, fname string) error {
+	fname = filepath.Join(filepath.Dir(fname), osf_contributors_name)
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	err = osf_encode(osf_contributors(doc), f)
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", fname, "format", "osf")
+	return nil
+}
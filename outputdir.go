@@ -0,0 +1,172 @@
+/*
+outputdir.go writing the outputs of a batch to a single directory with filenames derived from the dataset titles.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// the -name-from values, how the files in -output-dir are named
+var name_from_values = []string{"title", "input"}
+
+// the filename stem used for a dataset without a usable title
+const untitled_file_stem string = "untitled"
+
+// the longest filename stem derived from a title, in characters
+const title_stem_max_len int = 80
+
+// the filename stem for a title: lowercase, spaces become underscores and everything but letters, digits,
+// underscores and hyphens is left out
+func title_file_stem(title string) string {
+	var sb strings.Builder
+	n := 0
+	for _, r := range strings.ToLower(strings.Join(strings.Fields(title), " ")) {
+		if n >= title_stem_max_len {
+			break
+		}
+		switch {
+		case r == ' ' || r == '_':
+			r = '_'
+		case r == '-' || unicode.IsLetter(r) || unicode.IsDigit(r):
+		default:
+			continue
+		}
+		sb.WriteRune(r)
+		n++
+	}
+	// stripped characters can leave runs of underscores, as in "a & b"
+	stem := sb.String()
+	for strings.Contains(stem, "__") {
+		stem = strings.ReplaceAll(stem, "__", "_")
+	}
+	stem = strings.Trim(stem, "_-")
+	if stem == "" {
+		return untitled_file_stem
+	}
+	return stem
+}
+
+// check that a -name-from value is supported
+func check_name_from(value string) error {
+	if slices.Contains(name_from_values, value) {
+		return nil
+	}
+	return fmt.Errorf("unknown -name-from %q, use one of: %s", value, strings.Join(name_from_values, ", "))
+}
+
+// the filename stem of a dataset in -output-dir: its title, or with -name-from input the name of the input
+// file, the title is used for input without a file name such as stdin or -input-url
+func output_dir_stem(data Yoda18Metadata, source string) string {
+	if *name_from == "input" && source != "" && source != stdin_name {
+		return filepath.Base(input_file_stem(source))
+	}
+	return title_file_stem(data.Title)
+}
+
+// the formats of a -formats list such as "pdf,csv,json", each checked and given once
+func parse_output_formats(list string) ([]string, error) {
+	var formats []string
+	for _, format := range strings.Split(list, ",") {
+		format = strings.TrimSpace(format)
+		if format == "" {
+			continue
+		}
+		format, err := resolve_output_format(format, "")
+		if err != nil {
+			return nil, err
+		}
+		if format == "sqlite" || format == "ndjson" {
+			return nil, fmt.Errorf("-format %s writes all input files to one output, it cannot be used in -formats", format)
+		}
+		if !slices.Contains(formats, format) {
+			formats = append(formats, format)
+		}
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("-formats lists no output format")
+	}
+	return formats, nil
+}
+
+// write data in each format to stem plus the extension of the format, a format that fails is logged and does
+// not stop the others; name is the input shown in the PDF report, source the input file and raw, if not nil,
+// the input added to the PDF report
+func write_formats(data Yoda18Metadata, formats []string, stem string, name string, data_dir string, source string, raw []byte) error {
+	failed := 0
+	for _, format := range formats {
+		var err error
+		if format == "pdf" {
+			err = write_pdf_output(data, name, stem+".pdf", stem+".md", raw)
+		} else {
+			err = write_output_format(data, format, stem, data_dir, source)
+		}
+		if err != nil {
+			slog.Error("conversion failed", "file", name, "format", format, "error", err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d formats failed", failed, len(formats))
+	}
+	return nil
+}
+
+// stem itself when it was not used before, otherwise stem_2, stem_3, ... whichever is free first
+func unique_file_stem(used map[string]bool, stem string) string {
+	name := stem
+	for i := 2; used[name]; i++ {
+		name = fmt.Sprintf("%s_%d", stem, i)
+	}
+	used[name] = true
+	return name
+}
+
+// convert every input file to its own output in dir, one file per format, named after its title or input file;
+// the files are read concurrently and written in input order so the _2, _3 suffixes of equal names do not
+// depend on the worker timing
+func write_output_dir(ctx context.Context, fnames []string, formats []string, dir string, workers int) error {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	failed := 0
+	for _, res := range results {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if res.Err == nil {
+			stem := filepath.Join(dir, unique_file_stem(used, output_dir_stem(res.Data, res.File)))
+			var raw []byte
+			if *append_source && slices.Contains(formats, "pdf") {
+				raw, res.Err = read_metadata_input(res.File)
+			}
+			if res.Err == nil {
+				res.Err = write_formats(res.Data, formats, stem, res.File, "", res.File, raw)
+			}
+		}
+		if res.Err != nil {
+			slog.Error("conversion failed", "file", res.File, "error", res.Err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed", failed, len(fnames))
+	}
+	return nil
+}
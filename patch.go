@@ -0,0 +1,103 @@
+/*
+patch.go applying a partial metadata JSON file to a metadata document, so single fields can be updated.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// PatchMetadata returns base with the fields present in patchJSON replaced, keys are the JSON field names;
+// a null value leaves the field unchanged, a nested object such as Collected only replaces the fields it holds
+// and a list (Creator, Tag, ...) replaces the whole list
+func PatchMetadata(base Yoda18Metadata, patchJSON []byte) (Yoda18Metadata, error) {
+	var patch map[string]interface{}
+	err := json.Unmarshal(patchJSON, &patch)
+	if err != nil {
+		return base, fmt.Errorf("patch is not a JSON object: %w", err)
+	}
+	v := reflect.ValueOf(&base).Elem()
+	err = patch_struct(v, patch, "")
+	return base, err
+}
+
+// the struct fields by their JSON name
+func patch_fields(t reflect.Type) map[string]int {
+	fields := make(map[string]int)
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// apply the patch to the struct v, path is the JSON path of v used in errors
+func patch_struct(v reflect.Value, patch map[string]interface{}, path string) error {
+	fields := patch_fields(v.Type())
+	// sorted so the first unknown key reported does not change between runs
+	var keys []string
+	for key := range patch {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := patch[key]
+		i, ok := fields[key]
+		if !ok {
+			return fmt.Errorf("patch field %s%s does not exist", path, key)
+		}
+		if value == nil {
+			continue
+		}
+		field := v.Field(i)
+		if obj, ok := value.(map[string]interface{}); ok && field.Kind() == reflect.Struct {
+			err := patch_struct(field, obj, path+key+".")
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// anything else replaces the field, decoding through JSON gives the same rules as reading a file
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		nv := reflect.New(field.Type())
+		err = json.Unmarshal(raw, nv.Interface())
+		if err != nil {
+			return fmt.Errorf("patch field %s%s: %w", path, key, err)
+		}
+		field.Set(nv.Elem())
+	}
+	return nil
+}
+
+// apply the -patch file to doc, without -patch doc is returned as it is
+func apply_patch_file(doc Yoda18Metadata, fname string) (Yoda18Metadata, error) {
+	if fname == "" {
+		return doc, nil
+	}
+	raw, err := os.ReadFile(fname)
+	if err != nil {
+		return doc, err
+	}
+	doc, err = PatchMetadata(doc, raw)
+	if err != nil {
+		return doc, fmt.Errorf("%s: %w", fname, err)
+	}
+	return doc, nil
+}
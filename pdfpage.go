@@ -0,0 +1,62 @@
+/*
+pdfpage.go PDF page size and orientation selected with -page-size and -orientation.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/johnfercher/maroto/pkg/pdf"
+)
+
+// the accepted -page-size values
+var pdf_page_sizes = map[string]consts.PageSize{
+	"a4":     consts.A4,
+	"letter": consts.Letter,
+	"legal":  consts.Legal,
+}
+
+// the accepted -orientation values
+var pdf_orientations = map[string]consts.Orientation{
+	"portrait":  consts.Portrait,
+	"landscape": consts.Landscape,
+}
+
+// the text width of an A4 portrait page with the report margins, the layout is tuned for it
+const pdf_reference_width float64 = 190
+
+// check the -page-size and -orientation values, case is ignored
+func check_page_layout(size string, orientation string) error {
+	if _, ok := pdf_page_sizes[strings.ToLower(size)]; !ok {
+		return fmt.Errorf("unknown page size %q, expected one of: A4, Letter, Legal", size)
+	}
+	if _, ok := pdf_orientations[strings.ToLower(orientation)]; !ok {
+		return fmt.Errorf("unknown orientation %q, expected one of: portrait, landscape", orientation)
+	}
+	return nil
+}
+
+// a new PDF document with the -page-size and -orientation layout and the report margins
+func new_pdf_document() pdf.Maroto {
+	size, ok := pdf_page_sizes[strings.ToLower(*page_size)]
+	if !ok {
+		size = consts.A4
+	}
+	orientation, ok := pdf_orientations[strings.ToLower(*page_orientation)]
+	if !ok {
+		orientation = consts.Portrait
+	}
+	doc := pdf.NewMaroto(orientation, size)
+	doc.SetPageMargins(10, 10, 10)
+	return doc
+}
+
+// the ratio of the text width of doc to that of A4 portrait, wider pages fit more characters per line
+func pdf_width_factor(doc pdf.Maroto) float64 {
+	width, _ := doc.GetPageSize()
+	left, _, right, _ := doc.GetPageMargins()
+	return (width - left - right) / pdf_reference_width
+}
@@ -0,0 +1,148 @@
+/*
+pid.go normalises persistent identifiers (DOI, Handle, ARK, URL) to the https URL of their resolver, and checks
+that an identifier fits its scheme.
+*/
+
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// the resolver prefixes and URI schemes a Handle may be written with
+var handle_prefixes = []string{"https://hdl.handle.net/", "http://hdl.handle.net/", "hdl:"}
+
+// the resolvers an ARK may be written with, any of them is normalised to n2t.net
+var ark_prefixes = []string{"https://n2t.net/", "http://n2t.net/", "https://arks.org/", "http://arks.org/"}
+
+// an ARK: "ark:/" (the slash is optional since ARK 2023), the NAAN of the assigning organisation, and a name
+// of the ARK characters; the NAAN is betanumeric, digits and consonants except l
+var ark_pattern = regexp.MustCompile(`^(?i:ark):/?([0-9bcdfghjkmnpqrstvwxz]{5,})/([0-9A-Za-z=~*+@_$.%/-]+)$`)
+
+// a DOI: the directory indicator 10, a registrant code of digits (with optional .subdivisions) and a suffix
+var doi_pattern = regexp.MustCompile(`^10\.[0-9]{4,9}(\.[0-9]+)*/\S+$`)
+
+// a Handle prefix: digits with optional .subdivisions, as in 11245 or 20.500.12345
+var handle_prefix_pattern = regexp.MustCompile(`^[0-9]+(\.[0-9]+)*$`)
+
+// the ways an ISBN may be written before its digits
+var isbn_prefixes = []string{"urn:isbn:", "isbn-13:", "isbn-10:", "isbn:", "isbn"}
+
+// strip the first matching prefix from s, case insensitive
+func trim_pid_prefix(s string, prefixes []string) string {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(strings.ToLower(s), prefix) {
+			return s[len(prefix):]
+		}
+	}
+	return s
+}
+
+// NormalizePID returns the https URL of a DOI, Handle, ARK or URL identifier, e.g. "doi:10.1234/foo",
+// "10.1234/foo" and "https://dx.doi.org/10.1234/foo" all give "https://doi.org/10.1234/foo";
+// other schemes and identifiers that do not fit their scheme are an error
+func NormalizePID(scheme, identifier string) (string, error) {
+	id := strings.TrimSpace(identifier)
+	if id == "" {
+		return "", fmt.Errorf("no identifier specified")
+	}
+	switch strings.ToUpper(strings.TrimSpace(scheme)) {
+	case "DOI":
+		doi := doi_from_string(id)
+		if !doi_pattern.MatchString(doi) {
+			return "", fmt.Errorf("not a DOI: %q", identifier)
+		}
+		return "https://doi.org/" + doi, nil
+	case "HANDLE":
+		handle := trim_pid_prefix(id, handle_prefixes)
+		// a Handle is <prefix>/<suffix>, the prefix is numeric as in 11245/...
+		prefix, suffix, ok := strings.Cut(handle, "/")
+		if !ok || !handle_prefix_pattern.MatchString(prefix) || suffix == "" || strings.ContainsAny(handle, " \t") {
+			return "", fmt.Errorf("not a Handle: %q", identifier)
+		}
+		return "https://hdl.handle.net/" + handle, nil
+	case "ARK":
+		ark := trim_pid_prefix(id, ark_prefixes)
+		err := ValidateARK(ark)
+		if err != nil {
+			return "", err
+		}
+		return "https://n2t.net/ark:/" + strings.TrimPrefix(ark[len("ark:"):], "/"), nil
+	case "URL":
+		u, err := url.Parse(id)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("not an http(s) URL: %q", identifier)
+		}
+		return u.String(), nil
+	}
+	return "", fmt.Errorf("unsupported identifier scheme %q, use DOI, Handle, ARK or URL", scheme)
+}
+
+// ValidateARK checks the syntax of an ARK such as "ark:/13030/tf5p30086k", without a resolver prefix
+func ValidateARK(id string) error {
+	if !ark_pattern.MatchString(strings.TrimSpace(id)) {
+		return fmt.Errorf("not an ARK: %q, expected ark:/NAAN/name", id)
+	}
+	return nil
+}
+
+// ValidateISBN checks an ISBN-10 or ISBN-13, with or without hyphens, spaces or an "ISBN" prefix, including
+// its check digit
+func ValidateISBN(id string) error {
+	isbn := strings.TrimSpace(trim_pid_prefix(strings.TrimSpace(id), isbn_prefixes))
+	isbn = strings.NewReplacer("-", "", " ", "").Replace(isbn)
+	sum := 0
+	switch len(isbn) {
+	case 10:
+		for i, r := range isbn {
+			digit := int(r - '0')
+			if (r == 'X' || r == 'x') && i == 9 {
+				digit = 10
+			} else if r < '0' || r > '9' {
+				return fmt.Errorf("not an ISBN: %q", id)
+			}
+			sum += (10 - i) * digit
+		}
+		if sum%11 != 0 {
+			return fmt.Errorf("invalid ISBN-10 check digit: %q", id)
+		}
+	case 13:
+		for i, r := range isbn {
+			if r < '0' || r > '9' {
+				return fmt.Errorf("not an ISBN: %q", id)
+			}
+			sum += int(r-'0') * (1 + 2*(i%2))
+		}
+		if sum%10 != 0 {
+			return fmt.Errorf("invalid ISBN-13 check digit: %q", id)
+		}
+	default:
+		return fmt.Errorf("not an ISBN: %q, expected 10 or 13 digits", id)
+	}
+	return nil
+}
+
+// ValidatePID checks that identifier fits its scheme: a DOI (10.<registrant>/<suffix>), Handle, ARK, http(s)
+// URL or ISBN with a correct check digit; other schemes are not checked
+func ValidatePID(scheme, identifier string) error {
+	switch strings.ToUpper(strings.TrimSpace(scheme)) {
+	case "DOI", "HANDLE", "ARK", "URL":
+		_, err := NormalizePID(scheme, identifier)
+		return err
+	case "ISBN":
+		return ValidateISBN(identifier)
+	}
+	return nil
+}
+
+// the resolver URL of an identifier when it can be normalised, otherwise the identifier as given
+func pid_link(scheme, identifier string) string {
+	link, err := NormalizePID(scheme, identifier)
+	if err != nil {
+		return identifier
+	}
+	return link
+}
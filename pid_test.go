@@ -0,0 +1,123 @@
+package main
+
+import "testing"
+
+func TestValidateARK(t *testing.T) {
+	valid := []string{
+		"ark:/13030/tf5p30086k",
+		"ark:13030/tf5p30086k",
+		"ARK:/12148/btv1b8449691v/f29",
+		" ark:/99999/fk4.test-1 ",
+		"ark:/b5072/fk2=x~y*z+@_$%20",
+	}
+	invalid := []string{
+		"",
+		"13030/tf5p30086k",
+		"ark:/1303/tf5p30086k",
+		"ark:/13030/",
+		"ark:/130l0/tf5p30086k",
+		"ark:/13030/tf5p 30086k",
+		"https://n2t.net/ark:/13030/tf5p30086k",
+	}
+	for _, id := range valid {
+		if err := ValidateARK(id); err != nil {
+			t.Errorf("ValidateARK(%q): %v", id, err)
+		}
+	}
+	for _, id := range invalid {
+		if err := ValidateARK(id); err == nil {
+			t.Errorf("ValidateARK(%q) accepts an invalid ARK", id)
+		}
+	}
+}
+
+func TestNormalizePID(t *testing.T) {
+	tests := []struct {
+		scheme, id string
+		want       string
+	}{
+		{"DOI", "10.1234/foo", "https://doi.org/10.1234/foo"},
+		{"doi", "doi:10.1234/foo", "https://doi.org/10.1234/foo"},
+		{"DOI", "https://dx.doi.org/10.1234/foo", "https://doi.org/10.1234/foo"},
+		{"Handle", "11245/1.12345", "https://hdl.handle.net/11245/1.12345"},
+		{"Handle", "hdl:20.500.12345/678", "https://hdl.handle.net/20.500.12345/678"},
+		{"Handle", "https://hdl.handle.net/11245/abc", "https://hdl.handle.net/11245/abc"},
+		{"ARK", "ark:/13030/tf5p30086k", "https://n2t.net/ark:/13030/tf5p30086k"},
+		{"ARK", "ark:13030/tf5p30086k", "https://n2t.net/ark:/13030/tf5p30086k"},
+		{"ARK", "https://arks.org/ark:/13030/tf5p30086k", "https://n2t.net/ark:/13030/tf5p30086k"},
+		{"ARK", "http://n2t.net/ark:/13030/tf5p30086k", "https://n2t.net/ark:/13030/tf5p30086k"},
+		{"URL", "https://example.org/data?id=1", "https://example.org/data?id=1"},
+		// errors
+		{"DOI", "11.1234/foo", ""},
+		{"Handle", "abc/def", ""},
+		{"ARK", "ark:/1/x", ""},
+		{"URL", "ftp://example.org/data", ""},
+		{"URN", "urn:nbn:nl:ui:13-abc", ""},
+		{"DOI", " ", ""},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePID(tt.scheme, tt.id)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("NormalizePID(%q, %q) = %q, %v, want %q", tt.scheme, tt.id, got, err, tt.want)
+		}
+	}
+}
+
+func TestPIDLink(t *testing.T) {
+	if got := pid_link("ARK", "ark:/13030/tf5p30086k"); got != "https://n2t.net/ark:/13030/tf5p30086k" {
+		t.Errorf("pid_link of an ARK = %q", got)
+	}
+	if got := pid_link("ARK", "not an ark"); got != "not an ark" {
+		t.Errorf("pid_link of an invalid ARK = %q, want it as given", got)
+	}
+}
+
+func TestValidatePID(t *testing.T) {
+	tests := []struct {
+		scheme, id string
+		ok         bool
+	}{
+		{"DOI", "10.1234/foo", true},
+		{"DOI", "https://doi.org/10.1234/foo", true},
+		{"DOI", "10.12/foo", false},
+		{"DOI", "doi.org/foo", false},
+		{"Handle", "11245/1.12345", true},
+		{"Handle", "11245", false},
+		{"Handle", "11245/with space", false},
+		{"URL", "https://example.org", true},
+		{"URL", "example.org", false},
+		{"URL", "https://", false},
+		{"ISBN", "978-0-306-40615-7", true},
+		{"ISBN", "ISBN 0-306-40615-2", true},
+		{"ISBN", "0-8044-2957-X", true},
+		{"ISBN", "978-0-306-40615-8", false},
+		{"ISBN", "0-306-40615-3", false},
+		{"ISBN", "12345", false},
+		// schemes without a syntax are not checked
+		{"PURL", "anything", true},
+		{"", "anything", true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePID(tt.scheme, tt.id); (err == nil) != tt.ok {
+			t.Errorf("ValidatePID(%q, %q) = %v, want ok %t", tt.scheme, tt.id, err, tt.ok)
+		}
+	}
+}
+
+// an identifier that does not fit its scheme is a validation warning of the related datapackage
+func TestValidateRelatedIdentifier(t *testing.T) {
+	doc := parse_test_metadata(t, `{"Related_Datapackage": [
+		{"Relation_Type": "IsCitedBy", "Title": "a", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "10.1234/ok"}},
+		{"Relation_Type": "IsCitedBy", "Title": "b", "Persistent_Identifier": {"Identifier_Scheme": "DOI", "Identifier": "not a doi"}}
+	]}`)
+	found := map[string]string{}
+	for _, issue := range Validate(doc, false) {
+		found[issue.Field] = issue.Severity
+	}
+	if _, ok := found["Related_Datapackage[0].Persistent_Identifier.Identifier"]; ok {
+		t.Error("a valid DOI is reported")
+	}
+	if found["Related_Datapackage[1].Persistent_Identifier.Identifier"] != severity_warning {
+		t.Errorf("an invalid DOI is not a warning: %v", found)
+	}
+}
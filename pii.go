@@ -0,0 +1,118 @@
+/*
+pii.go scanning the metadata text for personally identifiable information that does not belong in published
+metadata: e-mail addresses, Dutch citizen service numbers (BSN) and phone numbers.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// the characters of text shown on either side of a match in the snippet
+const pii_snippet_context int = 20
+
+// PIIWarning is a piece of text that looks like personal information, Field is the flattened field path such
+// as Description or Contributor.2.Affiliation.1
+type PIIWarning struct {
+	Field          string
+	MatchedPattern string
+	Snippet        string
+}
+
+// a kind of personal information, check (when set) rules out matches that only look like it
+type pii_pattern struct {
+	Name  string
+	Regex *regexp.Regexp
+	Check func(match string) bool
+}
+
+// the patterns searched for: e-mail addresses as RFC 5322 addr-spec with a dot-atom local part, nine digit
+// numbers (also written 123.456.782) that pass the BSN eleven test, and international (+31 or 0031) or
+// Dutch national (10 digits from 0) phone numbers
+var pii_patterns = []pii_pattern{
+	{"email", regexp.MustCompile("(?i)[a-z0-9!#$%&'*+/=?^_`{|}~-]+(?:\\.[a-z0-9!#$%&'*+/=?^_`{|}~-]+)*@(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\\.)+[a-z](?:[a-z0-9-]*[a-z0-9])?"), nil},
+	{"bsn", regexp.MustCompile(`\b[0-9]{3}\.?[0-9]{3}\.?[0-9]{3}\b`), valid_bsn},
+	{"phone", regexp.MustCompile(`(?:\+|\b00)[1-9](?:[ -]?\(?[0-9]\)?){8,13}\b|\b0[1-9](?:[ -]?[0-9]){8}\b`), nil},
+}
+
+// the eleven test of a BSN: 9*d1 + 8*d2 + ... + 2*d8 - d9 is a multiple of 11, and not 0
+func valid_bsn(match string) bool {
+	digits := strings.ReplaceAll(match, ".", "")
+	if len(digits) != 9 {
+		return false
+	}
+	sum := 0
+	for i, r := range digits {
+		weight := 9 - i
+		if i == 8 {
+			weight = -1
+		}
+		sum += weight * int(r-'0')
+	}
+	return sum != 0 && sum%11 == 0
+}
+
+// the match with up to pii_snippet_context characters of text around it, on a single line
+func pii_snippet(text string, start, end int) string {
+	before := []rune(text[:start])
+	after := []rune(text[end:])
+	snippet := text[start:end]
+	if len(before) > pii_snippet_context {
+		snippet = "..." + string(before[len(before)-pii_snippet_context:]) + snippet
+	} else {
+		snippet = string(before) + snippet
+	}
+	if len(after) > pii_snippet_context {
+		snippet += string(after[:pii_snippet_context]) + "..."
+	} else {
+		snippet += string(after)
+	}
+	return strings.Join(strings.Fields(snippet), " ")
+}
+
+// ScanForPII returns a warning for every e-mail address, BSN or phone number found in the text fields of doc,
+// the fields in document order
+func ScanForPII(doc Yoda18Metadata) []PIIWarning {
+	var warnings []PIIWarning
+	for _, field := range flatten_metadata(doc) {
+		text := field[1]
+		for _, p := range pii_patterns {
+			for _, loc := range p.Regex.FindAllStringIndex(text, -1) {
+				if p.Check != nil && !p.Check(text[loc[0]:loc[1]]) {
+					continue
+				}
+				warnings = append(warnings, PIIWarning{Field: field[0], MatchedPattern: p.Name, Snippet: pii_snippet(text, loc[0], loc[1])})
+			}
+		}
+	}
+	return warnings
+}
+
+// print a line per PII warning, "Description: email: ...", prefixed by the filename when several files are
+// given, and return the number of warnings
+func write_pii_scan(ctx context.Context, w io.Writer, fnames []string, workers int) (int, error) {
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, res := range results {
+		if res.Err != nil {
+			return count, fmt.Errorf("%s: %w", res.File, res.Err)
+		}
+		prefix := ""
+		if len(fnames) > 1 {
+			prefix = res.File + ": "
+		}
+		for _, warning := range ScanForPII(res.Data) {
+			fmt.Fprintf(w, "%s%s: %s: %s\n", prefix, warning.Field, warning.MatchedPattern, warning.Snippet)
+			count++
+		}
+	}
+	return count, nil
+}
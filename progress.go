@@ -0,0 +1,90 @@
+/*
+progress.go showing how far a batch of input files is on stderr: a line redrawn in place on a terminal, a
+periodic line when stderr is redirected, and a summary with the time taken at the end.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// how often a progress line is printed when stderr is not a terminal
+const progress_interval time.Duration = 5 * time.Second
+
+// how often the line on a terminal is redrawn at most
+const progress_redraw_interval time.Duration = 100 * time.Millisecond
+
+// the progress of a batch, update and finish are called by one goroutine at a time
+type batch_progress struct {
+	w      io.Writer
+	tty    bool
+	total  int
+	done   int
+	failed int
+	start  time.Time
+	shown  time.Time
+}
+
+// a progress report for total files on stderr, nil (which reports nothing) with -quiet, -no-progress or a
+// single file
+func new_batch_progress(total int) *batch_progress {
+	if *quiet || *no_progress || total < 2 {
+		return nil
+	}
+	now := time.Now()
+	return &batch_progress{
+		w:     os.Stderr,
+		tty:   term.IsTerminal(int(os.Stderr.Fd())),
+		total: total,
+		start: now,
+		shown: now,
+	}
+}
+
+func (p *batch_progress) line() string {
+	line := fmt.Sprintf("processed %d/%d", p.done, p.total)
+	if p.failed > 0 {
+		line += fmt.Sprintf(" (%d failed)", p.failed)
+	}
+	return line
+}
+
+// count a processed file, ok is false for a file that failed
+func (p *batch_progress) update(ok bool) {
+	if p == nil {
+		return
+	}
+	p.done++
+	if !ok {
+		p.failed++
+	}
+	now := time.Now()
+	switch {
+	case p.tty && (now.Sub(p.shown) >= progress_redraw_interval || p.done == p.total):
+		fmt.Fprintf(p.w, "\r%s", p.line())
+		p.shown = now
+	case !p.tty && now.Sub(p.shown) >= progress_interval:
+		fmt.Fprintln(p.w, p.line())
+		p.shown = now
+	}
+}
+
+// print the summary: the files processed and failed, and the time they took
+func (p *batch_progress) finish() {
+	if p == nil {
+		return
+	}
+	elapsed := time.Since(p.start)
+	if p.tty {
+		// the summary replaces the redrawn line, which may be longer
+		fmt.Fprint(p.w, "\r\033[K")
+	}
+	rate := float64(p.done) / elapsed.Seconds()
+	fmt.Fprintf(p.w, "%s in %s (%.1f files/s)\n", p.line(), elapsed.Round(time.Millisecond), rate)
+}
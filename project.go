@@ -0,0 +1,44 @@
+/*
+project.go selecting a named subset of the metadata fields, for pipelines that need only some columns.
+*/
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// the JSON names of the top level metadata fields, in the order of Yoda18Metadata
+func project_field_names() []string {
+	t := reflect.TypeOf(Yoda18Metadata{})
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = t.Field(i).Name
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// ProjectFields returns the requested fields of the metadata by their JSON name, e.g.
+// ProjectFields(doc, []string{"Title", "License", "Language"}); an unknown name is an error listing the valid names
+func ProjectFields(doc Yoda18Metadata, fields []string) (map[string]interface{}, error) {
+	v := reflect.ValueOf(doc)
+	index := patch_fields(v.Type())
+	out := make(map[string]interface{}, len(fields))
+	for _, name := range fields {
+		i, ok := index[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, valid fields are %s", name, strings.Join(project_field_names(), ", "))
+		}
+		out[name] = v.Field(i).Interface()
+	}
+	return out, nil
+}
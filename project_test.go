@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// every top level field of the metadata by its JSON name
+var project_test_fields = []string{
+	"links", "Discipline", "Language", "Collected", "Covered_Geolocation_Place", "Covered_Period", "Tag",
+	"Related_Datapackage", "Retention_Period", "Data_Type", "Funding_Reference", "Creator", "Contributor",
+	"Data_Access_Restriction", "Title", "Description", "Version", "Retention_Information", "Embargo_End_Date",
+	"Data_Classification", "Collection_Name", "Remarks", "License",
+}
+
+func TestProjectFieldNames(t *testing.T) {
+	if got := project_field_names(); !reflect.DeepEqual(got, project_test_fields) {
+		t.Errorf("field names %v, want %v", got, project_test_fields)
+	}
+}
+
+func TestProjectFields(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	got, err := ProjectFields(doc, project_test_fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(project_test_fields) {
+		t.Errorf("%d fields, want %d", len(got), len(project_test_fields))
+	}
+	v := reflect.ValueOf(doc)
+	for i := 0; i < v.NumField(); i++ {
+		name := strings.Split(v.Type().Field(i).Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if !reflect.DeepEqual(got[name], v.Field(i).Interface()) {
+			t.Errorf("%s projected as %v, want %v", name, got[name], v.Field(i).Interface())
+		}
+	}
+
+	got, err = ProjectFields(doc, []string{"Title", "License", "Language"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{"Title": doc.Title, "License": doc.License, "Language": doc.Language}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ProjectFields = %v, want %v", got, want)
+	}
+}
+
+func TestProjectFieldsUnknown(t *testing.T) {
+	_, err := ProjectFields(Yoda18Metadata{}, []string{"Title", "Subtitle"})
+	if err == nil {
+		t.Fatal("an unknown field is not an error")
+	}
+	for _, want := range []string{`"Subtitle"`, "Title", "Retention_Period", "links"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not name %s", err, want)
+		}
+	}
+	// the JSON name is required, not the Go name
+	if _, err := ProjectFields(Yoda18Metadata{}, []string{"RetentionPeriod"}); err == nil {
+		t.Error("the Go field name is accepted")
+	}
+}
@@ -0,0 +1,96 @@
+/*
+qr.go drawing the DOI link of the dataset as a QR code, a PNG for posters and printed documentation that can
+also be put in the PDF report header.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/qr"
+)
+
+// the -qr-level values, the share of the code that may be damaged: L 7%, M 15%, Q 25% and H 30%
+var qr_levels = map[string]qr.ErrorCorrectionLevel{
+	"L": qr.L,
+	"M": qr.M,
+	"Q": qr.Q,
+	"H": qr.H,
+}
+
+// the smallest -qr-size, a QR code of a DOI link is over 30 modules wide
+const qr_min_size int = 64
+
+// check the -qr-size and -qr-level values
+func check_qr_options(size int, level string) error {
+	if size < qr_min_size {
+		return fmt.Errorf("-qr-size must be at least %d pixels, got %d", qr_min_size, size)
+	}
+	if _, ok := qr_levels[strings.ToUpper(level)]; !ok {
+		return fmt.Errorf("unknown -qr-level %q, use L, M, Q or H", level)
+	}
+	return nil
+}
+
+// the https://doi.org/ link encoded in the QR code: the -doi value, otherwise the DOI of the dataset read from
+// source (its System block, -system or -set DOI=..., the related datapackage it IsIdenticalTo or a link); an
+// *invalid_output_error when there is none
+func qr_doi_link(doc Yoda18Metadata, source string) (string, error) {
+	doi := doi_from_string(*qr_doi)
+	if *qr_doi != "" && doi == "" {
+		return "", &invalid_output_error{"QR code", []string{fmt.Sprintf("-doi %q is not a DOI", *qr_doi)}}
+	}
+	if doi == "" {
+		doi = dataset_doi(doc, source)
+	}
+	if doi == "" {
+		return "", &invalid_output_error{"QR code", []string{"no DOI found, the metadata has none, give it with -doi"}}
+	}
+	return pid_link("DOI", doi), nil
+}
+
+// the PNG of a size by size pixel QR code of content with the error correction level
+func qr_png(content string, size int, level string) ([]byte, error) {
+	code, err := qr.Encode(content, qr_levels[strings.ToUpper(level)], qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+	code, err = barcode.Scale(code, size, size)
+	if err != nil {
+		return nil, err
+	}
+	// the barcode image has 16-bit grey levels, which the PDF library cannot read; two colours are enough
+	img := image.NewPaletted(code.Bounds(), color.Palette{color.White, color.Black})
+	draw.Draw(img, img.Bounds(), code, code.Bounds().Min, draw.Src)
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// the PNG QR code of the DOI link of the dataset read from source, with -qr-size and -qr-level
+func dataset_qr_png(doc Yoda18Metadata, source string) ([]byte, error) {
+	link, err := qr_doi_link(doc, source)
+	if err != nil {
+		return nil, err
+	}
+	return qr_png(link, *qr_size, *qr_level)
+}
+
+// exportQR writes the PNG QR code of the DOI link of the metadata read from source to w
+func exportQR(doc Yoda18Metadata, source string, w io.Writer) error {
+	out, err := dataset_qr_png(doc, source)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQRDOILink(t *testing.T) {
+	doc := parse_test_metadata(t, `{"Title": "No DOI"}`)
+	var invalid *invalid_output_error
+	if _, err := qr_doi_link(doc, ""); !errors.As(err, &invalid) {
+		t.Errorf("error %v without DOI, want an *invalid_output_error", err)
+	}
+
+	set_test_flag(t, "doi", "not a doi")
+	if _, err := qr_doi_link(doc, ""); !errors.As(err, &invalid) {
+		t.Errorf("error %v for a bad -doi, want an *invalid_output_error", err)
+	}
+
+	set_test_flag(t, "doi", "doi:10.1234/ABC")
+	if link, err := qr_doi_link(doc, ""); err != nil || link != "https://doi.org/10.1234/ABC" {
+		t.Errorf("link %q, %v", link, err)
+	}
+}
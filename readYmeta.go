@@ -15,12 +15,21 @@ Date: 2022-08-22
 package main
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"time"
 
@@ -42,18 +51,18 @@ type Yoda18Metadata struct {
 		Rel  string `json:"rel"`
 		Href string `json:"href"`
 	} `json:"links"`
-	Discipline []string `json:"Discipline"`
-	Language   string   `json:"Language"`
+	Discipline StringOrSlice `json:"Discipline"`
+	Language   string        `json:"Language"`
 	Collected  struct {
 		StartDate string `json:"Start_Date"`
 		EndDate   string `json:"End_Date"`
 	} `json:"Collected"`
-	CoveredGeolocationPlace []string `json:"Covered_Geolocation_Place"`
+	CoveredGeolocationPlace StringOrSlice `json:"Covered_Geolocation_Place"`
 	CoveredPeriod           struct {
 		StartDate string `json:"Start_Date"`
 		EndDate   string `json:"End_Date"`
 	} `json:"Covered_Period"`
-	Tag                []string `json:"Tag"`
+	Tag                StringOrSlice `json:"Tag"`
 	RelatedDatapackage []struct {
 		PersistentIdentifier struct {
 			IdentifierScheme string `json:"Identifier_Scheme"`
@@ -62,29 +71,23 @@ type Yoda18Metadata struct {
 		RelationType string `json:"Relation_Type"`
 		Title        string `json:"Title"`
 	} `json:"Related_Datapackage"`
-	RetentionPeriod  int    `json:"Retention_Period"`
-	DataType         string `json:"Data_Type"`
+	RetentionPeriod  RetentionPeriod `json:"Retention_Period"`
+	DataType         string          `json:"Data_Type"`
 	FundingReference []struct {
 		FunderName  string `json:"Funder_Name"`
 		AwardNumber string `json:"Award_Number"`
 	} `json:"Funding_Reference"`
 	Creator []struct {
-		Name struct {
-			GivenName  string `json:"Given_Name"`
-			FamilyName string `json:"Family_Name"`
-		} `json:"Name"`
-		Affiliation      []string `json:"Affiliation"`
+		Name             NameStruct    `json:"Name"`
+		Affiliation      StringOrSlice `json:"Affiliation"`
 		PersonIdentifier []struct {
 			NameIdentifierScheme string `json:"Name_Identifier_Scheme"`
 			NameIdentifier       string `json:"Name_Identifier"`
 		} `json:"Person_Identifier"`
 	} `json:"Creator"`
 	Contributor []struct {
-		Name struct {
-			GivenName  string `json:"Given_Name"`
-			FamilyName string `json:"Family_Name"`
-		} `json:"Name"`
-		Affiliation      []string `json:"Affiliation"`
+		Name             NameStruct    `json:"Name"`
+		Affiliation      StringOrSlice `json:"Affiliation"`
 		PersonIdentifier []struct {
 			NameIdentifierScheme string `json:"Name_Identifier_Scheme"`
 			NameIdentifier       string `json:"Name_Identifier"`
@@ -101,73 +104,81 @@ type Yoda18Metadata struct {
 	CollectionName        string `json:"Collection_Name"`
 	Remarks               string `json:"Remarks"`
 	License               string `json:"License"`
+	// the top level keys without field, kept so they are reported and written back with -format json
+	Unmapped map[string]json.RawMessage `json:"-"`
+}
+
+// Person name used by the Creator and Contributor entries
+type NameStruct struct {
+	GivenName  string `json:"Given_Name"`
+	FamilyName string `json:"Family_Name"`
 }
 
 // Yoda metadata struct with advanced options
 type Yoda18MetadataV2 struct {
 	Links []struct {
-		Rel  string `json:"rel,omitempty"`
-		Href string `json:"href,omitempty"`
-	} `json:"links,omitempty"`
-	Discipline []string `json:"Discipline,omitempty"`
-	Language   string   `json:"Language,omitempty"`
+		Rel  string `json:"rel,omitempty" yaml:"rel,omitempty"`
+		Href string `json:"href,omitempty" yaml:"href,omitempty"`
+	} `json:"links,omitempty" yaml:"links,omitempty"`
+	Discipline StringOrSlice `json:"Discipline,omitempty" yaml:"Discipline,omitempty"`
+	Language   string        `json:"Language,omitempty" yaml:"Language,omitempty"`
 	Collected  struct {
-		StartDate string `json:"Start_Date,omitempty"`
-		EndDate   string `json:"End_Date,omitempty"`
-	} `json:"Collected,omitempty"`
-	CoveredGeolocationPlace []string `json:"Covered_Geolocation_Place,omitempty"`
+		StartDate string `json:"Start_Date,omitempty" yaml:"Start_Date,omitempty"`
+		EndDate   string `json:"End_Date,omitempty" yaml:"End_Date,omitempty"`
+	} `json:"Collected,omitempty" yaml:"Collected,omitempty"`
+	CoveredGeolocationPlace StringOrSlice `json:"Covered_Geolocation_Place,omitempty" yaml:"Covered_Geolocation_Place,omitempty"`
 	CoveredPeriod           struct {
-		StartDate string `json:"Start_Date,omitempty"`
-		EndDate   string `json:"End_Date,omitempty"`
-	} `json:"Covered_Period,omitempty"`
-	Tag                []string `json:"Tag,omitempty"`
+		StartDate string `json:"Start_Date,omitempty" yaml:"Start_Date,omitempty"`
+		EndDate   string `json:"End_Date,omitempty" yaml:"End_Date,omitempty"`
+	} `json:"Covered_Period,omitempty" yaml:"Covered_Period,omitempty"`
+	Tag                StringOrSlice `json:"Tag,omitempty" yaml:"Tag,omitempty"`
 	RelatedDatapackage []struct {
 		PersistentIdentifier struct {
-			IdentifierScheme string `json:"Identifier_Scheme,omitempty"`
-			Identifier       string `json:"Identifier,omitempty"`
-		} `json:"Persistent_Identifier,omitempty"`
-		RelationType string `json:"Relation_Type,omitempty"`
-		Title        string `json:"Title,omitempty"`
-	} `json:"Related_Datapackage,omitempty"`
-	RetentionPeriod  int    `json:"Retention_Period,omitempty"`
-	DataType         string `json:"Data_Type,omitempty"`
+			IdentifierScheme string `json:"Identifier_Scheme,omitempty" yaml:"Identifier_Scheme,omitempty"`
+			Identifier       string `json:"Identifier,omitempty" yaml:"Identifier,omitempty"`
+		} `json:"Persistent_Identifier,omitempty" yaml:"Persistent_Identifier,omitempty"`
+		RelationType string `json:"Relation_Type,omitempty" yaml:"Relation_Type,omitempty"`
+		Title        string `json:"Title,omitempty" yaml:"Title,omitempty"`
+	} `json:"Related_Datapackage,omitempty" yaml:"Related_Datapackage,omitempty"`
+	RetentionPeriod  RetentionPeriod `json:"Retention_Period,omitempty" yaml:"Retention_Period,omitempty"`
+	DataType         string          `json:"Data_Type,omitempty" yaml:"Data_Type,omitempty"`
 	FundingReference []struct {
-		FunderName  string `json:"Funder_Name,omitempty"`
-		AwardNumber string `json:"Award_Number,omitempty"`
-	} `json:"Funding_Reference,omitempty"`
+		FunderName  string `json:"Funder_Name,omitempty" yaml:"Funder_Name,omitempty"`
+		AwardNumber string `json:"Award_Number,omitempty" yaml:"Award_Number,omitempty"`
+	} `json:"Funding_Reference,omitempty" yaml:"Funding_Reference,omitempty"`
 	Creator []struct {
 		Name struct {
-			GivenName  string `json:"Given_Name,omitempty"`
-			FamilyName string `json:"Family_Name,omitempty"`
-		} `json:"Name,omitempty"`
-		Affiliation      []string `json:"Affiliation,omitempty"`
+			GivenName  string `json:"Given_Name,omitempty" yaml:"Given_Name,omitempty"`
+			FamilyName string `json:"Family_Name,omitempty" yaml:"Family_Name,omitempty"`
+		} `json:"Name,omitempty" yaml:"Name,omitempty"`
+		Affiliation      StringOrSlice `json:"Affiliation,omitempty" yaml:"Affiliation,omitempty"`
 		PersonIdentifier []struct {
-			NameIdentifierScheme string `json:"Name_Identifier_Scheme,omitempty"`
-			NameIdentifier       string `json:"Name_Identifier,omitempty"`
-		} `json:"Person_Identifier,omitempty"`
-	} `json:"Creator,omitempty"`
+			NameIdentifierScheme string `json:"Name_Identifier_Scheme,omitempty" yaml:"Name_Identifier_Scheme,omitempty"`
+			NameIdentifier       string `json:"Name_Identifier,omitempty" yaml:"Name_Identifier,omitempty"`
+		} `json:"Person_Identifier,omitempty" yaml:"Person_Identifier,omitempty"`
+	} `json:"Creator,omitempty" yaml:"Creator,omitempty"`
 	Contributor []struct {
 		Name struct {
-			GivenName  string `json:"Given_Name,omitempty"`
-			FamilyName string `json:"Family_Name,omitempty"`
-		} `json:"Name,omitempty"`
-		Affiliation      []string `json:"Affiliation,omitempty"`
+			GivenName  string `json:"Given_Name,omitempty" yaml:"Given_Name,omitempty"`
+			FamilyName string `json:"Family_Name,omitempty" yaml:"Family_Name,omitempty"`
+		} `json:"Name,omitempty" yaml:"Name,omitempty"`
+		Affiliation      StringOrSlice `json:"Affiliation,omitempty" yaml:"Affiliation,omitempty"`
 		PersonIdentifier []struct {
-			NameIdentifierScheme string `json:"Name_Identifier_Scheme,omitempty"`
-			NameIdentifier       string `json:"Name_Identifier,omitempty"`
-		} `json:"Person_Identifier,omitempty"`
-		ContributorType string `json:"Contributor_Type,omitempty"`
-	} `json:"Contributor,omitempty"`
-	DataAccessRestriction string `json:"Data_Access_Restriction,omitempty"`
-	Title                 string `json:"Title,omitempty"`
-	Description           string `json:"Description,omitempty"`
-	Version               string `json:"Version,omitempty"`
-	RetentionInformation  string `json:"Retention_Information,omitempty"`
-	EmbargoEndDate        string `json:"Embargo_End_Date,omitempty"`
-	DataClassification    string `json:"Data_Classification,omitempty"`
-	CollectionName        string `json:"Collection_Name,omitempty"`
-	Remarks               string `json:"Remarks,omitempty"`
-	License               string `json:"License,omitempty"`
+			NameIdentifierScheme string `json:"Name_Identifier_Scheme,omitempty" yaml:"Name_Identifier_Scheme,omitempty"`
+			NameIdentifier       string `json:"Name_Identifier,omitempty" yaml:"Name_Identifier,omitempty"`
+		} `json:"Person_Identifier,omitempty" yaml:"Person_Identifier,omitempty"`
+		ContributorType string `json:"Contributor_Type,omitempty" yaml:"Contributor_Type,omitempty"`
+	} `json:"Contributor,omitempty" yaml:"Contributor,omitempty"`
+	DataAccessRestriction string `json:"Data_Access_Restriction,omitempty" yaml:"Data_Access_Restriction,omitempty"`
+	Title                 string `json:"Title,omitempty" yaml:"Title,omitempty"`
+	Description           string `json:"Description,omitempty" yaml:"Description,omitempty"`
+	Version               string `json:"Version,omitempty" yaml:"Version,omitempty"`
+	RetentionInformation  string `json:"Retention_Information,omitempty" yaml:"Retention_Information,omitempty"`
+	EmbargoEndDate        string `json:"Embargo_End_Date,omitempty" yaml:"Embargo_End_Date,omitempty"`
+	DataClassification    string `json:"Data_Classification,omitempty" yaml:"Data_Classification,omitempty"`
+	CollectionName        string `json:"Collection_Name,omitempty" yaml:"Collection_Name,omitempty"`
+	Remarks               string `json:"Remarks,omitempty" yaml:"Remarks,omitempty"`
+	License               string `json:"License,omitempty" yaml:"License,omitempty"`
 }
 
 const DEBUG bool = false
@@ -179,79 +190,563 @@ const maxRGB8Bytes = 255
 
 var ERROR_COUNT uint = 0
 
+// command line flags
+var output_format = flag.String("format", "", "output format: pdf, latex, ris, csl, cff, jsonld, rocrate, codemeta, yaml, csv, xlsx (or excel), text (or txt), docx, html, json, combi, zenodo, figshare, osf, mods, oai, frictionless, dot, mermaid, graph, dcat, eml, iso19139, citation, template, email, readme, signposting, xmp, qr, sqlite, ndjson (default text on the console, or the format matching the -output extension)")
+var latex_fragment = flag.Bool("latex-fragment", false, "with -format latex only write the document body, for \\input into an existing document")
+var name_style = flag.String("name-style", "full", "how person names are written: full (Given Family) or citation (Family, Given)")
+var page_size = flag.String("page-size", "A4", "PDF page size: A4, Letter or Legal")
+var page_orientation = flag.String("orientation", "portrait", "PDF page orientation: portrait or landscape")
+var output_file = flag.String("output", "", "output file, for several input files the combined PDF, CSV or xlsx report or the SQLite database")
+var append_source = flag.Bool("append-source", false, "add the source metadata JSON to the PDF report as an appendix")
+var output_dir = flag.String("output-dir", "", "write one output file per input (and format) to this directory, named after the dataset title")
+var output_formats = flag.String("formats", "", "comma separated output formats written in one run, e.g. pdf,csv,json, one file per format")
+var name_from = flag.String("name-from", "title", "how the files in -output-dir are named: title (the dataset title) or input (the input file name)")
+var set_overrides set_flags
+var sort_values = flag.Bool("sort", false, "sort Discipline, Tag, Covered_Geolocation_Place and persons alphabetically instead of keeping the source order")
+var sort_order = flag.String("sort-order", "alphabetical", "with -sort the order of the creators and contributors: alphabetical, or orcid-first for those with an ORCID first")
+var decode_html = flag.Bool("decode-html", false, "decode HTML entities such as &eacute; in the metadata text before converting")
+var normalize = flag.Bool("normalize", false, "write values in their canonical form, the Language as its ISO 639-1 code")
+var fingerprint_mode = flag.Bool("fingerprint", false, "print the SHA-256 of the metadata content of each input file, independent of key order and formatting, with -output-dir also written to <name>.sha256")
+var cite_mode = flag.Bool("cite", false, "print the citation of each input file on a single line, in the -citation-style, instead of converting")
+var stats_mode = flag.Bool("stats", false, "print how often each tag, discipline and licence occurs in the input files instead of converting")
+var pii_scan = flag.Bool("pii-scan", false, "print the e-mail addresses, BSNs and phone numbers found in the metadata of the input files instead of converting, the exit status is 1 if there are any")
+var strict_schema = flag.Bool("strict-schema", false, "reject input with a key the metadata model does not have, instead of reporting it as an unmapped field")
+var tui_mode = flag.Bool("tui", false, "browse and edit the metadata fields of the input file in the terminal, s saves them to the file (or -output) as JSON")
+var watch_mode = flag.Bool("watch", false, "convert the input file again each time it is saved, until Ctrl-C")
+var pretty_json = flag.Bool("pretty", false, "with -format json or combi sort the keys of every object alphabetically instead of in the Yoda form order")
+var validate_mode = flag.Bool("validate", false, "print the validation errors and warnings of the input files instead of converting, as JSON with -format json; the exit status is 1 if there are errors")
+var count_words = flag.Bool("count-words", false, "print the word and character counts of Title, Description and Remarks instead of converting")
+var min_words = flag.Int("min-words", 20, "with -count-words the number of words below which a description is too short")
+var diff_mode = flag.Bool("diff", false, "compare two metadata files field by field: -diff a.json b.json")
+var no_progress = flag.Bool("no-progress", false, "do not show the progress of several input files on stderr")
+var bagit = flag.Bool("bagit", false, "with -output-dir and several input files write the BagIt bagit.txt, bag-info.txt and checksum manifest of the output files")
+var bagit_algorithm = flag.String("bagit-algorithm", "sha256", "with -bagit the checksum algorithm of the manifest: sha256 or sha512")
+var workers = flag.Int("workers", runtime.NumCPU(), "number of input files processed concurrently when several are given")
+var ror_lookup = flag.Bool("ror-lookup", false, "suggest ROR identifiers for affiliations using the ROR API (needs network access)")
+var ror_enrich = flag.Bool("ror-enrich", false, "replace affiliations by the name and id of the matching ROR organisation before converting (needs network access)")
+var verify_orcids = flag.Bool("verify-orcids", false, "check with the ORCID public API that the ORCID iDs of the persons exist (needs network access)")
+var funder_enrich = flag.Bool("funder-enrich", false, "replace funder names by the Crossref Funder Registry name and funder DOI before converting (needs network access)")
+var verbose = flag.Bool("v", false, "verbose, log every step (by default the warnings about the metadata and the errors are logged)")
+var quiet = flag.Bool("quiet", false, "no banner or summary line, and only errors are logged")
+var log_level = flag.String("log-level", "", "log level: debug, info, warn or error (overrides -v)")
+var log_format = flag.String("log-format", "text", "log format: text or json")
+var serve_addr = flag.String("serve", "", "run an HTTP conversion server on this address, e.g. :8080")
+var metrics_addr = flag.String("metrics-addr", "", "with -serve, expose Prometheus /metrics on this separate address, e.g. 127.0.0.1:9090")
+var input_url = flag.String("input-url", "", "read the metadata from this http(s) URL instead of a file")
+var url_timeout = flag.Duration("url-timeout", 30*time.Second, "time limit for downloading -input-url")
+var yoda_server = flag.String("yoda-server", "", "read the metadata of -collection from this Yoda portal, e.g. https://portal.yoda.example.nl")
+var yoda_collection = flag.String("collection", "", "with -yoda-server, the collection to read, e.g. /zone/home/research-myproject")
+var yoda_config_file = flag.String("yoda-config", "", "file with the Yoda username and password (default <user config dir>/readYmeta/yoda.json)")
+var patch_file = flag.String("patch", "", "JSON file with the fields to change, applied to every input before it is converted")
+var system_file = flag.String("system", "", "JSON file with the System block for -format combi, -set values override it")
+var category_map = flag.String("category-map", "", "JSON or YAML file mapping Disciplines to Figshare category IDs (and licences to licence IDs) for -format figshare")
+var contributor_types = flag.String("contributor-type", "", "only output the contributors of these comma separated Contributor_Types, e.g. DataManager,ProjectLeader (Unspecified for those without a type)")
+var oai_identifier_flag = flag.String("identifier", "", "with -format oai the OAI identifier of the record (default derived from the DOI, or from the metadata)")
+var graph_syntax = flag.String("graph-syntax", "dot", "with -format graph the diagram syntax: dot (Graphviz) or mermaid")
+var dcat_uri = flag.String("uri", "", "with -format dcat the IRI of the dataset (default the DOI of the data package)")
+var citation_style = flag.String("citation-style", "apa", "with -format citation the citation style: apa, chicago, ieee or vancouver")
+var citation_style_file = flag.String("citation-style-file", "", "with -format citation a CSL style file to use instead of -citation-style")
+var citation_html = flag.Bool("citation-html", false, "with -format citation write the citation as HTML instead of plain text")
+var html_jsonld = flag.Bool("html-jsonld", false, "with -format html embed the schema.org JSON-LD of -format jsonld in a <script type=\"application/ld+json\"> element")
+var template_file = flag.String("template", "", "with -format template the Go text/template file the metadata is rendered with")
+var qr_doi = flag.String("doi", "", "with -format qr, -qr or -format email the DOI to use, instead of the DOI found in the metadata")
+var signposting_base = flag.String("signposting-base", "", "with -format signposting the URL the JSON-LD file is published under, the describedby link is relative without it")
+var email_greeting = flag.String("salutation", "Dear", "with -format email the greeting before the given name of the first creator")
+var email_template_file = flag.String("email-template", "", "with -format email a Go text/template file for the message body instead of the built-in text")
+var qr_size = flag.Int("qr-size", 256, "with -format qr the width and height of the QR code PNG in pixels")
+var qr_level = flag.String("qr-level", "M", "QR code error correction level: L (7%), M (15%), Q (25%) or H (30%)")
+var pdf_qr = flag.Bool("qr", false, "put a QR code of the dataset DOI in the PDF report header")
+var iso_bbox = flag.String("bbox", "", "with -format iso19139 the placeholder bounding box west,east,south,north in decimal degrees, e.g. 3.2,7.3,50.7,53.6")
+var oai_list_records = flag.Bool("oai-list-records", false, "with -format oai and several input files write one ListRecords response instead of a record per file")
+var no_hash = flag.Bool("no-hash", false, "with -format frictionless do not compute the sha256 of the data package files, for very large packages")
+var osf_combined = flag.Bool("osf-combined", false, "with -format osf write the node and contributors payloads as one document instead of node.json and contributors.json")
+var discipline_list_file = flag.String("discipline-list", "", "file with the valid Discipline values, one per line (default the embedded OECD Fields of Science list)")
+var strict = flag.Bool("strict", false, "treat a Relation_Type that is not a DataCite relationType as an error")
+var schema_file = flag.String("schema", "", "validate the input against a JSON Schema file, \"default\" uses the bundled Yoda schema")
+
 func main() {
 
-	msg := "readYmeta2 v" + _MYVERSION_ + " - (C) Brett G. Olivier, Vrije Universiteit Amsterdam, 2023"
-	fmt.Println(msg)
-	// fmt.Println()
-	fmt.Println(" ")
+	flag.StringVar(output_dir, "outdir", "", "short for -output-dir")
+	flag.Var(&set_overrides, "set", "set an output field that has no Yoda counterpart, e.g. -set programmingLanguage=Go (repeatable, codemeta and combi)")
+	flag.Usage = usage
+	flag.Parse()
+
+	// the banner would be the first line of an NDJSON stream, an e-mail body, the JSON validation issues or the
+	// citations on stdout
+	email_stdout := *output_format == "email" && *output_file == "" && *output_dir == ""
+	validate_json := *validate_mode && *output_format == "json"
+	if !*quiet && !(*output_format == "ndjson" && *output_file == "") && !email_stdout && !validate_json && !*cite_mode {
+		msg := "readYmeta2 v" + _MYVERSION_ + " - (C) Brett G. Olivier, Vrije Universiteit Amsterdam, 2023"
+		fmt.Println(msg)
+		// fmt.Println()
+		fmt.Println(" ")
+	}
+
+	level, err := log_level_from_flags(*log_level, *verbose, *quiet)
+	errcntrl(err)
+	errcntrl(setup_logging(level, *log_format))
+	errcntrl(check_name_style(*name_style))
+	errcntrl(check_sort_order(*sort_order))
+	errcntrl(check_citation_style(*citation_style))
+	errcntrl(check_qr_options(*qr_size, *qr_level))
+	errcntrl(check_page_layout(*page_size, *page_orientation))
+	*output_format, err = resolve_output_format(*output_format, *output_file)
+	errcntrl(err)
+	errcntrl(check_name_from(*name_from))
+	// -formats writes several formats at once, otherwise there is just the one
+	output_format_list := []string{*output_format}
+	if *output_formats != "" {
+		output_format_list, err = parse_output_formats(*output_formats)
+		errcntrl(err)
+		if *output_file != "" {
+			errcntrl(fmt.Errorf("-formats writes a file per format, use -output-dir instead of -output"))
+		}
+	}
+	// a template that does not parse is reported before anything is written
+	if slices.Contains(output_format_list, "template") {
+		output_template, err = load_output_template(*template_file)
+		errcntrl(err)
+	}
+	if *email_template_file != "" && slices.Contains(output_format_list, "email") {
+		email_template, err = load_email_template(*email_template_file)
+		errcntrl(err)
+	}
+	if (*yoda_server == "") != (*yoda_collection == "") {
+		errcntrl(fmt.Errorf("-yoda-server and -collection must be given together"))
+	}
+
+	// Ctrl-C cancels the server and batch processing
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *serve_addr != "" {
+		errcntrl(serve(ctx, *serve_addr))
+		return
+	}
+
+	// compare two files, the exit status is 1 when they differ
+	if *diff_mode {
+		if flag.NArg() != 2 {
+			errcntrl(fmt.Errorf("-diff needs exactly two input files, got %d", flag.NArg()))
+		}
+		a, err := read_metadata_file(ctx, flag.Arg(0))
+		errcntrl(err)
+		b, err := read_metadata_file(ctx, flag.Arg(1))
+		errcntrl(err)
+		diffs := diff_metadata(a, b)
+		errcntrl(write_diff(os.Stdout, flag.Arg(0), flag.Arg(1), diffs))
+		if len(diffs) > 0 {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// a directory of metadata files is converted like that many input files, a data package directory is its
+	// yoda-metadata.json
+	inputs, err := metadata_input_files(flag.Args())
+	errcntrl(err)
+	batch := len(inputs) > 1
+	for _, arg := range flag.Args() {
+		if fi, err := os.Stat(arg); err == nil && fi.IsDir() && !slices.Contains(inputs, filepath.Join(arg, "yoda-metadata.json")) {
+			batch = true
+		}
+	}
+	if batch && len(inputs) == 0 {
+		errcntrl(fmt.Errorf("no metadata files found in %s", strings.Join(flag.Args(), ", ")))
+	}
+	if *watch_mode {
+		switch {
+		case batch:
+			errcntrl(fmt.Errorf("-watch follows a single input file, not %d", len(inputs)))
+		case *yoda_server != "" || *input_url != "" || flag.Arg(0) == stdin_name:
+			errcntrl(fmt.Errorf("-watch needs an input file, not a Yoda collection, URL or stdin"))
+		case *output_format == "sqlite" || *output_format == "ndjson":
+			errcntrl(fmt.Errorf("-watch cannot be used with -format %s", *output_format))
+		}
+	}
+	if *tui_mode {
+		switch {
+		case batch:
+			errcntrl(fmt.Errorf("-tui edits a single input file, not %d", len(inputs)))
+		case *yoda_server != "" || *input_url != "" || flag.Arg(0) == stdin_name:
+			errcntrl(fmt.Errorf("-tui needs an input file, not a Yoda collection, URL or stdin"))
+		case strings.HasSuffix(strings.ToLower(flag.Arg(0)), ".gz") && *output_file == "":
+			errcntrl(fmt.Errorf("-tui saves plain JSON, give the file to save to with -output"))
+		}
+	}
+	if *bagit {
+		if !batch || *output_dir == "" {
+			errcntrl(fmt.Errorf("-bagit needs -output-dir and several input files or a directory"))
+		}
+		if _, ok := bagit_algorithms[*bagit_algorithm]; !ok {
+			errcntrl(fmt.Errorf("unknown -bagit-algorithm %q, use sha256 or sha512", *bagit_algorithm))
+		}
+	}
+
+	// print the content hashes instead of converting
+	if *fingerprint_mode {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-fingerprint needs at least one input file"))
+		}
+		errcntrl(write_fingerprints(ctx, os.Stdout, inputs, *workers, *output_dir))
+		return
+	}
+
+	// print the citations instead of converting
+	if *cite_mode {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-cite needs at least one input file"))
+		}
+		errcntrl(write_citations(ctx, os.Stdout, inputs, *workers))
+		return
+	}
+
+	// print the frequency tables instead of converting
+	if *stats_mode {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-stats needs at least one input file"))
+		}
+		errcntrl(write_stats(ctx, os.Stdout, inputs, *workers))
+		return
+	}
+
+	// print the text metrics instead of converting
+	if *count_words {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-count-words needs at least one input file"))
+		}
+		errcntrl(write_word_counts(ctx, os.Stdout, inputs, *min_words, *workers))
+		return
+	}
+
+	// print the personal information found, the exit status is 1 when there is any
+	if *pii_scan {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-pii-scan needs at least one input file"))
+		}
+		n, err := write_pii_scan(ctx, os.Stdout, inputs, *workers)
+		errcntrl(err)
+		if n > 0 {
+			slog.Warn("personal information found", "warnings", n)
+			os.Exit(1)
+		}
+		return
+	}
+	if *validate_mode {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-validate needs at least one input file"))
+		}
+		n, err := write_validation(ctx, os.Stdout, inputs, *workers, validate_json, *strict)
+		errcntrl(err)
+		if n > 0 {
+			slog.Error("metadata is not valid", "errors", n)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// a line per input file, and per metadata file in an input directory, written while the files are read
+	if *output_format == "ndjson" {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-format ndjson needs at least one input file or directory"))
+		}
+		if *output_dir != "" {
+			errcntrl(fmt.Errorf("-format ndjson writes a single stream, use -output or stdout instead of -output-dir"))
+		}
+		err := write_ndjson(ctx, flag.Args(), *output_file, *workers)
+		if errors.Is(err, context.Canceled) {
+			slog.Warn("batch interrupted", "error", err)
+			stop()
+			os.Exit(130)
+		}
+		errcntrl(err)
+		return
+	}
+
+	// every input file, and every metadata file in an input directory, is loaded into the one database
+	if *output_format == "sqlite" {
+		if flag.NArg() == 0 {
+			errcntrl(fmt.Errorf("-format sqlite needs at least one input file or directory"))
+		}
+		if *output_dir != "" {
+			errcntrl(fmt.Errorf("-format sqlite writes a single database, use -output instead of -output-dir"))
+		}
+		err := write_sqlite(ctx, flag.Args(), *output_file, *workers)
+		if errors.Is(err, context.Canceled) {
+			slog.Warn("loading interrupted", "error", err)
+			stop()
+			os.Exit(130)
+		}
+		errcntrl(err)
+		return
+	}
+
+	// several input files (or a directory of them) are merged into a single PDF report, a CSV with one row per
+	// dataset, an xlsx workbook or a DOT or Mermaid diagram, the other formats, and every format with -output-dir,
+	// convert each file on its own
+	if batch {
+		if *workers < 1 {
+			errcntrl(fmt.Errorf("-workers must be at least 1, got %d", *workers))
+		}
+		if *oai_identifier_flag != "" {
+			errcntrl(fmt.Errorf("-identifier names a single record, it cannot be used with %d input files", len(inputs)))
+		}
+		if *dcat_uri != "" {
+			errcntrl(fmt.Errorf("-uri names a single dataset, it cannot be used with %d input files", len(inputs)))
+		}
+		if *output_formats != "" && *output_dir == "" {
+			errcntrl(fmt.Errorf("-formats with %d input files needs -output-dir", len(inputs)))
+		}
+		var err error
+		switch {
+		case *output_dir != "":
+			err = write_output_dir(ctx, inputs, output_format_list, *output_dir, *workers)
+		case *output_format == "pdf":
+			err = write_combined_pdf_report(ctx, inputs, *output_file, *workers)
+		case *output_format == "csv":
+			err = write_combined_csv(ctx, inputs, *output_file, *workers)
+		case *output_format == "xlsx":
+			err = write_combined_xlsx(ctx, inputs, *output_file, *workers)
+		case *output_format == "dot" || *output_format == "mermaid":
+			err = write_combined_diagram(ctx, inputs, *output_format, *output_file, *workers)
+		case *output_format == "oai" && *oai_list_records:
+			err = write_oai_list_records(ctx, inputs, *output_file, *workers)
+		default:
+			// the other formats write a file per input, which a single -output file cannot hold
+			if *output_file != "" {
+				errcntrl(fmt.Errorf("-output names a single file, with %d input files -format %s writes a file per input, use -output-dir to choose their directory", len(inputs), *output_format))
+			}
+			err = write_batch_format(ctx, inputs, *output_format, "output", *workers)
+		}
+		if errors.Is(err, context.Canceled) {
+			slog.Warn("batch interrupted", "error", err)
+			stop()
+			os.Exit(130)
+		}
+		errcntrl(err)
+		// the bag describes the first dataset and lists every file in the output directory
+		if *bagit {
+			first, err := read_metadata_file(ctx, inputs[0])
+			if err != nil {
+				slog.Warn("cannot read metadata for bag-info.txt", "file", inputs[0], "error", err)
+			}
+			errcntrl(WriteBagItManifest(*output_dir, []Yoda18Metadata{first}, *bagit_algorithm))
+			slog.Info("BagIt manifest written", "dir", *output_dir, "algorithm", *bagit_algorithm)
+		}
+		return
+	}
 
 	// define input and output files
 	input_file_name, input_file_path, output_file_path, err1 := get_input_file_path_from_clargs()
 	errcntrl(err1)
 
-	input_file_name_noext := strings.Join(strings.Split(input_file_name, "")[:len(input_file_name)-5], "")
-	output_file_name := filepath.Join(output_file_path, input_file_name_noext+".pdf")
-	output_file_name_md := filepath.Join(output_file_path, input_file_name_noext+".md")
+	// a data package directory holds its metadata in yoda-metadata.json
+	var input_data_dir string
+	if fi, err := os.Stat(input_file_name); err == nil && fi.IsDir() && *input_url == "" && *yoda_server == "" {
+		input_data_dir = input_file_name
+		input_file_name = filepath.Join(input_file_name, "yoda-metadata.json")
+	}
 
-	// winblowz
+	// the input file itself, formats such as oai read more than the metadata from it
+	input_source := input_file_name
+	if *yoda_server != "" || *input_url != "" {
+		input_source = ""
+	}
 
-	// fmt.Println("->", output_file_name)
-	output_file_path_full, _ := path.Split(strings.Replace(output_file_name, "\\", "/", -1))
-	// fmt.Println("->", fsplitd, fsplitf)
-	_ = os.MkdirAll(output_file_path_full, os.ModePerm)
+	input_file_name_noext := input_file_stem(input_file_name)
+	output_file_name := filepath.Join(output_file_path, input_file_name_noext+".pdf")
+	if *output_file != "" {
+		output_file_name = *output_file
+	}
+	output_file_name_md := strings.TrimSuffix(output_file_name, filepath.Ext(output_file_name)) + ".md"
 
 	// fmt.Println("-->", input_file_name)
 	// fmt.Println("-->", output_file_path)
 	// fmt.Println("-->", output_file_name)
 	// fmt.Println("-->", output_file_name_md)
 
-	if DEBUG {
-		fmt.Println(input_file_name)
-		fmt.Println(input_file_path)
-		fmt.Println(output_file_name)
+	slog.Debug("resolved paths", "file", input_file_name, "input_path", input_file_path, "output_file", output_file_name)
+
+	// browse and edit the fields, saved to the input file or -output
+	if *tui_mode {
+		save_name := input_file_name
+		if *output_file != "" {
+			save_name = *output_file
+		}
+		errcntrl(run_tui(input_file_name, save_name))
+		return
+	}
+
+	// convert again on every save until Ctrl-C
+	if *watch_mode {
+		errcntrl(watch_input(ctx, watch_target{
+			Input:        input_file_name,
+			DataDir:      input_data_dir,
+			Formats:      output_format_list,
+			OutputPath:   output_file_path,
+			OutputFile:   output_file_name,
+			OutputFileMD: output_file_name_md,
+		}))
+		return
 	}
 
-	// read metadata file
-	json_file, err1 := os.ReadFile(input_file_name)
+	// read metadata file, or stdin, decompressing gzip input
+	var json_file []byte
+	if *yoda_server != "" {
+		client, err := new_yoda_client(*yoda_server, *yoda_config_file)
+		errcntrl(err)
+		json_file, err1 = client.get_metadata_raw(ctx, *yoda_collection)
+	} else if *input_url != "" {
+		json_file, err1 = fetch_metadata_url(ctx, *input_url)
+	} else {
+		slog.Debug("reading input", "file", input_file_name)
+		json_file, err1 = read_metadata_input(input_file_name)
+	}
 	errcntrl(err1)
 
-	// print the file cast as string
-	if DEBUG {
-		fmt.Print(string(json_file))
+	// optional JSON Schema validation of the raw input
+	if *schema_file != "" {
+		violations, err := validate_json_schema(json_file, *schema_file)
+		errcntrl(err)
+		for _, v := range violations {
+			slog.Error("schema violation", "file", input_file_name, "field_path", v.Path, "error", v.Message)
+		}
+		slog.Info("schema validation finished", "file", input_file_name, "schema", *schema_file, "violations", len(violations))
 	}
 
 	// create metadata struct and fill it with file data
-	var json_dat Yoda18Metadata
-	err2 := json.Unmarshal(json_file, &json_dat)
+	json_dat, err2 := decode_metadata(json_file, *strict_schema)
 	errcntrl(err2)
+	if *decode_html {
+		json_dat = DecodeHTMLEntities(json_dat)
+	}
+	json_dat = NormalizeUnicode(json_dat)
+	json_dat, err2 = apply_patch_file(json_dat, *patch_file)
+	errcntrl(err2)
+	json_dat = filter_contributors(json_dat, *contributor_types)
+	if *ror_enrich {
+		enriched, err := EnrichAffiliations(ctx, json_dat)
+		if err != nil {
+			slog.Warn("ROR enrichment failed, affiliations kept as given", "file", input_file_name, "error", err)
+		} else {
+			json_dat = enriched
+		}
+	}
+	if *funder_enrich {
+		enriched, err := EnrichFunding(ctx, json_dat)
+		if err != nil {
+			slog.Warn("funder enrichment failed, funder names kept as given", "file", input_file_name, "error", err)
+		} else {
+			json_dat = enriched
+		}
+	}
+	if *normalize {
+		json_dat = normalize_metadata(json_dat)
+	}
+	if *sort_values {
+		sort_metadata(&json_dat, *sort_order)
+	}
+	slog.Debug("detected schema", "file", input_file_name, "schema", metadata_schema_link(json_dat))
+	if !*quiet && !email_stdout {
+		fmt.Println(SummaryLine(json_dat))
+	}
+	report_affiliation_ror(ctx, json_dat, input_file_name, *ror_lookup)
+	report_data_type(json_dat, input_file_name)
+	report_unmapped(json_dat, input_file_name)
+	report_disciplines(json_dat, input_file_name)
+	report_language(json_dat, input_file_name)
+	if *verify_orcids {
+		report_orcids(ctx, json_dat, input_file_name)
+	}
+	if n := report_relation_types(json_dat, input_file_name, *strict); n > 0 && *strict {
+		errcntrl(&invalid_output_error{"metadata", []string{fmt.Sprintf("%d related datapackages have an unknown relation type", n)}})
+	}
+
+	// with -output-dir the files are named after the dataset title or the input file
+	if *output_dir != "" {
+		output_file_path = *output_dir
+		input_file_name_noext = output_dir_stem(json_dat, input_source)
+		if *output_file == "" {
+			output_file_name = filepath.Join(output_file_path, input_file_name_noext+".pdf")
+			output_file_name_md = filepath.Join(output_file_path, input_file_name_noext+".md")
+		}
+	}
 
 	ERROR_COUNT = 0
-	// lets do something more useful
-	if DEBUG {
-		fmt.Printf("\n\n----------------\n\n")
+	slog.Debug("chosen format", "file", input_file_name, "format", *output_format)
+	if *output_formats != "" {
+		var raw []byte
+		if *append_source {
+			raw = json_file
+		}
+		errcntrl(write_formats(json_dat, output_format_list, filepath.Join(output_file_path, input_file_name_noext), input_file_name, input_data_dir, input_source, raw))
+		return
+	}
+	// the text report and the citation of a single file are meant for reading in the terminal
+	if *output_format == "text" && *output_file == "" && *output_dir == "" {
+		errcntrl(exportText(json_dat, os.Stdout))
+		return
+	}
+	if *output_format == "citation" && *output_file == "" && *output_dir == "" {
+		errcntrl(exportCitation(json_dat, input_source, os.Stdout))
+		return
+	}
+	// the e-mail body goes to stdout to be piped into sendmail
+	if *output_format == "email" && *output_file == "" && *output_dir == "" {
+		errcntrl(exportEmail(json_dat, input_source, os.Stdout))
+		return
+	}
+	if *output_format != "pdf" && *output_file != "" {
+		errcntrl(write_output_file(json_dat, *output_format, *output_file, input_data_dir, input_source))
+		return
+	}
+	if *output_format != "pdf" {
+		err := write_output_format(json_dat, *output_format, filepath.Join(output_file_path, input_file_name_noext), input_data_dir, input_source)
+		errcntrl(err)
+		return
 	}
 
-	if DEBUG {
-		fmt.Printf("\n\n-------***-------\n\n")
+	var source []byte
+	if *append_source {
+		source = json_file
 	}
+	errcntrl(write_pdf_output(json_dat, input_file_name, output_file_name, output_file_name_md, source))
+}
+
+// write the PDF report and its Markdown summary, name is the input shown in the header and footer,
+// a non-nil source is added as an appendix
+func write_pdf_output(data Yoda18Metadata, name string, output_file_name string, output_file_name_md string, source []byte) error {
+	// winblowz
+	output_file_path_full, _ := path.Split(strings.Replace(output_file_name, "\\", "/", -1))
+	_ = os.MkdirAll(output_file_path_full, os.ModePerm)
+
 	//// New way of doing things where we write the document directly
-	doc := pdf.NewMaroto(consts.Portrait, consts.A4)
+	ERROR_COUNT = 0
+	doc := new_pdf_document()
 	//m.SetBorder(true)
-	doc.SetPageMargins(10, 10, 10)
-	doc = generate_pdf_report_basic(json_dat, doc, input_file_name)
+	doc = generate_pdf_report_basic(data, doc, name)
+	if source != nil {
+		pdf_write_source_appendix(doc, source)
+	}
 	err := doc.OutputFileAndClose(output_file_name)
-	errcntrl(err)
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", output_file_name, "format", "pdf")
 
 	// write the contents of the metadata to a md file
-	mdoc := "string contents of file\nthe end\n"
-	mdoc = create_md_readme(json_dat)
-	_ = write_string_to_file(mdoc, output_file_name_md)
-
+	mdoc := create_md_readme(data)
+	return write_string_to_file(mdoc, output_file_name_md)
 }
 
 // handle and error
 func errcntrl(e error) {
+	// metadata a format cannot be written from is the user's to fix, not a failure of the program
+	var invalid *invalid_output_error
+	if errors.As(e, &invalid) {
+		slog.Error(invalid.Error())
+		os.Exit(1)
+	}
 	if e != nil {
 		panic(e)
 	}
@@ -273,10 +768,208 @@ func write_string_to_file(mdoc string, fname string) error {
 		log.Fatal(err2)
 	}
 
-	fmt.Println("done.")
+	slog.Info("output written", "file", fname)
 	return err2
 }
 
+// write the metadata in one of the non-PDF output formats, the extension is added to stem,
+// data_dir is the data package directory when the input was given as a directory, source the input file
+func write_output_format(data Yoda18Metadata, format string, stem string, data_dir string, source string) error {
+	ext, _, err := output_renderer(format, data_dir, source)
+	if err != nil {
+		return err
+	}
+	return write_output_file(data, format, stem+ext, data_dir, source)
+}
+
+// write the metadata in one of the non-PDF output formats to the file fname
+func write_output_file(data Yoda18Metadata, format string, fname string, data_dir string, source string) error {
+	_, render, err := output_renderer(format, data_dir, source)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(fname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = render(data, f)
+	if err != nil {
+		// no empty or half written file is left behind
+		f.Close()
+		os.Remove(fname)
+		return err
+	}
+	slog.Info("output written", "file", fname, "format", format)
+
+	if format == "osf" && !*osf_combined {
+		return write_osf_contributors(data, fname)
+	}
+	return nil
+}
+
+// the file extension and render function of a non-PDF output format, source is the input file for the formats
+// that take more than the metadata from it
+func output_renderer(format string, data_dir string, source string) (string, func(Yoda18Metadata, io.Writer) error, error) {
+	var ext string
+	var render func(Yoda18Metadata, io.Writer) error
+
+	switch format {
+	case "latex":
+		ext = ".tex"
+		render = write_latex
+	case "ris":
+		ext = ".ris"
+		render = exportRIS
+	case "csl":
+		ext = ".csl.json"
+		render = exportCSL
+	case "cff":
+		ext = ".cff"
+		render = exportCFF
+	case "jsonld":
+		ext = ".jsonld"
+		render = exportJSONLD
+	case "rocrate":
+		// RO-Crate requires this exact filename so each crate gets its own directory
+		ext = string(filepath.Separator) + rocrate_metadata_name
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportROCrate(d, data_dir, w)
+		}
+	case "yaml":
+		ext = ".yaml"
+		render = exportYAML
+	case "csv":
+		ext = ".csv"
+		render = exportCSV
+	case "xlsx":
+		ext = ".xlsx"
+		render = exportXLSX
+	case "text":
+		ext = ".txt"
+		render = exportText
+	case "docx":
+		ext = ".docx"
+		render = exportDOCX
+	case "html":
+		ext = ".html"
+		render = exportHTML
+	case "json":
+		ext = ".json"
+		render = exportJSON
+	case "combi":
+		ext = ".combi.json"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportCombi(d, *system_file, set_overrides.values(), w)
+		}
+	case "zenodo":
+		ext = ".zenodo.json"
+		render = exportZenodo
+	case "figshare":
+		ext = ".figshare.json"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportFigshare(d, *category_map, w)
+		}
+	case "osf":
+		// node.json and contributors.json go into a directory per dataset, a combined document is a single file
+		ext = string(filepath.Separator) + "node.json"
+		if *osf_combined {
+			ext = ".osf.json"
+		}
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportOSF(d, *osf_combined, w)
+		}
+	case "mods":
+		ext = ".mods.xml"
+		render = exportMODS
+	case "frictionless":
+		ext = string(filepath.Separator) + frictionless_metadata_name
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportFrictionless(d, data_dir, w)
+		}
+	case "dot":
+		ext = ".dot"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportDOT(d, source, w)
+		}
+	case "mermaid":
+		ext = ".mmd"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportMermaid(d, source, w)
+		}
+	case "dcat":
+		ext = ".ttl"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportDCAT(d, source, w)
+		}
+	case "eml":
+		ext = ".eml.xml"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportEML(d, source, w)
+		}
+	case "iso19139":
+		ext = ".iso19139.xml"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportISO19139(d, source, w)
+		}
+	case "oai":
+		ext = ".oai.xml"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportOAI(d, source, w)
+		}
+	case "template":
+		ext = template_output_extension(*template_file)
+		render = exportTemplate
+	case "citation":
+		ext = ".citation.txt"
+		if *citation_html {
+			ext = ".citation.html"
+		}
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportCitation(d, source, w)
+		}
+	case "xmp":
+		ext = ".xmp"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportXMP(d, source, w)
+		}
+	case "signposting":
+		ext = ".headers"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportSignposting(d, source, w)
+		}
+	case "readme":
+		ext = ".README.txt"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportReadme(d, source, w)
+		}
+	case "email":
+		ext = ".email.txt"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportEmail(d, source, w)
+		}
+	case "qr":
+		ext = "-doi.png"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportQR(d, source, w)
+		}
+	case "codemeta":
+		ext = ".codemeta.json"
+		render = func(d Yoda18Metadata, w io.Writer) error {
+			return exportCodeMeta(d, set_overrides.values(), w)
+		}
+	default:
+		return "", nil, fmt.Errorf("unknown output format: %s", format)
+	}
+	return ext, render, nil
+}
+
 // create a md string that represents the Yoda metadata
 func create_md_readme(data Yoda18Metadata) string {
 	var out string = "# Hokey Kokey Reportey\n"
@@ -284,13 +977,14 @@ func create_md_readme(data Yoda18Metadata) string {
 	var basic string = fmt.Sprintln("\n## Identification")
 	basic += fmt.Sprintf("- Title: %s\n", data.Title)
 	basic += fmt.Sprintf("- CollectionDate: %s to %s\n", data.Collected.StartDate, data.Collected.EndDate)
-	basic += fmt.Sprintf("- ResourceType: %s\n", data.DataType)
-	basic += fmt.Sprintf("- Rights: %s\n", data.License)
+	resource_type_general, resource_type := datacite_resource_type(data.DataType)
+	basic += fmt.Sprintf("- ResourceType: %s (%s)\n", resource_type_general, resource_type)
+	basic += fmt.Sprintf("- Rights: %s\n", canonical_license(data.License))
 	basic += fmt.Sprintf("- Version: %s\n", data.Version)
 
 	var basic2 string = fmt.Sprintln("\n## Creator")
 	for cre := range data.Creator {
-		basic2 += fmt.Sprintf("- Creator: %s %s ", data.Creator[cre].Name.GivenName, data.Creator[cre].Name.FamilyName)
+		basic2 += fmt.Sprintf("- Creator: %s ", formatName(data.Creator[cre].Name, *name_style))
 		for pid := range data.Creator[cre].PersonIdentifier {
 			basic2 += fmt.Sprintf("(%s: %s) ", data.Creator[cre].PersonIdentifier[pid].NameIdentifierScheme,
 				data.Creator[cre].PersonIdentifier[pid].NameIdentifier)
@@ -303,7 +997,10 @@ func create_md_readme(data Yoda18Metadata) string {
 	basic2 += fmt.Sprintln("\n## Description")
 	basic2 += fmt.Sprintf("%s\n", data.Description)
 
-	out = out + basic + basic2
+	var full string = fmt.Sprintln("\n## All metadata")
+	full += full_data_markdown(data)
+
+	out = out + basic + basic2 + full
 	return out
 }
 
@@ -378,10 +1075,14 @@ func get_input_file_path_from_clargs() (string, string, string, error) {
 	cDir, err = os.Getwd()
 	errcntrl(err)
 
-	if len(os.Args) > 1 {
-		fname = os.Args[1]
+	if *yoda_server != "" {
+		fname = collection_input_name(*yoda_collection)
+	} else if *input_url != "" {
+		fname = url_input_name(*input_url)
+	} else if flag.NArg() > 0 {
+		fname = flag.Arg(0)
 	} else {
-		fmt.Println("Filename argument not provided, using default: yoda-metadata.json")
+		slog.Info("filename argument not provided, using default", "file", "yoda-metadata.json")
 		fname = "yoda-metadata.json"
 	}
 
@@ -390,10 +1091,16 @@ func get_input_file_path_from_clargs() (string, string, string, error) {
 
 	//
 	_, err = os.Stat(input_file_path)
-	if os.IsNotExist(err) {
-		fmt.Println("Input file path does not exist:", input_file_path)
+	if *yoda_server != "" {
+		slog.Info("reading input from Yoda", "server", *yoda_server, "collection", *yoda_collection)
+	} else if *input_url != "" {
+		slog.Info("reading input from URL", "url", *input_url)
+	} else if fname == stdin_name {
+		slog.Info("reading input from stdin")
+	} else if os.IsNotExist(err) {
+		slog.Error("input file path does not exist", "file", input_file_path)
 	} else {
-		fmt.Println("Input file path exists:", input_file_path)
+		slog.Info("input file path exists", "file", input_file_path)
 		err = nil
 	}
 
@@ -403,16 +1110,17 @@ func get_input_file_path_from_clargs() (string, string, string, error) {
 	// fmt.Println(">", output_file_path)
 	_, err = os.Stat(output_file_path)
 
+	// the directory is created when an output file is written, a console report leaves no trace
 	if os.IsNotExist(err) {
-		fmt.Println("Output file path base does not exist:", output_file_path)
-		err = os.Mkdir(output_file_path, os.ModeDir)
+		slog.Info("output file path base does not exist, created on write", "file", output_file_path)
+		err = nil
 		//_, _ = os.Stat(output_file_path)
 		//		if os.IsNotExist(err2) {
 		//			fmt.Println("$%^&*()")
 		//		}
 
 	} else {
-		fmt.Println("Output file path base exists:", output_file_path)
+		slog.Info("output file path base exists", "file", output_file_path)
 		err = nil
 	}
 
@@ -425,87 +1133,153 @@ func generate_pdf_report_basic(data Yoda18Metadata, doc pdf.Maroto, fname string
 	var ctime = time.Now().String()
 	var colwidth uint = 12
 	var rowheight float64 = 4
-	var textblock_divider float64 = 20
-	var empty_line_height float64 = 2
 
-	pdf_write_header(doc, fmt.Sprintf("\"%s\" metadata", fname), rowheight, colwidth)
+	// with -qr the header shows the QR code of the DOI, a report without DOI gets none
+	var qr_image []byte
+	if *pdf_qr {
+		var err error
+		qr_image, err = dataset_qr_png(data, fname)
+		if err != nil {
+			slog.Warn("no QR code in the PDF header", "file", fname, "error", err)
+		}
+	}
+	pdf_write_header(doc, fmt.Sprintf("\"%s\" metadata", fname), rowheight, colwidth, qr_image)
 	pdf_write_footer(doc, fmt.Sprintf("\"%s\" metadata generated on %s\nby readYmeta v%s", fname, ctime, _MYVERSION_), rowheight, colwidth)
 
-	pdf_write_labelled_row(doc, "Title", data.Title, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+	return pdf_write_report_body(data, doc)
+}
+
+// write the metadata sections of the report, shared by the single and combined reports
+func pdf_write_report_body(data Yoda18Metadata, doc pdf.Maroto) pdf.Maroto {
+	var colwidth uint = 12
+	var rowheight float64 = 4
+	// characters per unit of row height, more fit on a wider (landscape or Letter) page
+	var textblock_divider float64 = 20 * pdf_width_factor(doc)
+	var empty_line_height float64 = 2
+
+	report := build_report(data)
+
+	pdf_write_labelled_row(doc, "Title", report.Title.Text, rowheight, colwidth, empty_line_height, consts.Normal, pdf_report_colour(report.Title.Level))
 	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
 	pdf_write_row(doc, "Description", rowheight, colwidth, consts.Bold, pdfBlack())
-	if float64(len(data.Description))/textblock_divider > rowheight {
-		pdf_write_row(doc, data.Description, float64(len(data.Description))/textblock_divider, colwidth, consts.Normal, pdfBlack())
+	description := report.Description
+	if description.Level != report_normal {
+		pdf_write_row(doc, description.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(description.Level))
+	} else if float64(len(description.Text))/textblock_divider > rowheight {
+		pdf_write_row(doc, description.Text, float64(len(description.Text))/textblock_divider, colwidth, consts.Normal, pdfBlack())
 	} else {
-		pdf_write_row(doc, data.Description, rowheight, colwidth, consts.Normal, pdfInfoColour())
+		pdf_write_row(doc, description.Text, rowheight, colwidth, consts.Normal, pdfInfoColour())
 	}
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
 	pdf_write_row(doc, "Tags", rowheight, colwidth, consts.Bold, pdfBlack())
-	pdf_write_list(doc, data.Tag, rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_list(doc, report_texts(report.Tags), rowheight, colwidth, consts.Normal, pdfBlack())
 	// pdf_write_list_sub1(doc, data.Tag, rowheight, colwidth, consts.Normal, pdfBlack())
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_creators(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_persons(doc, "Creators", report.Creators, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_contributors(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	if report.Note.Text != "" {
+		pdf_write_row(doc, report.Note.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(report.Note.Level))
+		pdf_write_empty_row(doc, rowheight*2, colwidth)
+	}
+	pdf_write_persons(doc, "Contributors", report.Contributors, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
 	pdf_write_row(doc, "Disciplines", rowheight, colwidth, consts.Bold, pdfBlack())
-	pdf_write_list(doc, data.Discipline, rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_list(doc, report_texts(report.Disciplines), rowheight, colwidth, consts.Normal, pdfBlack())
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_row(doc, "Collected", rowheight, colwidth, consts.Bold, pdfBlack())
-	pdf_write_row_tuple_indent(doc, "StartDate", data.Collected.StartDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
-	pdf_write_row_tuple_indent(doc, "EndDate", data.Collected.EndDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_pairs(doc, "Collected", report.Collected, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_row(doc, "Covered Period", rowheight, colwidth, consts.Bold, pdfBlack())
-	pdf_write_row_tuple_indent(doc, "StartDate", data.CoveredPeriod.StartDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
-	pdf_write_row_tuple_indent(doc, "EndDate", data.CoveredPeriod.EndDate, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+	pdf_write_pairs(doc, "Covered Period", report.CoveredPeriod, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_funding(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_pairs(doc, "Funding references", report.Funding, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_related(doc, data, rowheight, colwidth, consts.Normal, pdfBlack())
+	pdf_write_related(doc, report.Related, rowheight, colwidth)
 	pdf_write_empty_row(doc, empty_line_height, colwidth)
 
-	pdf_write_labelled_row_error(doc, "Dataset Version", data.Version, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Licence", data.License, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Data Type", data.DataType, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	//pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	if data.DataAccessRestriction == "Open - freely retrievable" && data.DataClassification == "Public" {
-		pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfGreen())
-		pdf_write_labelled_row(doc, "Data Access Restriction", data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfGreen())
-	} else if data.DataAccessRestriction == "Open - freely retrievable" && data.DataClassification != "Public" {
-		pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfErrorColour())
-		pdf_write_labelled_row(doc, "Data Access Restriction", data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfErrorColour())
-	} else {
-		pdf_write_labelled_row(doc, "Data Classification", data.DataClassification, rowheight, colwidth, empty_line_height, consts.Normal, pdfWarningColour())
-		pdf_write_labelled_row(doc, "Data Access Restriction", data.DataAccessRestriction, rowheight, colwidth, empty_line_height, consts.Normal, pdfWarningColour())
+	for _, field := range report.Fields {
+		pdf_write_labelled_row(doc, field.Label, field.Value.Text, rowheight, colwidth, empty_line_height, consts.Normal, pdf_report_colour(field.Value.Level))
+	}
+	if len(report.Unmapped) > 0 {
+		pdf_write_empty_row(doc, empty_line_height, colwidth)
+		pdf_write_pairs(doc, "Unmapped fields", report.Unmapped, rowheight, colwidth)
 	}
 
-	pdf_write_labelled_row(doc, "Language", data.Language, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Retention Period", fmt.Sprint(data.RetentionPeriod)+" years", rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Retention Information", data.RetentionInformation, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Embargo EndDate", data.EmbargoEndDate, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	pdf_write_labelled_row(doc, "Remarks", data.Remarks, rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
-	if ERROR_COUNT > 0 {
+	// every field as in the Markdown summary, a line per element of the lists
+	pdf_write_empty_row(doc, empty_line_height*2, colwidth)
+	pdf_write_row(doc, "All metadata", rowheight, colwidth, consts.Bold, pdfBlack())
+	for _, line := range GetFullData(data) {
+		line = collapse_whitespace(line, false)
+		pdf_write_row(doc, line, max(rowheight, float64(len(line))/textblock_divider), colwidth, consts.Normal, pdfBlack())
+	}
+	// counted on the report rather than ERROR_COUNT so the PDF and DOCX reports agree
+	if issues := report_issue_count(report); issues > 0 {
 		pdf_write_empty_row(doc, 20, colwidth)
 		doc.Line(10)
 
 		pdf_write_labelled_row(doc, "readYmeta diagnostics", fmt.Sprintf(" - %d warnings were generated, please check for missing (optional) information.",
-			ERROR_COUNT), rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
+			issues), rowheight, colwidth, empty_line_height, consts.Normal, pdfBlack())
 	}
 
 	return doc
 }
 
+// the PDF colour of a report level, warnings and errors are counted in ERROR_COUNT
+func pdf_report_colour(level report_level) color.Color {
+	switch level {
+	case report_good:
+		return pdfGreen()
+	case report_info:
+		return pdfInfoColour()
+	case report_warning:
+		return pdfWarningColour()
+	case report_error:
+		return pdfErrorColour()
+	}
+	return pdfBlack()
+}
+
+// the texts of a report list, pdf_write_list colours the nullstring items itself
+func report_texts(values []report_value) []string {
+	var out []string
+	for _, v := range values {
+		out = append(out, v.Text)
+	}
+	return out
+}
+
 // New style PDFreportwriter header writer
-func pdf_write_header(m pdf.Maroto, line string, rowheight float64, colwidth uint) {
+// the height of the header row with a QR code, in mm
+const pdf_qr_header_height float64 = 20
+
+// the page header, with the PNG qr_image (when not nil) right of the header line
+func pdf_write_header(m pdf.Maroto, line string, rowheight float64, colwidth uint, qr_image []byte) {
 	m.RegisterHeader(func() {
+		if qr_image != nil {
+			m.Row(pdf_qr_header_height, func() {
+				m.Col(colwidth-2, func() {
+					m.Text(line, props.Text{
+						Top:         0,
+						Size:        12,
+						Extrapolate: true,
+					})
+				})
+				m.Col(2, func() {
+					err := m.Base64Image(base64.StdEncoding.EncodeToString(qr_image), consts.Png, props.Rect{Center: true, Percent: 100})
+					if err != nil {
+						slog.Warn("cannot draw the QR code", "error", err)
+					}
+				})
+			})
+			m.Line(10)
+			return
+		}
 		m.Row(rowheight, func() {
 			m.Col(colwidth, func() {
 				m.Text(line, props.Text{
@@ -733,148 +1507,45 @@ func pdf_write_list_sub1(m pdf.Maroto, lines []string, rowheight float64, colwid
 	}
 }
 **/
-func pdf_write_creators(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
+// write creators or contributors with their role, affiliations and identifiers indented below the name
+func pdf_write_persons(m pdf.Maroto, title string, persons []report_person, rowheight float64, colwidth uint) {
 	var ind1 uint = 1
-	// var ind2 uint = 2
-	pdf_write_row(m, "Creators", rowheight, colwidth, consts.Bold, pdfBlack())
-	for i := range data.Creator {
-		GivenName := data.Creator[i].Name.GivenName
-		FamilyName := data.Creator[i].Name.FamilyName
-		textcolour2 := textcolour
-		if GivenName == "" {
-			GivenName = "GivenName"
-			textcolour2 = pdfWarningColour()
-		}
-		if FamilyName == "" {
-			FamilyName = "FamilyName"
-			textcolour2 = pdfWarningColour()
-		}
-
-		pdf_write_row(m, fmt.Sprintf("%s %s", GivenName, FamilyName), rowheight, colwidth, consts.Normal, textcolour2)
-		for j := range data.Creator[i].Affiliation {
-			textcolour2 = textcolour
-			text := data.Creator[i].Affiliation[j]
-			if text == "" {
-				text = "Affiliation"
-				textcolour2 = pdfWarningColour()
-			}
-			pdf_write_row_indent(m, text, rowheight, colwidth, consts.Normal, textcolour2, ind1)
-		}
-		for k := range data.Creator[i].PersonIdentifier {
-			text := data.Creator[i].PersonIdentifier[k].NameIdentifierScheme
-			text2 := data.Creator[i].PersonIdentifier[k].NameIdentifier
-			textcolour2 = textcolour
-			if text == "" {
-				text = "IdentifierScheme"
-				textcolour2 = pdfErrorColour()
-			}
-			if text2 == "" {
-				text2 = "Identifier"
-				textcolour2 = pdfErrorColour()
-			}
-
-			pdf_write_row_indent(m, fmt.Sprintf("(%s) %s", text, text2),
-				rowheight, colwidth, consts.Normal, textcolour2, ind1)
+	pdf_write_row(m, title, rowheight, colwidth, consts.Bold, pdfBlack())
+	group := ""
+	for _, p := range persons {
+		if p.Group != "" && p.Group != group {
+			// contributors come ordered by type, each type gets a heading
+			group = p.Group
+			pdf_write_row(m, group, rowheight, colwidth, consts.Italic, pdf_report_colour(p.Role.Level))
 		}
-	}
-}
-
-// write the list of contributors to the PDF if len(contributors) > len(creators) trigger a creator vs. contribtor warning
-func pdf_write_contributors(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
-	var ind1 uint = 1
-	if len(data.Contributor) >= len(data.Creator) {
-		pdf_write_row(m, "\"INFO: there are more contributors than creators listed, please note that dataset authors should always be listed as creators to get credit for the dataset.\"", rowheight, colwidth, consts.Normal, pdfInfoColour())
-		pdf_write_empty_row(m, rowheight*2, colwidth)
-	}
-	// var ind2 uint = 2
-	pdf_write_row(m, "Contributors", rowheight, colwidth, consts.Bold, pdfBlack())
-	for i := range data.Contributor {
-		GivenName := data.Contributor[i].Name.GivenName
-		FamilyName := data.Contributor[i].Name.FamilyName
-		ContributorType := data.Contributor[i].ContributorType
-		textcolour2 := textcolour
-		textcolour3 := textcolour
-		if GivenName == "" {
-			GivenName = "GivenName"
-			textcolour2 = pdfWarningColour()
-		}
-		if FamilyName == "" {
-			FamilyName = "FamilyName"
-			textcolour2 = pdfWarningColour()
-		}
-		if ContributorType == "" {
-			ContributorType = "ContributorType"
-			textcolour3 = pdfWarningColour()
-		}
-		pdf_write_row(m, fmt.Sprintf("%s %s", GivenName, FamilyName), rowheight, colwidth, consts.Normal, textcolour2)
-		pdf_write_row_indent(m, ContributorType, rowheight, colwidth, consts.Normal, textcolour3, ind1)
-		for j := range data.Contributor[i].Affiliation {
-			textcolour2 := textcolour
-			affil := data.Contributor[i].Affiliation[j]
-			if affil == "" {
-				affil = "Affiliation"
-				textcolour2 = pdfWarningColour()
-			}
-
-			pdf_write_row_indent(m, affil, rowheight, colwidth, consts.Normal, textcolour2, ind1)
+		pdf_write_row(m, p.Name.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(p.Name.Level))
+		if p.Role.Text != "" && p.Group == "" {
+			pdf_write_row_indent(m, p.Role.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(p.Role.Level), ind1)
 		}
-		for k := range data.Contributor[i].PersonIdentifier {
-			text := data.Contributor[i].PersonIdentifier[k].NameIdentifierScheme
-			text2 := data.Contributor[i].PersonIdentifier[k].NameIdentifier
-			textcolour2 := textcolour
-			if text == "" {
-				text = "IdentifierScheme"
-				textcolour2 = pdfWarningColour()
-			}
-			if text2 == "" {
-				text2 = "Identifier"
-				textcolour2 = pdfWarningColour()
-			}
-			pdf_write_row_indent(m, fmt.Sprintf("(%s) %s", text, text2),
-				rowheight, colwidth, consts.Normal, textcolour2, ind1)
+		for _, affil := range p.Affiliations {
+			pdf_write_row_indent(m, affil.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(affil.Level), ind1)
+		}
+		for _, id := range p.Identifiers {
+			pdf_write_row_indent(m, id.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(id.Level), ind1)
 		}
 	}
 }
 
-// new function for writing funders
-func pdf_write_funding(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
-	pdf_write_row(m, "Funding references", rowheight, colwidth, consts.Bold, pdfBlack())
-	for i := range data.FundingReference {
-		pdf_write_row_tuple_indent(m, data.FundingReference[i].FunderName, data.FundingReference[i].AwardNumber, rowheight, colwidth, consts.Normal, pdfBlack(), 1)
+// write labelled values such as dates and funders as indented label/value rows
+func pdf_write_pairs(m pdf.Maroto, title string, pairs []report_pair, rowheight float64, colwidth uint) {
+	pdf_write_row(m, title, rowheight, colwidth, consts.Bold, pdfBlack())
+	for _, pair := range pairs {
+		pdf_write_row_tuple_indent(m, pair.Label, pair.Value.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(pair.Value.Level), 1)
 	}
 }
 
 //new functions for writing related data packages
-func pdf_write_related(m pdf.Maroto, data Yoda18Metadata, rowheight float64, colwidth uint, fontstyle consts.Style, textcolour color.Color) {
+func pdf_write_related(m pdf.Maroto, related []report_related, rowheight float64, colwidth uint) {
 	pdf_write_row(m, "Related datapackages", rowheight, colwidth, consts.Bold, pdfBlack())
-	for i := range data.RelatedDatapackage {
-		textcolour2 := textcolour
-		reltype := data.RelatedDatapackage[i].RelationType
-		if reltype == "" {
-			reltype = "RelationType"
-			textcolour2 = pdfWarningColour()
-		}
-		pdf_write_row(m, reltype, rowheight, colwidth, consts.Normal, textcolour2)
-		textcolour3 := textcolour
-		text := data.RelatedDatapackage[i].PersistentIdentifier.IdentifierScheme
-		text2 := data.RelatedDatapackage[i].PersistentIdentifier.Identifier
-		if text == "" {
-			text = "IdentifierSchema"
-			textcolour3 = pdfWarningColour()
-		}
-		if text2 == "" {
-			text2 = "Identifier"
-			textcolour3 = pdfWarningColour()
-		}
-		pdf_write_row_indent(m, "("+text+") "+text2, rowheight, colwidth, consts.Normal, textcolour3, 1)
-
-		textcolour4 := textcolour
-		title := data.RelatedDatapackage[i].Title
-		if title == "" {
-			title = "Title"
-			textcolour4 = pdfWarningColour()
-		}
-		pdf_write_row_indent(m, title, rowheight, colwidth, consts.Normal, textcolour4, 1)
+	for _, rel := range related {
+		pdf_write_row(m, rel.Relation.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(rel.Relation.Level))
+		pdf_write_row_indent(m, rel.Identifier.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(rel.Identifier.Level), 1)
+		pdf_write_row_indent(m, rel.Title.Text, rowheight, colwidth, consts.Normal, pdf_report_colour(rel.Title.Level), 1)
 	}
 }
 
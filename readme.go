@@ -0,0 +1,174 @@
+/*
+readme.go the -format readme output: a plain text README to deposit with the data package, with the sections of
+the README templates for datasets: general information, authors, description, methodological information,
+sharing and access, retention, funding and how to cite, wrapped at 80 columns.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// the line width of the README
+const readme_width int = 80
+
+// the indent of the continuation lines of a field and of the lines below a list item
+const readme_indent string = "    "
+
+// write a section heading, underlined
+func readme_heading(sb *strings.Builder, heading string) {
+	sb.WriteString("\n" + heading + "\n" + strings.Repeat("-", len(heading)) + "\n")
+}
+
+// write "Label: value" wrapped, continuation lines indented; an empty value is left out
+func readme_field(sb *strings.Builder, label string, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	text := email_wrap(label+": "+value, readme_width-len(readme_indent))
+	sb.WriteString(strings.ReplaceAll(text, "\n", "\n"+readme_indent) + "\n")
+}
+
+// write a wrapped paragraph, existing paragraphs kept apart by an empty line
+func readme_paragraph(sb *strings.Builder, text string) {
+	var paragraphs []string
+	for _, p := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, email_wrap(p, readme_width))
+		}
+	}
+	sb.WriteString(strings.Join(paragraphs, "\n\n") + "\n")
+}
+
+// write a list item "  - text" wrapped, continuation lines indented to the text
+func readme_item(sb *strings.Builder, text string) {
+	lines := email_wrap(text, readme_width-len(readme_indent))
+	sb.WriteString("  - " + strings.ReplaceAll(lines, "\n", "\n"+readme_indent) + "\n")
+}
+
+// a date range "start to end", one date when the other is missing
+func readme_dates(start string, end string) string {
+	start, end = strings.TrimSpace(start), strings.TrimSpace(end)
+	if start != "" && end != "" && start != end {
+		return start + " to " + end
+	}
+	if start != "" {
+		return start
+	}
+	return end
+}
+
+// the README of the metadata read from source, now dates the citation when the metadata has no year
+func render_readme(doc Yoda18Metadata, source string, now time.Time) (string, error) {
+	var sb strings.Builder
+	sys := oai_system(doc, source)
+
+	title := strings.Join(strings.Fields(doc.Title), " ")
+	if title == "" {
+		title = "Untitled"
+	}
+	if version := strings.TrimSpace(doc.Version); version != "" {
+		title += " (version " + version + ")"
+	}
+	title = email_wrap(title, readme_width)
+	longest := 0
+	for _, line := range strings.Split(title, "\n") {
+		longest = max(longest, len([]rune(line)))
+	}
+	sb.WriteString(title + "\n" + strings.Repeat("=", longest) + "\n")
+
+	readme_heading(&sb, "GENERAL INFORMATION")
+	readme_field(&sb, "Title", strings.Join(strings.Fields(doc.Title), " "))
+	readme_field(&sb, "Version", doc.Version)
+	if doi := citation_doi(doc, sys); doi != "" {
+		readme_field(&sb, "DOI", pid_link("DOI", doi))
+	}
+	readme_field(&sb, "Collection", doc.CollectionName)
+	readme_field(&sb, "Data type", doc.DataType)
+	readme_field(&sb, "Language", doc.Language)
+	readme_field(&sb, "Discipline", strings.Join(doc.Discipline, "; "))
+	readme_field(&sb, "Keywords", strings.Join(doc.Tag, "; "))
+
+	if len(doc.Creator) > 0 {
+		readme_heading(&sb, "AUTHORS")
+		for i, cre := range doc.Creator {
+			name := formatName(cre.Name, "full")
+			if name == "" {
+				name = "(no name)"
+			}
+			sb.WriteString(email_wrap(fmt.Sprintf("%d. %s", i+1, name), readme_width) + "\n")
+			if affiliation := strings.Join(cre.Affiliation, "; "); affiliation != "" {
+				sb.WriteString(readme_indent + strings.ReplaceAll(email_wrap("Affiliation: "+affiliation, readme_width-len(readme_indent)), "\n", "\n"+readme_indent) + "\n")
+			}
+			for _, pid := range cre.PersonIdentifier {
+				if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && strings.TrimSpace(pid.NameIdentifier) != "" {
+					sb.WriteString(readme_indent + "ORCID: " + orcid_url(pid.NameIdentifier) + "\n")
+				}
+			}
+		}
+	}
+
+	if strings.TrimSpace(doc.Description) != "" {
+		readme_heading(&sb, "DESCRIPTION")
+		readme_paragraph(&sb, doc.Description)
+	}
+
+	collected := readme_dates(doc.Collected.StartDate, doc.Collected.EndDate)
+	covered := readme_dates(doc.CoveredPeriod.StartDate, doc.CoveredPeriod.EndDate)
+	if collected != "" || covered != "" || len(doc.CoveredGeolocationPlace) > 0 {
+		readme_heading(&sb, "METHODOLOGICAL INFORMATION")
+		readme_field(&sb, "Date of data collection", collected)
+		readme_field(&sb, "Period covered by the data", covered)
+		readme_field(&sb, "Geographic location", strings.Join(doc.CoveredGeolocationPlace, "; "))
+	}
+
+	readme_heading(&sb, "SHARING AND ACCESS INFORMATION")
+	readme_field(&sb, "License", canonical_license(doc.License))
+	readme_field(&sb, "License URL", dataset_license_url(doc, sys))
+	readme_field(&sb, "Access", doc.DataAccessRestriction)
+	readme_field(&sb, "Embargo end date", doc.EmbargoEndDate)
+	readme_field(&sb, "Data classification", doc.DataClassification)
+
+	if doc.RetentionPeriod > 0 || strings.TrimSpace(doc.RetentionInformation) != "" {
+		readme_heading(&sb, "RETENTION")
+		if doc.RetentionPeriod > 0 {
+			readme_field(&sb, "Retention period", fmt.Sprintf("%d years", doc.RetentionPeriod))
+		}
+		readme_field(&sb, "Retention information", doc.RetentionInformation)
+	}
+
+	if len(doc.FundingReference) > 0 {
+		readme_heading(&sb, "FUNDING")
+		sb.WriteString("This work was supported by:\n")
+		for _, fund := range doc.FundingReference {
+			item := strings.TrimSpace(fund.FunderName)
+			if award := strings.TrimSpace(fund.AwardNumber); award != "" {
+				item += " (award " + award + ")"
+			}
+			readme_item(&sb, item)
+		}
+	}
+
+	citation, err := format_citation(doc, sys, *citation_style, *citation_style_file, false, now)
+	if err != nil {
+		return "", err
+	}
+	readme_heading(&sb, "HOW TO CITE")
+	readme_paragraph(&sb, citation)
+	return sb.String(), nil
+}
+
+// exportReadme writes the README of the metadata read from source to w
+func exportReadme(doc Yoda18Metadata, source string, w io.Writer) error {
+	readme, err := render_readme(doc, source, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, readme)
+	return err
+}
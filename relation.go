@@ -0,0 +1,76 @@
+/*
+relation.go checking the Related_Datapackage Relation_Type against the DataCite relationType vocabulary.
+*/
+
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// DataCiteRelationTypes is the controlled list of DataCite (schema 4.6) relationType values
+var DataCiteRelationTypes = []string{
+	"IsCitedBy", "Cites",
+	"IsSupplementTo", "IsSupplementedBy",
+	"IsContinuedBy", "Continues",
+	"IsDescribedBy", "Describes",
+	"HasMetadata", "IsMetadataFor",
+	"HasVersion", "IsVersionOf",
+	"IsNewVersionOf", "IsPreviousVersionOf",
+	"IsPartOf", "HasPart",
+	"IsPublishedIn",
+	"IsReferencedBy", "References",
+	"IsDocumentedBy", "Documents",
+	"IsCompiledBy", "Compiles",
+	"IsVariantFormOf", "IsOriginalFormOf",
+	"IsIdenticalTo",
+	"IsReviewedBy", "Reviews",
+	"IsDerivedFrom", "IsSourceOf",
+	"IsRequiredBy", "Requires",
+	"IsObsoletedBy", "Obsoletes",
+	"IsCollectedBy", "Collects",
+	"IsTranslationOf", "HasTranslation",
+}
+
+// the DataCite relationType of a Relation_Type, the Yoda form stores it followed by a description
+// as in "IsSupplementTo: Is supplement to"
+func datacite_relation_type(value string) string {
+	if i := strings.Index(value, ":"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value)
+}
+
+// check that a Relation_Type is a DataCite relationType, an empty value is not checked
+func check_relation_type(value string) error {
+	if value == "" {
+		return nil
+	}
+	relation := datacite_relation_type(value)
+	for _, rt := range DataCiteRelationTypes {
+		if rt == relation {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown Relation_Type %q, expected a DataCite relationType such as IsSupplementTo or References", relation)
+}
+
+// log every related datapackage with an unknown Relation_Type, as an error when strict, returns how many there are
+func report_relation_types(doc Yoda18Metadata, fname string, strict bool) int {
+	unknown := 0
+	for i, rel := range doc.RelatedDatapackage {
+		if check_relation_type(rel.RelationType) == nil {
+			continue
+		}
+		unknown++
+		attrs := []any{"file", fname, "field_path", fmt.Sprintf("Related_Datapackage[%d].Relation_Type", i), "value", rel.RelationType}
+		if strict {
+			slog.Error("unknown DataCite relation type", attrs...)
+		} else {
+			slog.Warn("unknown DataCite relation type", attrs...)
+		}
+	}
+	return unknown
+}
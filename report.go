@@ -0,0 +1,265 @@
+/*
+report.go the content of the metadata report, shared by the PDF and DOCX writers so both show the same values and warnings.
+*/
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// a blank line, possibly holding whitespace, separates description paragraphs
+var paragraph_break = regexp.MustCompile(`\n[ \t]*\n`)
+
+// how a report value is highlighted
+type report_level int
+
+const (
+	report_normal report_level = iota
+	report_good
+	report_info
+	report_warning
+	report_error
+)
+
+// a single value of the report, missing values are replaced by a placeholder with a warning or error level
+type report_value struct {
+	Text  string
+	Level report_level
+}
+
+// a creator or contributor, Role is only set for contributors
+type report_person struct {
+	Name         report_value
+	Role         report_value
+	Group        string
+	Affiliations []report_value
+	Identifiers  []report_value
+}
+
+// a labelled value such as a date, a funder with its award number or a dataset field
+type report_pair struct {
+	Label string
+	Value report_value
+}
+
+// a related datapackage
+type report_related struct {
+	Relation   report_value
+	Identifier report_value
+	Title      report_value
+}
+
+// the report sections in the order they are written
+type report_model struct {
+	Title         report_value
+	Description   report_value
+	Tags          []report_value
+	Creators      []report_person
+	Note          report_value
+	Contributors  []report_person
+	Disciplines   []report_value
+	Collected     []report_pair
+	CoveredPeriod []report_pair
+	Funding       []report_pair
+	Related       []report_related
+	Fields        []report_pair
+	Unmapped      []report_pair
+}
+
+// the value with nullstring and the given level when s is empty
+func report_text(s string, level report_level, missing report_level) report_value {
+	if s == "" || s == " " {
+		return report_value{nullstring, missing}
+	}
+	return report_value{s, level}
+}
+
+// the value with a placeholder naming the missing field
+func report_placeholder(s string, placeholder string, missing report_level) report_value {
+	if s == "" {
+		return report_value{placeholder, missing}
+	}
+	return report_value{s, report_normal}
+}
+
+// a list with a warning for every empty item, an empty list shows a single nullstring
+func report_list(items []string) []report_value {
+	var out []report_value
+	for _, item := range items {
+		out = append(out, report_text(item, report_normal, report_warning))
+	}
+	if len(out) == 0 {
+		out = append(out, report_value{nullstring, report_warning})
+	}
+	return out
+}
+
+// a person, a missing identifier part is reported with the given level
+func report_new_person(name NameStruct, affiliations []string, scheme_ids [][2]string, id_missing report_level) report_person {
+	var p report_person
+	p.Name.Level = report_normal
+	if name.GivenName == "" {
+		name.GivenName = "GivenName"
+		p.Name.Level = report_warning
+	}
+	if name.FamilyName == "" {
+		name.FamilyName = "FamilyName"
+		p.Name.Level = report_warning
+	}
+	p.Name.Text = formatName(name, *name_style)
+	for _, affil := range affiliations {
+		p.Affiliations = append(p.Affiliations, report_placeholder(affil, "Affiliation", report_warning))
+	}
+	for _, id := range scheme_ids {
+		level := report_normal
+		if id[0] == "" {
+			id[0] = "IdentifierScheme"
+			level = id_missing
+		}
+		if id[1] == "" {
+			id[1] = "Identifier"
+			level = id_missing
+		}
+		p.Identifiers = append(p.Identifiers, report_value{fmt.Sprintf("(%s) %s", id[0], id[1]), level})
+	}
+	return p
+}
+
+// collect the report content of a dataset
+func build_report(data Yoda18Metadata) report_model {
+	var r report_model
+
+	r.Title = report_text(data.Title, report_normal, report_warning)
+	r.Description = report_text(data.Description, report_normal, report_warning)
+	r.Tags = report_list(data.Tag)
+	r.Disciplines = report_list(data.Discipline)
+
+	for _, cre := range data.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		// creators without a proper identifier cannot get credit for the dataset
+		r.Creators = append(r.Creators, report_new_person(cre.Name, cre.Affiliation, ids, report_error))
+	}
+	if len(data.Contributor) >= len(data.Creator) {
+		r.Note = report_value{"\"INFO: there are more contributors than creators listed, please note that dataset authors should always be listed as creators to get credit for the dataset.\"", report_info}
+	}
+	for _, i := range contributor_order(data) {
+		con := data.Contributor[i]
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		p := report_new_person(con.Name, con.Affiliation, ids, report_warning)
+		p.Role = report_placeholder(con.ContributorType, "ContributorType", report_warning)
+		p.Group = contributor_group(con.ContributorType)
+		r.Contributors = append(r.Contributors, p)
+	}
+
+	r.Collected = []report_pair{
+		{"StartDate", report_text(data.Collected.StartDate, report_normal, report_warning)},
+		{"EndDate", report_text(data.Collected.EndDate, report_normal, report_warning)},
+	}
+	r.CoveredPeriod = []report_pair{
+		{"StartDate", report_text(data.CoveredPeriod.StartDate, report_normal, report_warning)},
+		{"EndDate", report_text(data.CoveredPeriod.EndDate, report_normal, report_warning)},
+	}
+	for _, fund := range data.FundingReference {
+		r.Funding = append(r.Funding, report_pair{fund.FunderName, report_text(fund.AwardNumber, report_normal, report_warning)})
+	}
+	for _, rel := range data.RelatedDatapackage {
+		scheme, id := rel.PersistentIdentifier.IdentifierScheme, rel.PersistentIdentifier.Identifier
+		id_level := report_normal
+		if id != "" && ValidatePID(scheme, id) != nil {
+			// an identifier that does not fit its DOI, Handle, ARK, URL or ISBN scheme
+			id_level = report_warning
+		}
+		id = pid_link(scheme, id)
+		if scheme == "" {
+			scheme = "IdentifierSchema"
+			id_level = report_warning
+		}
+		if id == "" {
+			id = "Identifier"
+			id_level = report_warning
+		}
+		relation := report_placeholder(rel.RelationType, "RelationType", report_warning)
+		if check_relation_type(rel.RelationType) != nil {
+			// not a DataCite relationType
+			relation.Level = report_warning
+		}
+		r.Related = append(r.Related, report_related{
+			Relation:   relation,
+			Identifier: report_value{"(" + scheme + ") " + id, id_level},
+			Title:      report_placeholder(rel.Title, "Title", report_warning),
+		})
+	}
+
+	r.Fields = append(r.Fields, report_pair{"Dataset Version", report_text(data.Version, report_normal, report_error)})
+	if license, err := NormalizeLicense(data.License); err == nil {
+		r.Fields = append(r.Fields, report_pair{"Licence", report_value{license, report_normal}})
+	} else if data.License != "" {
+		// not a recognised SPDX licence
+		r.Fields = append(r.Fields, report_pair{"Licence", report_value{data.License, report_warning}})
+	} else {
+		r.Fields = append(r.Fields, report_pair{"Licence", report_text(data.License, report_normal, report_warning)})
+	}
+	if check_data_type(data.DataType) != nil {
+		// not in the Yoda Data_Type vocabulary
+		r.Fields = append(r.Fields, report_pair{"Data Type", report_text(data.DataType, report_warning, report_warning)})
+	} else {
+		r.Fields = append(r.Fields, report_pair{"Data Type", report_value{data.DataType, report_normal}})
+	}
+	access_level := report_warning
+	if data.DataAccessRestriction == "Open - freely retrievable" && data.DataClassification == "Public" {
+		access_level = report_good
+	} else if data.DataAccessRestriction == "Open - freely retrievable" {
+		access_level = report_error
+	}
+	r.Fields = append(r.Fields,
+		report_pair{"Data Classification", report_text(data.DataClassification, access_level, access_level)},
+		report_pair{"Data Access Restriction", report_text(data.DataAccessRestriction, access_level, access_level)},
+		report_pair{"Language", report_text(data.Language, report_normal, report_warning)},
+		report_pair{"Retention Period", report_value{fmt.Sprint(data.RetentionPeriod) + " years", report_normal}},
+		report_pair{"Retention Information", report_text(data.RetentionInformation, report_normal, report_warning)},
+		report_pair{"Embargo EndDate", report_text(data.EmbargoEndDate, report_normal, report_warning)},
+		report_pair{"Remarks", report_text(data.Remarks, report_normal, report_warning)},
+	)
+	// keys of the input that the metadata model does not have, e.g. from a newer schema
+	for _, key := range unmapped_keys(data) {
+		r.Unmapped = append(r.Unmapped, report_pair{key, report_value{unmapped_text(data.Unmapped[key]), report_warning}})
+	}
+	return r
+}
+
+// the number of warnings and errors in the report
+func report_issue_count(r report_model) int {
+	count := 0
+	add := func(values ...report_value) {
+		for _, v := range values {
+			if v.Level == report_warning || v.Level == report_error {
+				count++
+			}
+		}
+	}
+	add(r.Title, r.Description)
+	add(r.Tags...)
+	add(r.Disciplines...)
+	for _, p := range append(append([]report_person{}, r.Creators...), r.Contributors...) {
+		add(p.Name, p.Role)
+		add(p.Affiliations...)
+		add(p.Identifiers...)
+	}
+	for _, pairs := range [][]report_pair{r.Collected, r.CoveredPeriod, r.Funding, r.Fields, r.Unmapped} {
+		for _, pair := range pairs {
+			add(pair.Value)
+		}
+	}
+	for _, rel := range r.Related {
+		add(rel.Relation, rel.Identifier, rel.Title)
+	}
+	return count
+}
@@ -0,0 +1,60 @@
+/*
+retention.go the Retention_Period field, read from a JSON number as well as from a numeric string as some exports write it.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RetentionPeriod is the retention period in years, it is written as a number
+type RetentionPeriod int
+
+// UnmarshalJSON accepts 10 as well as "10", null leaves the period unchanged
+func (p *RetentionPeriod) UnmarshalJSON(raw []byte) error {
+	raw = bytes.TrimSpace(raw)
+	if string(raw) == "null" {
+		return nil
+	}
+	text := string(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		err := json.Unmarshal(raw, &text)
+		if err != nil {
+			return err
+		}
+	}
+	return p.parse(text)
+}
+
+// UnmarshalYAML accepts the same forms in YAML input
+func (p *RetentionPeriod) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind != yaml.ScalarNode {
+		return fmt.Errorf("Retention_Period: line %d: expected a number", node.Line)
+	}
+	if node.Tag == "!!null" {
+		return nil
+	}
+	return p.parse(node.Value)
+}
+
+// set the period from its text, an empty string is no period
+func (p *RetentionPeriod) parse(text string) error {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		*p = 0
+		return nil
+	}
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		return fmt.Errorf("Retention_Period: %q is not a whole number of years", text)
+	}
+	*p = RetentionPeriod(n)
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestRetentionPeriodJSON(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want RetentionPeriod
+	}{
+		{`10`, 10},
+		{`"10"`, 10},
+		{`" 25 "`, 25},
+		{`""`, 0},
+		{`null`, 0},
+		{`0`, 0},
+	}
+	for _, tt := range tests {
+		var doc Yoda18Metadata
+		if err := json.Unmarshal([]byte(`{"Retention_Period": `+tt.raw+`}`), &doc); err != nil {
+			t.Errorf("Retention_Period %s: %v", tt.raw, err)
+			continue
+		}
+		if doc.RetentionPeriod != tt.want {
+			t.Errorf("Retention_Period %s read as %d, want %d", tt.raw, doc.RetentionPeriod, tt.want)
+		}
+	}
+}
+
+func TestRetentionPeriodInvalid(t *testing.T) {
+	for _, raw := range []string{`"ten"`, `"10 years"`, `10.5`, `true`} {
+		var doc Yoda18Metadata
+		err := json.Unmarshal([]byte(`{"Retention_Period": `+raw+`}`), &doc)
+		if err == nil {
+			t.Errorf("Retention_Period %s is accepted", raw)
+			continue
+		}
+		if !strings.Contains(err.Error(), "Retention_Period") {
+			t.Errorf("error %q does not name the field", err)
+		}
+	}
+}
+
+func TestRetentionPeriodWritten(t *testing.T) {
+	doc := Yoda18Metadata{RetentionPeriod: 10}
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(raw), `"Retention_Period":10`) {
+		t.Errorf("the period is not written as a number: %s", raw)
+	}
+}
+
+func TestRetentionPeriodYAML(t *testing.T) {
+	for _, raw := range []string{"10", `"10"`} {
+		var p RetentionPeriod
+		if err := yaml.Unmarshal([]byte(raw), &p); err != nil {
+			t.Fatalf("%s: %v", raw, err)
+		}
+		if p != 10 {
+			t.Errorf("YAML %s read as %d", raw, p)
+		}
+	}
+	var p RetentionPeriod
+	if err := yaml.Unmarshal([]byte("ten"), &p); err == nil {
+		t.Error("YAML ten is accepted")
+	}
+}
@@ -0,0 +1,90 @@
+/*
+ris.go exports Yoda metadata as a RIS record for reference managers like EndNote and Zotero.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// write a single RIS tagged line, RIS values cannot span lines so newlines are flattened
+func write_ris_line(w io.Writer, tag string, value string) error {
+	value = strings.Join(strings.Fields(value), " ")
+	_, err := fmt.Fprintf(w, "%s  - %s\r\n", tag, value)
+	return err
+}
+
+// exportRIS writes the metadata as a RIS "DATA" record to w
+func exportRIS(doc Yoda18Metadata, w io.Writer) error {
+	var lines [][2]string
+
+	lines = append(lines, [2]string{"TY", "DATA"})
+	for i := range doc.Creator {
+		// RIS expects "Family, Given" whatever the -name-style
+		if name := formatName(doc.Creator[i].Name, "citation"); name != "" {
+			lines = append(lines, [2]string{"AU", name})
+		}
+	}
+	if doc.Title != "" {
+		lines = append(lines, [2]string{"TI", doc.Title})
+	}
+	if doc.Description != "" {
+		lines = append(lines, [2]string{"AB", doc.Description})
+	}
+	if year := metadata_year(doc); year != "" {
+		lines = append(lines, [2]string{"PY", year})
+	}
+	if doc.Language != "" {
+		lines = append(lines, [2]string{"LA", language_code(doc.Language)})
+	}
+	for i := range doc.Tag {
+		if doc.Tag[i] != "" {
+			lines = append(lines, [2]string{"KW", doc.Tag[i]})
+		}
+	}
+	for i := range doc.Links {
+		href := doc.Links[i].Href
+		if doi := doi_from_string(href); doi != "" {
+			lines = append(lines, [2]string{"DO", doi})
+		} else if href != "" && doc.Links[i].Rel != "describedby" {
+			// "describedby" points at the Yoda schema, not at the dataset
+			lines = append(lines, [2]string{"UR", href})
+		}
+	}
+
+	for _, line := range lines {
+		if err := write_ris_line(w, line[0], line[1]); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "ER  - \r\n")
+	return err
+}
+
+// the publication year of the dataset taken from the best available date, empty if there is none
+func metadata_year(doc Yoda18Metadata) string {
+	for _, date := range []string{doc.Collected.EndDate, doc.Collected.StartDate, doc.EmbargoEndDate} {
+		if len(date) >= 4 && strings.Trim(date[:4], "0123456789") == "" {
+			return date[:4]
+		}
+	}
+	return ""
+}
+
+// extract a bare DOI ("10.xxxx/...") from a DOI string or doi.org URL, empty if it is not a DOI
+func doi_from_string(s string) string {
+	s = strings.TrimSpace(s)
+	for _, prefix := range []string{"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:"} {
+		if strings.HasPrefix(strings.ToLower(s), prefix) {
+			s = s[len(prefix):]
+			break
+		}
+	}
+	if strings.HasPrefix(s, "10.") && strings.Contains(s, "/") {
+		return s
+	}
+	return ""
+}
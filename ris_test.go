@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestExportRIS(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	var buf bytes.Buffer
+	if err := exportRIS(doc, &buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n")
+	if lines[0] != "TY  - DATA" {
+		t.Errorf("first line %q, want TY  - DATA", lines[0])
+	}
+	if last := lines[len(lines)-1]; last != "ER  - " {
+		t.Errorf("last line %q, want ER  - ", last)
+	}
+	tag := regexp.MustCompile(`^[A-Z][A-Z0-9]  - `)
+	authors := 0
+	for _, line := range lines {
+		if !tag.MatchString(line) {
+			t.Errorf("line %q is not a RIS tag line", line)
+		}
+		if strings.HasPrefix(line, "AU  - ") {
+			authors++
+		}
+		if strings.HasPrefix(line, "UR  - ") && strings.Contains(line, "/schemas/") {
+			t.Errorf("the describedby schema link is written as the dataset URL: %q", line)
+		}
+	}
+	if authors != len(doc.Creator) {
+		t.Errorf("%d AU lines, want one per creator (%d)", authors, len(doc.Creator))
+	}
+}
+
+func TestExportRISLinks(t *testing.T) {
+	var doc Yoda18Metadata
+	doc.Links = append(doc.Links,
+		struct {
+			Rel  string `json:"rel"`
+			Href string `json:"href"`
+		}{"describedby", "https://yoda.uu.nl/schemas/default-1/metadata.json"},
+		struct {
+			Rel  string `json:"rel"`
+			Href string `json:"href"`
+		}{"landing", "https://doi.org/10.48338/VU01-ABC"},
+		struct {
+			Rel  string `json:"rel"`
+			Href string `json:"href"`
+		}{"landing", "https://data.example.org/dataset/1"},
+	)
+	var buf bytes.Buffer
+	if err := exportRIS(doc, &buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"DO  - 10.48338/VU01-ABC\r\n", "UR  - https://data.example.org/dataset/1\r\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "schemas") {
+		t.Errorf("the schema link is written:\n%s", out)
+	}
+}
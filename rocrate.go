@@ -0,0 +1,200 @@
+/*
+rocrate.go exports Yoda metadata as an RO-Crate 1.1 ro-crate-metadata.json, the dataset is the root data entity.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const rocrate_metadata_name string = "ro-crate-metadata.json"
+
+// a JSON-LD entity in the crate @graph
+type rocrate_entity map[string]interface{}
+
+// a JSON-LD reference to another entity
+func rocrate_ref(id string) rocrate_entity {
+	return rocrate_entity{"@id": id}
+}
+
+// make a local identifier fragment from a name, e.g. "Utrecht University" -> "#organization-utrecht-university"
+func rocrate_local_id(kind string, name string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else if sb.Len() > 0 && !strings.HasSuffix(sb.String(), "-") {
+			sb.WriteRune('-')
+		}
+	}
+	return "#" + kind + "-" + strings.TrimSuffix(sb.String(), "-")
+}
+
+// list the files below dir as crate relative paths, the crate metadata file itself is skipped
+func rocrate_list_files(dir string) ([]rocrate_entity, error) {
+	var files []rocrate_entity
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || d.Name() == rocrate_metadata_name {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		// @id is a relative URI so each path segment is percent-encoded
+		segments := strings.Split(filepath.ToSlash(rel), "/")
+		for i := range segments {
+			segments[i] = url.PathEscape(segments[i])
+		}
+		files = append(files, rocrate_entity{
+			"@id":         strings.Join(segments, "/"),
+			"@type":       "File",
+			"name":        d.Name(),
+			"contentSize": fmt.Sprint(info.Size()),
+		})
+		return nil
+	})
+	return files, err
+}
+
+// build the crate @graph, if data_dir is not empty its files are added as parts of the root dataset
+func rocrate_graph(doc Yoda18Metadata, data_dir string) ([]rocrate_entity, error) {
+	root := rocrate_entity{
+		"@id":         "./",
+		"@type":       "Dataset",
+		"name":        doc.Title,
+		"description": doc.Description,
+	}
+	graph := []rocrate_entity{
+		{
+			"@id":        rocrate_metadata_name,
+			"@type":      "CreativeWork",
+			"conformsTo": rocrate_ref("https://w3id.org/ro/crate/1.1"),
+			"about":      rocrate_ref("./"),
+		},
+		root,
+	}
+	// contextual entities are only added once, keyed by @id
+	seen := make(map[string]bool)
+	add_entity := func(e rocrate_entity) {
+		id := e["@id"].(string)
+		if !seen[id] {
+			seen[id] = true
+			graph = append(graph, e)
+		}
+	}
+
+	// datePublished is required by RO-Crate, fall back on today if the dataset has no usable date
+	if len(csl_date_parts(doc.Collected.EndDate)) == 3 {
+		root["datePublished"] = doc.Collected.EndDate
+	} else {
+		root["datePublished"] = time.Now().Format("2006-01-02")
+	}
+	if doc.Version != "" {
+		root["version"] = doc.Version
+	}
+	if len(doc.Tag) > 0 {
+		root["keywords"] = strings.Join(doc.Tag, ", ")
+	}
+
+	if lic_url := license_url(doc.License); lic_url != "" {
+		root["license"] = rocrate_ref(lic_url)
+		add_entity(rocrate_entity{"@id": lic_url, "@type": "CreativeWork", "name": canonical_license(doc.License)})
+	} else if doc.License != "" {
+		root["license"] = doc.License
+	}
+
+	var authors []rocrate_entity
+	for i, cre := range doc.Creator {
+		id := fmt.Sprintf("#creator-%d", i+1)
+		for _, pid := range cre.PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+				id = orcid_url(pid.NameIdentifier)
+			}
+		}
+		person := rocrate_entity{"@id": id, "@type": "Person", "name": formatName(cre.Name, "full")}
+		var affiliations []rocrate_entity
+		for _, aff := range cre.Affiliation {
+			if aff == "" {
+				continue
+			}
+			org_id := rocrate_local_id("organization", aff)
+			add_entity(rocrate_entity{"@id": org_id, "@type": "Organization", "name": aff})
+			affiliations = append(affiliations, rocrate_ref(org_id))
+		}
+		if len(affiliations) > 0 {
+			person["affiliation"] = affiliations
+		}
+		add_entity(person)
+		authors = append(authors, rocrate_ref(id))
+	}
+	if len(authors) > 0 {
+		root["author"] = authors
+	}
+
+	var funders, grants []rocrate_entity
+	for i, fund := range doc.FundingReference {
+		if fund.FunderName == "" {
+			continue
+		}
+		funder_id := rocrate_local_id("funder", fund.FunderName)
+		add_entity(rocrate_entity{"@id": funder_id, "@type": "Organization", "name": strings.TrimSpace(fund.FunderName)})
+		funders = append(funders, rocrate_ref(funder_id))
+		if fund.AwardNumber != "" {
+			grant_id := fmt.Sprintf("#grant-%d", i+1)
+			add_entity(rocrate_entity{"@id": grant_id, "@type": "Grant", "identifier": fund.AwardNumber, "funder": rocrate_ref(funder_id)})
+			grants = append(grants, rocrate_ref(grant_id))
+		}
+	}
+	if len(funders) > 0 {
+		root["funder"] = funders
+	}
+	if len(grants) > 0 {
+		root["funding"] = grants
+	}
+
+	if data_dir != "" {
+		files, err := rocrate_list_files(data_dir)
+		if err != nil {
+			return nil, err
+		}
+		var parts []rocrate_entity
+		for _, f := range files {
+			parts = append(parts, rocrate_ref(f["@id"].(string)))
+			add_entity(f)
+		}
+		root["hasPart"] = parts
+	}
+
+	return graph, nil
+}
+
+// exportROCrate writes the ro-crate-metadata.json for the dataset, files in data_dir (if any) become parts of the crate
+func exportROCrate(doc Yoda18Metadata, data_dir string, w io.Writer) error {
+	graph, err := rocrate_graph(doc, data_dir)
+	if err != nil {
+		return err
+	}
+	crate := map[string]interface{}{
+		"@context": "https://w3id.org/ro/crate/1.1/context",
+		"@graph":   graph,
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(crate)
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// an ISO 8601 date, as RO-Crate requires for datePublished
+var rocrate_test_date = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}`)
+
+// check the rules of RO-Crate 1.1 that can be checked without a JSON-LD processor, returns the entities by @id
+func check_rocrate(t *testing.T, raw []byte) map[string]map[string]any {
+	t.Helper()
+	var crate struct {
+		Context any              `json:"@context"`
+		Graph   []map[string]any `json:"@graph"`
+	}
+	if err := json.Unmarshal(raw, &crate); err != nil {
+		t.Fatalf("the crate is not JSON: %v", err)
+	}
+	if crate.Context != "https://w3id.org/ro/crate/1.1/context" {
+		t.Errorf("@context %v, want the RO-Crate 1.1 context", crate.Context)
+	}
+
+	entities := make(map[string]map[string]any)
+	for _, e := range crate.Graph {
+		id, _ := e["@id"].(string)
+		if id == "" || e["@type"] == nil {
+			t.Errorf("entity without @id or @type: %v", e)
+			continue
+		}
+		if entities[id] != nil {
+			t.Errorf("@id %s is used twice", id)
+		}
+		entities[id] = e
+	}
+
+	// the graph is flat: a nested object is a reference, a local reference points at an entity of the graph
+	var check_value func(id string, key string, v any)
+	check_value = func(id string, key string, v any) {
+		switch v := v.(type) {
+		case []any:
+			for _, item := range v {
+				check_value(id, key, item)
+			}
+		case map[string]any:
+			ref, ok := v["@id"].(string)
+			if !ok || len(v) != 1 {
+				t.Errorf("%s %s: a nested entity instead of a reference: %v", id, key, v)
+				return
+			}
+			if u, err := url.Parse(ref); err == nil && !u.IsAbs() && entities[ref] == nil {
+				t.Errorf("%s %s: reference %s is not in the graph", id, key, ref)
+			}
+		}
+	}
+	for id, e := range entities {
+		for key, v := range e {
+			check_value(id, key, v)
+		}
+	}
+
+	descriptor := entities[rocrate_metadata_name]
+	if descriptor == nil {
+		t.Fatal("no metadata descriptor")
+	}
+	if descriptor["@type"] != "CreativeWork" {
+		t.Errorf("descriptor @type %v, want CreativeWork", descriptor["@type"])
+	}
+	if conforms, _ := descriptor["conformsTo"].(map[string]any); conforms["@id"] != "https://w3id.org/ro/crate/1.1" {
+		t.Errorf("descriptor conformsTo %v", descriptor["conformsTo"])
+	}
+	if about, _ := descriptor["about"].(map[string]any); about["@id"] != "./" {
+		t.Errorf("descriptor about %v, want ./", descriptor["about"])
+	}
+
+	root := entities["./"]
+	if root == nil {
+		t.Fatal("no root data entity ./")
+	}
+	if root["@type"] != "Dataset" {
+		t.Errorf("root @type %v, want Dataset", root["@type"])
+	}
+	for _, key := range []string{"name", "description", "datePublished", "license"} {
+		if root[key] == nil {
+			t.Errorf("the root data entity has no %s", key)
+		}
+	}
+	if date, _ := root["datePublished"].(string); !rocrate_test_date.MatchString(date) {
+		t.Errorf("datePublished %q is not an ISO 8601 date", date)
+	}
+	return entities
+}
+
+func TestExportROCrate(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	var buf bytes.Buffer
+	if err := exportROCrate(doc, "", &buf); err != nil {
+		t.Fatal(err)
+	}
+	entities := check_rocrate(t, buf.Bytes())
+	if _, ok := entities["./"]["hasPart"]; ok {
+		t.Error("hasPart without a data directory")
+	}
+	authors, _ := entities["./"]["author"].([]any)
+	if len(authors) != len(doc.Creator) {
+		t.Errorf("%d authors, want %d", len(authors), len(doc.Creator))
+	}
+}
+
+func TestExportROCrateDirectory(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"data.csv", filepath.Join("raw", "scan 1.tif"), rocrate_metadata_name} {
+		if err := os.MkdirAll(filepath.Join(dir, filepath.Dir(name)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	doc := load_test_metadata(t, "yoda-metadata.json")
+	var buf bytes.Buffer
+	if err := exportROCrate(doc, dir, &buf); err != nil {
+		t.Fatal(err)
+	}
+	entities := check_rocrate(t, buf.Bytes())
+	parts, _ := entities["./"]["hasPart"].([]any)
+	if len(parts) != 2 {
+		t.Fatalf("hasPart %v, want the two data files", parts)
+	}
+	for _, part := range parts {
+		id := part.(map[string]any)["@id"].(string)
+		if entities[id]["@type"] != "File" {
+			t.Errorf("part %s is not a File", id)
+		}
+		path, err := url.PathUnescape(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(path))); err != nil {
+			t.Errorf("part %s is not a file of the crate: %v", id, err)
+		}
+	}
+}
+
+func TestROCrateLocalID(t *testing.T) {
+	if got := rocrate_local_id("organization", "Utrecht University (UU)"); got != "#organization-utrecht-university-uu" {
+		t.Errorf("rocrate_local_id = %q", got)
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ror.go checking that affiliations carry a ROR (Research Organization Registry) identifier,
+optionally suggesting one from the ROR API.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// the ROR affiliation matching endpoint
+const ror_api_url string = "https://api.ror.org/v2/organizations"
+
+// the maximum time a single ROR lookup may take
+const ror_lookup_timeout = 10 * time.Second
+
+var ror_id_pattern = regexp.MustCompile(`https?://ror\.org/0[a-z0-9]{6}[0-9]{2}`)
+
+// an affiliation without a ROR identifier
+type AffiliationWarning struct {
+	Field       string
+	Affiliation string
+}
+
+// collect the creator and contributor affiliations that do not contain a ROR identifier
+func check_affiliation_ror(doc Yoda18Metadata) []AffiliationWarning {
+	var warnings []AffiliationWarning
+	for i, cre := range doc.Creator {
+		for j, aff := range cre.Affiliation {
+			if aff != "" && !ror_id_pattern.MatchString(aff) {
+				warnings = append(warnings, AffiliationWarning{fmt.Sprintf("Creator[%d].Affiliation[%d]", i, j), aff})
+			}
+		}
+	}
+	for i, con := range doc.Contributor {
+		for j, aff := range con.Affiliation {
+			if aff != "" && !ror_id_pattern.MatchString(aff) {
+				warnings = append(warnings, AffiliationWarning{fmt.Sprintf("Contributor[%d].Affiliation[%d]", i, j), aff})
+			}
+		}
+	}
+	return warnings
+}
+
+// response of the ROR v2 affiliation matching, only the fields used here
+type ror_match_response struct {
+	Items []struct {
+		Chosen       bool    `json:"chosen"`
+		Score        float64 `json:"score"`
+		Organization struct {
+			ID    string `json:"id"`
+			Names []struct {
+				Value string   `json:"value"`
+				Types []string `json:"types"`
+			} `json:"names"`
+		} `json:"organization"`
+	} `json:"items"`
+}
+
+// ask the ROR API for the organisation matching a free text affiliation, returns its ROR id and display name,
+// empty strings if ROR has no confident match
+func lookup_ror(ctx context.Context, affiliation string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, ror_lookup_timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ror_api_url+"?affiliation="+url.QueryEscape(affiliation), nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("ROR lookup of %q failed: %s", affiliation, resp.Status)
+	}
+
+	var match ror_match_response
+	err = json.NewDecoder(resp.Body).Decode(&match)
+	if err != nil {
+		return "", "", err
+	}
+	for _, item := range match.Items {
+		if !item.Chosen {
+			continue
+		}
+		name := ""
+		for _, n := range item.Organization.Names {
+			for _, t := range n.Types {
+				if t == "ror_display" {
+					name = n.Value
+				}
+			}
+		}
+		return item.Organization.ID, name, nil
+	}
+	return "", "", nil
+}
+
+// the enriched affiliation of every affiliation looked up in this run, "" when ROR had no match
+var ror_enrich_cache = make(map[string]string)
+var ror_enrich_mu sync.Mutex
+
+// the affiliation as "<ROR name> (<ROR id>)", the affiliation itself if it has a ROR id or ROR has no match
+func enrich_affiliation(ctx context.Context, affiliation string) (string, error) {
+	if affiliation == "" || ror_id_pattern.MatchString(affiliation) {
+		return affiliation, nil
+	}
+	ror_enrich_mu.Lock()
+	enriched, ok := ror_enrich_cache[affiliation]
+	ror_enrich_mu.Unlock()
+	if !ok {
+		id, name, err := lookup_ror(ctx, affiliation)
+		if err != nil {
+			return affiliation, err
+		}
+		if id != "" {
+			if name == "" {
+				name = affiliation
+			}
+			enriched = name + " (" + id + ")"
+		}
+		ror_enrich_mu.Lock()
+		ror_enrich_cache[affiliation] = enriched
+		ror_enrich_mu.Unlock()
+	}
+	if enriched == "" {
+		return affiliation, nil
+	}
+	return enriched, nil
+}
+
+// EnrichAffiliations replaces the free text creator and contributor affiliations by the name and id of the
+// matching ROR organisation, as in "Vrije Universiteit Amsterdam (https://ror.org/008xxew50)"; affiliations
+// ROR has no confident match for are kept, each affiliation is looked up once per run
+func EnrichAffiliations(ctx context.Context, doc Yoda18Metadata) (Yoda18Metadata, error) {
+	// the persons are copied so the affiliations of the caller's document are left alone
+	enrich := func(affiliations []string) ([]string, error) {
+		out := make([]string, len(affiliations))
+		for i, aff := range affiliations {
+			var err error
+			out[i], err = enrich_affiliation(ctx, aff)
+			if err != nil {
+				return affiliations, err
+			}
+		}
+		return out, nil
+	}
+	var err error
+	doc.Creator = append(doc.Creator[:0:0], doc.Creator...)
+	for i := range doc.Creator {
+		doc.Creator[i].Affiliation, err = enrich(doc.Creator[i].Affiliation)
+		if err != nil {
+			return doc, err
+		}
+	}
+	doc.Contributor = append(doc.Contributor[:0:0], doc.Contributor...)
+	for i := range doc.Contributor {
+		doc.Contributor[i].Affiliation, err = enrich(doc.Contributor[i].Affiliation)
+		if err != nil {
+			return doc, err
+		}
+	}
+	return doc, nil
+}
+
+// print a warning for every affiliation without a ROR identifier, with lookup a ROR id is suggested
+func report_affiliation_ror(ctx context.Context, doc Yoda18Metadata, fname string, lookup bool) {
+	for _, w := range check_affiliation_ror(doc) {
+		slog.Warn("affiliation has no ROR identifier", "file", fname, "field_path", w.Field, "affiliation", w.Affiliation)
+		if !lookup || ctx.Err() != nil {
+			continue
+		}
+		id, name, err := lookup_ror(ctx, w.Affiliation)
+		if err != nil {
+			slog.Warn("ROR lookup failed", "file", fname, "field_path", w.Field, "error", err)
+		} else if id != "" {
+			slog.Info("ROR identifier suggestion", "file", fname, "field_path", w.Field, "ror_id", id, "ror_name", name)
+		} else {
+			slog.Info("no ROR match found", "file", fname, "field_path", w.Field)
+		}
+	}
+}
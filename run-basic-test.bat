@@ -9,7 +9,7 @@ del %TEST_DIR%\*.pdf
 
 : PAUSE
 
-go build readYmeta.go
+go build -o readYmeta.exe .
 
 readYmeta.exe
 readYmeta.exe %TEST_DIR%\yoda-metadata[blank].json
@@ -17,6 +17,8 @@ readYmeta.exe %TEST_DIR%\yoda-metadata[test].json
 readYmeta.exe %TEST_DIR%\yoda-metadata[uu011].json
 readYmeta.exe %TEST_DIR%\yoda-metadata[uu012].json
 readYmeta.exe %TEST_DIR%\yoda-metadata[uu013].json
+readYmeta.exe %TEST_DIR%\yoda-metadata.json.gz
+readYmeta.exe - < %TEST_DIR%\yoda-metadata.json.gz
 
 dir *.pdf
 dir %TEST_DIR%\*.pdf
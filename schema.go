@@ -0,0 +1,111 @@
+/*
+schema.go validating the raw Yoda metadata JSON against a JSON Schema.
+*/
+
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// the -schema value that selects the embedded schema
+const default_schema_name string = "default"
+
+// default Yoda metadata schema, modelled on the Yoda default-1 metadata schema
+//
+//go:embed assets/yoda-metadata-schema.json
+var default_yoda_schema []byte
+
+// a single schema violation, Path is a JSON path such as $.Creator[0].Name
+type SchemaViolation struct {
+	Path    string
+	Message string
+}
+
+// validate the raw metadata against the schema at schema_path ("default" for the embedded schema)
+func validate_json_schema(raw []byte, schema_path string) ([]SchemaViolation, error) {
+	var schema_bytes []byte
+	var err error
+
+	if schema_path == default_schema_name {
+		schema_bytes = default_yoda_schema
+	} else {
+		schema_bytes, err = os.ReadFile(schema_path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	compiler := jsonschema.NewCompiler()
+	err = compiler.AddResource("schema.json", bytes.NewReader(schema_bytes))
+	if err != nil {
+		return nil, fmt.Errorf("cannot load schema %s: %w", schema_path, err)
+	}
+	schema, err := compiler.Compile("schema.json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot compile schema %s: %w", schema_path, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var instance interface{}
+	err = dec.Decode(&instance)
+	if err != nil {
+		return nil, err
+	}
+
+	err = schema.Validate(instance)
+	var verr *jsonschema.ValidationError
+	if errors.As(err, &verr) {
+		return schema_violations(verr), nil
+	}
+	return nil, err
+}
+
+// flatten the validation error tree, only the leaves describe actual violations
+func schema_violations(verr *jsonschema.ValidationError) []SchemaViolation {
+	if len(verr.Causes) == 0 {
+		return []SchemaViolation{{Path: json_pointer_to_path(verr.InstanceLocation), Message: verr.Message}}
+	}
+	var out []SchemaViolation
+	for _, cause := range verr.Causes {
+		out = append(out, schema_violations(cause)...)
+	}
+	return out
+}
+
+// convert a JSON pointer (/Creator/0/Name) to a JSON path ($.Creator[0].Name)
+func json_pointer_to_path(pointer string) string {
+	path := "$"
+	if pointer == "" {
+		return path
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		if _, err := strconv.Atoi(token); err == nil {
+			path += "[" + token + "]"
+		} else {
+			path += "." + token
+		}
+	}
+	return path
+}
+
+// the schema a Yoda metadata document declares with its "describedby" link, empty if there is none
+func metadata_schema_link(doc Yoda18Metadata) string {
+	for _, link := range doc.Links {
+		if link.Rel == "describedby" {
+			return link.Href
+		}
+	}
+	return ""
+}
@@ -0,0 +1,315 @@
+/*
+server.go HTTP server mode, converts and validates Yoda metadata documents posted to a REST API.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// the largest metadata document the server accepts
+const serve_max_body int64 = 10 << 20
+
+// how long in-flight requests get to finish on shutdown
+const serve_shutdown_timeout = 10 * time.Second
+
+// Content-Type of every format the server can return
+var serve_content_types = map[string]string{
+	"pdf":          "application/pdf",
+	"md":           "text/markdown; charset=utf-8",
+	"text":         "text/plain; charset=utf-8",
+	"latex":        "application/x-latex; charset=utf-8",
+	"ris":          "application/x-research-info-systems; charset=utf-8",
+	"csl":          "application/vnd.citationstyles.csl+json",
+	"cff":          "application/x-yaml; charset=utf-8",
+	"jsonld":       "application/ld+json",
+	"rocrate":      "application/ld+json",
+	"codemeta":     "application/ld+json",
+	"yaml":         "application/yaml; charset=utf-8",
+	"csv":          "text/csv; charset=utf-8",
+	"xlsx":         "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"docx":         "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+	"html":         "text/html; charset=utf-8",
+	"json":         "application/json",
+	"combi":        "application/json",
+	"zenodo":       "application/json",
+	"figshare":     "application/json",
+	"osf":          "application/vnd.api+json",
+	"mods":         "application/mods+xml",
+	"oai":          "application/xml",
+	"frictionless": "application/json",
+	"dot":          "text/vnd.graphviz; charset=utf-8",
+	"mermaid":      "text/vnd.mermaid; charset=utf-8",
+	"dcat":         "text/turtle; charset=utf-8",
+	"eml":          "application/xml",
+	"iso19139":     "application/xml",
+	"citation":     "text/plain; charset=utf-8",
+	"template":     "text/plain; charset=utf-8",
+	"email":        "text/plain; charset=utf-8",
+	"signposting":  "text/plain; charset=utf-8",
+	"readme":       "text/plain; charset=utf-8",
+	"xmp":          "application/rdf+xml",
+	"qr":           "image/png",
+}
+
+// the PDF report uses the global ERROR_COUNT so reports are generated one at a time
+var serve_pdf_lock sync.Mutex
+
+// a single problem reported by /validate
+type ValidationError struct {
+	Path     string `json:"path"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// the /validate response
+type ValidationResult struct {
+	Valid  bool              `json:"valid"`
+	Errors []ValidationError `json:"errors"`
+}
+
+// run the HTTP server on addr until ctx is cancelled (SIGINT), then shut down gracefully,
+// with -metrics-addr the Prometheus metrics are served on their own address
+func serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/convert", serve_convert)
+	mux.HandleFunc("/validate", serve_validate)
+	mux.HandleFunc("/healthz", serve_healthz)
+
+	// the metrics server stops together with the main server
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	metrics_errc := make(chan error, 1)
+	if *metrics_addr != "" {
+		go func() {
+			metrics_errc <- run_http_server(ctx, "metrics", *metrics_addr, metrics_handler())
+		}()
+	} else {
+		metrics_errc <- nil
+	}
+
+	err := run_http_server(ctx, "conversion", addr, mux)
+	cancel()
+	if err2 := <-metrics_errc; err == nil {
+		err = err2
+	}
+	return err
+}
+
+// serve handler on addr until ctx is cancelled, then give in-flight requests time to finish
+func run_http_server(ctx context.Context, name string, addr string, handler http.Handler) error {
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP server listening", "server", name, "addr", addr)
+		errc <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down HTTP server", "server", name)
+	shutdown_ctx, cancel := context.WithTimeout(context.Background(), serve_shutdown_timeout)
+	defer cancel()
+	err := srv.Shutdown(shutdown_ctx)
+	if err2 := <-errc; !errors.Is(err2, http.ErrServerClosed) && err == nil {
+		err = err2
+	}
+	return err
+}
+
+// read the posted body, writes the error response and returns false if the request cannot be handled
+func serve_read_body(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return nil, false
+	}
+	raw, err := io.ReadAll(http.MaxBytesReader(w, r.Body, serve_max_body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	return raw, true
+}
+
+// POST /convert?format=<format> returns the posted metadata document in the requested format
+func serve_convert(w http.ResponseWriter, r *http.Request) {
+	raw, ok := serve_read_body(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "pdf"
+	}
+	if f, ok := output_format_aliases[format]; ok {
+		format = f
+	}
+	content_type, ok := serve_content_types[format]
+	if !ok {
+		record_conversion("unknown", conversion_error, 0)
+		http.Error(w, fmt.Sprintf("unknown output format: %s", format), http.StatusBadRequest)
+		return
+	}
+
+	start := time.Now()
+	status := conversion_error
+	defer func() {
+		record_conversion(format, status, time.Since(start))
+	}()
+
+	var data Yoda18Metadata
+	err := json.Unmarshal(raw, &data)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid metadata document: %s", err), http.StatusBadRequest)
+		return
+	}
+	if *decode_html {
+		data = DecodeHTMLEntities(data)
+	}
+	data = NormalizeUnicode(data)
+	if *normalize {
+		data = normalize_metadata(data)
+	}
+	if *sort_values {
+		sort_metadata(&data, *sort_order)
+	}
+
+	// render into a buffer first so a failed conversion can still return an error status
+	var buf bytes.Buffer
+	switch format {
+	case "pdf":
+		err = RenderPDF(r.Context(), data, "request", &buf)
+	case "html":
+		var page []byte
+		if page, err = RenderHTML(r.Context(), data, *html_jsonld); err == nil {
+			buf.Write(page)
+		}
+	case "md":
+		_, err = buf.WriteString(create_md_readme(data))
+	default:
+		var render func(Yoda18Metadata, io.Writer) error
+		_, render, err = output_renderer(format, "", "")
+		if err == nil {
+			err = render(data, &buf)
+		}
+	}
+	if errors.Is(err, context.Canceled) {
+		// the client went away, there is nobody to answer
+		status = conversion_cancelled
+		slog.Info("conversion cancelled", "format", format)
+		return
+	}
+	if err != nil {
+		slog.Error("conversion failed", "format", format, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	status = conversion_ok
+	slog.Info("converted", "format", format, "bytes", buf.Len())
+	w.Header().Set("Content-Type", content_type)
+	_, _ = buf.WriteTo(w)
+}
+
+// GET /healthz answers ok while the server is up, for load balancers and container health checks
+func serve_healthz(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = io.WriteString(w, "ok\n")
+}
+
+// POST /validate checks the posted metadata document against the -schema (or the bundled) schema
+func serve_validate(w http.ResponseWriter, r *http.Request) {
+	raw, ok := serve_read_body(w, r)
+	if !ok {
+		return
+	}
+	schema := *schema_file
+	if schema == "" {
+		schema = default_schema_name
+	}
+
+	result, err := validate_document(r.Context(), raw, schema, *strict)
+	if err != nil {
+		slog.Info("validation cancelled")
+		return
+	}
+
+	record_validation(result)
+	slog.Info("validated", "schema", schema, "valid", result.Valid, "errors", len(result.Errors))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// run all checks on a raw metadata document: the schema (errors) and the checks of Validate with strict,
+// the only error returned is that of a cancelled ctx
+func validate_document(ctx context.Context, raw []byte, schema string, strict bool) (ValidationResult, error) {
+	result := ValidationResult{Errors: []ValidationError{}}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+
+	violations, err := validate_json_schema(raw, schema)
+	if err != nil {
+		// the body is not JSON at all
+		result.Errors = append(result.Errors, ValidationError{"$", err.Error(), severity_error})
+	}
+	for _, v := range violations {
+		result.Errors = append(result.Errors, ValidationError{v.Path, v.Message, severity_error})
+	}
+	if err == nil {
+		var data Yoda18Metadata
+		if json.Unmarshal(raw, &data) == nil {
+			for _, issue := range Validate(data, strict) {
+				result.Errors = append(result.Errors, ValidationError{"$." + issue.Field, issue.Message, issue.Severity})
+			}
+		}
+	}
+	result.Valid = true
+	for _, e := range result.Errors {
+		if e.Severity == severity_error {
+			result.Valid = false
+		}
+	}
+	return result, ctx.Err()
+}
+
+// RenderPDF generates the single dataset PDF report into w, name is shown in the header and footer,
+// returns the ctx error if it is cancelled while waiting for another report
+func RenderPDF(ctx context.Context, data Yoda18Metadata, name string, w io.Writer) error {
+	serve_pdf_lock.Lock()
+	defer serve_pdf_lock.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	ERROR_COUNT = 0
+	doc := new_pdf_document()
+	doc = generate_pdf_report_basic(data, doc, name)
+	buf, err := doc.Output()
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,93 @@
+/*
+signposting.go the FAIR Signposting typed links of a dataset landing page as HTTP Link headers (RFC 8288):
+cite-as, author, license, describedby and type, to paste into the web server configuration.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// the content types of the describedby links
+const (
+	signposting_datacite_type string = "application/vnd.datacite.datacite+xml"
+	signposting_jsonld_type   string = "application/ld+json"
+)
+
+// the DataCite XML of a DOI, served by the DOI content negotiation of DataCite
+const signposting_datacite_url string = "https://data.crosscite.org/application/vnd.datacite.datacite+xml/"
+
+// a typed link
+type signposting_link struct {
+	Target string
+	Rel    string
+	Type   string
+}
+
+// the link as a Link header field value, the target percent-encoded where a URI cannot hold the character
+func (l signposting_link) String() string {
+	value := turtle_iri(l.Target) + fmt.Sprintf("; rel=%q", l.Rel)
+	if l.Type != "" {
+		value += fmt.Sprintf("; type=%q", l.Type)
+	}
+	return value
+}
+
+// the name of the JSON-LD file written next to the links, as -format jsonld names it
+func signposting_jsonld_name(doc Yoda18Metadata, source string) string {
+	if *output_dir != "" || source == "" {
+		return output_dir_stem(doc, source) + ".jsonld"
+	}
+	return filepath.Base(input_file_stem(source)) + ".jsonld"
+}
+
+// the typed links of the dataset read from source: the DOI (cite-as), the ORCID of every creator that has one
+// (author), the licence URL, its DataCite XML when it has a DOI and the JSON-LD file (describedby, below
+// -signposting-base when given) and the schema.org types of the landing page
+func signposting_links(doc Yoda18Metadata, source string) []signposting_link {
+	var links []signposting_link
+	sys := oai_system(doc, source)
+	doi := citation_doi(doc, sys)
+	if doi != "" {
+		links = append(links, signposting_link{pid_link("DOI", doi), "cite-as", ""})
+	}
+	for _, cre := range doc.Creator {
+		for _, pid := range cre.PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && strings.TrimSpace(pid.NameIdentifier) != "" {
+				links = append(links, signposting_link{orcid_url(pid.NameIdentifier), "author", ""})
+				break
+			}
+		}
+	}
+	if uri := dataset_license_url(doc, sys); uri != "" {
+		links = append(links, signposting_link{uri, "license", ""})
+	}
+	if doi != "" {
+		links = append(links, signposting_link{signposting_datacite_url + strings.TrimPrefix(pid_link("DOI", doi), "https://doi.org/"), "describedby", signposting_datacite_type})
+	}
+	jsonld := signposting_jsonld_name(doc, source)
+	if base := strings.TrimSpace(*signposting_base); base != "" {
+		jsonld = strings.TrimSuffix(base, "/") + "/" + jsonld
+	}
+	links = append(links,
+		signposting_link{jsonld, "describedby", signposting_jsonld_type},
+		signposting_link{"https://schema.org/AboutPage", "type", ""},
+		signposting_link{"https://schema.org/Dataset", "type", ""},
+	)
+	return links
+}
+
+// exportSignposting writes the Signposting links of the metadata read from source to w, a Link header per link,
+// which is the same as one header with the links separated by commas
+func exportSignposting(doc Yoda18Metadata, source string, w io.Writer) error {
+	for _, link := range signposting_links(doc, source) {
+		if _, err := fmt.Fprintf(w, "Link: %s\n", link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// a link of a Link header field value with its target and parameters
+type signposting_test_link struct {
+	Target string
+	Params map[string]string
+}
+
+// parse a Link header field value as RFC 8288 section 3 defines it: comma separated link-values of a
+// <URI-Reference> followed by ;-separated parameters whose values are tokens or quoted-strings
+func signposting_test_parse(t *testing.T, value string) []signposting_test_link {
+	t.Helper()
+	var links []signposting_test_link
+	s := value
+	ows := func() { s = strings.TrimLeft(s, " \t") }
+	token := func() string {
+		end := 0
+		for end < len(s) && strings.IndexByte("!#$%&'*+-.^_`|~", s[end]) >= 0 ||
+			end < len(s) && (s[end] >= '0' && s[end] <= '9' || s[end] >= 'a' && s[end] <= 'z' || s[end] >= 'A' && s[end] <= 'Z') {
+			end++
+		}
+		tok := s[:end]
+		s = s[end:]
+		return tok
+	}
+	for {
+		ows()
+		if !strings.HasPrefix(s, "<") {
+			t.Fatalf("link-value does not start with <: %q", s)
+		}
+		end := strings.IndexByte(s, '>')
+		if end < 0 {
+			t.Fatalf("unterminated URI-Reference in %q", s)
+		}
+		link := signposting_test_link{Target: s[1:end], Params: map[string]string{}}
+		s = s[end+1:]
+		for ows(); strings.HasPrefix(s, ";"); ows() {
+			s = s[1:]
+			ows()
+			name := strings.ToLower(token())
+			if name == "" {
+				t.Fatalf("link-param without name at %q", s)
+			}
+			ows()
+			if !strings.HasPrefix(s, "=") {
+				link.Params[name] = ""
+				continue
+			}
+			s = s[1:]
+			ows()
+			if strings.HasPrefix(s, `"`) {
+				var sb strings.Builder
+				i := 1
+				for ; i < len(s) && s[i] != '"'; i++ {
+					if s[i] == '\\' {
+						i++
+					}
+					if i < len(s) {
+						sb.WriteByte(s[i])
+					}
+				}
+				if i >= len(s) {
+					t.Fatalf("unterminated quoted-string in %q", value)
+				}
+				link.Params[name] = sb.String()
+				s = s[i+1:]
+			} else if link.Params[name] = token(); link.Params[name] == "" {
+				t.Fatalf("link-param %s without value in %q", name, value)
+			}
+		}
+		links = append(links, link)
+		if s == "" {
+			return links
+		}
+		if !strings.HasPrefix(s, ",") {
+			t.Fatalf("unexpected %q after a link-value", s)
+		}
+		s = s[1:]
+	}
+}
+
+// the Link header lines of exportSignposting joined into one field value
+func signposting_test_value(t *testing.T, doc Yoda18Metadata) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := exportSignposting(doc, "", &buf); err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		value, ok := strings.CutPrefix(line, "Link: ")
+		if !ok {
+			t.Fatalf("line %q is not a Link header", line)
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, ", ")
+}
+
+func TestSignpostingLinks(t *testing.T) {
+	set_test_flag(t, "signposting-base", "https://example.org/my data/")
+	doc := parse_test_metadata(t, `{"Title": "Signposted", "License": "Creative Commons Attribution 4.0 International Public License",
+		"links": [{"rel": "describedby", "href": "https://yoda.uu.nl/schemas/default-2/metadata.json"}, {"rel": "doi", "href": "https://doi.org/10.1234/ABC"}],
+		"Creator": [
+			{"Name": {"Given_Name": "Ada", "Family_Name": "Lovelace"}, "Person_Identifier": [{"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0002-1825-0097"}, {"Name_Identifier_Scheme": "ORCID", "Name_Identifier": "0000-0001-5109-3700"}]},
+			{"Name": {"Given_Name": "Alan", "Family_Name": "Turing"}}]}`)
+
+	links := signposting_test_parse(t, signposting_test_value(t, doc))
+	rels := map[string][]string{}
+	for _, link := range links {
+		u, err := url.Parse(link.Target)
+		if err != nil || !u.IsAbs() {
+			t.Errorf("target %q is not an absolute URI: %v", link.Target, err)
+		}
+		rels[link.Params["rel"]] = append(rels[link.Params["rel"]], link.Target)
+		if rel := link.Params["rel"]; rel == "describedby" && link.Params["type"] == "" {
+			t.Errorf("describedby link %s without type", link.Target)
+		}
+	}
+	if cite := rels["cite-as"]; len(cite) != 1 || cite[0] != "https://doi.org/10.1234/ABC" {
+		t.Errorf("cite-as %v", cite)
+	}
+	if authors := rels["author"]; len(authors) != 1 || authors[0] != "https://orcid.org/0000-0002-1825-0097" {
+		t.Errorf("author %v, want the first ORCID of the only creator with one", authors)
+	}
+	if license := rels["license"]; len(license) != 1 || license[0] != "https://creativecommons.org/licenses/by/4.0/" {
+		t.Errorf("license %v", license)
+	}
+	if described := rels["describedby"]; len(described) != 2 || !strings.HasPrefix(described[1], "https://example.org/my%20data/") {
+		t.Errorf("describedby %v, want the DataCite XML and the JSON-LD below the encoded base", described)
+	}
+	if types := rels["type"]; len(types) != 2 || types[1] != "https://schema.org/Dataset" {
+		t.Errorf("type %v", types)
+	}
+}
+
+func TestSignpostingWithoutDOI(t *testing.T) {
+	links := signposting_test_parse(t, signposting_test_value(t, parse_test_metadata(t, `{"Title": "No DOI"}`)))
+	for _, link := range links {
+		if rel := link.Params["rel"]; rel == "cite-as" || rel == "license" || rel == "author" {
+			t.Errorf("%s link %s without DOI, licence or ORCID", rel, link.Target)
+		}
+	}
+	if len(links) != 3 || links[0].Params["type"] != signposting_jsonld_type || strings.Contains(links[0].Target, "/") {
+		t.Errorf("links %+v, want a relative JSON-LD describedby and the two types", links)
+	}
+}
+
+func TestSignpostingParse(t *testing.T) {
+	// the parser itself, on the examples of RFC 8288 section 3.5
+	links := signposting_test_parse(t, `<http://example.com/TheBook/chapter2>; rel="previous"; title="previous chapter", </TheBook/chapter4>; rel=next;title="a \"quoted\" title"`)
+	if len(links) != 2 || links[0].Params["rel"] != "previous" || links[1].Params["rel"] != "next" || links[1].Params["title"] != `a "quoted" title` {
+		t.Errorf("parsed %+v", links)
+	}
+}
@@ -0,0 +1,112 @@
+/*
+sort.go optional alphabetical ordering of the multi-value fields, so that equal metadata gives identical output.
+*/
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// the orders persons can be sorted in
+const (
+	SortAlphabetical = "alphabetical"
+	SortORCIDFirst   = "orcid-first"
+)
+
+var sort_orders = []string{SortAlphabetical, SortORCIDFirst}
+
+// check that a -sort-order value is supported
+func check_sort_order(order string) error {
+	if slices.Contains(sort_orders, order) {
+		return nil
+	}
+	return fmt.Errorf("unknown sort order %q, use one of: %s", order, strings.Join(sort_orders, ", "))
+}
+
+// sort Discipline, Tag and Covered_Geolocation_Place alphabetically and the creators and contributors
+// in the given person order; the comparison ignores case and equal entries keep their source order
+func sort_metadata(doc *Yoda18Metadata, order string) {
+	sort_strings_fold(doc.Discipline)
+	sort_strings_fold(doc.CoveredGeolocationPlace)
+	*doc = SortTags(SortContributorsBy(SortCreatorsBy(*doc, order), order))
+}
+
+// SortCreators returns a copy of doc with the creators sorted by family name, then given name
+func SortCreators(doc Yoda18Metadata) Yoda18Metadata {
+	return SortCreatorsBy(doc, SortAlphabetical)
+}
+
+// SortCreatorsBy returns a copy of doc with the creators sorted alphabetically, or with orcid-first those with
+// an ORCID before those without and each group alphabetically
+func SortCreatorsBy(doc Yoda18Metadata, order string) Yoda18Metadata {
+	creators := slices.Clone(doc.Creator)
+	has_orcid := func(i int) bool {
+		for _, pid := range creators[i].PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+				return true
+			}
+		}
+		return false
+	}
+	sort.SliceStable(creators, func(i, j int) bool {
+		return person_less(creators[i].Name, has_orcid(i), creators[j].Name, has_orcid(j), order)
+	})
+	doc.Creator = creators
+	return doc
+}
+
+// SortContributors returns a copy of doc with the contributors sorted by family name, then given name
+func SortContributors(doc Yoda18Metadata) Yoda18Metadata {
+	return SortContributorsBy(doc, SortAlphabetical)
+}
+
+// SortContributorsBy returns a copy of doc with the contributors in the given order, as SortCreatorsBy
+func SortContributorsBy(doc Yoda18Metadata, order string) Yoda18Metadata {
+	contributors := slices.Clone(doc.Contributor)
+	has_orcid := func(i int) bool {
+		for _, pid := range contributors[i].PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+				return true
+			}
+		}
+		return false
+	}
+	sort.SliceStable(contributors, func(i, j int) bool {
+		return person_less(contributors[i].Name, has_orcid(i), contributors[j].Name, has_orcid(j), order)
+	})
+	doc.Contributor = contributors
+	return doc
+}
+
+// SortTags returns a copy of doc with the tags sorted alphabetically
+func SortTags(doc Yoda18Metadata) Yoda18Metadata {
+	doc.Tag = slices.Clone(doc.Tag)
+	sort_strings_fold(doc.Tag)
+	return doc
+}
+
+func sort_strings_fold(values []string) {
+	sort.SliceStable(values, func(i, j int) bool {
+		return strings.ToLower(values[i]) < strings.ToLower(values[j])
+	})
+}
+
+// with orcid-first a person with an ORCID goes first, otherwise the names decide
+func person_less(a NameStruct, a_orcid bool, b NameStruct, b_orcid bool, order string) bool {
+	if order == SortORCIDFirst && a_orcid != b_orcid {
+		return a_orcid
+	}
+	return name_less(a, b)
+}
+
+func name_less(a NameStruct, b NameStruct) bool {
+	fa, fb := strings.ToLower(a.FamilyName), strings.ToLower(b.FamilyName)
+	if fa != fb {
+		return fa < fb
+	}
+	return strings.ToLower(a.GivenName) < strings.ToLower(b.GivenName)
+}
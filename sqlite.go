@@ -0,0 +1,238 @@
+/*
+sqlite.go loading the metadata of a collection of input files into a SQLite database with a table per kind of
+record, for answering questions about a whole vault with SQL.
+*/
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// the database filename used when -output is not given
+const combined_sqlite_default_name string = "combined-metadata.db"
+
+// the tables, created when they do not exist yet so a database can be loaded again; the child tables refer to
+// datasets.id and are replaced as a whole when their dataset is loaded again
+var sqlite_schema = []string{
+	`CREATE TABLE IF NOT EXISTS datasets (
+		id INTEGER PRIMARY KEY,
+		source TEXT NOT NULL UNIQUE,
+		title TEXT,
+		description TEXT,
+		version TEXT,
+		language TEXT,
+		data_type TEXT,
+		license TEXT,
+		data_classification TEXT,
+		data_access_restriction TEXT,
+		collected_start TEXT,
+		collected_end TEXT,
+		covered_start TEXT,
+		covered_end TEXT,
+		retention_period INTEGER,
+		retention_information TEXT,
+		embargo_end_date TEXT,
+		collection_name TEXT,
+		remarks TEXT,
+		loaded TEXT NOT NULL
+	)`,
+	`CREATE TABLE IF NOT EXISTS persons (
+		dataset_id INTEGER NOT NULL REFERENCES datasets(id),
+		role TEXT NOT NULL,
+		contributor_type TEXT,
+		given TEXT,
+		family TEXT,
+		orcid TEXT,
+		affiliation TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS funding (
+		dataset_id INTEGER NOT NULL REFERENCES datasets(id),
+		funder TEXT,
+		award TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS related (
+		dataset_id INTEGER NOT NULL REFERENCES datasets(id),
+		relation_type TEXT,
+		scheme TEXT,
+		identifier TEXT,
+		title TEXT
+	)`,
+	`CREATE TABLE IF NOT EXISTS tags (
+		dataset_id INTEGER NOT NULL REFERENCES datasets(id),
+		tag TEXT NOT NULL
+	)`,
+	`CREATE INDEX IF NOT EXISTS persons_dataset ON persons(dataset_id)`,
+	`CREATE INDEX IF NOT EXISTS funding_dataset ON funding(dataset_id)`,
+	`CREATE INDEX IF NOT EXISTS related_dataset ON related(dataset_id)`,
+	`CREATE INDEX IF NOT EXISTS tags_dataset ON tags(dataset_id)`,
+}
+
+// the child tables of datasets
+var sqlite_child_tables = []string{"persons", "funding", "related", "tags"}
+
+// a blank value is stored as NULL, so "orcid IS NULL" finds the persons without one
+func sql_text(value string) any {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// the ORCID of a person as https://orcid.org/ URL, nil if there is none
+func sql_orcid(ids [][2]string) any {
+	for _, id := range ids {
+		if strings.EqualFold(id[0], "ORCID") && strings.TrimSpace(id[1]) != "" {
+			return orcid_url(id[1])
+		}
+	}
+	return nil
+}
+
+// insert or update the dataset loaded from source and replace its persons, funding, related datapackages and
+// tags, so loading the same file again does not add rows
+func sqlite_upsert_dataset(ctx context.Context, tx *sql.Tx, source string, doc Yoda18Metadata, loaded time.Time) error {
+	var id int64
+	err := tx.QueryRowContext(ctx, `INSERT INTO datasets (source, title, description, version, language, data_type,
+			license, data_classification, data_access_restriction, collected_start, collected_end, covered_start,
+			covered_end, retention_period, retention_information, embargo_end_date, collection_name, remarks, loaded)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source) DO UPDATE SET title = excluded.title, description = excluded.description,
+			version = excluded.version, language = excluded.language, data_type = excluded.data_type,
+			license = excluded.license, data_classification = excluded.data_classification,
+			data_access_restriction = excluded.data_access_restriction, collected_start = excluded.collected_start,
+			collected_end = excluded.collected_end, covered_start = excluded.covered_start,
+			covered_end = excluded.covered_end, retention_period = excluded.retention_period,
+			retention_information = excluded.retention_information, embargo_end_date = excluded.embargo_end_date,
+			collection_name = excluded.collection_name, remarks = excluded.remarks, loaded = excluded.loaded
+		RETURNING id`,
+		source, sql_text(doc.Title), sql_text(doc.Description), sql_text(doc.Version), sql_text(doc.Language),
+		sql_text(doc.DataType), sql_text(canonical_license(doc.License)), sql_text(doc.DataClassification),
+		sql_text(doc.DataAccessRestriction), sql_text(doc.Collected.StartDate), sql_text(doc.Collected.EndDate),
+		sql_text(doc.CoveredPeriod.StartDate), sql_text(doc.CoveredPeriod.EndDate), int(doc.RetentionPeriod),
+		sql_text(doc.RetentionInformation), sql_text(doc.EmbargoEndDate), sql_text(doc.CollectionName),
+		sql_text(doc.Remarks), loaded.UTC().Format(time.RFC3339)).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	for _, table := range sqlite_child_tables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE dataset_id = ?", id); err != nil {
+			return err
+		}
+	}
+
+	insert := func(query string, args ...any) {
+		if err == nil {
+			_, err = tx.ExecContext(ctx, query, args...)
+		}
+	}
+	const person_query = "INSERT INTO persons (dataset_id, role, contributor_type, given, family, orcid, affiliation) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		insert(person_query, id, "Creator", nil, sql_text(cre.Name.GivenName), sql_text(cre.Name.FamilyName),
+			sql_orcid(ids), sql_text(strings.Join(cre.Affiliation, "; ")))
+	}
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		insert(person_query, id, "Contributor", sql_text(con.ContributorType), sql_text(con.Name.GivenName),
+			sql_text(con.Name.FamilyName), sql_orcid(ids), sql_text(strings.Join(con.Affiliation, "; ")))
+	}
+	for _, fund := range doc.FundingReference {
+		insert("INSERT INTO funding (dataset_id, funder, award) VALUES (?, ?, ?)", id, sql_text(fund.FunderName), sql_text(fund.AwardNumber))
+	}
+	for _, rel := range doc.RelatedDatapackage {
+		pid := rel.PersistentIdentifier
+		insert("INSERT INTO related (dataset_id, relation_type, scheme, identifier, title) VALUES (?, ?, ?, ?, ?)", id,
+			sql_text(rel.RelationType), sql_text(pid.IdentifierScheme), sql_text(pid.Identifier), sql_text(rel.Title))
+	}
+	for _, tag := range doc.Tag {
+		if strings.TrimSpace(tag) != "" {
+			insert("INSERT INTO tags (dataset_id, tag) VALUES (?, ?)", id, strings.TrimSpace(tag))
+		}
+	}
+	return err
+}
+
+// load every input file into the SQLite database outname, creating it and its tables if needed; a dataset is
+// keyed by the absolute path of its input file, loading it again replaces its rows; files that cannot be read
+// are skipped, the files are read by a pool of workers and written in one transaction
+func write_sqlite(ctx context.Context, args []string, outname string, workers int) error {
+	if outname == "" {
+		outname = filepath.Join("output", combined_sqlite_default_name)
+		slog.Info("output filename not provided, using default", "file", outname)
+	}
+	fnames, err := metadata_input_files(args)
+	if err != nil {
+		return err
+	}
+	if len(fnames) == 0 {
+		return fmt.Errorf("no metadata files found in %s", strings.Join(args, ", "))
+	}
+
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(outname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite", outname)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	for _, stmt := range sqlite_schema {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("%s: %w", outname, err)
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	loaded := time.Now()
+	datasets, failed := 0, 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("cannot read metadata, skipped", "file", res.File, "error", res.Err)
+			failed++
+			continue
+		}
+		source, err := filepath.Abs(res.File)
+		if err != nil {
+			return err
+		}
+		if err := sqlite_upsert_dataset(ctx, tx, source, res.Data, loaded); err != nil {
+			return fmt.Errorf("%s: %w", res.File, err)
+		}
+		datasets++
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", outname, "datasets", datasets, "skipped", failed)
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+stats.go how often each tag, discipline and licence occurs in a collection of metadata documents.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// count the values that are not blank, after key
+func count_values(counts map[string]int, values []string, key func(string) string) {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			counts[key(value)]++
+		}
+	}
+}
+
+// TagFrequency counts the documents' tags, in lowercase so "Milk" and "milk" are counted together
+func TagFrequency(docs []Yoda18Metadata) map[string]int {
+	counts := make(map[string]int)
+	for _, doc := range docs {
+		count_values(counts, doc.Tag, strings.ToLower)
+	}
+	return counts
+}
+
+// DisciplineFrequency counts the documents' disciplines as written
+func DisciplineFrequency(docs []Yoda18Metadata) map[string]int {
+	counts := make(map[string]int)
+	for _, doc := range docs {
+		count_values(counts, doc.Discipline, func(s string) string { return s })
+	}
+	return counts
+}
+
+// LicenseFrequency counts the documents' licences, by SPDX identifier when the licence is recognised
+func LicenseFrequency(docs []Yoda18Metadata) map[string]int {
+	counts := make(map[string]int)
+	for _, doc := range docs {
+		count_values(counts, []string{doc.License}, func(s string) string {
+			if spdx, err := NormalizeLicense(s); err == nil {
+				return spdx
+			}
+			return s
+		})
+	}
+	return counts
+}
+
+// the counts as table rows, most frequent first and equal counts alphabetically
+func frequency_rows(counts map[string]int) [][]string {
+	values := make([]string, 0, len(counts))
+	for value := range counts {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	var rows [][]string
+	for _, value := range values {
+		rows = append(rows, []string{value, fmt.Sprint(counts[value])})
+	}
+	return rows
+}
+
+// read the input files and print the tag, discipline and licence frequency tables, files that cannot be read
+// are left out
+func write_stats(ctx context.Context, w io.Writer, fnames []string, workers int) error {
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+	var docs []Yoda18Metadata
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("cannot read metadata, skipped", "file", res.File, "error", res.Err)
+			continue
+		}
+		docs = append(docs, res.Data)
+	}
+
+	width := text_width(w)
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%d datasets\n", len(docs)))
+	text_write_table(&sb, "Tag", []string{"Tag", "Count"}, frequency_rows(TagFrequency(docs)), width)
+	text_write_table(&sb, "Discipline", []string{"Discipline", "Count"}, frequency_rows(DisciplineFrequency(docs)), width)
+	text_write_table(&sb, "License", []string{"License", "Count"}, frequency_rows(LicenseFrequency(docs)), width)
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
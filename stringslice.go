@@ -0,0 +1,59 @@
+/*
+stringslice.go list fields that older Yoda files write as a single string instead of an array.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// StringOrSlice is a list of strings read from a JSON array as well as from a single string, it is always
+// written as an array
+type StringOrSlice []string
+
+// UnmarshalJSON accepts ["a", "b"] as well as "a", an empty string is an empty list
+func (s *StringOrSlice) UnmarshalJSON(raw []byte) error {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		var value string
+		err := json.Unmarshal(raw, &value)
+		if err != nil {
+			return err
+		}
+		*s = nil
+		if value != "" {
+			*s = StringOrSlice{value}
+		}
+		return nil
+	}
+	var values []string
+	err := json.Unmarshal(raw, &values)
+	if err != nil {
+		return fmt.Errorf("expected a string or a list of strings: %w", err)
+	}
+	*s = values
+	return nil
+}
+
+// UnmarshalYAML accepts the same forms in YAML input
+func (s *StringOrSlice) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*s = nil
+		if node.Tag != "!!null" && node.Value != "" {
+			*s = StringOrSlice{node.Value}
+		}
+		return nil
+	}
+	var values []string
+	err := node.Decode(&values)
+	if err != nil {
+		return fmt.Errorf("line %d: expected a string or a list of strings", node.Line)
+	}
+	*s = values
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStringOrSliceJSON(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want StringOrSlice
+	}{
+		{`["a", "b"]`, StringOrSlice{"a", "b"}},
+		{`"a"`, StringOrSlice{"a"}},
+		{` "a, b" `, StringOrSlice{"a, b"}},
+		{`""`, nil},
+		{`[]`, StringOrSlice{}},
+		{`null`, nil},
+	}
+	for _, tt := range tests {
+		var s StringOrSlice
+		if err := json.Unmarshal([]byte(tt.raw), &s); err != nil {
+			t.Errorf("%s: %v", tt.raw, err)
+			continue
+		}
+		if !slices.Equal(s, tt.want) || (s == nil) != (tt.want == nil) {
+			t.Errorf("%s read as %#v, want %#v", tt.raw, s, tt.want)
+		}
+	}
+	for _, raw := range []string{`1`, `{"a": "b"}`, `[1, 2]`, `true`} {
+		var s StringOrSlice
+		if err := json.Unmarshal([]byte(raw), &s); err == nil {
+			t.Errorf("%s read as %#v, want an error", raw, s)
+		}
+	}
+}
+
+func TestStringOrSliceYAML(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want StringOrSlice
+	}{
+		{"Tag: [a, b]", StringOrSlice{"a", "b"}},
+		{"Tag:\n  - a\n  - b", StringOrSlice{"a", "b"}},
+		{"Tag: a", StringOrSlice{"a"}},
+		{"Tag: 12", StringOrSlice{"12"}},
+		{"Tag: ''", nil},
+		{"Tag: ~", nil},
+	}
+	for _, tt := range tests {
+		var doc struct {
+			Tag StringOrSlice `yaml:"Tag"`
+		}
+		if err := yaml.Unmarshal([]byte(tt.raw), &doc); err != nil {
+			t.Errorf("%q: %v", tt.raw, err)
+			continue
+		}
+		if !slices.Equal(doc.Tag, tt.want) {
+			t.Errorf("%q read as %#v, want %#v", tt.raw, doc.Tag, tt.want)
+		}
+	}
+	var doc struct {
+		Tag StringOrSlice `yaml:"Tag"`
+	}
+	if err := yaml.Unmarshal([]byte("Tag:\n  a: b"), &doc); err == nil {
+		t.Errorf("a mapping read as %#v, want an error", doc.Tag)
+	}
+}
+
+func TestStringOrSliceMetadata(t *testing.T) {
+	single := parse_test_metadata(t, `{"Tag": "milk", "Discipline": "Natural Sciences", "Covered_Geolocation_Place": "Senegal",
+		"Creator": [{"Name": {"Given_Name": "Douwe", "Family_Name": "Molenaar"}, "Affiliation": "VU Amsterdam"}]}`)
+	list := parse_test_metadata(t, `{"Tag": ["milk"], "Discipline": ["Natural Sciences"], "Covered_Geolocation_Place": ["Senegal"],
+		"Creator": [{"Name": {"Given_Name": "Douwe", "Family_Name": "Molenaar"}, "Affiliation": ["VU Amsterdam"]}]}`)
+	a, _ := json.Marshal(single)
+	b, _ := json.Marshal(list)
+	if string(a) != string(b) {
+		t.Errorf("a single string gives\n%s\nand a list\n%s", a, b)
+	}
+	// written back always as an array
+	out, err := json.Marshal(StringOrSlice{"milk"})
+	if err != nil || string(out) != `["milk"]` {
+		t.Errorf("written as %s, %v", out, err)
+	}
+}
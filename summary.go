@@ -0,0 +1,84 @@
+/*
+summary.go one line human readable description of a dataset for batch logs.
+*/
+
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// the maximum length of a SummaryLine in characters
+const summary_max_length int = 120
+
+// SummaryLine returns a one-liner such as "MyDataset v1.2 (Smith et al., 2022) [CC-BY-4.0, Open Access]",
+// missing fields are replaced by placeholders and the title is shortened to keep the line under 120 characters
+func SummaryLine(doc Yoda18Metadata) string {
+	title := strings.Join(strings.Fields(doc.Title), " ")
+	if title == "" {
+		title = "Untitled"
+	}
+
+	version := "v?"
+	if doc.Version != "" {
+		version = "v" + strings.TrimPrefix(strings.TrimSpace(doc.Version), "v")
+	}
+
+	author := "unknown author"
+	if len(doc.Creator) > 0 && doc.Creator[0].Name.FamilyName != "" {
+		author = doc.Creator[0].Name.FamilyName
+		if len(doc.Creator) == 2 && doc.Creator[1].Name.FamilyName != "" {
+			author += " & " + doc.Creator[1].Name.FamilyName
+		} else if len(doc.Creator) > 2 {
+			author += " et al."
+		}
+	}
+
+	year := "n.d."
+	if len(doc.Collected.StartDate) >= 4 && strings.Trim(doc.Collected.StartDate[:4], "0123456789") == "" {
+		year = doc.Collected.StartDate[:4]
+	}
+
+	license := canonical_license(doc.License)
+	if license == "" {
+		license = "no licence"
+	}
+
+	access := summary_access(doc.DataAccessRestriction)
+
+	tail := fmt.Sprintf(" %s (%s, %s) [%s, %s]", version, author, year, license, access)
+
+	// the title gives way first, the tail itself is only cut if it alone is too long
+	room := summary_max_length - utf8.RuneCountInString(tail)
+	if room < 10 {
+		room = 10
+	}
+	line := truncate_runes(title, room) + tail
+	return truncate_runes(line, summary_max_length)
+}
+
+// a short label for the Yoda Data_Access_Restriction
+func summary_access(restriction string) string {
+	switch {
+	case restriction == "":
+		return "access unknown"
+	case strings.HasPrefix(restriction, "Open"):
+		return "Open Access"
+	case strings.HasPrefix(restriction, "Restricted"):
+		return "Restricted Access"
+	case strings.HasPrefix(restriction, "Closed"):
+		return "Closed Access"
+	}
+	return restriction
+}
+
+// shorten s to at most max runes, marking the cut with an ellipsis
+func truncate_runes(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:max-1]) + "…"
+}
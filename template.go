@@ -0,0 +1,80 @@
+/*
+template.go the -format template output: the metadata rendered with a Go text/template given with -template.
+*/
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// the template of -format template, parsed once before any output is written
+var output_template *template.Template
+
+// the functions available in a -template
+var template_funcs = template.FuncMap{
+	"join":       template_join,
+	"formatName": formatName,
+	"formatDate": template_format_date,
+	"wrap":       template_wrap,
+}
+
+// join the values with sep, for {{join ", " .Tag}} or {{.Tag | join ", "}}
+func template_join(sep string, values []string) string {
+	return strings.Join(values, sep)
+}
+
+// a YYYY-MM-DD, YYYY-MM or YYYY date in the Go time layout, e.g. {{formatDate "2 January 2006" .Collected.EndDate}};
+// a date that cannot be parsed is returned as it is
+func template_format_date(layout string, date string) string {
+	date = strings.TrimSpace(date)
+	for _, input := range []string{"2006-01-02", "2006-01", "2006"} {
+		if t, err := time.Parse(input, date); err == nil {
+			return t.Format(layout)
+		}
+	}
+	return date
+}
+
+// parse the -template file, a missing file or a syntax error is reported with the file name
+func load_output_template(fname string) (*template.Template, error) {
+	if fname == "" {
+		return nil, fmt.Errorf("-format template needs a template file, given with -template")
+	}
+	t, err := template.New(filepath.Base(fname)).Funcs(template_funcs).Option("missingkey=error").ParseFiles(fname)
+	if err != nil {
+		return nil, fmt.Errorf("cannot use template: %w", err)
+	}
+	return t, nil
+}
+
+// the extension of the template output, the one before .tmpl as in report.md.tmpl, otherwise .txt
+func template_output_extension(fname string) string {
+	base := filepath.Base(fname)
+	if strings.HasSuffix(base, ".tmpl") {
+		if ext := filepath.Ext(strings.TrimSuffix(base, ".tmpl")); ext != "" {
+			return ext
+		}
+	}
+	return ".txt"
+}
+
+// exportTemplate renders the metadata with the -template to w, nothing is written when the template fails
+func exportTemplate(doc Yoda18Metadata, w io.Writer) error {
+	if output_template == nil {
+		return fmt.Errorf("-format template needs a template file, given with -template")
+	}
+	var buf bytes.Buffer
+	err := output_template.Execute(&buf, doc)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
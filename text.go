@@ -0,0 +1,264 @@
+/*
+text.go plain text report of Yoda metadata with aligned label/value columns and person tables.
+*/
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"golang.org/x/term"
+)
+
+// the report width when not writing to a terminal
+const text_default_width int = 100
+
+// the narrowest report that is still laid out in columns
+const text_min_width int = 40
+
+// the report width: the terminal width when w is a terminal, otherwise 100 columns
+func text_width(w io.Writer) int {
+	if f, ok := w.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		if width, _, err := term.GetSize(int(f.Fd())); err == nil && width >= text_min_width {
+			return width
+		}
+	}
+	return text_default_width
+}
+
+// word wrap s to lines of at most width display columns, existing line breaks are kept
+func text_wrap(s string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case runewidth.StringWidth(line)+1+runewidth.StringWidth(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+			// a single word longer than the line is cut
+			for runewidth.StringWidth(line) > width {
+				head := runewidth.Truncate(line, width, "")
+				if head == "" {
+					break
+				}
+				lines = append(lines, head)
+				line = line[len(head):]
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// write a label and its wrapped value, continuation lines are indented to the value column
+func text_write_field(sb *strings.Builder, label string, value string, label_width int, width int) {
+	for i, line := range text_wrap(value, width-label_width) {
+		prefix := strings.Repeat(" ", label_width)
+		if i == 0 {
+			prefix = runewidth.FillRight(label+":", label_width)
+		}
+		sb.WriteString(strings.TrimRight(prefix+line, " ") + "\n")
+	}
+}
+
+// write a titled list with one dash per item, empty lists are left out
+func text_write_list(sb *strings.Builder, label string, items []string, width int) {
+	var filled []string
+	for _, item := range items {
+		if strings.TrimSpace(item) != "" {
+			filled = append(filled, item)
+		}
+	}
+	if len(filled) == 0 {
+		return
+	}
+	sb.WriteString("\n" + label + ":\n")
+	for _, item := range filled {
+		for i, line := range text_wrap(item, width-4) {
+			if i == 0 {
+				sb.WriteString("  - " + line + "\n")
+			} else {
+				sb.WriteString("    " + line + "\n")
+			}
+		}
+	}
+}
+
+// write a table with a header row, columns are as wide as their content until the table would exceed width,
+// then the widest columns are narrowed and their cells shortened with an ellipsis
+func text_write_table(sb *strings.Builder, label string, header []string, rows [][]string, width int) {
+	if len(rows) == 0 {
+		return
+	}
+	const indent, gap = 2, 2
+	widths := make([]int, len(header))
+	for _, row := range append([][]string{header}, rows...) {
+		for c, cell := range row {
+			if n := runewidth.StringWidth(cell); n > widths[c] {
+				widths[c] = n
+			}
+		}
+	}
+	room := width - indent - gap*(len(header)-1)
+	for {
+		total, widest := 0, 0
+		for c, n := range widths {
+			total += n
+			if n > widths[widest] {
+				widest = c
+			}
+		}
+		if total <= room || widths[widest] <= runewidth.StringWidth(header[widest]) {
+			break
+		}
+		widths[widest]--
+	}
+
+	sb.WriteString("\n" + label + ":\n")
+	write_row := func(row []string) {
+		line := strings.Repeat(" ", indent)
+		for c, cell := range row {
+			if c > 0 {
+				line += strings.Repeat(" ", gap)
+			}
+			line += runewidth.FillRight(runewidth.Truncate(cell, widths[c], "…"), widths[c])
+		}
+		sb.WriteString(strings.TrimRight(line, " ") + "\n")
+	}
+	write_row(header)
+	var rule []string
+	for _, n := range widths {
+		rule = append(rule, strings.Repeat("-", n))
+	}
+	write_row(rule)
+	for _, row := range rows {
+		write_row(row)
+	}
+}
+
+// the person identifiers as "SCHEME: id" joined by commas
+func text_person_ids(scheme_ids [][2]string) string {
+	var ids []string
+	for _, id := range scheme_ids {
+		if id[1] != "" {
+			ids = append(ids, fmt.Sprintf("%s: %s", id[0], id[1]))
+		}
+	}
+	return strings.Join(ids, ", ")
+}
+
+// render the report laid out for the given width
+func render_text_report(doc Yoda18Metadata, width int) string {
+	if width < text_min_width {
+		width = text_min_width
+	}
+	var sb strings.Builder
+
+	title := strings.Join(strings.Fields(doc.Title), " ")
+	if title == "" {
+		title = "Untitled"
+	}
+	for _, line := range text_wrap(title, width) {
+		sb.WriteString(line + "\n")
+	}
+	underline := runewidth.StringWidth(title)
+	if underline > width {
+		underline = width
+	}
+	sb.WriteString(strings.Repeat("=", underline) + "\n\n")
+
+	fields := basic_data_fields(doc)
+	label_width := 0
+	for _, field := range fields {
+		if n := runewidth.StringWidth(field[0]) + 2; n > label_width {
+			label_width = n
+		}
+	}
+	for _, field := range fields {
+		if field[0] != "Title" {
+			text_write_field(&sb, field[0], field[1], label_width, width)
+		}
+	}
+
+	text_write_list(&sb, "Discipline", doc.Discipline, width)
+	text_write_list(&sb, "Tag", doc.Tag, width)
+	text_write_list(&sb, "Covered_Geolocation_Place", doc.CoveredGeolocationPlace, width)
+
+	var rows [][]string
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		rows = append(rows, []string{formatName(cre.Name, *name_style), strings.Join(cre.Affiliation, "; "), text_person_ids(ids)})
+	}
+	text_write_table(&sb, "Creator", []string{"Name", "Affiliation", "Identifier"}, rows, width)
+
+	// a table per Contributor_Type
+	rows = nil
+	group := ""
+	for _, i := range contributor_order(doc) {
+		con := doc.Contributor[i]
+		if g := contributor_group(con.ContributorType); g != group {
+			text_write_table(&sb, "Contributor ("+group+")", []string{"Name", "Affiliation", "Identifier"}, rows, width)
+			rows, group = nil, g
+		}
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		rows = append(rows, []string{formatName(con.Name, *name_style), strings.Join(con.Affiliation, "; "), text_person_ids(ids)})
+	}
+	text_write_table(&sb, "Contributor ("+group+")", []string{"Name", "Affiliation", "Identifier"}, rows, width)
+
+	var items []string
+	for _, fund := range doc.FundingReference {
+		if fund.AwardNumber != "" {
+			items = append(items, fmt.Sprintf("%s (%s)", fund.FunderName, fund.AwardNumber))
+		} else {
+			items = append(items, fund.FunderName)
+		}
+	}
+	text_write_list(&sb, "Funding_Reference", items, width)
+
+	items = nil
+	for _, rel := range doc.RelatedDatapackage {
+		item := rel.Title
+		if rel.RelationType != "" {
+			item = rel.RelationType + " - " + item
+		}
+		if rel.PersistentIdentifier.Identifier != "" {
+			pid := rel.PersistentIdentifier
+			item += fmt.Sprintf(" (%s: %s)", pid.IdentifierScheme, pid_link(pid.IdentifierScheme, pid.Identifier))
+		}
+		items = append(items, item)
+	}
+	text_write_list(&sb, "Related_Datapackage", items, width)
+
+	items = nil
+	for _, key := range unmapped_keys(doc) {
+		items = append(items, key+": "+unmapped_text(doc.Unmapped[key]))
+	}
+	text_write_list(&sb, "Unmapped fields", items, width)
+
+	// every field as in the Markdown summary, a line per element of the lists
+	text_write_list(&sb, "All metadata", GetFullData(doc), width)
+
+	return sb.String()
+}
+
+// exportText writes the metadata as a plain text report to w, as wide as the terminal if w is one
+func exportText(doc Yoda18Metadata, w io.Writer) error {
+	_, err := io.WriteString(w, render_text_report(doc, text_width(w)))
+	return err
+}
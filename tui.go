@@ -0,0 +1,199 @@
+/*
+tui.go the -tui mode: the metadata fields of a file as a tree in the terminal, to browse, edit and save them
+without editing the JSON by hand.
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// the key help on the status line
+const tui_help string = "↑↓ move  Enter open/close  e edit  s save  q quit"
+
+// the characters of a value shown in the tree, the editor shows all of it
+const tui_value_width int = 80
+
+// an editable value in the tree, Value is a field of the document so setting it edits the document
+type tui_field struct {
+	Name  string
+	Path  string
+	Value reflect.Value
+}
+
+// the JSON name of a struct field, as in the metadata file
+func tui_field_name(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// the line of a value in the tree, on one line and shortened
+func (f *tui_field) label() string {
+	text := strings.Join(strings.Fields(fmt.Sprint(f.Value.Interface())), " ")
+	if text == "" {
+		return tview.Escape(f.Name) + ": [yellow](empty)[-]"
+	}
+	return tview.Escape(f.Name + ": " + truncate_runes(text, tui_value_width))
+}
+
+// the value in the editor, a line break as \n so it fits the one line
+func (f *tui_field) edit_text() string {
+	return strings.ReplaceAll(fmt.Sprint(f.Value.Interface()), "\n", `\n`)
+}
+
+// set the value from the editor text, a number field must get a whole number
+func (f *tui_field) set(text string) error {
+	switch f.Value.Kind() {
+	case reflect.String:
+		f.Value.SetString(strings.ReplaceAll(text, `\n`, "\n"))
+	case reflect.Int:
+		n, err := strconv.Atoi(strings.TrimSpace(text))
+		if err != nil {
+			return fmt.Errorf("%s must be a whole number", f.Name)
+		}
+		f.Value.SetInt(int64(n))
+	default:
+		return fmt.Errorf("%s cannot be edited", f.Name)
+	}
+	return nil
+}
+
+// add the node of value v, named name, below parent: a struct or list is a node with its fields or items
+// below it, closed until it is opened, another value is a leaf that can be edited
+func tui_add_node(parent *tview.TreeNode, name string, path string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		node := tview.NewTreeNode(tview.Escape(name)).SetColor(tcell.ColorTeal).SetExpanded(false)
+		for i := 0; i < v.NumField(); i++ {
+			field := tui_field_name(v.Type().Field(i))
+			if field == "-" {
+				continue
+			}
+			tui_add_node(node, field, path+"."+field, v.Field(i))
+		}
+		parent.AddChild(node)
+	case reflect.Slice:
+		node := tview.NewTreeNode(tview.Escape(fmt.Sprintf("%s (%d)", name, v.Len()))).SetColor(tcell.ColorTeal).SetExpanded(false)
+		for i := 0; i < v.Len(); i++ {
+			tui_add_node(node, fmt.Sprintf("[%d]", i+1), fmt.Sprintf("%s[%d]", path, i), v.Index(i))
+		}
+		parent.AddChild(node)
+	default:
+		f := &tui_field{Name: name, Path: path, Value: v}
+		parent.AddChild(tview.NewTreeNode(f.label()).SetReference(f))
+	}
+}
+
+// browse and edit the metadata file fname in the terminal, s writes the edited metadata as the JSON of
+// -format json to save_name
+func run_tui(fname string, save_name string) error {
+	raw, err := read_metadata_input(fname)
+	if err != nil {
+		return err
+	}
+	doc, err := decode_metadata(raw, *strict_schema)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+
+	root := tview.NewTreeNode(tview.Escape(fname)).SetColor(tcell.ColorYellow)
+	v := reflect.ValueOf(&doc).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		// the unmapped fields are saved as they were read
+		if name := tui_field_name(v.Type().Field(i)); name != "-" {
+			tui_add_node(root, name, name, v.Field(i))
+		}
+	}
+	tree := tview.NewTreeView().SetRoot(root).SetCurrentNode(root)
+	tree.SetBorder(true).SetTitle(" " + tview.Escape(strings.TrimSpace(doc.Title)) + " ")
+	tree.SetSelectedFunc(func(node *tview.TreeNode) {
+		node.SetExpanded(!node.IsExpanded())
+	})
+
+	editor := tview.NewInputField().SetLabel("edit: ")
+	status := tview.NewTextView().SetDynamicColors(true).SetText(tui_help)
+	app := tview.NewApplication()
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(tree, 0, 1, true).
+		AddItem(editor, 1, 0, false).
+		AddItem(status, 1, 0, false)
+
+	modified := false
+	quit_asked := false
+	var editing *tview.TreeNode
+	show := func(msg string) {
+		status.SetText(msg + "  [gray]" + tui_help + "[-]")
+	}
+
+	editor.SetDoneFunc(func(key tcell.Key) {
+		f := editing.GetReference().(*tui_field)
+		if key == tcell.KeyEnter {
+			if err := f.set(editor.GetText()); err != nil {
+				show("[red]" + tview.Escape(err.Error()) + "[-]")
+				return
+			}
+			editing.SetText(f.label())
+			modified = true
+			show("[green]" + tview.Escape(f.Path) + " changed[-]")
+		} else {
+			status.SetText(tui_help)
+		}
+		editor.SetLabel("edit: ").SetText("")
+		editing = nil
+		app.SetFocus(tree)
+	})
+
+	tree.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Rune() {
+		case 'e':
+			node := tree.GetCurrentNode()
+			if node == nil {
+				return nil
+			}
+			f, ok := node.GetReference().(*tui_field)
+			if !ok {
+				show("[yellow]open the node to edit its fields[-]")
+				return nil
+			}
+			editing = node
+			editor.SetLabel(f.Path + ": ").SetText(f.edit_text())
+			app.SetFocus(editor)
+			return nil
+		case 's':
+			out, err := ordered_json(doc, output_key_order())
+			if err == nil {
+				err = os.WriteFile(save_name, out, 0644)
+			}
+			if err != nil {
+				show("[red]" + tview.Escape(err.Error()) + "[-]")
+				return nil
+			}
+			modified = false
+			show("[green]saved to " + tview.Escape(save_name) + "[-]")
+			return nil
+		case 'q':
+			if modified && !quit_asked {
+				quit_asked = true
+				show("[yellow]there are unsaved changes, s saves them, q again quits without saving[-]")
+				return nil
+			}
+			app.Stop()
+			return nil
+		}
+		quit_asked = false
+		return event
+	})
+
+	return app.SetRoot(layout, true).Run()
+}
@@ -0,0 +1,18 @@
+/*
+unicode.go bringing the text of the metadata to Unicode NFC, so an accented name typed on different systems
+compares equal.
+*/
+
+package main
+
+import (
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeUnicode returns a copy of doc with every string in Unicode normalization form C, an "é" written as
+// "e" and a combining accent (NFD, as macOS file systems and some exports do) becomes the single character "é"
+func NormalizeUnicode(doc Yoda18Metadata) Yoda18Metadata {
+	return map_metadata_strings(doc, func(s string, _ bool) string {
+		return norm.NFC.String(s)
+	})
+}
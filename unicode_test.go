@@ -0,0 +1,62 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// "Émile Brontë" in NFC and in NFD, with the accents as combining characters
+const (
+	unicode_test_nfc string = "\u00c9mile Bront\u00eb"
+	unicode_test_nfd string = "E\u0301mile Bronte\u0308"
+)
+
+func TestNormalizeUnicode(t *testing.T) {
+	if unicode_test_nfc == unicode_test_nfd || norm.NFC.String(unicode_test_nfd) != unicode_test_nfc {
+		t.Fatal("the test names are not the NFC and NFD forms of one name")
+	}
+	doc := parse_test_metadata(t, `{"Title": "Cafe\u0301", "Tag": ["nai\u0308ve"],
+		"Creator": [{"Name": {"Given_Name": "E\u0301mile", "Family_Name": "Bronte\u0308"}, "Affiliation": ["Universite\u0301"]}]}`)
+	got := NormalizeUnicode(doc)
+	if got.Title != "Café" {
+		t.Errorf("Title %q, want NFC", got.Title)
+	}
+	if name := formatName(got.Creator[0].Name, "full"); name != unicode_test_nfc {
+		t.Errorf("creator %q, want %q", name, unicode_test_nfc)
+	}
+	if got.Creator[0].Affiliation[0] != "Université" {
+		t.Errorf("affiliation %q, want NFC", got.Creator[0].Affiliation[0])
+	}
+	if got.Tag[0] != "naïve" {
+		t.Errorf("tag %q, want NFC", got.Tag[0])
+	}
+	// the input keeps its NFD strings
+	if doc.Creator[0].Name.GivenName != "E\u0301mile" {
+		t.Errorf("the input changed to %q", doc.Creator[0].Name.GivenName)
+	}
+}
+
+func TestNormalizeUnicodeCreatorsCompareEqual(t *testing.T) {
+	nfc := NormalizeUnicode(parse_test_metadata(t, `{"Title": "Data", "Creator": [{"Name": {"Given_Name": "Émile", "Family_Name": "Brontë"}}]}`))
+	nfd := NormalizeUnicode(parse_test_metadata(t, `{"Title": "Data", "Creator": [{"Name": {"Given_Name": "E\u0301mile", "Family_Name": "Bronte\u0308"}}]}`))
+	if apa_author(nfc.Creator[0].Name) != apa_author(nfd.Creator[0].Name) {
+		t.Errorf("APA author %q and %q", apa_author(nfc.Creator[0].Name), apa_author(nfd.Creator[0].Name))
+	}
+	// the initial of a decomposed name is the whole accented letter, not the bare E
+	if got := apa_author(nfd.Creator[0].Name); got != "Brontë, É." {
+		t.Errorf("APA author %q", got)
+	}
+	a, err := CanonicalJSON(nfc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := CanonicalJSON(nfd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(a, b) {
+		t.Errorf("canonical JSON differs:\n%s\n%s", a, b)
+	}
+}
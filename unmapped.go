@@ -0,0 +1,109 @@
+/*
+unmapped.go keeping the top level keys of a metadata file that Yoda18Metadata does not model, such as the fields
+of a newer Yoda schema, so they are reported instead of silently dropped; -strict-schema rejects them instead.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// the characters of an unmapped value shown in the reports
+const unmapped_value_width int = 200
+
+// the decoding of Yoda18Metadata without its UnmarshalJSON
+type yoda18_metadata_fields Yoda18Metadata
+
+// the JSON keys that Yoda18Metadata models, and System, which vault exports have next to the metadata
+var yoda_known_keys map[string]bool
+var yoda_known_keys_once sync.Once
+
+func known_metadata_keys() map[string]bool {
+	yoda_known_keys_once.Do(func() {
+		yoda_known_keys = map[string]bool{"System": true}
+		t := reflect.TypeOf(Yoda18Metadata{})
+		for i := 0; i < t.NumField(); i++ {
+			if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+				yoda_known_keys[name] = true
+			}
+		}
+	})
+	return yoda_known_keys
+}
+
+// decode a metadata document, strict makes a key without field, also in a nested object, an error instead of
+// keeping it in Unmapped
+func decode_metadata(raw []byte, strict bool) (Yoda18Metadata, error) {
+	var d Yoda18Metadata
+	if !strict {
+		err := json.Unmarshal(raw, &d)
+		return d, err
+	}
+	fields := struct {
+		*yoda18_metadata_fields
+		System json.RawMessage `json:"System"`
+	}{yoda18_metadata_fields: (*yoda18_metadata_fields)(&d)}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&fields); err != nil {
+		return d, fmt.Errorf("-strict-schema: %w", err)
+	}
+	return d, nil
+}
+
+// UnmarshalJSON decodes the metadata and keeps the top level keys it has no field for in Unmapped
+func (d *Yoda18Metadata) UnmarshalJSON(raw []byte) error {
+	if err := json.Unmarshal(raw, (*yoda18_metadata_fields)(d)); err != nil {
+		return err
+	}
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return err
+	}
+	known := known_metadata_keys()
+	d.Unmapped = nil
+	for key, value := range keys {
+		if known[key] {
+			continue
+		}
+		if d.Unmapped == nil {
+			d.Unmapped = make(map[string]json.RawMessage)
+		}
+		d.Unmapped[key] = value
+	}
+	return nil
+}
+
+// the unmapped keys of doc, sorted
+func unmapped_keys(doc Yoda18Metadata) []string {
+	keys := make([]string, 0, len(doc.Unmapped))
+	for key := range doc.Unmapped {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// an unmapped value as compact JSON, shortened for the reports
+func unmapped_text(value json.RawMessage) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, value) != nil {
+		return truncate_runes(string(value), unmapped_value_width)
+	}
+	return truncate_runes(buf.String(), unmapped_value_width)
+}
+
+// warn about every key of the input that is not in the metadata model
+func report_unmapped(doc Yoda18Metadata, fname string) {
+	for _, key := range unmapped_keys(doc) {
+		slog.Warn("unmapped field, not in the metadata model of this version", "file", fname, "field_path", key, "value", unmapped_text(doc.Unmapped[key]))
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+const unmapped_test_document string = `{
+	"Title": "A dataset",
+	"Data_Steward": "someone",
+	"Creator": [{"Name": {"Given_Name": "A", "Family_Name": "B"}, "Nickname": "ab"}],
+	"System": {"Publication_Date": "2024-01-01"}
+}`
+
+func TestDecodeMetadata(t *testing.T) {
+	doc, err := decode_metadata([]byte(unmapped_test_document), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title != "A dataset" {
+		t.Errorf("title %q", doc.Title)
+	}
+	if keys := unmapped_keys(doc); len(keys) != 1 || keys[0] != "Data_Steward" {
+		t.Errorf("unmapped keys %v, want [Data_Steward]", keys)
+	}
+
+	if _, err := decode_metadata([]byte(unmapped_test_document), true); err == nil {
+		t.Error("strict decoding accepts unknown keys")
+	}
+	if _, err := decode_metadata([]byte(`{"Title": "A dataset", "System": {}}`), true); err != nil {
+		t.Errorf("strict decoding rejects the System block: %v", err)
+	}
+}
+
+// json.Unmarshal, as used by the server and the library functions, does not follow -strict-schema
+func TestUnmarshalIgnoresStrictFlag(t *testing.T) {
+	set_test_flag(t, "strict-schema", "true")
+	var doc Yoda18Metadata
+	if err := json.Unmarshal([]byte(unmapped_test_document), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Unmapped["Data_Steward"]; !ok {
+		t.Error("the unmapped key is not kept")
+	}
+}
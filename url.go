@@ -0,0 +1,143 @@
+/*
+url.go reading Yoda metadata published at a URL with an HTTP GET.
+*/
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// the User-Agent sent with every metadata request
+const url_user_agent string = "readYmeta/" + _MYVERSION_ + " (+https://github.com/vu-rdm-tech/yoda-metadata-toolkit)"
+
+// the longest redirect chain that is followed
+const url_max_redirects int = 10
+
+// the largest metadata document that is downloaded
+const url_max_body int64 = 10 << 20
+
+// the input name used when the URL path has no filename
+const url_default_name string = "url-metadata.json"
+
+// the Content-Types a metadata document may be served with, anything else (an HTML login page) is refused
+func url_content_type_ok(content_type string) bool {
+	if content_type == "" {
+		return true
+	}
+	media, _, err := mime.ParseMediaType(content_type)
+	if err != nil {
+		return false
+	}
+	switch media {
+	case "application/json", "text/json", "text/plain", "application/octet-stream", "application/gzip", "application/x-gzip":
+		return true
+	}
+	return strings.HasSuffix(media, "+json")
+}
+
+// download the raw metadata at raw_url, gzip content is decompressed, the request is bounded by -url-timeout
+func fetch_metadata_url(ctx context.Context, raw_url string) ([]byte, error) {
+	u, err := url.Parse(raw_url)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported URL scheme %q in %s, use http or https", u.Scheme, raw_url)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *url_timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", url_user_agent)
+	req.Header.Set("Accept", "application/json")
+
+	client := &http.Client{
+		CheckRedirect: func(next *http.Request, via []*http.Request) error {
+			if len(via) >= url_max_redirects {
+				return fmt.Errorf("stopped after %d redirects", url_max_redirects)
+			}
+			slog.Debug("following redirect", "url", next.URL.String())
+			return nil
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("no metadata found at %s (404 Not Found)", raw_url)
+	case resp.StatusCode == http.StatusForbidden:
+		return nil, fmt.Errorf("access to %s is forbidden (403), the dataset may not be public", raw_url)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("fetching %s failed: %s", raw_url, resp.Status)
+	}
+	if ct := resp.Header.Get("Content-Type"); !url_content_type_ok(ct) {
+		return nil, fmt.Errorf("%s is served as %q, expected JSON metadata", raw_url, ct)
+	}
+
+	raw, err := read_limited(resp.Body, url_max_body)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", raw_url, err)
+	}
+	slog.Info("metadata downloaded", "url", resp.Request.URL.String(), "bytes", len(raw))
+	if bytes.HasPrefix(raw, gzip_magic) {
+		raw, err = gunzip_bytes(raw)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return unwrap_combi_json(raw)
+}
+
+// LoadFromURL downloads and parses the Yoda metadata published at raw_url
+func LoadFromURL(ctx context.Context, raw_url string) (Yoda18Metadata, error) {
+	var data Yoda18Metadata
+	raw, err := fetch_metadata_url(ctx, raw_url)
+	if err != nil {
+		return data, err
+	}
+	err = json.Unmarshal(raw, &data)
+	return NormalizeUnicode(data), err
+}
+
+// read all of r, an error when there is more than limit bytes instead of silently cutting the rest off
+func read_limited(r io.Reader, limit int64) ([]byte, error) {
+	raw, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(raw)) > limit {
+		return nil, fmt.Errorf("response too large, more than %d bytes", limit)
+	}
+	return raw, nil
+}
+
+// the input filename for a URL, used to name the output files
+func url_input_name(raw_url string) string {
+	u, err := url.Parse(raw_url)
+	if err != nil {
+		return url_default_name
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." || name == "" {
+		return url_default_name
+	}
+	return name
+}
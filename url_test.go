@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromURL(t *testing.T) {
+	raw, err := os.ReadFile("test-data/yoda-metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metadata.json", func(w http.ResponseWriter, r *http.Request) {
+		if ua := r.Header.Get("User-Agent"); ua != url_user_agent {
+			t.Errorf("User-Agent = %q, want %q", ua, url_user_agent)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(raw)
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/metadata.json", http.StatusFound)
+	})
+	mux.HandleFunc("/loop", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	})
+	mux.HandleFunc("/private", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html>please log in</html>"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, path := range []string{"/metadata.json", "/moved"} {
+		doc, err := LoadFromURL(context.Background(), srv.URL+path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if doc.Title == "" {
+			t.Errorf("%s: no title read", path)
+		}
+	}
+
+	errors := map[string]string{
+		"/missing": "404",
+		"/private": "403",
+		"/loop":    "redirects",
+		"/login":   "expected JSON",
+	}
+	for path, want := range errors {
+		_, err := LoadFromURL(context.Background(), srv.URL+path)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %v, want one mentioning %q", path, err, want)
+		}
+	}
+}
+
+func TestReadLimited(t *testing.T) {
+	if raw, err := read_limited(strings.NewReader("12345"), 5); err != nil || string(raw) != "12345" {
+		t.Errorf("read_limited at the limit = %q, %v", raw, err)
+	}
+	if _, err := read_limited(strings.NewReader("123456"), 5); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("read_limited over the limit: error %v, want response too large", err)
+	}
+}
@@ -0,0 +1,218 @@
+/*
+validate.go all checks of a metadata document in one typed result: the missing values and the vocabulary,
+identifier and access checks that the report highlights, for -validate and the /validate endpoint.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// the severities of a ValidationIssue, only errors make a document invalid
+const (
+	severity_error   string = "error"
+	severity_warning string = "warning"
+)
+
+// ValidationIssue is a problem found in a metadata document, Field is the field path as in the log, such as
+// Creator[0].Person_Identifier[1] or Related_Datapackage[2].Relation_Type, Severity is "error" or "warning"
+type ValidationIssue struct {
+	Field    string `json:"field"`
+	Message  string `json:"message"`
+	Severity string `json:"severity"`
+}
+
+// the issues of a person: a missing name part or an affiliation without ROR identifier is a warning, a missing
+// identifier part has the given severity
+func person_issues(field string, name NameStruct, affiliations []string, scheme_ids [][2]string, id_missing string) []ValidationIssue {
+	var issues []ValidationIssue
+	if strings.TrimSpace(name.GivenName) == "" {
+		issues = append(issues, ValidationIssue{field + ".Name.Given_Name", "no given name", severity_warning})
+	}
+	if strings.TrimSpace(name.FamilyName) == "" {
+		issues = append(issues, ValidationIssue{field + ".Name.Family_Name", "no family name", severity_warning})
+	}
+	for i, aff := range affiliations {
+		aff_field := fmt.Sprintf("%s.Affiliation[%d]", field, i)
+		if strings.TrimSpace(aff) == "" {
+			issues = append(issues, ValidationIssue{aff_field, "empty affiliation", severity_warning})
+		} else if !ror_id_pattern.MatchString(aff) {
+			issues = append(issues, ValidationIssue{aff_field, fmt.Sprintf("affiliation %q has no ROR identifier", aff), severity_warning})
+		}
+	}
+	for i, id := range scheme_ids {
+		if strings.TrimSpace(id[0]) == "" || strings.TrimSpace(id[1]) == "" {
+			issues = append(issues, ValidationIssue{fmt.Sprintf("%s.Person_Identifier[%d]", field, i), "identifier scheme or identifier missing", id_missing})
+		}
+	}
+	return issues
+}
+
+// Validate runs all checks on doc and returns the issues in document order, nil when there are none; with
+// strict a Relation_Type that is not a DataCite relationType is an error, as -strict makes it
+func Validate(doc Yoda18Metadata, strict bool) []ValidationIssue {
+	var issues []ValidationIssue
+	missing := func(field, value, severity string) {
+		if strings.TrimSpace(value) == "" {
+			issues = append(issues, ValidationIssue{field, "no value", severity})
+		}
+	}
+
+	missing("Title", doc.Title, severity_warning)
+	missing("Description", doc.Description, severity_warning)
+	if disciplines, err := discipline_issues(doc); err == nil {
+		issues = append(issues, disciplines...)
+	}
+	for i, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		// creators without a proper identifier cannot get credit for the dataset
+		issues = append(issues, person_issues(fmt.Sprintf("Creator[%d]", i), cre.Name, cre.Affiliation, ids, severity_error)...)
+	}
+	for i, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		field := fmt.Sprintf("Contributor[%d]", i)
+		issues = append(issues, person_issues(field, con.Name, con.Affiliation, ids, severity_warning)...)
+		missing(field+".Contributor_Type", con.ContributorType, severity_warning)
+	}
+
+	missing("Collected.Start_Date", doc.Collected.StartDate, severity_warning)
+	missing("Collected.End_Date", doc.Collected.EndDate, severity_warning)
+	missing("Covered_Period.Start_Date", doc.CoveredPeriod.StartDate, severity_warning)
+	missing("Covered_Period.End_Date", doc.CoveredPeriod.EndDate, severity_warning)
+	for i, fund := range doc.FundingReference {
+		missing(fmt.Sprintf("Funding_Reference[%d].Award_Number", i), fund.AwardNumber, severity_warning)
+	}
+	relation_severity := severity_warning
+	if strict {
+		relation_severity = severity_error
+	}
+	for i, rel := range doc.RelatedDatapackage {
+		field := fmt.Sprintf("Related_Datapackage[%d]", i)
+		if rel.RelationType == "" {
+			missing(field+".Relation_Type", rel.RelationType, severity_warning)
+		} else if err := check_relation_type(rel.RelationType); err != nil {
+			issues = append(issues, ValidationIssue{field + ".Relation_Type", err.Error(), relation_severity})
+		}
+		pid := rel.PersistentIdentifier
+		missing(field+".Persistent_Identifier.Identifier_Scheme", pid.IdentifierScheme, severity_warning)
+		if pid.Identifier == "" {
+			missing(field+".Persistent_Identifier.Identifier", pid.Identifier, severity_warning)
+		} else if err := ValidatePID(pid.IdentifierScheme, pid.Identifier); err != nil {
+			issues = append(issues, ValidationIssue{field + ".Persistent_Identifier.Identifier", err.Error(), severity_warning})
+		}
+		missing(field+".Title", rel.Title, severity_warning)
+	}
+
+	missing("Version", doc.Version, severity_error)
+	if err := check_license(doc.License); err != nil {
+		issues = append(issues, ValidationIssue{"License", err.Error(), severity_warning})
+	}
+	if err := check_data_type(doc.DataType); err != nil {
+		issues = append(issues, ValidationIssue{"Data_Type", err.Error(), severity_warning})
+	}
+	if doc.DataAccessRestriction == "Open - freely retrievable" && doc.DataClassification != "Public" {
+		issues = append(issues, ValidationIssue{"Data_Classification", fmt.Sprintf("open access data must be classified Public, not %q", doc.DataClassification), severity_error})
+	}
+	if doc.Language == "" {
+		missing("Language", doc.Language, severity_warning)
+	} else if _, err := NormalizeLanguage(doc.Language); err != nil {
+		issues = append(issues, ValidationIssue{"Language", err.Error(), severity_warning})
+	}
+	missing("Retention_Information", doc.RetentionInformation, severity_warning)
+	missing("Embargo_End_Date", doc.EmbargoEndDate, severity_warning)
+	missing("Remarks", doc.Remarks, severity_warning)
+	for _, key := range unmapped_keys(doc) {
+		issues = append(issues, ValidationIssue{key, "unmapped field, not in the metadata model of this version", severity_warning})
+	}
+	return issues
+}
+
+// the number of issues that are errors
+func validation_error_count(issues []ValidationIssue) int {
+	count := 0
+	for _, issue := range issues {
+		if issue.Severity == severity_error {
+			count++
+		}
+	}
+	return count
+}
+
+// the issues of one input file
+type FileValidation struct {
+	File   string            `json:"file"`
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// ValidateAll reads and validates the input files with the given number of workers, in the order of fnames,
+// strict as for Validate; a file that cannot be read has one error without field, once ctx is cancelled the
+// ctx error is returned
+func ValidateAll(ctx context.Context, fnames []string, workers int, strict bool) ([]FileValidation, error) {
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return nil, err
+	}
+	validations := make([]FileValidation, len(results))
+	for i, res := range results {
+		issues := Validate(res.Data, strict)
+		if res.Err != nil {
+			issues = []ValidationIssue{{"", res.Err.Error(), severity_error}}
+		}
+		validations[i] = FileValidation{res.File, issues}
+	}
+	return validations, nil
+}
+
+// validate the input files and print their issues, a line "error Version: no value" per issue (prefixed by the
+// filename when several files are given), or with as_json a JSON array of the issues, an object of the arrays
+// by filename for several files; a file that cannot be read has one error, returns the number of errors
+func write_validation(ctx context.Context, w io.Writer, fnames []string, workers int, as_json bool, strict bool) (int, error) {
+	results, err := ValidateAll(ctx, fnames, workers, strict)
+	if err != nil {
+		return 0, err
+	}
+	errors := 0
+	by_file := make(map[string][]ValidationIssue)
+	for _, res := range results {
+		issues := res.Issues
+		errors += validation_error_count(issues)
+		if as_json {
+			if issues == nil {
+				issues = []ValidationIssue{}
+			}
+			by_file[res.File] = issues
+			continue
+		}
+		prefix := ""
+		if len(fnames) > 1 {
+			prefix = res.File + ": "
+		}
+		for _, issue := range issues {
+			if issue.Field == "" {
+				fmt.Fprintf(w, "%s%-7s %s\n", prefix, issue.Severity, issue.Message)
+			} else {
+				fmt.Fprintf(w, "%s%-7s %s: %s\n", prefix, issue.Severity, issue.Field, issue.Message)
+			}
+		}
+	}
+	if !as_json {
+		return errors, nil
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if len(results) == 1 {
+		return errors, enc.Encode(by_file[results[0].File])
+	}
+	return errors, enc.Encode(by_file)
+}
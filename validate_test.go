@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// the severity of the issue of field in issues, "" when there is none
+func validate_test_severity(issues []ValidationIssue, field string) string {
+	for _, issue := range issues {
+		if issue.Field == field {
+			return issue.Severity
+		}
+	}
+	return ""
+}
+
+func TestValidateComplete(t *testing.T) {
+	issues := Validate(load_test_metadata(t, "yoda-metadata[douwe].json"), false)
+	if n := validation_error_count(issues); n != 0 {
+		t.Errorf("%d errors in complete metadata: %+v", n, issues)
+	}
+	if got := validate_test_severity(issues, "Remarks"); got != severity_warning {
+		t.Errorf("missing Remarks is %q, want a warning", got)
+	}
+}
+
+func TestValidateSeverities(t *testing.T) {
+	tests := []struct {
+		name   string
+		edit   func(doc *Yoda18Metadata)
+		field  string
+		wanted string
+	}{
+		{"no version", func(doc *Yoda18Metadata) { doc.Version = " " }, "Version", severity_error},
+		{"no title", func(doc *Yoda18Metadata) { doc.Title = "" }, "Title", severity_warning},
+		{"creator identifier", func(doc *Yoda18Metadata) { doc.Creator[0].PersonIdentifier[0].NameIdentifier = "" }, "Creator[0].Person_Identifier[0]", severity_error},
+		{"creator given name", func(doc *Yoda18Metadata) { doc.Creator[0].Name.GivenName = "" }, "Creator[0].Name.Given_Name", severity_warning},
+		{"contributor identifier", func(doc *Yoda18Metadata) { doc.Contributor[0].PersonIdentifier[0].NameIdentifierScheme = "" }, "Contributor[0].Person_Identifier[0]", severity_warning},
+		{"contributor type", func(doc *Yoda18Metadata) { doc.Contributor[0].ContributorType = "" }, "Contributor[0].Contributor_Type", severity_warning},
+		{"open access not public", func(doc *Yoda18Metadata) {
+			doc.DataAccessRestriction, doc.DataClassification = "Open - freely retrievable", "Basic"
+		}, "Data_Classification", severity_error},
+		{"relation type", func(doc *Yoda18Metadata) { doc.RelatedDatapackage[0].RelationType = "Likes: something" }, "Related_Datapackage[0].Relation_Type", severity_warning},
+		{"related DOI", func(doc *Yoda18Metadata) { doc.RelatedDatapackage[0].PersistentIdentifier.Identifier = "not a doi" }, "Related_Datapackage[0].Persistent_Identifier.Identifier", severity_warning},
+		{"language", func(doc *Yoda18Metadata) { doc.Language = "Klingon" }, "Language", severity_warning},
+		{"licence", func(doc *Yoda18Metadata) { doc.License = "My own licence" }, "License", severity_warning},
+		{"unmapped", func(doc *Yoda18Metadata) { doc.Unmapped = map[string]json.RawMessage{"Extra": []byte(`"x"`)} }, "Extra", severity_warning},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+			tt.edit(&doc)
+			if got := validate_test_severity(Validate(doc, false), tt.field); got != tt.wanted {
+				t.Errorf("%s has severity %q, want %q", tt.field, got, tt.wanted)
+			}
+		})
+	}
+}
+
+func TestValidateStrictRelationType(t *testing.T) {
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	doc.RelatedDatapackage[0].RelationType = "Likes: something"
+	field := "Related_Datapackage[0].Relation_Type"
+	if got := validate_test_severity(Validate(doc, false), field); got != severity_warning {
+		t.Errorf("%s has severity %q, want a warning", field, got)
+	}
+	if got := validate_test_severity(Validate(doc, true), field); got != severity_error {
+		t.Errorf("%s has severity %q with strict, want an error", field, got)
+	}
+
+	raw, err := os.ReadFile(filepath.Join("test-data", "yoda-metadata[douwe].json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw = bytes.Replace(raw, []byte(`"IsSupplementTo:`), []byte(`"Likes:`), 1)
+	for _, strict := range []bool{false, true} {
+		result, err := validate_document(context.Background(), raw, default_schema_name, strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Valid == strict {
+			t.Errorf("/validate with strict %t gives valid %t: %+v", strict, result.Valid, result.Errors)
+		}
+	}
+}
+
+func TestWriteValidation(t *testing.T) {
+	good := filepath.Join("test-data", "yoda-metadata[douwe].json")
+	var buf bytes.Buffer
+	errors, err := write_validation(context.Background(), &buf, []string{good}, 1, false, false)
+	if err != nil || errors != 0 {
+		t.Fatalf("%d errors, %v", errors, err)
+	}
+	if !strings.Contains(buf.String(), "warning Remarks: no value\n") {
+		t.Errorf("text output:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	missing := filepath.Join(t.TempDir(), "missing.json")
+	errors, err = write_validation(context.Background(), &buf, []string{good, missing}, 1, true, false)
+	if err != nil || errors != 1 {
+		t.Fatalf("%d errors, %v", errors, err)
+	}
+	var by_file map[string][]ValidationIssue
+	if err := json.Unmarshal(buf.Bytes(), &by_file); err != nil {
+		t.Fatalf("%v in %s", err, buf.String())
+	}
+	if len(by_file) != 2 || len(by_file[missing]) != 1 || validate_test_severity(by_file[good], "Remarks") != severity_warning {
+		t.Errorf("JSON output %s", buf.String())
+	}
+}
@@ -0,0 +1,127 @@
+/*
+watch.go the -watch mode: the input file is converted again whenever it is saved, for a live preview of the
+PDF report while the metadata is edited.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// how long the input must be left alone after a write before it is converted, an editor saves a file with
+// several writes
+const watch_debounce time.Duration = 500 * time.Millisecond
+
+// the output names of a single input file, as main resolves them from the flags
+type watch_target struct {
+	Input        string   // the metadata file
+	DataDir      string   // the data package directory when the input was given as one
+	Formats      []string // the -format, or the -formats list
+	OutputPath   string   // the directory of the output files without -output-dir
+	OutputFile   string   // the -output file of the PDF report, or of the other format
+	OutputFileMD string   // the Markdown summary next to the -output PDF
+}
+
+// convert the input once with the output flags, as main converts a single file; errors are returned instead of
+// stopping the program so the next save is converted again
+func convert_watched(ctx context.Context, t watch_target) error {
+	data, err := read_metadata_file(ctx, t.Input)
+	if err != nil {
+		return err
+	}
+	report_data_type(data, t.Input)
+	report_disciplines(data, t.Input)
+	report_language(data, t.Input)
+
+	var raw []byte
+	if *append_source {
+		if raw, err = read_metadata_input(t.Input); err != nil {
+			return err
+		}
+	}
+	format := t.Formats[0]
+	switch {
+	case *output_dir != "":
+		stem := filepath.Join(*output_dir, output_dir_stem(data, t.Input))
+		return write_formats(data, t.Formats, stem, t.Input, t.DataDir, t.Input, raw)
+	case *output_file != "" && format == "pdf":
+		return write_pdf_output(data, t.Input, t.OutputFile, t.OutputFileMD, raw)
+	case *output_file != "":
+		return write_output_file(data, format, t.OutputFile, t.DataDir, t.Input)
+	case format == "text":
+		return exportText(data, os.Stdout)
+	case format == "citation":
+		return exportCitation(data, t.Input, os.Stdout)
+	case format == "email":
+		return exportEmail(data, t.Input, os.Stdout)
+	}
+	stem := filepath.Join(t.OutputPath, input_file_stem(t.Input))
+	return write_formats(data, t.Formats, stem, t.Input, t.DataDir, t.Input, raw)
+}
+
+// report a conversion of the watched file on stderr
+func watch_report(t watch_target, err error) {
+	now := time.Now().Format("15:04:05")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s rebuild of %s failed: %v\n", now, t.Input, err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s rebuilt %s (%s)\n", now, t.Input, strings.Join(t.Formats, ", "))
+}
+
+// convert the input, then again each time it is written, until ctx is cancelled (Ctrl-C); the directory of
+// the input is watched, so a file that the editor saves by replacing it is followed too
+func watch_input(ctx context.Context, t watch_target) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	abs, err := filepath.Abs(t.Input)
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(filepath.Dir(abs)); err != nil {
+		return err
+	}
+
+	watch_report(t, convert_watched(ctx, t))
+	fmt.Fprintf(os.Stderr, "watching %s for changes, press Ctrl-C to stop\n", t.Input)
+
+	// a stopped timer, started by a write and restarted by every write that follows within watch_debounce
+	debounce := time.NewTimer(watch_debounce)
+	debounce.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Fprintln(os.Stderr, "watch stopped")
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) != abs || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+				continue
+			}
+			slog.Debug("input changed", "file", t.Input, "event", event.Op.String())
+			debounce.Reset(watch_debounce)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "watch error: %v\n", err)
+		case <-debounce.C:
+			watch_report(t, convert_watched(ctx, t))
+		}
+	}
+}
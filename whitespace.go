@@ -0,0 +1,64 @@
+/*
+whitespace.go cleaning up the spaces, tabs and newlines of the free text fields, part of -normalize.
+*/
+
+package main
+
+import (
+	"reflect"
+	"strings"
+)
+
+// the fields whose blank lines separate paragraphs, these are kept when the whitespace is collapsed
+var paragraph_fields = map[string]bool{
+	"Description": true,
+	"Remarks":     true,
+}
+
+// NormalizeWhitespace returns a copy of doc with every string trimmed and every run of spaces, tabs and newlines
+// in it collapsed to a single space; the paragraphs of Description and Remarks stay separated by a blank line
+func NormalizeWhitespace(doc Yoda18Metadata) Yoda18Metadata {
+	return map_metadata_strings(doc, collapse_whitespace)
+}
+
+// a copy of doc with every string replaced by f of it, paragraphs tells f whether the field is one of the
+// paragraph_fields; lists are copied first so doc itself is not changed
+func map_metadata_strings(doc Yoda18Metadata, f func(s string, paragraphs bool) string) Yoda18Metadata {
+	map_strings_value(reflect.ValueOf(&doc).Elem(), false, f)
+	return doc
+}
+
+func map_strings_value(v reflect.Value, paragraphs bool, f func(string, bool) string) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(f(v.String(), paragraphs))
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		list := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(list, v)
+		for i := 0; i < list.Len(); i++ {
+			map_strings_value(list.Index(i), false, f)
+		}
+		v.Set(list)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			map_strings_value(v.Field(i), paragraph_fields[v.Type().Field(i).Name], f)
+		}
+	}
+}
+
+// s trimmed with its whitespace collapsed, with paragraphs the blank lines between paragraphs are kept
+func collapse_whitespace(s string, paragraphs bool) string {
+	if !paragraphs {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	var paras []string
+	for _, para := range paragraph_break.Split(strings.ReplaceAll(s, "\r\n", "\n"), -1) {
+		if para = strings.Join(strings.Fields(para), " "); para != "" {
+			paras = append(paras, para)
+		}
+	}
+	return strings.Join(paras, "\n\n")
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollapseWhitespace(t *testing.T) {
+	tests := []struct {
+		in         string
+		paragraphs bool
+		want       string
+	}{
+		{"  a \t b\n\nc  ", false, "a b c"},
+		{"a  b c\u0085d", false, "a b c d"},
+		{"zero\u200bwidth", false, "zero\u200bwidth"},
+		{" \t\r\n\v\f ", false, ""},
+		{"", true, ""},
+		{"one\r\n\r\ntwo", true, "one\n\ntwo"},
+		{"one\n \t \ntwo\nstill two", true, "one\n\ntwo still two"},
+		{"\n\n\n\none\n\n\n\n\n\ntwo\n\n\n\n", true, "one\n\ntwo"},
+		{"one\rtwo", true, "one two"},
+	}
+	for _, tt := range tests {
+		if got := collapse_whitespace(tt.in, tt.paragraphs); got != tt.want {
+			t.Errorf("collapse_whitespace(%q, %v) = %q, want %q", tt.in, tt.paragraphs, got, tt.want)
+		}
+	}
+}
+
+func TestCollapseWhitespaceLarge(t *testing.T) {
+	// a megabyte of whitespace and of blank lines is collapsed in one pass
+	in := "a" + strings.Repeat(" \t", 1<<19) + "b"
+	if got := collapse_whitespace(in, false); got != "a b" {
+		t.Errorf("got %d bytes, want \"a b\"", len(got))
+	}
+	in = "a" + strings.Repeat("\n \n", 1<<18) + "b"
+	if got := collapse_whitespace(in, true); got != "a\n\nb" {
+		t.Errorf("got %d bytes, want two paragraphs", len(got))
+	}
+}
+
+func TestNormalizeWhitespace(t *testing.T) {
+	doc := parse_test_metadata(t, `{"Title": "  A\ttitle \n", "Description": "First  paragraph\n\n\n  second\tparagraph ",
+		"Tag": ["  milk ", "\tsenegal"], "Creator": [{"Name": {"Given_Name": " Douwe ", "Family_Name": "Molenaar\n"}, "Affiliation": [" VU  Amsterdam "]}]}`)
+	got := NormalizeWhitespace(doc)
+	if got.Title != "A title" || got.Description != "First paragraph\n\nsecond paragraph" {
+		t.Errorf("Title %q, Description %q", got.Title, got.Description)
+	}
+	if got.Tag[0] != "milk" || got.Tag[1] != "senegal" {
+		t.Errorf("Tag %q", got.Tag)
+	}
+	if cre := got.Creator[0]; cre.Name.GivenName != "Douwe" || cre.Name.FamilyName != "Molenaar" || cre.Affiliation[0] != "VU Amsterdam" {
+		t.Errorf("Creator %+v", cre)
+	}
+	// the lists of doc are copied, not changed in place
+	if doc.Tag[0] != "  milk " || doc.Creator[0].Affiliation[0] != " VU  Amsterdam " {
+		t.Errorf("the input changed: %q %q", doc.Tag, doc.Creator[0].Affiliation)
+	}
+}
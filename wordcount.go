@@ -0,0 +1,60 @@
+/*
+wordcount.go word and character counts of the free text fields, to track how complete the descriptions are.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// a counted text field
+type text_metric struct {
+	Field string
+	Words int
+	Chars int
+}
+
+// the word and character counts of Title, Description and Remarks
+func text_metrics(doc Yoda18Metadata) []text_metric {
+	var metrics []text_metric
+	for _, field := range [][2]string{{"Title", doc.Title}, {"Description", doc.Description}, {"Remarks", doc.Remarks}} {
+		text := strings.TrimSpace(field[1])
+		metrics = append(metrics, text_metric{field[0], len(strings.Fields(text)), utf8.RuneCountInString(text)})
+	}
+	return metrics
+}
+
+// print one metric per line, "Description.words 63", prefixed by the filename when several files are given;
+// a description of fewer than min_words words is reported as too short
+func write_word_counts(ctx context.Context, w io.Writer, fnames []string, min_words int, workers int) error {
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+	for _, res := range results {
+		if res.Err != nil {
+			return fmt.Errorf("%s: %w", res.File, res.Err)
+		}
+		prefix := ""
+		if len(fnames) > 1 {
+			prefix = res.File + " "
+		}
+		too_short := false
+		for _, m := range text_metrics(res.Data) {
+			fmt.Fprintf(w, "%s%s.words %d\n", prefix, m.Field, m.Words)
+			fmt.Fprintf(w, "%s%s.characters %d\n", prefix, m.Field, m.Chars)
+			if m.Field == "Description" && m.Words < min_words {
+				too_short = true
+				slog.Warn("description too short", "file", res.File, "words", m.Words, "minimum", min_words)
+			}
+		}
+		fmt.Fprintf(w, "%sDescription.too_short %t\n", prefix, too_short)
+	}
+	return nil
+}
@@ -0,0 +1,214 @@
+/*
+xlsx.go exports Yoda metadata as an Excel workbook with sheets for the dataset fields, creators, contributors, funding and
+related datapackages.
+*/
+
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// the workbook filename used when -output is not given
+const combined_xlsx_default_name string = "combined-metadata.xlsx"
+
+// the widest a column is made by the auto-width, in characters
+const xlsx_max_col_width int = 60
+
+// the rows of one worksheet
+type xlsx_sheet struct {
+	Name   string
+	Header []string
+	Rows   [][]string
+}
+
+// the empty sheets of a metadata workbook, every sheet starts with a Dataset column
+func xlsx_new_sheets() []*xlsx_sheet {
+	fields := []string{"Dataset"}
+	for _, field := range basic_data_fields(Yoda18Metadata{}) {
+		fields = append(fields, field[0])
+	}
+	return []*xlsx_sheet{
+		{Name: "Summary", Header: fields},
+		{Name: "Creators", Header: []string{"Dataset", "Family_Name", "Given_Name", "Affiliation", "ORCID"}},
+		{Name: "Contributors", Header: []string{"Dataset", "Contributor_Type", "Family_Name", "Given_Name", "Affiliation", "ORCID"}},
+		{Name: "Funding", Header: []string{"Dataset", "Funder_Name", "Award_Number"}},
+		{Name: "Related", Header: []string{"Dataset", "Title", "Relation_Type", "Identifier_Scheme", "Identifier"}},
+	}
+}
+
+// the ORCID of a person, empty if there is none
+func xlsx_orcid(scheme_ids [][2]string) string {
+	for _, id := range scheme_ids {
+		if strings.EqualFold(id[0], "ORCID") && id[1] != "" {
+			return id[1]
+		}
+	}
+	return ""
+}
+
+// add the rows of one dataset to the sheets made by xlsx_new_sheets, dataset names the source in the Dataset column
+func xlsx_add_dataset(sheets []*xlsx_sheet, dataset string, doc Yoda18Metadata) {
+	row := []string{dataset}
+	for _, field := range basic_data_fields(doc) {
+		row = append(row, field[1])
+	}
+	sheets[0].Rows = append(sheets[0].Rows, row)
+
+	for _, cre := range doc.Creator {
+		var ids [][2]string
+		for _, pid := range cre.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		sheets[1].Rows = append(sheets[1].Rows, []string{dataset, cre.Name.FamilyName, cre.Name.GivenName,
+			strings.Join(cre.Affiliation, "; "), xlsx_orcid(ids)})
+	}
+	for _, con := range doc.Contributor {
+		var ids [][2]string
+		for _, pid := range con.PersonIdentifier {
+			ids = append(ids, [2]string{pid.NameIdentifierScheme, pid.NameIdentifier})
+		}
+		sheets[2].Rows = append(sheets[2].Rows, []string{dataset, con.ContributorType, con.Name.FamilyName, con.Name.GivenName,
+			strings.Join(con.Affiliation, "; "), xlsx_orcid(ids)})
+	}
+
+	for _, fund := range doc.FundingReference {
+		sheets[3].Rows = append(sheets[3].Rows, []string{dataset, fund.FunderName, fund.AwardNumber})
+	}
+
+	for _, rel := range doc.RelatedDatapackage {
+		sheets[4].Rows = append(sheets[4].Rows, []string{dataset, rel.Title, rel.RelationType,
+			rel.PersistentIdentifier.IdentifierScheme, pid_link(rel.PersistentIdentifier.IdentifierScheme, rel.PersistentIdentifier.Identifier)})
+	}
+}
+
+// write the sheets as an xlsx workbook to w, with a bold frozen header row and columns sized to their content
+func write_xlsx(sheets []*xlsx_sheet, w io.Writer) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	bold, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+
+	for i, sheet := range sheets {
+		if i == 0 {
+			err = f.SetSheetName(f.GetSheetName(0), sheet.Name)
+		} else {
+			_, err = f.NewSheet(sheet.Name)
+		}
+		if err != nil {
+			return err
+		}
+
+		widths := make([]int, len(sheet.Header))
+		for r, row := range append([][]string{sheet.Header}, sheet.Rows...) {
+			cell, err := excelize.CoordinatesToCellName(1, r+1)
+			if err != nil {
+				return err
+			}
+			err = f.SetSheetRow(sheet.Name, cell, &row)
+			if err != nil {
+				return err
+			}
+			for c, value := range row {
+				// the longest line of a multi-line value decides the width
+				for _, line := range strings.Split(value, "\n") {
+					if n := utf8.RuneCountInString(line); c < len(widths) && n > widths[c] {
+						widths[c] = n
+					}
+				}
+			}
+		}
+
+		err = f.SetRowStyle(sheet.Name, 1, 1, bold)
+		if err != nil {
+			return err
+		}
+		err = f.SetPanes(sheet.Name, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"})
+		if err != nil {
+			return err
+		}
+		for c, width := range widths {
+			col, err := excelize.ColumnNumberToName(c + 1)
+			if err != nil {
+				return err
+			}
+			if width > xlsx_max_col_width {
+				width = xlsx_max_col_width
+			}
+			err = f.SetColWidth(sheet.Name, col, col, float64(width+2))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return f.Write(w)
+}
+
+// RenderExcel writes a workbook with the metadata of the datasets to w, a row per dataset on the Summary sheet and
+// a row per creator and dataset on the Creators sheet; the Dataset column holds the titles
+func RenderExcel(docs []Yoda18Metadata, w io.Writer) error {
+	sheets := xlsx_new_sheets()
+	for _, doc := range docs {
+		xlsx_add_dataset(sheets, doc.Title, doc)
+	}
+	return write_xlsx(sheets, w)
+}
+
+// exportXLSX writes a workbook with the metadata of a single dataset to w
+func exportXLSX(doc Yoda18Metadata, w io.Writer) error {
+	return RenderExcel([]Yoda18Metadata{doc}, w)
+}
+
+// write one workbook holding the rows of all input files, the Dataset column holds the filename,
+// files that cannot be read are skipped
+func write_combined_xlsx(ctx context.Context, fnames []string, outname string, workers int) error {
+	if outname == "" {
+		outname = filepath.Join("output", combined_xlsx_default_name)
+		slog.Info("output filename not provided, using default", "file", outname)
+	}
+
+	results, err := run_batch(ctx, fnames, workers, read_metadata_file)
+	if err != nil {
+		return err
+	}
+
+	sheets := xlsx_new_sheets()
+	datasets := 0
+	for _, res := range results {
+		if res.Err != nil {
+			slog.Error("cannot read metadata, skipped", "file", res.File, "error", res.Err)
+			continue
+		}
+		xlsx_add_dataset(sheets, res.File, res.Data)
+		datasets++
+	}
+
+	err = os.MkdirAll(filepath.Dir(outname), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(outname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = write_xlsx(sheets, f)
+	if err != nil {
+		return err
+	}
+	slog.Info("output written", "file", outname, "datasets", datasets)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRenderExcel(t *testing.T) {
+	docs := []Yoda18Metadata{
+		load_test_metadata(t, "yoda-metadata.json"),
+		load_test_metadata(t, "yoda-metadata[douwe].json"),
+	}
+	var buf bytes.Buffer
+	if err := RenderExcel(docs, &buf); err != nil {
+		t.Fatal(err)
+	}
+	f, err := excelize.OpenReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	want := []string{"Summary", "Creators", "Contributors", "Funding", "Related"}
+	sheets := f.GetSheetList()
+	if len(sheets) != len(want) {
+		t.Fatalf("sheets %v, want %v", sheets, want)
+	}
+	for i := range want {
+		if sheets[i] != want[i] {
+			t.Errorf("sheet %d is %q, want %q", i+1, sheets[i], want[i])
+		}
+	}
+
+	rows, err := f.GetRows("Summary")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1+len(docs) {
+		t.Errorf("Summary has %d rows, want a header and one per document", len(rows))
+	}
+	creators := 0
+	for _, doc := range docs {
+		creators += len(doc.Creator)
+	}
+	if rows, _ := f.GetRows("Creators"); len(rows) != 1+creators {
+		t.Errorf("Creators has %d rows, want a header and %d creators", len(rows), creators)
+	} else if rows[1][0] != docs[0].Title || rows[1][1] != docs[0].Creator[0].Name.FamilyName {
+		t.Errorf("first creator row %v", rows[1])
+	}
+
+	for _, sheet := range want {
+		panes, err := f.GetPanes(sheet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !panes.Freeze || panes.YSplit != 1 {
+			t.Errorf("%s: header row not frozen: %+v", sheet, panes)
+		}
+		id, err := f.GetCellStyle(sheet, "A1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		style, err := f.GetStyle(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if style.Font == nil || !style.Font.Bold {
+			t.Errorf("%s: header not bold", sheet)
+		}
+		if width, err := f.GetColWidth(sheet, "A"); err != nil || width <= 2 {
+			t.Errorf("%s: column A width %v, %v", sheet, width, err)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+/*
+xmp.go exports the Dublin Core fields of the metadata as an XMP packet in a .xmp sidecar file, for embedding into
+TIFF and other image files with XMP-aware tools.
+*/
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const (
+	xmp_meta_namespace   string = "adobe:ns:meta/"
+	rdf_namespace        string = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	xmp_rights_namespace string = "http://ns.adobe.com/xap/1.0/rights/"
+)
+
+// the XMP packet wrapper, begin holds the byte order mark and the id is the fixed one of the XMP specification
+const (
+	xmp_packet_begin string = "<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n"
+	xmp_packet_end   string = "<?xpacket end=\"w\"?>\n"
+)
+
+// the x:xmpmeta element of an XMP packet
+type XMPMeta struct {
+	XMLName xml.Name `xml:"x:xmpmeta"`
+	XmlnsX  string   `xml:"xmlns:x,attr"`
+	RDF     XMPRDF   `xml:"rdf:RDF"`
+}
+
+// the RDF of the packet with the one resource, the file the packet is embedded in
+type XMPRDF struct {
+	XmlnsRDF    string         `xml:"xmlns:rdf,attr"`
+	Description XMPDescription `xml:"rdf:Description"`
+}
+
+// the XMP properties, rdf:about is empty as XMP requires
+type XMPDescription struct {
+	About          string  `xml:"rdf:about,attr"`
+	XmlnsDC        string  `xml:"xmlns:dc,attr"`
+	XmlnsXMPRights string  `xml:"xmlns:xmpRights,attr"`
+	Title          *XMPAlt `xml:"dc:title,omitempty"`
+	Creator        *XMPSeq `xml:"dc:creator,omitempty"`
+	Description    *XMPAlt `xml:"dc:description,omitempty"`
+	Subject        *XMPBag `xml:"dc:subject,omitempty"`
+	Rights         *XMPAlt `xml:"dc:rights,omitempty"`
+	WebStatement   string  `xml:"xmpRights:WebStatement,omitempty"`
+}
+
+// a language alternative, XMP text in the x-default language
+type XMPAlt struct {
+	Items []XMPLangItem `xml:"rdf:Alt>rdf:li"`
+}
+
+// a text of a language alternative with its language
+type XMPLangItem struct {
+	Lang  string `xml:"xml:lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+// an ordered array, such as the creators
+type XMPSeq struct {
+	Items []string `xml:"rdf:Seq>rdf:li"`
+}
+
+// an unordered array, such as the keywords
+type XMPBag struct {
+	Items []string `xml:"rdf:Bag>rdf:li"`
+}
+
+// the x-default language alternative of the values joined, nil without values
+func xmp_alt(values []string) *XMPAlt {
+	if len(values) == 0 {
+		return nil
+	}
+	return &XMPAlt{Items: []XMPLangItem{{"x-default", strings.Join(values, "; ")}}}
+}
+
+// the XMP packet of the metadata read from source: title, creators, description and rights as the Dublin
+// Core record of -format oai maps them, the Tag values as dc:subject and the licence URL as
+// xmpRights:WebStatement
+func xmp_packet(doc Yoda18Metadata, source string) XMPMeta {
+	sys := oai_system(doc, source)
+	dc := oai_dc_record(doc, sys)
+	desc := XMPDescription{
+		XmlnsDC:        dc_namespace,
+		XmlnsXMPRights: xmp_rights_namespace,
+		Title:          xmp_alt(dc.Title),
+		Description:    xmp_alt(dc.Description),
+		Rights:         xmp_alt(dc.Rights),
+		WebStatement:   dataset_license_url(doc, sys),
+	}
+	if len(dc.Creator) > 0 {
+		desc.Creator = &XMPSeq{Items: dc.Creator}
+	}
+	if tags := oai_append(nil, doc.Tag...); len(tags) > 0 {
+		desc.Subject = &XMPBag{Items: tags}
+	}
+	return XMPMeta{
+		XmlnsX: xmp_meta_namespace,
+		RDF:    XMPRDF{XmlnsRDF: rdf_namespace, Description: desc},
+	}
+}
+
+// exportXMP writes the metadata read from source as an XMP packet to w
+func exportXMP(doc Yoda18Metadata, source string, w io.Writer) error {
+	if _, err := io.WriteString(w, xmp_packet_begin); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(xmp_packet(doc, source)); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n"+xmp_packet_end)
+	return err
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// the XMP packet as an XMP reader sees it, every element in its namespace
+type xmp_test_packet struct {
+	XMLName     xml.Name `xml:"adobe:ns:meta/ xmpmeta"`
+	Description struct {
+		About string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+		Title struct {
+			Items []struct {
+				Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Alt>li"`
+		} `xml:"http://purl.org/dc/elements/1.1/ title"`
+		Creator struct {
+			Items []string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Seq>li"`
+		} `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Subject struct {
+			Items []string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Bag>li"`
+		} `xml:"http://purl.org/dc/elements/1.1/ subject"`
+		Rights struct {
+			Items []string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# Alt>li"`
+		} `xml:"http://purl.org/dc/elements/1.1/ rights"`
+		WebStatement string `xml:"http://ns.adobe.com/xap/1.0/rights/ WebStatement"`
+	} `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# RDF>Description"`
+}
+
+func TestExportXMP(t *testing.T) {
+	source := filepath.Join("test-data", "yoda-metadata[douwe].json")
+	doc := load_test_metadata(t, "yoda-metadata[douwe].json")
+	var buf bytes.Buffer
+	if err := exportXMP(doc, source, &buf); err != nil {
+		t.Fatal(err)
+	}
+	raw := buf.Bytes()
+	if !bytes.HasPrefix(raw, []byte(xmp_packet_begin)) || !bytes.HasSuffix(raw, []byte(xmp_packet_end)) {
+		t.Errorf("not wrapped in the xpacket processing instructions:\n%s", raw)
+	}
+	elements := check_xml_namespaces(t, raw, xmp_meta_namespace, rdf_namespace, dc_namespace, xmp_rights_namespace)
+	// dc:creator holds an ordered rdf:Seq, dc:subject an unordered rdf:Bag
+	if i := slices.Index(elements, "creator"); i < 0 || elements[i+1] != "Seq" {
+		t.Errorf("dc:creator is not an rdf:Seq: %v", elements)
+	}
+	if i := slices.Index(elements, "subject"); i < 0 || elements[i+1] != "Bag" {
+		t.Errorf("dc:subject is not an rdf:Bag: %v", elements)
+	}
+
+	var packet xmp_test_packet
+	if err := xml.Unmarshal(raw, &packet); err != nil {
+		t.Fatal(err)
+	}
+	d := packet.Description
+	if !strings.Contains(string(raw), `rdf:about=""`) || d.About != "" {
+		t.Errorf("rdf:about %q, want it empty", d.About)
+	}
+	if len(d.Title.Items) != 1 || d.Title.Items[0].Lang != "x-default" || d.Title.Items[0].Value != doc.Title {
+		t.Errorf("dc:title %+v", d.Title.Items)
+	}
+	if len(d.Creator.Items) != len(doc.Creator) || d.Creator.Items[0] != formatName(doc.Creator[0].Name, "citation") {
+		t.Errorf("dc:creator %v", d.Creator.Items)
+	}
+	if !slices.Equal(d.Subject.Items, []string(doc.Tag)) {
+		t.Errorf("dc:subject %v, want %v", d.Subject.Items, doc.Tag)
+	}
+	if len(d.Rights.Items) != 1 || !strings.HasPrefix(d.Rights.Items[0], "CC-BY-4.0") {
+		t.Errorf("dc:rights %v", d.Rights.Items)
+	}
+	if d.WebStatement != "https://creativecommons.org/licenses/by/4.0/" {
+		t.Errorf("xmpRights:WebStatement %q", d.WebStatement)
+	}
+}
+
+func TestExportXMPEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := exportXMP(parse_test_metadata(t, `{}`), "", &buf); err != nil {
+		t.Fatal(err)
+	}
+	elements := check_xml_namespaces(t, buf.Bytes(), xmp_meta_namespace, rdf_namespace)
+	if !slices.Equal(elements, []string{"xmpmeta", "RDF", "Description"}) {
+		t.Errorf("elements of empty metadata %v, want an empty rdf:Description", elements)
+	}
+}
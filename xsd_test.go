@@ -0,0 +1,687 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const xsd_test_namespace string = "http://www.w3.org/2001/XMLSchema"
+
+// an element of an XML document or schema, with the namespace prefixes in scope for the QNames in schema attributes
+type xsd_node struct {
+	Name     xml.Name
+	Attr     []xml.Attr
+	Children []*xsd_node
+	Text     string
+	ns       map[string]string
+}
+
+// the value of an attribute without namespace, "" when it is missing
+func (n *xsd_node) attr(name string) string {
+	for _, a := range n.Attr {
+		if a.Name.Space == "" && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// the namespace name of a QName in a schema attribute, an unprefixed name is in the default namespace
+func (n *xsd_node) qname(value string) xml.Name {
+	prefix, local, found := strings.Cut(value, ":")
+	if !found {
+		return xml.Name{Space: n.ns[""], Local: value}
+	}
+	return xml.Name{Space: n.ns[prefix], Local: local}
+}
+
+// the schema children of a node, annotations left out
+func (n *xsd_node) schema_children() []*xsd_node {
+	var children []*xsd_node
+	for _, c := range n.Children {
+		if c.Name.Space == xsd_test_namespace && c.Name.Local != "annotation" {
+			children = append(children, c)
+		}
+	}
+	return children
+}
+
+// the first schema child with one of the names, nil if there is none
+func (n *xsd_node) child(names ...string) *xsd_node {
+	for _, c := range n.schema_children() {
+		for _, name := range names {
+			if c.Name.Local == name {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// parse XML into a tree of nodes, the text of a node is its own character data
+func parse_xsd_node(raw []byte) (*xsd_node, error) {
+	dec := xml.NewDecoder(bytes.NewReader(raw))
+	var root *xsd_node
+	var stack []*xsd_node
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			n := &xsd_node{Name: tok.Name, Attr: tok.Attr, ns: map[string]string{}}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, n)
+				for prefix, space := range parent.ns {
+					n.ns[prefix] = space
+				}
+			} else {
+				root = n
+			}
+			for _, a := range tok.Attr {
+				if a.Name.Space == "xmlns" {
+					n.ns[a.Name.Local] = a.Value
+				} else if a.Name.Space == "" && a.Name.Local == "xmlns" {
+					n.ns[""] = a.Value
+				}
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(tok)
+			}
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("no root element")
+	}
+	return root, nil
+}
+
+// a schema document, for the namespace of its declarations
+type xsd_schema struct {
+	target    string
+	qualified bool
+}
+
+// a declaration or type in its schema document, or an XSD built-in type when builtin is set
+type xsd_decl struct {
+	node    *xsd_node
+	schema  *xsd_schema
+	builtin string
+}
+
+// the global declarations of a set of schema documents, for the XSD subset of the schemas in test-data/schemas:
+// elements with substitution groups, complex types with sequence, choice, group and any particles, simple and
+// complex content extension, simple types with restriction facets, lists and unions, attributes and attribute groups
+type xsd_validator struct {
+	elements     map[xml.Name]xsd_decl
+	types        map[xml.Name]xsd_decl
+	groups       map[xml.Name]xsd_decl
+	attributes   map[xml.Name]xsd_decl
+	attr_groups  map[xml.Name]xsd_decl
+	substitution map[xml.Name]xml.Name
+	loaded       map[string]bool
+	errs         []string
+}
+
+// load a schema file with the files it imports and includes
+func load_xsd_validator(fname string) (*xsd_validator, error) {
+	v := &xsd_validator{
+		elements:     map[xml.Name]xsd_decl{},
+		types:        map[xml.Name]xsd_decl{},
+		groups:       map[xml.Name]xsd_decl{},
+		attributes:   map[xml.Name]xsd_decl{},
+		attr_groups:  map[xml.Name]xsd_decl{},
+		substitution: map[xml.Name]xml.Name{},
+		loaded:       map[string]bool{},
+	}
+	return v, v.load(fname)
+}
+
+func (v *xsd_validator) load(fname string) error {
+	if v.loaded[fname] {
+		return nil
+	}
+	v.loaded[fname] = true
+	raw, err := os.ReadFile(fname)
+	if err != nil {
+		return err
+	}
+	root, err := parse_xsd_node(raw)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fname, err)
+	}
+	if root.Name != (xml.Name{Space: xsd_test_namespace, Local: "schema"}) {
+		return fmt.Errorf("%s: not an XML schema", fname)
+	}
+	schema := &xsd_schema{target: root.attr("targetNamespace"), qualified: root.attr("elementFormDefault") == "qualified"}
+	for _, n := range root.schema_children() {
+		name := xml.Name{Space: schema.target, Local: n.attr("name")}
+		decl := xsd_decl{node: n, schema: schema}
+		switch n.Name.Local {
+		case "import", "include":
+			if location := n.attr("schemaLocation"); location != "" {
+				if err := v.load(filepath.Join(filepath.Dir(fname), location)); err != nil {
+					return err
+				}
+			}
+		case "element":
+			v.elements[name] = decl
+			if head := n.attr("substitutionGroup"); head != "" {
+				v.substitution[name] = n.qname(head)
+			}
+		case "complexType", "simpleType":
+			v.types[name] = decl
+		case "group":
+			v.groups[name] = decl
+		case "attribute":
+			v.attributes[name] = decl
+		case "attributeGroup":
+			v.attr_groups[name] = decl
+		default:
+			return fmt.Errorf("%s: unsupported schema component %s", fname, n.Name.Local)
+		}
+	}
+	return nil
+}
+
+// validate a document, returns the problems found
+func (v *xsd_validator) validate(raw []byte) []string {
+	v.errs = nil
+	root, err := parse_xsd_node(raw)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	decl, ok := v.elements[root.Name]
+	if !ok || decl.node.attr("abstract") == "true" {
+		return []string{fmt.Sprintf("root element {%s}%s is not declared", root.Name.Space, root.Name.Local)}
+	}
+	v.validate_element(root, decl, "/"+root.Name.Local)
+	return v.errs
+}
+
+func (v *xsd_validator) errorf(path string, format string, args ...any) {
+	v.errs = append(v.errs, path+": "+fmt.Sprintf(format, args...))
+}
+
+// resolve a type QName in the schema of decl
+func (v *xsd_validator) resolve_type(decl xsd_decl, qname string) xsd_decl {
+	name := decl.node.qname(qname)
+	if name.Space == xsd_test_namespace {
+		return xsd_decl{builtin: name.Local}
+	}
+	typ, ok := v.types[name]
+	if !ok {
+		v.errorf("schema", "type {%s}%s is not declared", name.Space, name.Local)
+		return xsd_decl{builtin: "anyType"}
+	}
+	return typ
+}
+
+// the type of an element or attribute declaration: named, inline or the ur-type
+func (v *xsd_validator) type_of(decl xsd_decl, ur string) xsd_decl {
+	if typ := decl.node.attr("type"); typ != "" {
+		return v.resolve_type(decl, typ)
+	}
+	if inline := decl.node.child("complexType", "simpleType"); inline != nil {
+		return xsd_decl{node: inline, schema: decl.schema}
+	}
+	return xsd_decl{builtin: ur}
+}
+
+// the content of a complex type: the particles in order (an extension adds its particle after those of the base),
+// whether text may be mixed in, the simple type of simple content and the attribute declarations
+type xsd_content struct {
+	particles []xsd_decl
+	mixed     bool
+	simple    *xsd_decl
+	attrs     []xsd_decl
+}
+
+func (v *xsd_validator) content(typ xsd_decl) xsd_content {
+	if typ.builtin != "" || typ.node.Name.Local == "simpleType" {
+		return xsd_content{simple: &typ}
+	}
+	c := xsd_content{mixed: typ.node.attr("mixed") == "true"}
+	body := typ.node
+	if derived := typ.node.child("simpleContent", "complexContent"); derived != nil {
+		if derived.attr("mixed") == "true" {
+			c.mixed = true
+		}
+		body = derived.child("extension", "restriction")
+		base := v.resolve_type(typ, body.attr("base"))
+		if body.Name.Local == "extension" || derived.Name.Local == "simpleContent" {
+			inherited := v.content(base)
+			c.attrs = inherited.attrs
+			c.simple = inherited.simple
+			if body.Name.Local == "extension" {
+				c.particles = inherited.particles
+				c.mixed = c.mixed || inherited.mixed
+			}
+		}
+	}
+	for _, n := range body.schema_children() {
+		decl := xsd_decl{node: n, schema: typ.schema}
+		switch n.Name.Local {
+		case "sequence", "choice", "group", "all":
+			c.particles = append(c.particles, decl)
+		case "attribute", "attributeGroup", "anyAttribute":
+			c.attrs = append(c.attrs, decl)
+		}
+	}
+	return c
+}
+
+// validate an element against its declaration
+func (v *xsd_validator) validate_element(doc *xsd_node, decl xsd_decl, path string) {
+	typ := v.type_of(decl, "anyType")
+	if typ.builtin == "anyType" {
+		return
+	}
+	c := v.content(typ)
+	v.validate_attributes(doc, c.attrs, path)
+	if c.simple != nil {
+		if len(doc.Children) > 0 {
+			v.errorf(path, "element %s in simple content", doc.Children[0].Name.Local)
+			return
+		}
+		if err := v.check_simple(doc.Text, *c.simple); err != nil {
+			v.errorf(path, "%v", err)
+		}
+		return
+	}
+	if !c.mixed && strings.TrimSpace(doc.Text) != "" {
+		v.errorf(path, "text %q in element-only content", strings.TrimSpace(doc.Text))
+	}
+
+	// the children match the particles as a sequence, matched remembers the declaration of each child
+	matched := map[int]xsd_decl{}
+	reached := 0
+	positions := map[int]bool{0: true}
+	for _, p := range c.particles {
+		positions = v.match(p, doc.Children, positions, matched, &reached)
+	}
+	if !positions[len(doc.Children)] {
+		if reached < len(doc.Children) {
+			v.errorf(path, "element %s is not expected here", doc.Children[reached].Name.Local)
+		} else {
+			v.errorf(path, "content is incomplete, a required element is missing")
+		}
+		return
+	}
+	counts := map[string]int{}
+	for i, child := range doc.Children {
+		counts[child.Name.Local]++
+		if d, ok := matched[i]; ok && d.node != nil {
+			v.validate_element(child, d, fmt.Sprintf("%s/%s[%d]", path, child.Name.Local, counts[child.Name.Local]))
+		}
+	}
+}
+
+// the attribute declarations of a complex type, expanded from references and attribute groups
+func (v *xsd_validator) attribute_uses(decls []xsd_decl, uses map[xml.Name]xsd_decl) (any_attribute bool) {
+	for _, d := range decls {
+		switch d.node.Name.Local {
+		case "anyAttribute":
+			any_attribute = true
+		case "attributeGroup":
+			group, ok := v.attr_groups[d.node.qname(d.node.attr("ref"))]
+			if !ok {
+				v.errorf("schema", "attribute group %s is not declared", d.node.attr("ref"))
+				continue
+			}
+			var children []xsd_decl
+			for _, n := range group.node.schema_children() {
+				children = append(children, xsd_decl{node: n, schema: group.schema})
+			}
+			any_attribute = v.attribute_uses(children, uses) || any_attribute
+		case "attribute":
+			if ref := d.node.attr("ref"); ref != "" {
+				name := d.node.qname(ref)
+				global, ok := v.attributes[name]
+				if !ok {
+					v.errorf("schema", "attribute %s is not declared", ref)
+					continue
+				}
+				// the use of a reference is on the reference
+				uses[name] = xsd_decl{node: &xsd_node{Name: global.node.Name, Attr: append(global.node.Attr, xml.Attr{Name: xml.Name{Local: "use"}, Value: d.node.attr("use")}), Children: global.node.Children, ns: global.node.ns}, schema: global.schema}
+				continue
+			}
+			name := xml.Name{Local: d.node.attr("name")}
+			if d.node.attr("form") == "qualified" {
+				name.Space = d.schema.target
+			}
+			uses[name] = d
+		}
+	}
+	return any_attribute
+}
+
+func (v *xsd_validator) validate_attributes(doc *xsd_node, decls []xsd_decl, path string) {
+	uses := map[xml.Name]xsd_decl{}
+	any_attribute := v.attribute_uses(decls, uses)
+	present := map[xml.Name]bool{}
+	for _, a := range doc.Attr {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") || a.Name.Space == xsi_namespace {
+			continue
+		}
+		present[a.Name] = true
+		use, ok := uses[a.Name]
+		if !ok {
+			if !any_attribute {
+				v.errorf(path, "attribute %s is not declared", a.Name.Local)
+			}
+			continue
+		}
+		if fixed := use.node.attr("fixed"); fixed != "" && a.Value != fixed {
+			v.errorf(path, "attribute %s is %q, fixed to %q", a.Name.Local, a.Value, fixed)
+		}
+		if err := v.check_simple(a.Value, v.type_of(use, "anySimpleType")); err != nil {
+			v.errorf(path, "attribute %s: %v", a.Name.Local, err)
+		}
+	}
+	for name, use := range uses {
+		if use.node.attr("use") == "required" && !present[name] {
+			v.errorf(path, "required attribute %s is missing", name.Local)
+		}
+	}
+}
+
+// the minOccurs and maxOccurs of a particle, max is -1 for unbounded
+func xsd_occurs(n *xsd_node) (int, int) {
+	min, max := 1, 1
+	if s := n.attr("minOccurs"); s != "" {
+		min, _ = strconv.Atoi(s)
+	}
+	if s := n.attr("maxOccurs"); s == "unbounded" {
+		max = -1
+	} else if s != "" {
+		max, _ = strconv.Atoi(s)
+	}
+	return min, max
+}
+
+// the positions in children after matching the particle from each of the positions, reached is the furthest
+// position a child matched at
+func (v *xsd_validator) match(p xsd_decl, children []*xsd_node, from map[int]bool, matched map[int]xsd_decl, reached *int) map[int]bool {
+	min, max := xsd_occurs(p.node)
+	result := map[int]bool{}
+	if min == 0 {
+		for pos := range from {
+			result[pos] = true
+		}
+	}
+	seen := map[int]bool{}
+	current := from
+	for i := 1; max < 0 || i <= max; i++ {
+		next := v.match_once(p, children, current, matched, reached)
+		fresh := map[int]bool{}
+		for pos := range next {
+			if i >= min {
+				result[pos] = true
+			}
+			if !seen[pos] {
+				seen[pos] = true
+				fresh[pos] = true
+			}
+		}
+		// stop once an unbounded particle only matches nothing
+		if len(fresh) == 0 {
+			break
+		}
+		current = fresh
+	}
+	return result
+}
+
+func (v *xsd_validator) match_once(p xsd_decl, children []*xsd_node, from map[int]bool, matched map[int]xsd_decl, reached *int) map[int]bool {
+	next := map[int]bool{}
+	switch p.node.Name.Local {
+	case "element":
+		for pos := range from {
+			if pos >= len(children) {
+				continue
+			}
+			if decl, ok := v.element_matches(p, children[pos].Name); ok {
+				next[pos+1] = true
+				if _, done := matched[pos]; !done {
+					matched[pos] = decl
+				}
+				*reached = max(*reached, pos+1)
+			}
+		}
+	case "any":
+		namespace := p.node.attr("namespace")
+		for pos := range from {
+			if pos >= len(children) {
+				continue
+			}
+			if namespace == "##other" && children[pos].Name.Space == p.schema.target {
+				continue
+			}
+			next[pos+1] = true
+			matched[pos] = xsd_decl{}
+			*reached = max(*reached, pos+1)
+		}
+	case "sequence":
+		next = from
+		for _, n := range p.node.schema_children() {
+			next = v.match(xsd_decl{node: n, schema: p.schema}, children, next, matched, reached)
+		}
+	case "choice":
+		for _, n := range p.node.schema_children() {
+			for pos := range v.match(xsd_decl{node: n, schema: p.schema}, children, from, matched, reached) {
+				next[pos] = true
+			}
+		}
+	case "group":
+		group, ok := v.groups[p.node.qname(p.node.attr("ref"))]
+		if !ok {
+			v.errorf("schema", "group %s is not declared", p.node.attr("ref"))
+			return next
+		}
+		next = v.match(xsd_decl{node: group.node.child("sequence", "choice"), schema: group.schema}, children, from, matched, reached)
+	default:
+		v.errorf("schema", "unsupported particle %s", p.node.Name.Local)
+	}
+	return next
+}
+
+// whether an element particle matches an element name, with the declaration the element is validated against
+func (v *xsd_validator) element_matches(p xsd_decl, name xml.Name) (xsd_decl, bool) {
+	if ref := p.node.attr("ref"); ref != "" {
+		head := p.node.qname(ref)
+		for member := name; ; {
+			if member == head {
+				decl, ok := v.elements[name]
+				return decl, ok && decl.node.attr("abstract") != "true"
+			}
+			var ok bool
+			if member, ok = v.substitution[member]; !ok {
+				return xsd_decl{}, false
+			}
+		}
+	}
+	local := xml.Name{Local: p.node.attr("name")}
+	if form := p.node.attr("form"); form == "qualified" || (form == "" && p.schema.qualified) {
+		local.Space = p.schema.target
+	}
+	return p, local == name
+}
+
+// the XSD built-in types the schemas use, with their lexical space
+var xsd_test_builtins = map[string]*regexp.Regexp{
+	"anySimpleType":      nil,
+	"string":             nil,
+	"normalizedString":   regexp.MustCompile(`^[^\t\n\r]*$`),
+	"token":              regexp.MustCompile(`^(\S+( \S+)*)?$`),
+	"anyURI":             regexp.MustCompile(`^\S*$`),
+	"boolean":            regexp.MustCompile(`^(true|false|1|0)$`),
+	"decimal":            regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`),
+	"double":             regexp.MustCompile(`^([+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?|-?INF|NaN)$`),
+	"integer":            regexp.MustCompile(`^[+-]?\d+$`),
+	"nonNegativeInteger": regexp.MustCompile(`^\+?\d+$`),
+	"positiveInteger":    regexp.MustCompile(`^\+?0*[1-9]\d*$`),
+	"date":               regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}(Z|[+-]\d{2}:\d{2})?$`),
+	"dateTime":           regexp.MustCompile(`^-?\d{4,}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})?$`),
+	"gYear":              regexp.MustCompile(`^-?\d{4,}(Z|[+-]\d{2}:\d{2})?$`),
+	"gYearMonth":         regexp.MustCompile(`^-?\d{4,}-(0[1-9]|1[0-2])(Z|[+-]\d{2}:\d{2})?$`),
+	"language":           regexp.MustCompile(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`),
+	"NCName":             regexp.MustCompile(`^[\pL_][\pL\pN._-]*$`),
+	"ID":                 regexp.MustCompile(`^[\pL_][\pL\pN._-]*$`),
+	"NMTOKEN":            regexp.MustCompile(`^[\pL\pN._:-]+$`),
+}
+
+// whether a simple type keeps its white space, the other types collapse it
+func (v *xsd_validator) preserves_space(typ xsd_decl) bool {
+	if typ.builtin != "" {
+		return typ.builtin == "string" || typ.builtin == "anySimpleType"
+	}
+	if r := typ.node.child("restriction"); r != nil {
+		if base := r.attr("base"); base != "" {
+			return v.preserves_space(v.resolve_type(typ, base))
+		}
+		return v.preserves_space(xsd_decl{node: r.child("simpleType"), schema: typ.schema})
+	}
+	return false
+}
+
+// check a value against a simple type
+func (v *xsd_validator) check_simple(value string, typ xsd_decl) error {
+	if !v.preserves_space(typ) {
+		value = strings.Join(strings.Fields(value), " ")
+	}
+	if typ.builtin != "" {
+		re, ok := xsd_test_builtins[typ.builtin]
+		if !ok {
+			return fmt.Errorf("unsupported built-in type %s", typ.builtin)
+		}
+		if re != nil && !re.MatchString(value) {
+			return fmt.Errorf("%q is not a valid %s", value, typ.builtin)
+		}
+		if typ.builtin == "date" && !strings.HasPrefix(value, "-") {
+			if _, err := time.Parse("2006-01-02", value[:10]); err != nil {
+				return fmt.Errorf("%q is not a valid date", value)
+			}
+		}
+		return nil
+	}
+	if typ.node.Name.Local != "simpleType" {
+		return fmt.Errorf("complex type %s used as a simple type", typ.node.attr("name"))
+	}
+	switch body := typ.node.schema_children()[0]; body.Name.Local {
+	case "restriction":
+		base := xsd_decl{node: body.child("simpleType"), schema: typ.schema}
+		if name := body.attr("base"); name != "" {
+			base = v.resolve_type(typ, name)
+		}
+		if err := v.check_simple(value, base); err != nil {
+			return err
+		}
+		return xsd_check_facets(value, body)
+	case "list":
+		item := xsd_decl{node: body.child("simpleType"), schema: typ.schema}
+		if name := body.attr("itemType"); name != "" {
+			item = v.resolve_type(typ, name)
+		}
+		for _, field := range strings.Fields(value) {
+			if err := v.check_simple(field, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "union":
+		var members []xsd_decl
+		for _, name := range strings.Fields(body.attr("memberTypes")) {
+			members = append(members, v.resolve_type(typ, name))
+		}
+		for _, n := range body.schema_children() {
+			members = append(members, xsd_decl{node: n, schema: typ.schema})
+		}
+		for _, member := range members {
+			if v.check_simple(value, member) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not a value of any member type of the union", value)
+	default:
+		return fmt.Errorf("unsupported simple type %s", body.Name.Local)
+	}
+}
+
+// check a value against the facets of a restriction, patterns of one restriction match if any of them does
+func xsd_check_facets(value string, restriction *xsd_node) error {
+	var enumeration, patterns []string
+	for _, facet := range restriction.schema_children() {
+		limit := facet.attr("value")
+		switch facet.Name.Local {
+		case "enumeration":
+			enumeration = append(enumeration, limit)
+		case "pattern":
+			patterns = append(patterns, limit)
+		case "minLength", "maxLength":
+			n, _ := strconv.Atoi(limit)
+			length := len([]rune(value))
+			if (facet.Name.Local == "minLength" && length < n) || (facet.Name.Local == "maxLength" && length > n) {
+				return fmt.Errorf("%q violates %s %d", value, facet.Name.Local, n)
+			}
+		case "minInclusive", "maxInclusive":
+			f, err := strconv.ParseFloat(value, 64)
+			bound, _ := strconv.ParseFloat(limit, 64)
+			if err != nil || (facet.Name.Local == "minInclusive" && f < bound) || (facet.Name.Local == "maxInclusive" && f > bound) {
+				return fmt.Errorf("%q violates %s %s", value, facet.Name.Local, limit)
+			}
+		case "simpleType":
+		default:
+			return fmt.Errorf("unsupported facet %s", facet.Name.Local)
+		}
+	}
+	if len(enumeration) > 0 && !slices.Contains(enumeration, value) {
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(enumeration, ", "))
+	}
+	if len(patterns) > 0 {
+		for _, pattern := range patterns {
+			if regexp.MustCompile("^(?:" + pattern + ")$").MatchString(value) {
+				return nil
+			}
+		}
+		return fmt.Errorf("%q does not match the pattern %s", value, strings.Join(patterns, " | "))
+	}
+	return nil
+}
+
+// validate the XML output against an XML schema in test-data/schemas
+func check_test_xsd(t *testing.T, schema string, raw []byte) {
+	t.Helper()
+	for _, problem := range test_xsd_problems(t, schema, raw) {
+		t.Errorf("%s: %s", schema, problem)
+	}
+}
+
+// the problems of the XML against an XML schema in test-data/schemas
+func test_xsd_problems(t *testing.T, schema string, raw []byte) []string {
+	t.Helper()
+	v, err := load_xsd_validator(filepath.Join("test-data", "schemas", schema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v.validate(raw)
+}
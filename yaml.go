@@ -0,0 +1,53 @@
+/*
+yaml.go reading and writing Yoda metadata as YAML with the same key names as the JSON.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// true if the filename (optionally gzip compressed) has a YAML extension
+func is_yaml_name(fname string) bool {
+	fname = strings.TrimSuffix(strings.ToLower(fname), ".gz")
+	ext := filepath.Ext(fname)
+	return ext == ".yaml" || ext == ".yml"
+}
+
+// convert a YAML metadata document to JSON, unknown keys are dropped
+func yaml_to_json(raw []byte) ([]byte, error) {
+	var data Yoda18MetadataV2
+	err := yaml.Unmarshal(raw, &data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(data)
+}
+
+// exportYAML writes the metadata as YAML to w, empty optional fields are omitted
+func exportYAML(doc Yoda18Metadata, w io.Writer) error {
+	// the V2 struct carries the omitempty tags, it is filled through JSON as the two structs differ in their Name type
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var data Yoda18MetadataV2
+	err = json.Unmarshal(raw, &data)
+	if err != nil {
+		return err
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	err = enc.Encode(data)
+	if err != nil {
+		return err
+	}
+	return enc.Close()
+}
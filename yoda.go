@@ -0,0 +1,205 @@
+/*
+yoda.go reading the metadata of a collection directly from a Yoda portal through its REST API.
+*/
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// the config file holding the Yoda credentials, relative to the user config directory
+const yoda_config_name string = "readYmeta/yoda.json"
+
+// how often a request answered with 503 Service Unavailable is retried
+const yoda_max_retries int = 3
+
+// the wait before the first retry, doubled for every next one unless the server sends Retry-After
+const yoda_retry_wait = 2 * time.Second
+
+// the credentials in the Yoda config file, kept out of the flags so they do not end up in the shell history
+type yoda_config struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// YodaClient talks to the API of a Yoda portal, authenticating with a username and (data access) password
+type YodaClient struct {
+	Server   string
+	Username string
+	Password string
+	Client   *http.Client
+}
+
+// the Yoda API response envelope
+type yoda_response struct {
+	Status     string          `json:"status"`
+	StatusInfo string          `json:"status_info"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// the config file to read, -yoda-config or the default in the user config directory
+func yoda_config_path(config_file string) (string, error) {
+	if config_file != "" {
+		return config_file, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, yoda_config_name), nil
+}
+
+// create a client for server with the credentials from config_file, an empty config_file uses the default location
+func new_yoda_client(server string, config_file string) (*YodaClient, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+	// the credentials are sent with Basic auth, which is only safe over TLS
+	if u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("unsupported Yoda server URL %q, use https://...", server)
+	}
+
+	fname, err := yoda_config_path(config_file)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the Yoda credentials: %w", err)
+	}
+	if fi, err := os.Stat(fname); err == nil && fi.Mode().Perm()&0o077 != 0 {
+		slog.Warn("Yoda config file is readable by others, restrict it with chmod 600", "file", fname)
+	}
+	var cfg yoda_config
+	err = json.Unmarshal(raw, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fname, err)
+	}
+	if cfg.Username == "" || cfg.Password == "" {
+		return nil, fmt.Errorf("%s needs a username and password", fname)
+	}
+
+	return &YodaClient{
+		Server:   strings.TrimRight(server, "/"),
+		Username: cfg.Username,
+		Password: cfg.Password,
+		Client:   &http.Client{Timeout: *url_timeout},
+	}, nil
+}
+
+// call the Yoda API function fn with the given arguments, a 503 is retried with a growing wait
+func (c *YodaClient) call(ctx context.Context, fn string, args interface{}) (json.RawMessage, error) {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+	form := url.Values{"data": {string(data)}}.Encode()
+
+	wait := yoda_retry_wait
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.Server+"/api/"+fn, strings.NewReader(form))
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(c.Username, c.Password)
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", url_user_agent)
+
+		resp, err := c.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		body, err := read_limited(resp.Body, url_max_body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusServiceUnavailable && attempt < yoda_max_retries {
+			if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs > 0 {
+				wait = time.Duration(secs) * time.Second
+			}
+			slog.Warn("Yoda server unavailable, retrying", "function", fn, "attempt", attempt+1, "wait", wait)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+			wait *= 2
+			continue
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK, http.StatusBadRequest:
+			// Yoda reports failures such as a missing collection in the envelope
+		case http.StatusUnauthorized, http.StatusForbidden:
+			return nil, fmt.Errorf("Yoda login as %s refused (%s), check the config file", c.Username, resp.Status)
+		default:
+			return nil, fmt.Errorf("Yoda API %s failed: %s", fn, resp.Status)
+		}
+
+		var env yoda_response
+		err = json.Unmarshal(body, &env)
+		if err != nil {
+			return nil, fmt.Errorf("Yoda API %s returned no JSON: %w", fn, err)
+		}
+		if env.Status != "ok" {
+			return nil, fmt.Errorf("Yoda API %s: %s (%s)", fn, env.StatusInfo, env.Status)
+		}
+		return env.Data, nil
+	}
+}
+
+// the raw metadata JSON of a collection, as stored in its yoda-metadata.json
+func (c *YodaClient) get_metadata_raw(ctx context.Context, collection string) ([]byte, error) {
+	data, err := c.call(ctx, "meta_form_load", map[string]string{"coll": collection})
+	if err != nil {
+		return nil, err
+	}
+	var form struct {
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	err = json.Unmarshal(data, &form)
+	if err != nil {
+		return nil, err
+	}
+	if len(form.Metadata) == 0 || string(form.Metadata) == "null" {
+		return nil, fmt.Errorf("collection %s has no metadata", collection)
+	}
+	slog.Info("metadata downloaded", "server", c.Server, "collection", collection, "bytes", len(form.Metadata))
+	return form.Metadata, nil
+}
+
+// GetMetadata reads and parses the metadata of a Yoda collection such as /zone/home/research-myproject
+func (c *YodaClient) GetMetadata(ctx context.Context, collection string) (Yoda18Metadata, error) {
+	var data Yoda18Metadata
+	raw, err := c.get_metadata_raw(ctx, collection)
+	if err != nil {
+		return data, err
+	}
+	err = json.Unmarshal(raw, &data)
+	return NormalizeUnicode(data), err
+}
+
+// the input filename for a collection, used to name the output files
+func collection_input_name(collection string) string {
+	name := path.Base(strings.TrimRight(collection, "/"))
+	if name == "/" || name == "." || name == "" {
+		return url_default_name
+	}
+	return name + ".json"
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNewYodaClientNeedsHTTPS(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "yoda.json")
+	if err := os.WriteFile(config, []byte(`{"username": "u", "password": "p"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, server := range []string{"http://portal.yoda.example.nl", "portal.yoda.example.nl", "ftp://portal.yoda.example.nl"} {
+		if _, err := new_yoda_client(server, config); err == nil || !strings.Contains(err.Error(), "https://") {
+			t.Errorf("new_yoda_client(%q): error %v, want the https:// hint", server, err)
+		}
+	}
+	c, err := new_yoda_client("https://portal.yoda.example.nl/", config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server != "https://portal.yoda.example.nl" || c.Username != "u" || c.Password != "p" {
+		t.Errorf("client = %+v", c)
+	}
+}
+
+func TestYodaGetMetadata(t *testing.T) {
+	raw, err := os.ReadFile("test-data/yoda-metadata.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "u" || pass != "p" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/api/meta_form_load" {
+			http.NotFound(w, r)
+			return
+		}
+		data, _ := json.Marshal(map[string]json.RawMessage{"metadata": raw})
+		json.NewEncoder(w).Encode(yoda_response{Status: "ok", Data: data})
+	}))
+	defer srv.Close()
+
+	c := &YodaClient{Server: srv.URL, Username: "u", Password: "p", Client: srv.Client()}
+	doc, err := c.GetMetadata(context.Background(), "/zone/home/research-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Title == "" {
+		t.Error("no title read")
+	}
+
+	c.Password = "wrong"
+	if _, err := c.GetMetadata(context.Background(), "/zone/home/research-test"); err == nil || !strings.Contains(err.Error(), "refused") {
+		t.Errorf("wrong password: error %v, want the login refused", err)
+	}
+}
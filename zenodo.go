@@ -0,0 +1,166 @@
+/*
+zenodo.go exports Yoda metadata as the JSON body of the Zenodo deposition API, to publish a copy of a data package on Zenodo.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"html"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Zenodo creator, name is "Family, Given"
+type ZenodoCreator struct {
+	Name        string `json:"name"`
+	Affiliation string `json:"affiliation,omitempty"`
+	ORCID       string `json:"orcid,omitempty"`
+}
+
+// Zenodo related identifier, relation is a DataCite relationType in lower camel case
+type ZenodoRelatedIdentifier struct {
+	Identifier string `json:"identifier"`
+	Relation   string `json:"relation"`
+}
+
+// the deposition metadata
+type ZenodoMetadata struct {
+	UploadType         string                    `json:"upload_type"`
+	Title              string                    `json:"title"`
+	Description        string                    `json:"description"`
+	Creators           []ZenodoCreator           `json:"creators"`
+	Keywords           []string                  `json:"keywords,omitempty"`
+	Version            string                    `json:"version,omitempty"`
+	License            string                    `json:"license,omitempty"`
+	AccessRight        string                    `json:"access_right"`
+	EmbargoDate        string                    `json:"embargo_date,omitempty"`
+	AccessConditions   string                    `json:"access_conditions,omitempty"`
+	RelatedIdentifiers []ZenodoRelatedIdentifier `json:"related_identifiers,omitempty"`
+}
+
+// the body of a Zenodo deposition create or update request
+type ZenodoDeposition struct {
+	Metadata ZenodoMetadata `json:"metadata"`
+}
+
+// the licence used when the Yoda licence has no Zenodo identifier
+const zenodo_fallback_license string = "other-open"
+
+// the DataCite relationTypes Zenodo does not accept as relation, the rest are accepted in lower camel case
+var zenodo_unsupported_relations = map[string]bool{
+	"HasVersion": true, "IsVersionOf": true, "IsPublishedIn": true,
+	"IsCollectedBy": true, "Collects": true, "IsTranslationOf": true, "HasTranslation": true,
+}
+
+// the Zenodo licence identifier, the SPDX identifier in lower case as in cc-by-4.0
+func zenodo_license(license string) string {
+	id, err := NormalizeLicense(license)
+	if err != nil {
+		slog.Warn("licence has no Zenodo identifier, using "+zenodo_fallback_license, "license", license)
+		return zenodo_fallback_license
+	}
+	return strings.ToLower(id)
+}
+
+// the Zenodo access_right with its embargo_date or access_conditions, an open data package whose
+// Embargo_End_Date lies in the future is embargoed until that date
+func zenodo_access(doc Yoda18Metadata, today string) (right string, embargo string, conditions string) {
+	switch {
+	case strings.HasPrefix(doc.DataAccessRestriction, "Open"):
+		if csl_date_parts(doc.EmbargoEndDate) != nil && len(doc.EmbargoEndDate) == len("2006-01-02") && doc.EmbargoEndDate > today {
+			return "embargoed", doc.EmbargoEndDate, ""
+		}
+		return "open", "", ""
+	case strings.HasPrefix(doc.DataAccessRestriction, "Closed"):
+		return "closed", "", ""
+	}
+	// Zenodo requires the conditions of restricted access
+	return "restricted", "", "Available upon request."
+}
+
+// the description as HTML, each paragraph of the Yoda description becomes a <p> element
+func zenodo_description(description string) string {
+	var sb strings.Builder
+	for _, para := range paragraph_break.Split(strings.ReplaceAll(description, "\r\n", "\n"), -1) {
+		para = strings.TrimSpace(para)
+		if para == "" {
+			continue
+		}
+		sb.WriteString("<p>" + strings.ReplaceAll(html.EscapeString(para), "\n", "<br>") + "</p>")
+	}
+	return sb.String()
+}
+
+// map the metadata to a Zenodo deposition, today (YYYY-MM-DD) decides whether an embargo is still running
+func zenodo_deposition(doc Yoda18Metadata, today string) ZenodoDeposition {
+	m := ZenodoMetadata{
+		UploadType:  "dataset",
+		Title:       doc.Title,
+		Description: zenodo_description(doc.Description),
+		Creators:    []ZenodoCreator{},
+		Version:     doc.Version,
+		License:     zenodo_license(doc.License),
+	}
+	m.AccessRight, m.EmbargoDate, m.AccessConditions = zenodo_access(doc, today)
+
+	for _, creator := range doc.Creator {
+		name := strings.TrimSpace(creator.Name.FamilyName)
+		if given := strings.TrimSpace(creator.Name.GivenName); given != "" {
+			if name != "" {
+				name += ", "
+			}
+			name += given
+		}
+		if name == "" {
+			continue
+		}
+		c := ZenodoCreator{Name: name, Affiliation: strings.Join(creator.Affiliation, "; ")}
+		for _, pid := range creator.PersonIdentifier {
+			if strings.EqualFold(pid.NameIdentifierScheme, "ORCID") && pid.NameIdentifier != "" {
+				// Zenodo wants the bare ORCID iD
+				c.ORCID = strings.TrimPrefix(orcid_url(pid.NameIdentifier), "https://orcid.org/")
+				break
+			}
+		}
+		m.Creators = append(m.Creators, c)
+	}
+
+	for _, tag := range doc.Tag {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			m.Keywords = append(m.Keywords, tag)
+		}
+	}
+
+	for _, rel := range doc.RelatedDatapackage {
+		pid := rel.PersistentIdentifier
+		link, err := NormalizePID(pid.IdentifierScheme, pid.Identifier)
+		if err != nil {
+			slog.Warn("related datapackage left out of the Zenodo deposition", "title", rel.Title, "error", err)
+			continue
+		}
+		relation := datacite_relation_type(rel.RelationType)
+		if check_relation_type(rel.RelationType) != nil || relation == "" || zenodo_unsupported_relations[relation] {
+			slog.Warn("related datapackage left out of the Zenodo deposition, Zenodo does not accept its relation type",
+				"title", rel.Title, "relation_type", rel.RelationType)
+			continue
+		}
+		m.RelatedIdentifiers = append(m.RelatedIdentifiers, ZenodoRelatedIdentifier{
+			Identifier: link,
+			Relation:   strings.ToLower(relation[:1]) + relation[1:],
+		})
+	}
+
+	return ZenodoDeposition{Metadata: m}
+}
+
+// exportZenodo writes the Zenodo deposition JSON of the metadata to w
+func exportZenodo(doc Yoda18Metadata, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	// the description is HTML, keep its tags readable
+	enc.SetEscapeHTML(false)
+	return enc.Encode(zenodo_deposition(doc, time.Now().Format("2006-01-02")))
+}